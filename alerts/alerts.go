@@ -0,0 +1,201 @@
+package alerts
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"kiro-manager/backup"
+	"kiro-manager/internal/atomicfile"
+	"kiro-manager/settings"
+)
+
+// 警示規則類型
+const (
+	KindUsedPercent  = "usedPercent"  // 已使用百分比達到門檻
+	KindMinRemaining = "minRemaining" // 剩餘額度低於門檻
+)
+
+// resetTolerance 用量下降超過此值時視為計費週期重置
+const resetTolerance = 1e-6
+
+// Rule 單一警示等級
+type Rule struct {
+	Kind      string  `json:"kind"`
+	Threshold float64 `json:"threshold"`
+}
+
+// ID 規則的識別字串（用於記錄本週期已觸發的等級）
+func (r Rule) ID() string {
+	return fmt.Sprintf("%s:%g", r.Kind, r.Threshold)
+}
+
+// FromSettings 將設定中的規則轉為 Rule，略過無效的規則
+func FromSettings(rules []settings.AlertRule) []Rule {
+	result := make([]Rule, 0, len(rules))
+	for _, r := range rules {
+		if !r.Valid() {
+			continue
+		}
+		if r.UsedPercent > 0 {
+			result = append(result, Rule{Kind: KindUsedPercent, Threshold: r.UsedPercent})
+		} else {
+			result = append(result, Rule{Kind: KindMinRemaining, Threshold: r.MinRemaining})
+		}
+	}
+	return result
+}
+
+// Snapshot 評估警示所需的用量資訊
+type Snapshot struct {
+	SubscriptionTitle string
+	UsageLimit        float64
+	CurrentUsage      float64
+	NextResetAt       string // 下次重置時間（RFC3339），未知時為空
+}
+
+// Event 新觸發的警示
+type Event struct {
+	Backup            string  `json:"backup"`
+	Rule              Rule    `json:"rule"`
+	UsedPercent       float64 `json:"usedPercent"`
+	Remaining         float64 `json:"remaining"`
+	UsageLimit        float64 `json:"usageLimit"`
+	CurrentUsage      float64 `json:"currentUsage"`
+	SubscriptionTitle string  `json:"subscriptionTitle"`
+	NextResetAt       string  `json:"nextResetAt,omitempty"`
+	Time              string  `json:"time"`    // 觸發時間（RFC3339）
+	Message           string  `json:"message"` // 顯示用訊息
+}
+
+// State 帳號的警示狀態（保存於備份目錄的 alert-state.json）
+type State struct {
+	Cycle     string    `json:"cycle"`     // 目前計費週期的重置時間（RFC3339），未知時為空
+	LastUsage float64   `json:"lastUsage"` // 上次評估時的已使用量
+	Fired     []string  `json:"fired"`     // 本週期已觸發的規則 ID
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// Evaluate 依最新用量評估規則，回傳本週期新觸發的警示並更新 state
+// 以下情況視為新的計費週期，已觸發的等級會重新啟用：
+// - 已超過上次記錄的重置時間，且 API 回報了新的重置時間
+// - 已使用量下降
+func Evaluate(state *State, name string, snap Snapshot, rules []Rule, now time.Time) []Event {
+	if newCycle(state, snap, now) {
+		state.Fired = nil
+	}
+	state.Cycle = snap.NextResetAt
+	state.LastUsage = snap.CurrentUsage
+	state.UpdatedAt = now.UTC()
+
+	if snap.UsageLimit <= 0 {
+		return nil
+	}
+
+	usedPercent := snap.CurrentUsage / snap.UsageLimit * 100
+	remaining := snap.UsageLimit - snap.CurrentUsage
+
+	var events []Event
+	for _, rule := range rules {
+		id := rule.ID()
+		if slices.Contains(state.Fired, id) || !triggered(rule, usedPercent, remaining) {
+			continue
+		}
+		state.Fired = append(state.Fired, id)
+		events = append(events, Event{
+			Backup:            name,
+			Rule:              rule,
+			UsedPercent:       usedPercent,
+			Remaining:         remaining,
+			UsageLimit:        snap.UsageLimit,
+			CurrentUsage:      snap.CurrentUsage,
+			SubscriptionTitle: snap.SubscriptionTitle,
+			NextResetAt:       snap.NextResetAt,
+			Time:              now.Format(time.RFC3339),
+			Message:           message(name, rule, snap, usedPercent, remaining),
+		})
+	}
+	return events
+}
+
+// newCycle 判斷是否已進入新的計費週期
+func newCycle(state *State, snap Snapshot, now time.Time) bool {
+	if snap.CurrentUsage < state.LastUsage-resetTolerance {
+		return true
+	}
+	if state.Cycle == "" || snap.NextResetAt == "" || snap.NextResetAt == state.Cycle {
+		return false
+	}
+	resetAt, err := time.Parse(time.RFC3339, state.Cycle)
+	return err == nil && !now.Before(resetAt)
+}
+
+// triggered 判斷規則的條件是否成立
+func triggered(rule Rule, usedPercent, remaining float64) bool {
+	switch rule.Kind {
+	case KindUsedPercent:
+		return usedPercent >= rule.Threshold
+	case KindMinRemaining:
+		return remaining < rule.Threshold
+	default:
+		return false
+	}
+}
+
+// message 產生警示訊息
+func message(name string, rule Rule, snap Snapshot, usedPercent, remaining float64) string {
+	if rule.Kind == KindMinRemaining {
+		return fmt.Sprintf("%s 剩餘額度 %.2f，已低於 %g（%.2f / %.2f）",
+			name, remaining, rule.Threshold, snap.CurrentUsage, snap.UsageLimit)
+	}
+	return fmt.Sprintf("%s 已使用 %.0f%% 額度，達到 %g%% 警示（剩餘 %.2f）",
+		name, usedPercent, rule.Threshold, remaining)
+}
+
+// statePath 取得備份的警示狀態檔路徑
+func statePath(name string) (string, error) {
+	if err := backup.ValidateBackupName(name); err != nil {
+		return "", err
+	}
+	if !backup.BackupExists(name) {
+		return "", backup.ErrBackupNotFound
+	}
+	backupPath, err := backup.GetBackupPath(name)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(backupPath, backup.AlertStateFileName), nil
+}
+
+// Check 讀取帳號的警示狀態、評估規則並寫回（持有跨行程鎖）
+// 狀態檔損毀時視為沒有觸發紀錄
+func Check(name string, snap Snapshot, rules []Rule, now time.Time) ([]Event, error) {
+	path, err := statePath(name)
+	if err != nil {
+		return nil, err
+	}
+
+	var events []Event
+	err = atomicfile.Update(path, 0600, func(old []byte, exists bool) ([]byte, error) {
+		var state State
+		if exists {
+			if err := json.Unmarshal(old, &state); err != nil {
+				slog.Warn("discarding unreadable alert state", "backup", name, "error", err)
+				state = State{}
+			}
+		}
+		events = Evaluate(&state, name, snap, rules, now)
+		return json.MarshalIndent(state, "", "  ")
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to update alert state: %w", err)
+	}
+
+	for _, e := range events {
+		slog.Info("usage alert", "backup", name, "rule", e.Rule.ID(), "usedPercent", e.UsedPercent, "remaining", e.Remaining)
+	}
+	return events, nil
+}
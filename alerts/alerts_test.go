@@ -0,0 +1,256 @@
+package alerts
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"testing/quick"
+	"time"
+
+	"kiro-manager/backup"
+	"kiro-manager/internal/datadir"
+	"kiro-manager/settings"
+)
+
+var (
+	testRules = []Rule{
+		{Kind: KindUsedPercent, Threshold: 50},
+		{Kind: KindUsedPercent, Threshold: 80},
+		{Kind: KindMinRemaining, Threshold: 10},
+	}
+	testNow = time.Date(2025, 12, 10, 8, 0, 0, 0, time.UTC)
+)
+
+const testReset = "2026-01-01T00:00:00Z"
+
+// ruleIDs 取得事件觸發的規則 ID
+func ruleIDs(events []Event) []string {
+	ids := make([]string, 0, len(events))
+	for _, e := range events {
+		ids = append(ids, e.Rule.ID())
+	}
+	return ids
+}
+
+// snapshot 建立總額度 100 的用量快照
+func snapshot(used float64, nextResetAt string) Snapshot {
+	return Snapshot{SubscriptionTitle: "KIRO PRO", UsageLimit: 100, CurrentUsage: used, NextResetAt: nextResetAt}
+}
+
+// TestFromSettings 測試設定規則的轉換與無效規則的略過
+func TestFromSettings(t *testing.T) {
+	rules := FromSettings([]settings.AlertRule{
+		{UsedPercent: 80},
+		{MinRemaining: 25},
+		{UsedPercent: 120},
+		{UsedPercent: 50, MinRemaining: 5},
+		{},
+	})
+	got := []string{}
+	for _, r := range rules {
+		got = append(got, r.ID())
+	}
+	if strings.Join(got, ",") != "usedPercent:80,minRemaining:25" {
+		t.Errorf("FromSettings = %v", got)
+	}
+}
+
+// TestEvaluate_EdgeTriggered 測試每個等級在跨過門檻時觸發一次，之後不再重複
+func TestEvaluate_EdgeTriggered(t *testing.T) {
+	var state State
+	steps := []struct {
+		used float64
+		want string
+	}{
+		{30, ""},
+		{55, "usedPercent:50"},
+		{60, ""},
+		{85, "usedPercent:80"},
+		{95, "minRemaining:10"},
+		{99, ""},
+	}
+	for i, step := range steps {
+		events := Evaluate(&state, "work", snapshot(step.used, testReset), testRules, testNow.Add(time.Duration(i)*time.Hour))
+		if got := strings.Join(ruleIDs(events), ","); got != step.want {
+			t.Errorf("step %d (used %.0f): events = %q, want %q", i, step.used, got, step.want)
+		}
+	}
+
+	if len(state.Fired) != 3 || state.LastUsage != 99 || state.Cycle != testReset {
+		t.Errorf("unexpected state: %+v", state)
+	}
+}
+
+// TestEvaluate_MultipleLevelsAtOnce 測試一次跨過多個等級時全部觸發
+func TestEvaluate_MultipleLevelsAtOnce(t *testing.T) {
+	var state State
+	events := Evaluate(&state, "work", snapshot(92, testReset), testRules, testNow)
+	if got := strings.Join(ruleIDs(events), ","); got != "usedPercent:50,usedPercent:80,minRemaining:10" {
+		t.Fatalf("events = %q", got)
+	}
+
+	e := events[1]
+	if e.Backup != "work" || e.UsedPercent != 92 || e.Remaining != 8 || e.Message == "" || e.Time != testNow.Format(time.RFC3339) {
+		t.Errorf("unexpected event: %+v", e)
+	}
+}
+
+// TestEvaluate_NewCycle 測試超過重置時間或用量下降時重新啟用已觸發的等級
+func TestEvaluate_NewCycle(t *testing.T) {
+	t.Run("reset time passed", func(t *testing.T) {
+		var state State
+		Evaluate(&state, "work", snapshot(60, "2025-12-11T00:00:00Z"), testRules, testNow)
+
+		// 重置時間已過，但 API 仍回報舊的重置時間：尚未重置
+		if events := Evaluate(&state, "work", snapshot(60, "2025-12-11T00:00:00Z"), testRules, testNow.Add(48*time.Hour)); len(events) != 0 {
+			t.Errorf("stale reset time should not start a new cycle: %v", ruleIDs(events))
+		}
+		// API 回報新的重置時間，用量仍高於門檻（例如重置後很快又用掉）
+		events := Evaluate(&state, "work", snapshot(60, "2026-01-11T00:00:00Z"), testRules, testNow.Add(49*time.Hour))
+		if got := strings.Join(ruleIDs(events), ","); got != "usedPercent:50" {
+			t.Errorf("events after reset = %q, want usedPercent:50", got)
+		}
+	})
+
+	t.Run("reset time not reached", func(t *testing.T) {
+		var state State
+		Evaluate(&state, "work", snapshot(60, "2025-12-11T00:00:00Z"), testRules, testNow)
+		// 只有天數的回應每次推算的重置時間會略有不同，未到原本的重置時間不視為新週期
+		if events := Evaluate(&state, "work", snapshot(61, "2025-12-11T01:00:00Z"), testRules, testNow.Add(time.Hour)); len(events) != 0 {
+			t.Errorf("drifting reset time should not start a new cycle: %v", ruleIDs(events))
+		}
+	})
+
+	t.Run("usage dropped", func(t *testing.T) {
+		var state State
+		Evaluate(&state, "work", snapshot(60, ""), testRules, testNow)
+		if events := Evaluate(&state, "work", snapshot(5, ""), testRules, testNow.Add(time.Hour)); len(events) != 0 {
+			t.Errorf("no level should fire right after reset: %v", ruleIDs(events))
+		}
+		events := Evaluate(&state, "work", snapshot(51, ""), testRules, testNow.Add(2*time.Hour))
+		if got := strings.Join(ruleIDs(events), ","); got != "usedPercent:50" {
+			t.Errorf("events after usage drop = %q, want usedPercent:50", got)
+		}
+	})
+}
+
+// TestEvaluate_ZeroLimit 測試總額度為 0 時不觸發
+func TestEvaluate_ZeroLimit(t *testing.T) {
+	var state State
+	events := Evaluate(&state, "work", Snapshot{UsageLimit: 0, CurrentUsage: 0}, testRules, testNow)
+	if len(events) != 0 {
+		t.Errorf("events = %v, want none", ruleIDs(events))
+	}
+}
+
+// TestCheck_PersistsState 測試 Check 將觸發紀錄寫入備份目錄，重複呼叫不再觸發
+func TestCheck_PersistsState(t *testing.T) {
+	t.Cleanup(func() { settings.LoadSettings() })
+	t.Setenv(datadir.EnvVar, t.TempDir())
+	settings.LoadSettings()
+
+	if _, err := Check("missing", snapshot(90, ""), testRules, testNow); !errors.Is(err, backup.ErrBackupNotFound) {
+		t.Fatalf("Check on missing backup: err = %v, want ErrBackupNotFound", err)
+	}
+
+	backupPath, err := backup.GetBackupPath("work")
+	if err != nil {
+		t.Fatalf("GetBackupPath failed: %v", err)
+	}
+	if err := os.MkdirAll(backupPath, 0700); err != nil {
+		t.Fatalf("MkdirAll failed: %v", err)
+	}
+
+	events, err := Check("work", snapshot(90, testReset), testRules, testNow)
+	if err != nil {
+		t.Fatalf("Check failed: %v", err)
+	}
+	if len(events) != 2 {
+		t.Fatalf("first Check events = %v, want 2", ruleIDs(events))
+	}
+
+	data, err := os.ReadFile(filepath.Join(backupPath, backup.AlertStateFileName))
+	if err != nil {
+		t.Fatalf("alert state not written: %v", err)
+	}
+	var state State
+	if err := json.Unmarshal(data, &state); err != nil || len(state.Fired) != 2 || state.Cycle != testReset {
+		t.Fatalf("unexpected state file %s (err %v)", data, err)
+	}
+
+	events, err = Check("work", snapshot(90, testReset), testRules, testNow.Add(time.Hour))
+	if err != nil || len(events) != 0 {
+		t.Errorf("second Check = %v, %v; want no events", ruleIDs(events), err)
+	}
+}
+
+// TestRunCommand 測試使用者指令收到 JSON 事件與環境變數
+func TestRunCommand(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh")
+	}
+
+	out := filepath.Join(t.TempDir(), "event.json")
+	event := Evaluate(&State{}, "work", snapshot(85, testReset), testRules[1:2], testNow)[0]
+	command := `cat > "$OUT" && printf '%s|%s' "$KIRO_ALERT_BACKUP" "$KIRO_ALERT_RULE" > "$OUT.env"`
+	t.Setenv("OUT", out)
+
+	if err := RunCommand(context.Background(), command, event); err != nil {
+		t.Fatalf("RunCommand failed: %v", err)
+	}
+
+	var got Event
+	data, _ := os.ReadFile(out)
+	if err := json.Unmarshal(data, &got); err != nil || got.Backup != "work" || got.Rule != event.Rule {
+		t.Errorf("stdin payload = %s (err %v)", data, err)
+	}
+	env, _ := os.ReadFile(out + ".env")
+	if string(env) != "work|usedPercent:80" {
+		t.Errorf("env = %q", env)
+	}
+
+	if err := RunCommand(context.Background(), "echo boom >&2; exit 3", event); err == nil || !strings.Contains(err.Error(), "boom") {
+		t.Errorf("failing command error = %v, want output included", err)
+	}
+}
+
+// **Feature: alerts, Property 1: Each Level Fires Once Per Cycle**
+// *For any* non-decreasing sequence of usage within one cycle, each rule SHALL
+// fire at most once, and a rule whose condition holds at the end SHALL have fired.
+func TestProperty_EachLevelFiresOncePerCycle(t *testing.T) {
+	f := func(seed int64, n uint8) bool {
+		rng := rand.New(rand.NewSource(seed))
+		var state State
+		counts := make(map[string]int)
+		used := 0.0
+		for i := 0; i < 1+int(n%50); i++ {
+			used = min(used+rng.Float64()*10, 120)
+			for _, e := range Evaluate(&state, "work", snapshot(used, testReset), testRules, testNow.Add(time.Duration(i)*time.Minute)) {
+				counts[e.Rule.ID()]++
+			}
+		}
+		for _, rule := range testRules {
+			if counts[rule.ID()] > 1 {
+				return false
+			}
+			if triggered(rule, used, 100-used) && counts[rule.ID()] != 1 {
+				return false
+			}
+		}
+		return true
+	}
+
+	config := &quick.Config{
+		MaxCount: 100,
+	}
+
+	if err := quick.Check(f, config); err != nil {
+		t.Errorf("Property test failed: %v", err)
+	}
+}
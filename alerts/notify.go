@@ -0,0 +1,122 @@
+package alerts
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"time"
+
+	"kiro-manager/internal/cmdutil"
+)
+
+// DefaultCommandTimeout 使用者指令的預設執行時間上限
+const DefaultCommandTimeout = 30 * time.Second
+
+// notificationTitle 桌面通知標題
+const notificationTitle = "Kiro Manager 用量警示"
+
+// windowsToastScript 以 WinRT 顯示 Windows 通知，標題與內容由環境變數傳入避免注入
+const windowsToastScript = `[Windows.UI.Notifications.ToastNotificationManager, Windows.UI.Notifications, ContentType = WindowsRuntime] | Out-Null
+$xml = [Windows.UI.Notifications.ToastNotificationManager]::GetTemplateContent([Windows.UI.Notifications.ToastTemplateType]::ToastText02)
+$text = $xml.GetElementsByTagName('text')
+$text.Item(0).AppendChild($xml.CreateTextNode($env:KIRO_ALERT_TITLE)) | Out-Null
+$text.Item(1).AppendChild($xml.CreateTextNode($env:KIRO_ALERT_BODY)) | Out-Null
+$toast = [Windows.UI.Notifications.ToastNotification]::new($xml)
+[Windows.UI.Notifications.ToastNotificationManager]::CreateToastNotifier('{1AC14E77-02E7-4E5D-B744-2EB1AE5198B7}\WindowsPowerShell\v1.0\powershell.exe').Show($toast)`
+
+// darwinNotifyScript 以 AppleScript 顯示 macOS 通知，標題與內容由參數傳入避免注入
+const darwinNotifyScript = `on run argv
+display notification (item 2 of argv) with title (item 1 of argv)
+end run`
+
+// ErrUnsupportedPlatform 目前平台不支援桌面通知
+var ErrUnsupportedPlatform = errors.New("desktop notification is not supported on " + runtime.GOOS)
+
+// Notifier 將警示送往桌面通知與使用者指令
+type Notifier struct {
+	Desktop bool          // 是否顯示桌面通知
+	Command string        // 觸發時執行的指令（空字串表示不執行）
+	Timeout time.Duration // 指令執行時間上限（0 使用 DefaultCommandTimeout）
+}
+
+// Send 依序送出警示；失敗只記錄警告，不影響其他事件
+func (n Notifier) Send(events []Event) {
+	for _, e := range events {
+		if n.Desktop {
+			if err := Notify(notificationTitle, e.Message); err != nil {
+				slog.Warn("failed to show desktop notification", "backup", e.Backup, "error", err)
+			}
+		}
+		if n.Command != "" {
+			timeout := n.Timeout
+			if timeout <= 0 {
+				timeout = DefaultCommandTimeout
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			if err := RunCommand(ctx, n.Command, e); err != nil {
+				slog.Warn("alert command failed", "backup", e.Backup, "error", err)
+			}
+			cancel()
+		}
+	}
+}
+
+// Notify 顯示桌面通知
+// Linux 使用 notify-send，macOS 使用 osascript，Windows 使用 PowerShell 的 WinRT 通知
+func Notify(title, body string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "linux":
+		cmd = exec.Command("notify-send", "--app-name=Kiro Manager", "--", title, body)
+	case "darwin":
+		cmd = exec.Command("osascript", "-e", darwinNotifyScript, title, body)
+	case "windows":
+		cmd = exec.Command("powershell", "-NoProfile", "-NonInteractive", "-Command", windowsToastScript)
+		cmd.Env = append(os.Environ(), "KIRO_ALERT_TITLE="+title, "KIRO_ALERT_BODY="+body)
+		cmdutil.HideWindow(cmd)
+	default:
+		return ErrUnsupportedPlatform
+	}
+
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("%w: %s", err, bytes.TrimSpace(output))
+	}
+	return nil
+}
+
+// RunCommand 以系統 shell 執行使用者指令（Windows 為 cmd /C，其他平台為 sh -c）
+// 事件以 JSON 由標準輸入傳入，主要欄位同時以 KIRO_ALERT_* 環境變數提供
+func RunCommand(ctx context.Context, command string, event Event) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", command)
+		cmdutil.HideWindow(cmd)
+	} else {
+		cmd = exec.CommandContext(ctx, "sh", "-c", command)
+	}
+	cmd.Stdin = bytes.NewReader(payload)
+	cmd.Env = append(os.Environ(),
+		"KIRO_ALERT_BACKUP="+event.Backup,
+		"KIRO_ALERT_RULE="+event.Rule.ID(),
+		"KIRO_ALERT_USED_PERCENT="+strconv.FormatFloat(event.UsedPercent, 'f', 2, 64),
+		"KIRO_ALERT_REMAINING="+strconv.FormatFloat(event.Remaining, 'f', 2, 64),
+		"KIRO_ALERT_MESSAGE="+event.Message,
+	)
+
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("%w: %s", err, bytes.TrimSpace(output))
+	}
+	return nil
+}
@@ -1,29 +1,54 @@
 package main
 
 import (
+	"archive/zip"
+	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"log/slog"
+	"os"
 	"os/exec"
 	"path/filepath"
 	"runtime"
+	"strings"
 	"time"
 
+	"kiro-manager/alerts"
 	"kiro-manager/awssso"
 	"kiro-manager/backup"
+	"kiro-manager/internal/atomicfile"
+	"kiro-manager/internal/endpoints"
+	"kiro-manager/internal/logging"
 	"kiro-manager/internal/shield"
+	"kiro-manager/internal/transport"
+	"kiro-manager/kiropath"
 	"kiro-manager/kiroprocess"
 	"kiro-manager/kiroversion"
 	"kiro-manager/machineid"
+	"kiro-manager/report"
 	// "kiro-manager/reset" // 暫時停用硬一鍵新機功能
 	"kiro-manager/settings"
 	"kiro-manager/softreset"
 	"kiro-manager/tokenrefresh"
 	"kiro-manager/usage"
+	"kiro-manager/usagehistory"
+	"kiro-manager/workspace"
 )
 
 // App struct
 type App struct {
 	ctx context.Context
+	// stopWatcher 停止 Kiro 進程監看（應用程式關閉時呼叫）
+	stopWatcher context.CancelFunc
+	// logOutput 記錄檔以外的額外記錄輸出（CLI --verbose 時為 stderr）
+	logOutput io.Writer
+	// logLevel 覆寫設定中的記錄等級（CLI --verbose 時為 debug）
+	logLevel *slog.Level
+	// migrationErr 啟動時加密舊版明文備份失敗的原因（憑證仍為明文）
+	migrationErr error
 }
 
 // NewApp creates a new App application struct
@@ -41,26 +66,69 @@ func (a *App) startup(ctx context.Context) {
 
 	// 不再於啟動時自動備份，避免觸發防毒軟體誤報
 	// 改為在用戶首次執行需要備份的操作時才觸發
+
+	// 一次性遷移：舊版將設定與備份存放於執行檔同層，搬移至使用者資料目錄
+	// 設定需先搬移，備份根目錄才能依設定中的 dataDir 解析
+	settings.MigrateLegacySettings()
+	backup.MigrateLegacyBackups()
+
+	// 記錄檔（依設定的等級，輸出前遮蔽 token 與識別資訊）
+	a.setupLogging()
+
+	// 一次性遷移：將舊版明文備份中的憑證加密
+	// 金鑰不可用（無 keyring 且未設定密碼）時略過，待 UnlockBackups 後再遷移
+	if _, err := backup.MigratePlaintextBackups(); err != nil && !errors.Is(err, backup.ErrEncryptionKeyUnavailable) {
+		slog.Warn("failed to encrypt plaintext backups", "error", err)
+		a.migrationErr = err
+	}
+
+	// 監看 Kiro 的啟動與結束並送往前端（CLI 由 kiro watch 自行監看）
+	var watchCtx context.Context
+	watchCtx, a.stopWatcher = context.WithCancel(ctx)
+	watchKiro(watchCtx)
+}
+
+// shutdown is called when the app is closing
+func (a *App) shutdown(ctx context.Context) {
+	if a.stopWatcher != nil {
+		a.stopWatcher()
+	}
+}
+
+// setupLogging 初始化記錄檔；無法寫入時仍可繼續執行（僅失去記錄）
+func (a *App) setupLogging() {
+	level := settings.GetLogLevel()
+	if a.logLevel != nil {
+		level = *a.logLevel
+	}
+	if err := logging.Setup(logging.Options{Level: level, Extra: a.logOutput}); err != nil {
+		slog.Warn("failed to open log file", "error", err)
+		return
+	}
+	slog.Info("kiro-manager started", "version", appVersion, "platform", runtime.GOOS)
 }
 
 // BackupItem 備份項目（前端用）
 type BackupItem struct {
-	Name              string  `json:"name"`
-	BackupTime        string  `json:"backupTime"`
-	HasToken          bool    `json:"hasToken"`
-	HasMachineID      bool    `json:"hasMachineId"`
-	MachineID         string  `json:"machineId"`
-	Provider          string  `json:"provider"`
-	IsCurrent         bool    `json:"isCurrent"`
-	IsOriginalMachine bool    `json:"isOriginalMachine"` // Machine ID 與原始機器相同
-	IsTokenExpired    bool    `json:"isTokenExpired"`    // Token 是否已過期
+	Name              string `json:"name"`
+	BackupTime        string `json:"backupTime"`
+	HasToken          bool   `json:"hasToken"`
+	HasMachineID      bool   `json:"hasMachineId"`
+	MachineID         string `json:"machineId"`
+	Provider          string `json:"provider"`
+	IsCurrent         bool   `json:"isCurrent"`
+	IsOriginalMachine bool   `json:"isOriginalMachine"` // Machine ID 與原始機器相同
+	IsTokenExpired    bool   `json:"isTokenExpired"`    // Token 是否已過期
 	// Usage 相關欄位 (Requirements: 1.1, 1.2)
-	SubscriptionTitle string  `json:"subscriptionTitle"` // 訂閱類型名稱
-	UsageLimit        float64 `json:"usageLimit"`        // 總額度
-	CurrentUsage      float64 `json:"currentUsage"`      // 已使用
-	Balance           float64 `json:"balance"`           // 餘額
-	IsLowBalance      bool    `json:"isLowBalance"`      // 餘額低於 20%
-	CachedAt          string  `json:"cachedAt"`          // 緩存時間（用於前端判斷冷卻期）
+	SubscriptionTitle string            `json:"subscriptionTitle"` // 訂閱類型名稱
+	UsageLimit        float64           `json:"usageLimit"`        // 總額度
+	CurrentUsage      float64           `json:"currentUsage"`      // 已使用
+	Balance           float64           `json:"balance"`           // 餘額
+	IsLowBalance      bool              `json:"isLowBalance"`      // 餘額低於 20%
+	CachedAt          string            `json:"cachedAt"`          // 緩存時間（用於前端判斷冷卻期）
+	Breakdown         []usage.Breakdown `json:"breakdown"`         // 基本、試用與獎勵額度明細
+	NextResetAt       string            `json:"nextResetAt"`       // 下次重置時間（RFC3339），未知時為空
+	DaysUntilReset    int               `json:"daysUntilReset"`    // 距離重置的天數
 }
 
 // Result 通用回傳結果
@@ -76,8 +144,8 @@ func (a *App) GetBackupList() ([]BackupItem, error) {
 		return nil, err
 	}
 
-	// 取得當前 Machine ID（優先使用軟重置的自訂 ID）
-	currentMachineID := a.GetCurrentMachineID()
+	// 依 token 識別資訊找出目前登入帳號對應的備份（Machine ID 優先使用軟重置的自訂 ID）
+	currentBackupName := currentBackupName(a.GetCurrentMachineID())
 
 	// 讀取原始 Machine ID
 	var originalMachineID string
@@ -96,6 +164,7 @@ func (a *App) GetBackupList() ([]BackupItem, error) {
 			Name:         b.Name,
 			HasToken:     b.HasToken,
 			HasMachineID: b.HasMachineID,
+			IsCurrent:    b.Name == currentBackupName,
 		}
 
 		if !b.BackupTime.IsZero() {
@@ -106,20 +175,18 @@ func (a *App) GetBackupList() ([]BackupItem, error) {
 			mid, err := backup.ReadBackupMachineID(b.Name)
 			if err == nil {
 				item.MachineID = mid.MachineID
-				item.IsCurrent = mid.MachineID == currentMachineID
 				item.IsOriginalMachine = mid.MachineID == originalMachineID
 			}
 		}
 
 		// 讀取 token 中的 provider 和過期狀態
 		if b.HasToken {
-			token, err := backup.ReadBackupToken(b.Name)
-			if err == nil && token != nil {
-				if token.Provider != "" {
-					item.Provider = token.Provider
+			if summary, err := backup.ReadBackupTokenSummary(b.Name); err == nil {
+				if summary.Identity.Provider != "" {
+					item.Provider = summary.Identity.Provider
 				}
 				// 檢查 token 是否已過期
-				item.IsTokenExpired = awssso.IsTokenExpired(token)
+				item.IsTokenExpired = awssso.IsTokenExpired(&awssso.KiroAuthToken{ExpiresAt: summary.ExpiresAt})
 			}
 		}
 
@@ -129,11 +196,10 @@ func (a *App) GetBackupList() ([]BackupItem, error) {
 			item.UsageLimit = usageCache.UsageLimit
 			item.CurrentUsage = usageCache.CurrentUsage
 			item.Balance = usageCache.Balance
-			// 使用設定的閾值重新計算 IsLowBalance
-			threshold := settings.GetLowBalanceThreshold()
-			if usageCache.UsageLimit > 0 {
-				item.IsLowBalance = (usageCache.Balance / usageCache.UsageLimit) < threshold
-			}
+			item.Breakdown = usageCache.Breakdown
+			item.NextResetAt = usageCache.NextResetAt
+			item.DaysUntilReset = usage.DaysUntilReset(usageCache.NextResetAt, time.Now())
+			item.IsLowBalance = lowBalance(usageCache.Balance, usageCache.UsageLimit)
 			// 傳遞緩存時間供前端判斷冷卻期
 			if !usageCache.CachedAt.IsZero() {
 				item.CachedAt = usageCache.CachedAt.Format(time.RFC3339)
@@ -149,74 +215,61 @@ func (a *App) GetBackupList() ([]BackupItem, error) {
 
 // UsageCacheResult 餘額刷新結果
 type UsageCacheResult struct {
-	Success           bool    `json:"success"`
-	Message           string  `json:"message"`
-	SubscriptionTitle string  `json:"subscriptionTitle"`
-	UsageLimit        float64 `json:"usageLimit"`
-	CurrentUsage      float64 `json:"currentUsage"`
-	Balance           float64 `json:"balance"`
-	IsLowBalance      bool    `json:"isLowBalance"`
-	IsTokenExpired    bool    `json:"isTokenExpired"` // Token 是否已過期（刷新成功後為 false）
-	CachedAt          string  `json:"cachedAt"`       // 緩存時間（用於前端判斷冷卻期）
+	Success           bool              `json:"success"`
+	Message           string            `json:"message"`
+	SubscriptionTitle string            `json:"subscriptionTitle"`
+	UsageLimit        float64           `json:"usageLimit"`
+	CurrentUsage      float64           `json:"currentUsage"`
+	Balance           float64           `json:"balance"`
+	IsLowBalance      bool              `json:"isLowBalance"`
+	IsTokenExpired    bool              `json:"isTokenExpired"` // Token 是否已過期（刷新成功後為 false）
+	CachedAt          string            `json:"cachedAt"`       // 緩存時間（用於前端判斷冷卻期）
+	Breakdown         []usage.Breakdown `json:"breakdown"`      // 基本、試用與獎勵額度明細
+	Credits           []usage.Credit    `json:"credits"`        // 仍有剩餘的額度（依到期時間由早到晚）
+	NextResetAt       string            `json:"nextResetAt"`    // 下次重置時間（RFC3339），未知時為空
+	DaysUntilReset    int               `json:"daysUntilReset"` // 距離重置的天數
 }
 
 // RefreshBackupUsage 刷新指定備份的餘額資訊
 // 需求: 1.1, 1.2, 1.3, 1.4, 1.5
 func (a *App) RefreshBackupUsage(name string) UsageCacheResult {
+	result, err := a.refreshBackupUsage(name)
+	if err != nil {
+		return UsageCacheResult{Success: false, Message: err.Error()}
+	}
+	return *result
+}
+
+// refreshBackupUsage 刷新指定備份的餘額資訊並寫入緩存
+// 錯誤保留原始類型（如 *tokenrefresh.RefreshError），供 CLI 判斷結束碼
+func (a *App) refreshBackupUsage(name string) (*UsageCacheResult, error) {
 	if name == "" {
-		return UsageCacheResult{Success: false, Message: "備份名稱不能為空"}
+		return nil, errors.New("備份名稱不能為空")
 	}
 
 	if !backup.BackupExists(name) {
-		return UsageCacheResult{Success: false, Message: "備份不存在"}
+		return nil, errors.New("備份不存在")
 	}
 
 	// 先讀取備份的 Machine ID（用於 Token 刷新和 API 呼叫）
 	mid, err := backup.ReadBackupMachineID(name)
 	if err != nil {
-		return UsageCacheResult{Success: false, Message: "無法讀取備份的 Machine ID"}
+		return nil, errors.New("無法讀取備份的 Machine ID")
 	}
 	hashedMachineID := machineid.HashMachineID(mid.MachineID)
 
 	// 讀取備份的 token
 	token, err := backup.ReadBackupToken(name)
 	if err != nil {
-		return UsageCacheResult{Success: false, Message: "無法讀取備份的 token"}
+		return nil, errors.New("無法讀取備份的 token")
 	}
 
 	// 檢查 token 是否已過期（需求 1.1）
 	if awssso.IsTokenExpired(token) {
 		// 嘗試刷新 Token（需求 1.1, 1.2, 1.3）
-		// 使用對應環境快照的 Machine ID 的 SHA256 雜湊值
-		var newTokenInfo *tokenrefresh.TokenInfo
-		var err error
-
-		// 檢查是否為 IdC 認證，如果是則從備份目錄讀取 clientId/clientSecret
-		authType := tokenrefresh.DetectAuthType(token)
-		if authType == "idc" && token.ClientIdHash != "" {
-			// 從備份目錄讀取 IdC credentials
-			clientID, clientSecret, credErr := backup.ReadBackupIdCCredentials(name, token.ClientIdHash)
-			if credErr != nil {
-				return UsageCacheResult{Success: false, Message: "無法讀取 IdC 認證資訊: " + credErr.Error()}
-			}
-			newTokenInfo, err = tokenrefresh.RefreshAccessTokenFromBackup(token, hashedMachineID, clientID, clientSecret)
-		} else {
-			// Social 認證或其他情況，使用原有邏輯
-			newTokenInfo, err = tokenrefresh.RefreshAccessToken(token, hashedMachineID)
-		}
-
-		if err != nil {
+		if err := refreshBackupToken(name, token, hashedMachineID); err != nil {
 			// 刷新失敗，返回錯誤（需求 1.5）
-			return UsageCacheResult{Success: false, Message: err.Error()}
-		}
-
-		// 更新 token 結構的新值（需求 1.2, 1.3）
-		token.AccessToken = newTokenInfo.AccessToken
-		token.ExpiresAt = newTokenInfo.ExpiresAt.UTC().Format("2006-01-02T15:04:05.000Z")
-
-		// 呼叫 WriteBackupToken() 持久化刷新後的 token（需求 3.1, 3.2）
-		if err := backup.WriteBackupToken(name, token.AccessToken, token.ExpiresAt); err != nil {
-			return UsageCacheResult{Success: false, Message: "Token 刷新成功但寫入失敗: " + err.Error()}
+			return nil, err
 		}
 	}
 
@@ -224,46 +277,324 @@ func (a *App) RefreshBackupUsage(name string) UsageCacheResult {
 	// hashedMachineID 已在上方計算
 	usageInfo, err := usage.GetUsageLimitsWithMachineID(token, hashedMachineID)
 	if err != nil {
-		return UsageCacheResult{Success: false, Message: fmt.Sprintf("API 呼叫失敗: %v", err)}
+		return nil, fmt.Errorf("API 呼叫失敗: %w", err)
 	}
 
 	if usageInfo == nil || usageInfo.SubscriptionTitle == "" {
-		return UsageCacheResult{Success: false, Message: "無法取得用量資訊"}
+		return nil, errors.New("無法取得用量資訊")
 	}
 
-	// 使用設定的閾值重新計算 IsLowBalance
-	threshold := settings.GetLowBalanceThreshold()
-	isLowBalance := false
-	if usageInfo.UsageLimit > 0 {
-		isLowBalance = (usageInfo.Balance / usageInfo.UsageLimit) < threshold
+	isLowBalance := lowBalance(usageInfo.Balance, usageInfo.UsageLimit)
+
+	// 寫入緩存與用量歷史，並評估用量警示
+	if err := a.saveUsage(name, usageInfo, isLowBalance); err != nil {
+		return nil, fmt.Errorf("緩存寫入失敗: %w", err)
 	}
 
-	// 寫入緩存
+	// 緩存時間為當前時間（WriteUsageCache 會設定 CachedAt）
+	cachedAt := time.Now().Format(time.RFC3339)
+
+	return &UsageCacheResult{
+		Success:           true,
+		Message:           "刷新成功",
+		SubscriptionTitle: usageInfo.SubscriptionTitle,
+		UsageLimit:        usageInfo.UsageLimit,
+		CurrentUsage:      usageInfo.CurrentUsage,
+		Balance:           usageInfo.Balance,
+		IsLowBalance:      isLowBalance,
+		IsTokenExpired:    false, // 刷新成功代表 token 有效
+		CachedAt:          cachedAt,
+		Breakdown:         usageInfo.Breakdown,
+		Credits:           usage.Credits(usageInfo.Breakdown),
+		NextResetAt:       usageInfo.NextResetAt,
+		DaysUntilReset:    usage.DaysUntilReset(usageInfo.NextResetAt, time.Now()),
+	}, nil
+}
+
+// lowBalance 依設定的閾值判斷是否為低餘額
+func lowBalance(balance, usageLimit float64) bool {
+	return usage.IsLowBalance(balance, usageLimit, settings.GetLowBalanceThreshold())
+}
+
+// saveUsage 將成功查詢的用量寫入備份的緩存，附加至用量歷史並評估用量警示
+// 歷史寫入與警示失敗不影響緩存（只記錄警告）；超過保留天數的紀錄會一併清除
+func (a *App) saveUsage(name string, usageInfo *usage.UsageInfo, isLowBalance bool) error {
 	cache := &backup.UsageCache{
 		SubscriptionTitle: usageInfo.SubscriptionTitle,
 		UsageLimit:        usageInfo.UsageLimit,
 		CurrentUsage:      usageInfo.CurrentUsage,
 		Balance:           usageInfo.Balance,
 		IsLowBalance:      isLowBalance,
+		Breakdown:         usageInfo.Breakdown,
+		NextResetAt:       usageInfo.NextResetAt,
+		Raw:               usageInfo.Raw,
 	}
 	if err := backup.WriteUsageCache(name, cache); err != nil {
-		return UsageCacheResult{Success: false, Message: fmt.Sprintf("緩存寫入失敗: %v", err)}
+		return err
 	}
 
-	// 緩存時間為當前時間（WriteUsageCache 會設定 CachedAt）
-	cachedAt := time.Now().Format(time.RFC3339)
+	fetchedAt := usageInfo.FetchedAt
+	if fetchedAt.IsZero() {
+		fetchedAt = time.Now()
+	}
+	record := usagehistory.Record{
+		Time:              fetchedAt.UTC(),
+		SubscriptionTitle: usageInfo.SubscriptionTitle,
+		UsageLimit:        usageInfo.UsageLimit,
+		CurrentUsage:      usageInfo.CurrentUsage,
+	}
+	if err := usagehistory.Append(name, record); err != nil {
+		slog.Warn("failed to append usage history", "backup", name, "error", err)
+	} else {
+		before := fetchedAt.AddDate(0, 0, -settings.GetHistoryRetentionDays())
+		if _, err := usagehistory.Prune(name, before); err != nil {
+			slog.Warn("failed to prune usage history", "backup", name, "error", err)
+		}
+	}
 
-	return UsageCacheResult{
-		Success:           true,
-		Message:           "刷新成功",
+	a.checkAlerts(name, usageInfo, fetchedAt)
+	return nil
+}
+
+// checkAlerts 依帳號的警示規則評估最新用量，並將新觸發的事件送往前端、桌面通知與指令
+func (a *App) checkAlerts(name string, usageInfo *usage.UsageInfo, now time.Time) {
+	snapshot := alerts.Snapshot{
 		SubscriptionTitle: usageInfo.SubscriptionTitle,
 		UsageLimit:        usageInfo.UsageLimit,
 		CurrentUsage:      usageInfo.CurrentUsage,
-		Balance:           usageInfo.Balance,
-		IsLowBalance:      isLowBalance,
-		IsTokenExpired:    false, // 刷新成功代表 token 有效
-		CachedAt:          cachedAt,
+		NextResetAt:       usageInfo.NextResetAt,
+	}
+	events, err := alerts.Check(name, snapshot, alerts.FromSettings(settings.GetAlertRules(name)), now)
+	if err != nil {
+		slog.Warn("failed to evaluate usage alerts", "backup", name, "error", err)
+		return
+	}
+	if len(events) == 0 {
+		return
+	}
+
+	desktop, command := settings.GetAlertOptions()
+	emitAlerts(a.ctx, events)
+	notifyAlerts(alerts.Notifier{Desktop: desktop, Command: command}, events)
+}
+
+// UsageForecastResult 用量預測結果（前端用）
+type UsageForecastResult struct {
+	Success  bool                  `json:"success"`
+	Message  string                `json:"message"`
+	Forecast usagehistory.Forecast `json:"forecast"`
+}
+
+// GetUsageForecast 依備份的用量歷史計算每日消耗速率、預估用完日期與每週用量
+func (a *App) GetUsageForecast(name string) UsageForecastResult {
+	forecast, err := usageForecast(name, time.Now())
+	if err != nil {
+		return UsageForecastResult{Success: false, Message: err.Error()}
+	}
+	return UsageForecastResult{Success: true, Message: "計算完成", Forecast: *forecast}
+}
+
+// usageForecast 讀取用量歷史並計算預測
+func usageForecast(name string, now time.Time) (*usagehistory.Forecast, error) {
+	records, err := usagehistory.Read(name)
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, errors.New("尚無用量歷史，請先刷新用量")
+	}
+	forecast := usagehistory.Compute(records, now)
+	return &forecast, nil
+}
+
+// PruneUsageHistory 依設定的保留天數清理所有備份的用量歷史
+func (a *App) PruneUsageHistory() Result {
+	retention := settings.GetHistoryRetentionDays()
+	removed, err := usagehistory.PruneAll(retention, time.Now())
+	if err != nil {
+		return Result{Success: false, Message: fmt.Sprintf("清理用量歷史失敗: %v", err)}
 	}
+	return Result{Success: true, Message: fmt.Sprintf("已清除 %d 筆超過 %d 天的用量紀錄", removed, retention)}
+}
+
+// UsageReportResult 用量報表匯出結果
+type UsageReportResult struct {
+	Success  bool   `json:"success"`
+	Message  string `json:"message"`
+	Path     string `json:"path"`     // 報表檔案路徑
+	RowCount int    `json:"rowCount"` // 報表中的帳號數
+}
+
+// ExportUsageReport 匯出帳號用量報表至資料目錄的 reports/
+// format: csv、json 或 md；from / to 為期間（YYYY-MM-DD 或 RFC3339，空字串表示不限，結束日包含當天）
+func (a *App) ExportUsageReport(format, from, to string) UsageReportResult {
+	parsed, err := report.ParseFormat(format)
+	if err != nil {
+		return UsageReportResult{Success: false, Message: fmt.Sprintf("不支援的報表格式: %s（可用 csv、json、md）", format)}
+	}
+	format = parsed
+	r, err := report.ParseRange(from, to, time.Local)
+	if err != nil {
+		return UsageReportResult{Success: false, Message: err.Error()}
+	}
+
+	path, rows, err := a.exportUsageReport(format, r, "")
+	if err != nil {
+		return UsageReportResult{Success: false, Message: fmt.Sprintf("匯出報表失敗: %v", err)}
+	}
+	return UsageReportResult{Success: true, Message: "報表已匯出至 " + path, Path: path, RowCount: rows}
+}
+
+// exportUsageReport 產生報表並寫入 dest（空值時使用資料目錄 reports/ 下的預設檔名），回傳實際寫入的路徑與帳號數
+func (a *App) exportUsageReport(format string, r report.Range, dest string) (string, int, error) {
+	now := time.Now()
+	if dest == "" {
+		dataDir, err := settings.GetDataDir()
+		if err != nil {
+			return "", 0, err
+		}
+		name := "kiro-usage-report-" + now.Format("20060102-150405") + "." + format
+		dest = filepath.Join(dataDir, "reports", name)
+	}
+
+	usageReport, err := a.usageReport(r, now)
+	if err != nil {
+		return "", 0, err
+	}
+	var buf bytes.Buffer
+	if err := report.Write(&buf, format, *usageReport); err != nil {
+		return "", 0, err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(dest), 0700); err != nil {
+		return "", 0, err
+	}
+	if err := atomicfile.WriteFile(dest, buf.Bytes(), 0600); err != nil {
+		return "", 0, err
+	}
+
+	slog.Info("usage report exported", "path", dest, "rows", len(usageReport.Rows))
+	return dest, len(usageReport.Rows), nil
+}
+
+// usageReport 依備份列表、用量緩存與用量歷史產生報表
+// 期間不限時用量欄位取自緩存；指定期間時取自期間內最後一筆歷史紀錄，期間內沒有紀錄的帳號不列入
+func (a *App) usageReport(r report.Range, now time.Time) (*report.Report, error) {
+	items, err := a.GetBackupList()
+	if err != nil {
+		return nil, err
+	}
+
+	result := &report.Report{GeneratedAt: now.Format(time.RFC3339), Rows: []report.Row{}}
+	if !r.From.IsZero() {
+		result.From = r.From.Format(time.RFC3339)
+	}
+	if !r.To.IsZero() {
+		result.To = r.To.Format(time.RFC3339)
+	}
+
+	for _, item := range items {
+		row := report.Row{
+			Name:              item.Name,
+			Provider:          item.Provider,
+			SubscriptionTitle: item.SubscriptionTitle,
+			UsageLimit:        item.UsageLimit,
+			CurrentUsage:      item.CurrentUsage,
+			Balance:           item.Balance,
+			LastRefreshedAt:   item.CachedAt,
+		}
+		if token, err := backup.ReadBackupToken(item.Name); err == nil {
+			row.AuthType = tokenrefresh.DetectAuthType(token)
+			row.TokenExpiresAt = token.ExpiresAt
+		}
+
+		records, err := usagehistory.Read(item.Name)
+		if err != nil {
+			slog.Warn("failed to read usage history for report", "backup", item.Name, "error", err)
+			records = nil
+		}
+		if !report.ApplyHistory(&row, records, r) {
+			continue
+		}
+		result.Rows = append(result.Rows, row)
+	}
+	return result, nil
+}
+
+// refreshBackupToken 刷新備份的 AccessToken 並寫回備份（token 結構會就地更新）
+// 使用對應環境快照的 Machine ID 的 SHA256 雜湊值
+// 刷新失敗時回傳 *tokenrefresh.RefreshError，呼叫端可依 Code 判斷錯誤類型
+func refreshBackupToken(name string, token *awssso.KiroAuthToken, hashedMachineID string) error {
+	var newTokenInfo *tokenrefresh.TokenInfo
+	var err error
+
+	// 檢查是否為 IdC 認證，如果是則從備份目錄讀取 clientId/clientSecret
+	authType := tokenrefresh.DetectAuthType(token)
+	if authType == "idc" && token.ClientIdHash != "" {
+		// 從備份目錄讀取 IdC credentials
+		clientID, clientSecret, credErr := backup.ReadBackupIdCCredentials(name, token.ClientIdHash)
+		if credErr != nil {
+			return fmt.Errorf("無法讀取 IdC 認證資訊: %w", credErr)
+		}
+		newTokenInfo, err = tokenrefresh.RefreshAccessTokenFromBackup(token, hashedMachineID, clientID, clientSecret)
+	} else {
+		// Social 認證或其他情況，使用原有邏輯
+		newTokenInfo, err = tokenrefresh.RefreshAccessToken(token, hashedMachineID)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	// 更新 token 結構的新值（需求 1.2, 1.3）
+	previousRefreshToken := token.RefreshToken
+	update := backup.TokenUpdate{
+		AccessToken:  newTokenInfo.AccessToken,
+		ExpiresAt:    newTokenInfo.ExpiresAt.UTC().Format("2006-01-02T15:04:05.000Z"),
+		RefreshToken: newTokenInfo.RefreshToken,
+		ProfileArn:   newTokenInfo.ProfileArn,
+	}
+	token.AccessToken = update.AccessToken
+	token.ExpiresAt = update.ExpiresAt
+	if update.RefreshToken != "" {
+		token.RefreshToken = update.RefreshToken
+	}
+	if update.ProfileArn != "" {
+		token.ProfileArn = update.ProfileArn
+	}
+
+	// 此備份為目前登入中的帳號時，先同步更新 SSO cache：
+	// 伺服器輪替 RefreshToken 後舊值即失效，Kiro 必須優先取得新的 RefreshToken
+	syncErr := syncLiveToken(previousRefreshToken, update)
+
+	// 持久化刷新後的 token，包含伺服器輪替後的 RefreshToken（需求 3.1, 3.2）
+	// 同步失敗時仍寫入備份，讓使用者可重新切換至此帳號修復 SSO cache
+	if err := backup.UpdateBackupToken(name, update); err != nil {
+		if syncErr != nil {
+			return fmt.Errorf("Token 刷新成功但同步 SSO cache 與寫入備份皆失敗: %w", errors.Join(syncErr, err))
+		}
+		return fmt.Errorf("Token 刷新成功但寫入失敗: %w", err)
+	}
+	if syncErr != nil {
+		return fmt.Errorf("Token 刷新成功但同步 SSO cache 失敗（新的 Token 已寫入備份，請重新切換至此帳號）: %w", syncErr)
+	}
+
+	return nil
+}
+
+// syncLiveToken 若 SSO cache 中的 token 與刷新前的備份 token 屬於同一帳號，寫入刷新結果
+// 以刷新前的 RefreshToken 判斷是否為同一帳號；不是同一帳號時不做任何事
+func syncLiveToken(previousRefreshToken string, update backup.TokenUpdate) error {
+	if previousRefreshToken == "" {
+		return nil
+	}
+
+	live, err := awssso.ReadKiroAuthToken()
+	if err != nil || live.RefreshToken != previousRefreshToken {
+		return nil
+	}
+
+	return awssso.UpdateKiroAuthToken(update.Fields()...)
 }
 
 // CreateBackup 建立新備份
@@ -273,6 +604,9 @@ func (a *App) CreateBackup(name string) Result {
 	}
 
 	if err := backup.CreateBackup(name); err != nil {
+		if errors.Is(err, backup.ErrInvalidBackupName) {
+			return Result{Success: false, Message: fmt.Sprintf("備份名稱無效: %v", err)}
+		}
 		return Result{Success: false, Message: err.Error()}
 	}
 
@@ -282,28 +616,124 @@ func (a *App) CreateBackup(name string) Result {
 // SwitchToBackup 切換至指定備份帳號
 // 注意：硬一鍵新機功能暫時停用，此函數目前僅恢復 token
 func (a *App) SwitchToBackup(name string) Result {
-	if name == "" {
-		return Result{Success: false, Message: "請選擇備份"}
+	result, closed, err := a.switchToBackup(name)
+	if err != nil {
+		return Result{Success: false, Message: err.Error()}
 	}
 
-	// 檢測並強制關閉 Kiro
-	if kiroprocess.IsKiroRunning() {
-		killed, err := kiroprocess.KillKiroProcesses()
-		if err != nil {
-			return Result{Success: false, Message: fmt.Sprintf("關閉 Kiro 失敗: %v", err)}
-		}
-		if killed == 0 && kiroprocess.IsKiroRunning() {
-			return Result{Success: false, Message: "無法關閉 Kiro，請手動關閉後重試"}
+	relaunched := relaunchMessage(a.relaunchKiro(closed))
+	if len(result.Changed) == 0 {
+		return Result{Success: true, Message: "切換成功（SSO cache 已是此帳號，無需變更）" + relaunched}
+	}
+	return Result{Success: true, Message: "切換成功（僅恢復 Token，Machine ID 未變更）" + relaunched}
+}
+
+// closedKiro 被關閉的 Kiro 的狀態，用於之後重新啟動
+type closedKiro struct {
+	wasRunning bool     // 關閉前 Kiro 是否正在執行
+	workspaces []string // 關閉時開啟的資料夾與工作區（僅正常結束時讀取）
+}
+
+// closeKiro 關閉執行中的 Kiro：先要求正常結束，超過設定的等待時間才強制終止
+// 關閉進度會送往前端
+func (a *App) closeKiro() (closedKiro, error) {
+	ctx := a.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	result, err := kiroprocess.Shutdown(ctx, kiroprocess.ShutdownOptions{
+		Timeout: settings.GetShutdownTimeout(),
+		OnProgress: func(p kiroprocess.Progress) {
+			emitShutdownProgress(a.ctx, p)
+		},
+	})
+	if errors.Is(err, kiroprocess.ErrStillRunning) {
+		return closedKiro{}, errors.New("無法關閉 Kiro，請手動關閉後重試")
+	}
+	if err != nil {
+		return closedKiro{}, fmt.Errorf("關閉 Kiro 失敗: %w", err)
+	}
+
+	closed := closedKiro{wasRunning: result.Total > 0}
+	if closed.wasRunning && result.Graceful() {
+		// Kiro 正常結束時才會寫入視窗狀態，因此在關閉後讀取；
+		// 被強制終止時讀到的是更早之前的狀態，重新啟動時不開啟任何工作區
+		closed.workspaces, _ = kiroprocess.OpenedWorkspaces()
+	}
+	return closed, nil
+}
+
+// relaunchKiro 依設定重新啟動被關閉的 Kiro，回傳是否有嘗試重新啟動
+func (a *App) relaunchKiro(closed closedKiro) (bool, error) {
+	afterSwitch, reopenWorkspaces := settings.GetRelaunchOptions()
+	if !afterSwitch || !closed.wasRunning {
+		return false, nil
+	}
+
+	var paths []string
+	if reopenWorkspaces {
+		paths = closed.workspaces
+	}
+	_, err := a.launchKiro(paths)
+	return true, err
+}
+
+// relaunchMessage 重新啟動的結果（附加在操作結果訊息之後）
+func relaunchMessage(attempted bool, err error) string {
+	switch {
+	case !attempted:
+		return ""
+	case err != nil:
+		return fmt.Sprintf("，但重新啟動 Kiro 失敗: %v", err)
+	default:
+		return "，已重新啟動 Kiro"
+	}
+}
+
+// launchKiro 啟動 Kiro 並開啟指定路徑，等待主進程出現
+func (a *App) launchKiro(paths []string) (kiroprocess.ProcessInfo, error) {
+	ctx := a.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return kiroprocess.Launch(ctx, kiroprocess.LaunchOptions{Paths: paths})
+}
+
+// LaunchKiro 啟動 Kiro
+func (a *App) LaunchKiro() Result {
+	if _, err := a.launchKiro(nil); err != nil {
+		if errors.Is(err, kiropath.ErrKiroNotFound) {
+			return Result{Success: false, Message: "找不到 Kiro 安裝位置"}
 		}
+		return Result{Success: false, Message: fmt.Sprintf("啟動 Kiro 失敗: %v", err)}
+	}
+	return Result{Success: true, Message: "已啟動 Kiro"}
+}
+
+// switchToBackup 關閉 Kiro 後恢復指定備份，回傳實際變更的檔案與被關閉的 Kiro 的狀態
+// 恢復失敗時 SSO cache 會回滾為切換前的狀態，並依設定以原本的帳號重新啟動 Kiro
+func (a *App) switchToBackup(name string) (*backup.RestoreResult, closedKiro, error) {
+	if name == "" {
+		return nil, closedKiro{}, errors.New("請選擇備份")
+	}
+
+	closed, err := a.closeKiro()
+	if err != nil {
+		return nil, closedKiro{}, err
 	}
 
 	// 硬一鍵新機功能暫時停用，不再修改系統 Machine ID
 	// 僅恢復 token
-	if err := backup.RestoreBackup(name); err != nil {
-		return Result{Success: false, Message: fmt.Sprintf("恢復 Token 失敗: %v", err)}
+	result, err := backup.RestoreBackup(name)
+	if err != nil {
+		relaunched := relaunchMessage(a.relaunchKiro(closed))
+		if errors.Is(err, backup.ErrChecksumMismatch) {
+			return nil, closedKiro{}, fmt.Errorf("備份檔案校驗失敗，可能已損毀或被修改: %w%s", err, relaunched)
+		}
+		return nil, closedKiro{}, fmt.Errorf("恢復 Token 失敗: %w%s", err, relaunched)
 	}
 
-	return Result{Success: true, Message: "切換成功（僅恢復 Token，Machine ID 未變更）"}
+	return result, closed, nil
 }
 
 // RestoreOriginal 還原原始機器（僅還原 Machine ID，不涉及 token）
@@ -322,10 +752,44 @@ func (a *App) DeleteBackup(name string) Result {
 	if err := backup.DeleteBackup(name); err != nil {
 		return Result{Success: false, Message: err.Error()}
 	}
+	if err := settings.RenameAlertAccount(name, ""); err != nil {
+		slog.Warn("failed to remove alert rules", "backup", name, "error", err)
+	}
+	if err := settings.RenameWorkspaceBackup(name, ""); err != nil {
+		slog.Warn("failed to remove workspace bindings", "backup", name, "error", err)
+	}
 
 	return Result{Success: true, Message: "刪除成功"}
 }
 
+// RenameBackup 重新命名備份
+func (a *App) RenameBackup(oldName, newName string) Result {
+	if oldName == backup.OriginalBackupName {
+		return Result{Success: false, Message: "不能重新命名原始備份"}
+	}
+	if newName == "" {
+		return Result{Success: false, Message: "備份名稱不能為空"}
+	}
+	if newName == backup.OriginalBackupName {
+		return Result{Success: false, Message: "不能使用保留名稱 original"}
+	}
+
+	if err := backup.RenameBackup(oldName, newName); err != nil {
+		if errors.Is(err, backup.ErrInvalidBackupName) {
+			return Result{Success: false, Message: fmt.Sprintf("備份名稱無效: %v", err)}
+		}
+		return Result{Success: false, Message: err.Error()}
+	}
+	if err := settings.RenameAlertAccount(oldName, newName); err != nil {
+		slog.Warn("failed to move alert rules", "from", oldName, "to", newName, "error", err)
+	}
+	if err := settings.RenameWorkspaceBackup(oldName, newName); err != nil {
+		slog.Warn("failed to move workspace bindings", "from", oldName, "to", newName, "error", err)
+	}
+
+	return Result{Success: true, Message: "重新命名成功"}
+}
+
 // GetCurrentMachineID 取得當前 Machine ID
 // 如果軟重置已啟用（有自訂 ID 且已 Patch），返回自訂 ID
 // 否則返回系統原始 Machine ID
@@ -341,6 +805,34 @@ func (a *App) GetCurrentMachineID() string {
 	return id
 }
 
+// UnlockBackups 設定備份加密密碼（系統無 OS keyring 時使用）
+// 設定後會嘗試遷移尚未加密的舊版備份
+func (a *App) UnlockBackups(passphrase string) Result {
+	if passphrase == "" {
+		return Result{Success: false, Message: "密碼不能為空"}
+	}
+
+	backup.SetPassphrase(passphrase)
+	if _, err := backup.MigratePlaintextBackups(); err != nil {
+		if errors.Is(err, backup.ErrWrongEncryptionKey) {
+			backup.SetPassphrase("")
+			return Result{Success: false, Message: "密碼錯誤"}
+		}
+		return Result{Success: false, Message: fmt.Sprintf("解鎖備份失敗: %v", err)}
+	}
+
+	a.migrationErr = nil
+	return Result{Success: true, Message: "備份已解鎖"}
+}
+
+// GetStartupWarning 取得啟動時發生、需要提醒使用者的問題（沒有時為空字串）
+func (a *App) GetStartupWarning() string {
+	if a.migrationErr != nil {
+		return fmt.Sprintf("加密舊版備份失敗，憑證仍以明文保存: %v", a.migrationErr)
+	}
+	return ""
+}
+
 // EnsureOriginalBackup 確保原始備份存在
 func (a *App) EnsureOriginalBackup() Result {
 	created, err := backup.EnsureOriginalBackup()
@@ -361,15 +853,194 @@ func (a *App) ResetToNewMachine() Result {
 	return Result{Success: false, Message: "硬一鍵新機功能暫時停用，請使用軟一鍵新機"}
 }
 
+// appVersion 應用程式版本
+const appVersion = "0.2.0"
+
 // GetAppInfo 取得應用資訊
 func (a *App) GetAppInfo() map[string]string {
+	dataDir, _ := settings.GetDataDir()
 	return map[string]string{
-		"version":   "0.2.0",
+		"version":   appVersion,
 		"platform":  runtime.GOOS,
+		"dataDir":   dataDir,
 		"buildTime": time.Now().Format("2025-12-07"),
 	}
 }
 
+// Diagnostics 診斷資訊（前端與 CLI 用）
+type Diagnostics struct {
+	Version       string             `json:"version"`
+	Platform      string             `json:"platform"`
+	DataDir       string             `json:"dataDir"`
+	SettingsPath  string             `json:"settingsPath"`
+	SSOCachePath  string             `json:"ssoCachePath"`
+	KiroRunning   bool               `json:"kiroRunning"`
+	AuthType      string             `json:"authType"`      // 目前登入帳號的認證類型（未登入時為空）
+	Provider      string             `json:"provider"`      // 目前登入帳號的 Provider
+	TokenRegion   string             `json:"tokenRegion"`   // token 中的 region 欄位（原始值）
+	TokenExpired  bool               `json:"tokenExpired"`  // 目前的 AccessToken 是否已過期
+	CurrentBackup string             `json:"currentBackup"` // 目前登入帳號對應的備份（無法判定時為空）
+	Endpoints     endpoints.Resolved `json:"endpoints"`     // 依 token 區域與設定覆寫解析的 API 端點
+}
+
+// GetDiagnostics 取得診斷資訊（資料路徑、目前帳號與解析後的 API 端點）
+func (a *App) GetDiagnostics() Diagnostics {
+	diag := Diagnostics{
+		Version:     appVersion,
+		Platform:    runtime.GOOS,
+		KiroRunning: kiroprocess.IsKiroRunning(),
+	}
+	diag.DataDir, _ = settings.GetDataDir()
+	diag.SettingsPath, _ = settings.GetSettingsPath()
+	diag.SSOCachePath, _ = awssso.GetSSOCachePath()
+
+	profileArn := ""
+	if token, err := awssso.ReadKiroAuthToken(); err == nil {
+		diag.AuthType = tokenrefresh.DetectAuthType(token)
+		diag.Provider = token.Provider
+		diag.TokenRegion = token.Region
+		diag.TokenExpired = awssso.IsTokenExpired(token)
+		profileArn = token.ProfileArn
+	}
+	diag.CurrentBackup = currentBackupName(a.GetCurrentMachineID())
+	diag.Endpoints = settings.ResolveEndpoints(diag.TokenRegion, profileArn)
+
+	return diag
+}
+
+// ExportDiagnosticsLog 匯出診斷記錄（診斷資訊、設定與記錄檔）為 zip，存放於資料目錄的 diagnostics/
+// 記錄內容已遮蔽 token 與識別資訊，設定中的代理密碼亦會隱藏，可直接附於問題回報
+func (a *App) ExportDiagnosticsLog() Result {
+	path, err := a.exportDiagnostics("")
+	if err != nil {
+		return Result{Success: false, Message: fmt.Sprintf("匯出診斷記錄失敗: %v", err)}
+	}
+	return Result{Success: true, Message: "診斷記錄已匯出至 " + path}
+}
+
+// exportDiagnostics 匯出診斷記錄至 dest（空值時使用資料目錄下的預設檔名），回傳實際寫入的路徑
+func (a *App) exportDiagnostics(dest string) (string, error) {
+	if dest == "" {
+		dataDir, err := settings.GetDataDir()
+		if err != nil {
+			return "", err
+		}
+		name := "kiro-manager-diagnostics-" + time.Now().Format("20060102-150405") + ".zip"
+		dest = filepath.Join(dataDir, "diagnostics", name)
+	}
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	now := time.Now()
+
+	create := func(name string) (io.Writer, error) {
+		return zw.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: now})
+	}
+	addJSON := func(name string, v any) error {
+		data, err := json.MarshalIndent(v, "", "  ")
+		if err != nil {
+			return err
+		}
+		w, err := create(name)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(data)
+		return err
+	}
+
+	if err := addJSON("diagnostics.json", a.GetDiagnostics()); err != nil {
+		return "", err
+	}
+
+	if err := addJSON("settings.json", settings.GetCurrentSettings().Redacted()); err != nil {
+		return "", err
+	}
+
+	if logDir, err := logging.Dir(); err == nil {
+		for _, path := range logging.Files(logDir) {
+			data, err := os.ReadFile(path)
+			if err != nil {
+				continue
+			}
+			w, err := create("logs/" + filepath.Base(path))
+			if err != nil {
+				return "", err
+			}
+			// 記錄寫入時已遮蔽，匯出前再檢查一次
+			if _, err := io.WriteString(w, logging.RedactString(string(data))); err != nil {
+				return "", err
+			}
+		}
+	}
+
+	if err := zw.Close(); err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(filepath.Dir(dest), 0700); err != nil {
+		return "", err
+	}
+	if err := atomicfile.WriteFile(dest, buf.Bytes(), 0600); err != nil {
+		return "", err
+	}
+
+	slog.Info("diagnostics exported", "path", dest)
+	return dest, nil
+}
+
+// connectionTestTimeout 單一端點連線測試的逾時時間
+const connectionTestTimeout = 15 * time.Second
+
+// ConnectionTestResult 連線測試結果
+type ConnectionTestResult struct {
+	Success bool               `json:"success"`
+	Message string             `json:"message"`
+	Reports []transport.Report `json:"reports"` // 各端點的逐段測試報告
+}
+
+// TestConnection 以目前的網路設定（代理、CA 憑證）測試 Token 刷新與用量查詢端點的連線
+// 失敗時回報是哪一段（DNS、TCP、代理、TLS、HTTP）無法連通
+func (a *App) TestConnection() ConnectionTestResult {
+	region, profileArn := "", ""
+	if token, err := awssso.ReadKiroAuthToken(); err == nil {
+		region, profileArn = token.Region, token.ProfileArn
+	}
+	resolved := settings.ResolveEndpoints(region, profileArn)
+
+	return testConnection([]string{
+		tokenrefresh.SocialRefreshURL,
+		resolved.IdCRefreshURL,
+		resolved.UsageBaseURL,
+	})
+}
+
+// testConnection 逐一測試 URL 的連線
+func testConnection(urls []string) ConnectionTestResult {
+	cfg := settings.TransportConfig()
+	result := ConnectionTestResult{Success: true, Reports: make([]transport.Report, 0, len(urls))}
+
+	var failures []string
+	for _, rawURL := range urls {
+		ctx, cancel := context.WithTimeout(context.Background(), connectionTestTimeout)
+		report := transport.Diagnose(ctx, cfg, rawURL)
+		cancel()
+
+		result.Reports = append(result.Reports, report)
+		if !report.OK {
+			result.Success = false
+			last := report.Hops[len(report.Hops)-1]
+			failures = append(failures, fmt.Sprintf("%s（%s 階段失敗: %s）", last.Target, report.FailedHop, last.Error))
+		}
+	}
+
+	if result.Success {
+		result.Message = fmt.Sprintf("%d 個端點皆可連線", len(urls))
+	} else {
+		result.Message = "無法連線: " + strings.Join(failures, "；")
+	}
+	return result
+}
+
 // GetCurrentProvider 取得當前 Kiro 登入的帳號來源（Provider）
 // 讀取 ~/.aws/sso/cache/kiro-auth-token.json 中的 provider 欄位
 func (a *App) GetCurrentProvider() string {
@@ -382,11 +1053,15 @@ func (a *App) GetCurrentProvider() string {
 
 // CurrentUsageInfo 當前帳號用量資訊（前端用）
 type CurrentUsageInfo struct {
-	SubscriptionTitle string  `json:"subscriptionTitle"` // 訂閱類型名稱
-	UsageLimit        float64 `json:"usageLimit"`        // 總額度
-	CurrentUsage      float64 `json:"currentUsage"`      // 已使用
-	Balance           float64 `json:"balance"`           // 餘額
-	IsLowBalance      bool    `json:"isLowBalance"`      // 餘額低於 20%
+	SubscriptionTitle string            `json:"subscriptionTitle"` // 訂閱類型名稱
+	UsageLimit        float64           `json:"usageLimit"`        // 總額度
+	CurrentUsage      float64           `json:"currentUsage"`      // 已使用
+	Balance           float64           `json:"balance"`           // 餘額
+	IsLowBalance      bool              `json:"isLowBalance"`      // 餘額低於 20%
+	Breakdown         []usage.Breakdown `json:"breakdown"`         // 基本、試用與獎勵額度明細
+	Credits           []usage.Credit    `json:"credits"`           // 仍有剩餘的額度（依到期時間由早到晚）
+	NextResetAt       string            `json:"nextResetAt"`       // 下次重置時間（RFC3339），未知時為空
+	DaysUntilReset    int               `json:"daysUntilReset"`    // 距離重置的天數
 }
 
 // GetCurrentUsageInfo 取得當前帳號的用量資訊
@@ -394,24 +1069,22 @@ type CurrentUsageInfo struct {
 func (a *App) GetCurrentUsageInfo() *CurrentUsageInfo {
 	// 取得當前 Machine ID（優先使用軟重置的自訂 ID）
 	currentMachineID := a.GetCurrentMachineID()
-	threshold := settings.GetLowBalanceThreshold()
 
-	// 查找當前 Machine ID 對應的備份
-	backupName := a.findBackupByMachineID(currentMachineID)
+	// 查找目前登入帳號對應的備份
+	backupName := currentBackupName(currentMachineID)
 	if backupName != "" {
 		// 優先從緩存讀取
 		if usageCache, err := backup.ReadUsageCache(backupName); err == nil && usageCache != nil {
-			// 使用設定的閾值重新計算 IsLowBalance
-			isLowBalance := false
-			if usageCache.UsageLimit > 0 {
-				isLowBalance = (usageCache.Balance / usageCache.UsageLimit) < threshold
-			}
 			return &CurrentUsageInfo{
 				SubscriptionTitle: usageCache.SubscriptionTitle,
 				UsageLimit:        usageCache.UsageLimit,
 				CurrentUsage:      usageCache.CurrentUsage,
 				Balance:           usageCache.Balance,
-				IsLowBalance:      isLowBalance,
+				IsLowBalance:      lowBalance(usageCache.Balance, usageCache.UsageLimit),
+				Breakdown:         usageCache.Breakdown,
+				Credits:           usage.Credits(usageCache.Breakdown),
+				NextResetAt:       usageCache.NextResetAt,
+				DaysUntilReset:    usage.DaysUntilReset(usageCache.NextResetAt, time.Now()),
 			}
 		}
 	}
@@ -428,22 +1101,11 @@ func (a *App) GetCurrentUsageInfo() *CurrentUsageInfo {
 		return nil
 	}
 
-	// 使用設定的閾值重新計算 IsLowBalance
-	isLowBalance := false
-	if usageInfo.UsageLimit > 0 {
-		isLowBalance = (usageInfo.Balance / usageInfo.UsageLimit) < threshold
-	}
+	isLowBalance := lowBalance(usageInfo.Balance, usageInfo.UsageLimit)
 
-	// 如果找到對應的備份，將結果寫入緩存
+	// 如果找到對應的備份，將結果寫入緩存與用量歷史，並評估用量警示
 	if backupName != "" {
-		cache := &backup.UsageCache{
-			SubscriptionTitle: usageInfo.SubscriptionTitle,
-			UsageLimit:        usageInfo.UsageLimit,
-			CurrentUsage:      usageInfo.CurrentUsage,
-			Balance:           usageInfo.Balance,
-			IsLowBalance:      isLowBalance,
-		}
-		backup.WriteUsageCache(backupName, cache)
+		a.saveUsage(backupName, usageInfo, isLowBalance)
 	}
 
 	return &CurrentUsageInfo{
@@ -452,28 +1114,21 @@ func (a *App) GetCurrentUsageInfo() *CurrentUsageInfo {
 		CurrentUsage:      usageInfo.CurrentUsage,
 		Balance:           usageInfo.Balance,
 		IsLowBalance:      isLowBalance,
+		Breakdown:         usageInfo.Breakdown,
+		Credits:           usage.Credits(usageInfo.Breakdown),
+		NextResetAt:       usageInfo.NextResetAt,
+		DaysUntilReset:    usage.DaysUntilReset(usageInfo.NextResetAt, time.Now()),
 	}
 }
 
-// findBackupByMachineID 根據 Machine ID 查找對應的備份名稱
-func (a *App) findBackupByMachineID(machineID string) string {
-	backups, err := backup.ListBackups()
-	if err != nil {
+// currentBackupName 取得目前登入帳號對應的備份名稱，無法判定時回傳空字串
+// 依 refresh token 指紋、clientIdHash、profile ARN 比對，Machine ID 僅作為最後備援
+func currentBackupName(machineID string) string {
+	match, err := backup.ResolveCurrentAccount(machineID)
+	if err != nil || match == nil {
 		return ""
 	}
-
-	for _, b := range backups {
-		if b.Name == backup.OriginalBackupName {
-			continue
-		}
-		if b.HasMachineID {
-			mid, err := backup.ReadBackupMachineID(b.Name)
-			if err == nil && mid.MachineID == machineID {
-				return b.Name
-			}
-		}
-	}
-	return ""
+	return match.Name
 }
 
 // IsKiroRunning 檢查 Kiro 是否正在運行
@@ -506,15 +1161,8 @@ type SoftResetStatus struct {
 
 // SoftResetToNewMachine 軟一鍵新機（跨平台，不需要管理員權限）
 func (a *App) SoftResetToNewMachine() Result {
-	// 檢測並強制關閉 Kiro
-	if kiroprocess.IsKiroRunning() {
-		killed, err := kiroprocess.KillKiroProcesses()
-		if err != nil {
-			return Result{Success: false, Message: fmt.Sprintf("關閉 Kiro 失敗: %v", err)}
-		}
-		if killed == 0 && kiroprocess.IsKiroRunning() {
-			return Result{Success: false, Message: "無法關閉 Kiro，請手動關閉後重試"}
-		}
+	if _, err := a.closeKiro(); err != nil {
+		return Result{Success: false, Message: err.Error()}
 	}
 
 	result, err := softreset.SoftResetEnvironment()
@@ -550,17 +1198,15 @@ func (a *App) GetSoftResetStatus() SoftResetStatus {
 }
 
 // RestoreSoftReset 還原軟重置（恢復系統原始 Machine ID）
-func (a *App) RestoreSoftReset() Result {
-	// 檢測並強制關閉 Kiro
-	if kiroprocess.IsKiroRunning() {
-		killed, err := kiroprocess.KillKiroProcesses()
-		if err != nil {
-			return Result{Success: false, Message: fmt.Sprintf("關閉 Kiro 失敗: %v", err)}
-		}
-		if killed == 0 && kiroprocess.IsKiroRunning() {
-			return Result{Success: false, Message: "無法關閉 Kiro，請手動關閉後重試"}
-		}
+func (a *App) RestoreSoftReset() (result Result) {
+	closed, err := a.closeKiro()
+	if err != nil {
+		return Result{Success: false, Message: err.Error()}
 	}
+	// 流程結束後（含還原失敗）重新啟動 Kiro，與切換帳號一致，不讓 Kiro 停留在關閉狀態
+	defer func() {
+		result.Message += relaunchMessage(a.relaunchKiro(closed))
+	}()
 
 	// 執行還原（刪除自訂 Machine ID、還原 extension.js）
 	if err := softreset.RestoreOriginalMachineID(); err != nil {
@@ -580,7 +1226,7 @@ func (a *App) RestoreSoftReset() Result {
 			backupMID, err := backup.ReadBackupMachineID(b.Name)
 			if err == nil && backupMID.MachineID == originalMachineID {
 				// 找到匹配的備份，恢復 SSO cache（token）
-				if err := backup.RestoreBackup(b.Name); err == nil {
+				if _, err := backup.RestoreBackup(b.Name); err == nil {
 					return Result{
 						Success: true,
 						Message: fmt.Sprintf("已還原為系統原始 Machine ID，並恢復帳號「%s」", b.Name),
@@ -596,15 +1242,8 @@ func (a *App) RestoreSoftReset() Result {
 
 // RepatchExtension 重新 Patch extension.js（Kiro 更新後使用）
 func (a *App) RepatchExtension() Result {
-	// 檢測並強制關閉 Kiro
-	if kiroprocess.IsKiroRunning() {
-		killed, err := kiroprocess.KillKiroProcesses()
-		if err != nil {
-			return Result{Success: false, Message: fmt.Sprintf("關閉 Kiro 失敗: %v", err)}
-		}
-		if killed == 0 && kiroprocess.IsKiroRunning() {
-			return Result{Success: false, Message: "無法關閉 Kiro，請手動關閉後重試"}
-		}
+	if _, err := a.closeKiro(); err != nil {
+		return Result{Success: false, Message: err.Error()}
 	}
 
 	if err := softreset.PatchExtensionJS(); err != nil {
@@ -616,15 +1255,8 @@ func (a *App) RepatchExtension() Result {
 
 // UnpatchExtension 移除 Patch（還原 extension.js）
 func (a *App) UnpatchExtension() Result {
-	// 檢測並強制關閉 Kiro
-	if kiroprocess.IsKiroRunning() {
-		killed, err := kiroprocess.KillKiroProcesses()
-		if err != nil {
-			return Result{Success: false, Message: fmt.Sprintf("關閉 Kiro 失敗: %v", err)}
-		}
-		if killed == 0 && kiroprocess.IsKiroRunning() {
-			return Result{Success: false, Message: "無法關閉 Kiro，請手動關閉後重試"}
-		}
+	if _, err := a.closeKiro(); err != nil {
+		return Result{Success: false, Message: err.Error()}
 	}
 
 	if err := softreset.UnpatchExtensionJS(); err != nil {
@@ -634,15 +1266,139 @@ func (a *App) UnpatchExtension() Result {
 	return Result{Success: true, Message: "已移除 Patch"}
 }
 
+// ============================================================================
+// 工作區綁定功能
+// ============================================================================
+
+// errKiroRunning 需要切換帳號但 Kiro 正在執行，且呼叫端不允許關閉 Kiro
+var errKiroRunning = errors.New("Kiro 正在執行，切換帳號需要先關閉 Kiro")
+
+// OpenWorkspaceResult 以綁定的帳號開啟目錄的結果（前端用）
+type OpenWorkspaceResult struct {
+	Success  bool   `json:"success"`
+	Message  string `json:"message"`
+	Path     string `json:"path"`     // 開啟的目錄（絕對路徑）
+	Backup   string `json:"backup"`   // 綁定的備份，目錄未綁定時為空
+	Switched bool   `json:"switched"` // 是否切換了帳號
+	PID      int    `json:"pid"`      // Kiro 主進程的 PID
+}
+
+// GetWorkspaceBindings 取得目錄與帳號的綁定
+func (a *App) GetWorkspaceBindings() []workspace.Binding {
+	bindings := settings.GetWorkspaceBindings()
+	if bindings == nil {
+		return []workspace.Binding{}
+	}
+	return bindings
+}
+
+// SetWorkspaceBinding 將目錄綁定至備份（已綁定時改為新的備份）
+func (a *App) SetWorkspaceBinding(path, backupName string) Result {
+	path = strings.TrimSpace(path)
+	if !filepath.IsAbs(path) {
+		return Result{Success: false, Message: "請輸入目錄的絕對路徑"}
+	}
+	if info, err := os.Stat(path); err != nil || !info.IsDir() {
+		return Result{Success: false, Message: fmt.Sprintf("目錄不存在: %s", path)}
+	}
+	if backupName == "" || !backup.BackupExists(backupName) {
+		return Result{Success: false, Message: fmt.Sprintf("備份不存在: %s", backupName)}
+	}
+
+	bindings := workspace.Set(settings.GetWorkspaceBindings(), path, backupName)
+	if err := settings.SaveWorkspaceBindings(bindings); err != nil {
+		return Result{Success: false, Message: fmt.Sprintf("儲存綁定失敗: %v", err)}
+	}
+	return Result{Success: true, Message: fmt.Sprintf("已將 %s 綁定至 %s", filepath.Clean(path), backupName)}
+}
+
+// RemoveWorkspaceBinding 移除目錄的綁定
+func (a *App) RemoveWorkspaceBinding(path string) Result {
+	bindings, removed := workspace.Remove(settings.GetWorkspaceBindings(), path)
+	if !removed {
+		return Result{Success: false, Message: fmt.Sprintf("目錄未綁定帳號: %s", path)}
+	}
+	if err := settings.SaveWorkspaceBindings(bindings); err != nil {
+		return Result{Success: false, Message: fmt.Sprintf("儲存綁定失敗: %v", err)}
+	}
+	return Result{Success: true, Message: "已移除綁定"}
+}
+
+// OpenWorkspace 切換至目錄綁定的帳號（目前不是該帳號時），再以 Kiro 開啟目錄
+func (a *App) OpenWorkspace(dir string) OpenWorkspaceResult {
+	result, err := a.openWorkspace(dir, true)
+	if errors.Is(err, backup.ErrBackupNotFound) {
+		err = fmt.Errorf("綁定的備份不存在: %s", result.Backup)
+	}
+	if err != nil {
+		return OpenWorkspaceResult{Success: false, Message: err.Error(), Path: result.Path, Backup: result.Backup}
+	}
+	return result
+}
+
+// openWorkspace 依綁定切換帳號後以 Kiro 開啟目錄；目錄未綁定時以目前的帳號開啟
+// 需要切換帳號且 Kiro 正在執行時，closeRunning 為 false 會回傳 errKiroRunning
+func (a *App) openWorkspace(dir string, closeRunning bool) (OpenWorkspaceResult, error) {
+	path, err := filepath.Abs(dir)
+	if err != nil {
+		return OpenWorkspaceResult{}, fmt.Errorf("無效的路徑: %s", dir)
+	}
+	result := OpenWorkspaceResult{Path: path}
+	if info, err := os.Stat(path); err != nil || !info.IsDir() {
+		return result, fmt.Errorf("目錄不存在: %s", path)
+	}
+
+	if binding, ok := workspace.Resolve(settings.GetWorkspaceBindings(), path); ok {
+		result.Backup = binding.Backup
+		if !backup.BackupExists(binding.Backup) {
+			return result, fmt.Errorf("%w: %s", backup.ErrBackupNotFound, binding.Backup)
+		}
+		if binding.Backup != currentBackupName(a.GetCurrentMachineID()) {
+			if !closeRunning && kiroprocess.IsKiroRunning() {
+				return result, errKiroRunning
+			}
+			if _, _, err := a.switchToBackup(binding.Backup); err != nil {
+				return result, err
+			}
+			result.Switched = true
+		}
+	}
+
+	process, err := a.launchKiro([]string{path})
+	if err != nil {
+		return result, fmt.Errorf("啟動 Kiro 失敗: %w", err)
+	}
+	result.Success = true
+	result.PID = process.PID
+	switch {
+	case result.Switched:
+		result.Message = fmt.Sprintf("已切換至 %s 並以 Kiro 開啟 %s", result.Backup, path)
+	case result.Backup != "":
+		result.Message = fmt.Sprintf("目前已是 %s，已以 Kiro 開啟 %s", result.Backup, path)
+	default:
+		result.Message = fmt.Sprintf("目錄未綁定帳號，已以目前的帳號開啟 %s", path)
+	}
+	return result, nil
+}
+
 // ============================================================================
 // 全域設定功能
 // ============================================================================
 
 // AppSettings 應用設定（前端用）
 type AppSettings struct {
-	LowBalanceThreshold float64 `json:"lowBalanceThreshold"` // 低餘額閾值（0.0 ~ 1.0）
-	KiroVersion         string  `json:"kiroVersion"`         // Kiro IDE 版本號
-	UseAutoDetect       bool    `json:"useAutoDetect"`       // 是否使用自動偵測版本號
+	LowBalanceThreshold float64                   `json:"lowBalanceThreshold"` // 低餘額閾值（0.0 ~ 1.0）
+	KiroVersion         string                    `json:"kiroVersion"`         // Kiro IDE 版本號
+	UseAutoDetect       bool                      `json:"useAutoDetect"`       // 是否使用自動偵測版本號
+	DataDir             string                    `json:"dataDir"`             // 資料目錄覆寫（空字串使用預設目錄）
+	Retry               settings.RetrySettings    `json:"retry"`               // Token 刷新與用量查詢的重試策略
+	Endpoints           settings.EndpointSettings `json:"endpoints"`           // IdC 刷新與用量 API 端點覆寫
+	Network             settings.NetworkSettings  `json:"network"`             // 代理與額外的 CA 憑證
+	Logging             settings.LoggingSettings  `json:"logging"`             // 記錄等級
+	History             settings.HistorySettings  `json:"history"`             // 用量歷史保留天數
+	Alerts              settings.AlertSettings    `json:"alerts"`              // 用量警示規則與通知方式
+	Shutdown            settings.ShutdownSettings `json:"shutdown"`            // 等待 Kiro 正常結束的秒數
+	Relaunch            settings.RelaunchSettings `json:"relaunch"`            // 切換帳號後是否重新啟動 Kiro
 }
 
 // GetSettings 取得全域設定
@@ -652,22 +1408,71 @@ func (a *App) GetSettings() AppSettings {
 		LowBalanceThreshold: s.LowBalanceThreshold,
 		KiroVersion:         s.KiroVersion,
 		UseAutoDetect:       s.UseAutoDetect,
+		DataDir:             s.DataDir,
+		Retry:               s.Retry,
+		Endpoints:           s.Endpoints,
+		Network:             s.Network,
+		Logging:             s.Logging,
+		History:             s.History,
+		Alerts:              s.Alerts,
+		Shutdown:            s.Shutdown,
+		Relaunch:            s.Relaunch,
 	}
 }
 
 // SaveSettings 儲存全域設定
+// 資料目錄變更時，會將既有備份搬移至新目錄（新目錄已有備份時保留不覆蓋）
+// 目錄綁定只透過 SetWorkspaceBinding / RemoveWorkspaceBinding 修改，此處沿用目前的綁定
 func (a *App) SaveSettings(appSettings AppSettings) Result {
+	current := settings.GetCurrentSettings()
+
 	s := &settings.Settings{
 		LowBalanceThreshold: appSettings.LowBalanceThreshold,
 		KiroVersion:         appSettings.KiroVersion,
 		UseAutoDetect:       appSettings.UseAutoDetect,
+		DataDir:             appSettings.DataDir,
+		Retry:               appSettings.Retry,
+		Endpoints:           appSettings.Endpoints,
+		Network:             appSettings.Network,
+		Logging:             appSettings.Logging,
+		History:             appSettings.History,
+		Alerts:              appSettings.Alerts,
+		Shutdown:            appSettings.Shutdown,
+		Relaunch:            appSettings.Relaunch,
+		Workspaces:          current.Workspaces,
 	}
-	if err := settings.SaveSettings(s); err != nil {
-		return Result{Success: false, Message: fmt.Sprintf("儲存設定失敗: %v", err)}
+	if err := a.saveSettings(s); err != nil {
+		return Result{Success: false, Message: err.Error()}
 	}
 	return Result{Success: true, Message: "設定已儲存"}
 }
 
+// saveSettings 儲存設定並套用記錄等級（GUI 與 CLI 共用）
+// 資料目錄變更時，會將既有備份搬移至新目錄；新目錄已有備份時拒絕變更，避免既有備份從列表消失
+func (a *App) saveSettings(s *settings.Settings) error {
+	oldBackupRoot, _ := backup.GetBackupRootPath()
+	if newBackupRoot, err := backup.BackupRootPathFor(s.DataDir); err == nil && oldBackupRoot != "" {
+		if errors.Is(backup.CheckBackupRootMove(oldBackupRoot, newBackupRoot), backup.ErrBackupRootExists) {
+			return fmt.Errorf("新的資料目錄已有備份（%s），無法搬移目前的備份，設定未變更", newBackupRoot)
+		}
+	}
+
+	if err := settings.SaveSettings(s); err != nil {
+		return fmt.Errorf("儲存設定失敗: %w", err)
+	}
+	if a.logLevel == nil {
+		logging.SetLevel(settings.GetLogLevel())
+	}
+
+	newBackupRoot, err := backup.GetBackupRootPath()
+	if err == nil && oldBackupRoot != "" && newBackupRoot != oldBackupRoot {
+		if err := backup.MoveBackupRoot(oldBackupRoot, newBackupRoot); err != nil {
+			return fmt.Errorf("設定已儲存，但搬移備份失敗（備份仍位於 %s）: %w", oldBackupRoot, err)
+		}
+	}
+	return nil
+}
+
 // GetDetectedKiroVersion 自動偵測 Kiro IDE 執行檔的版本號
 func (a *App) GetDetectedKiroVersion() Result {
 	version, err := kiroversion.GetKiroVersion()
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"kiro-manager/backup"
+	"kiro-manager/internal/datadir"
+	"kiro-manager/settings"
+	"kiro-manager/workspace"
+)
+
+// TestSaveSettings_KeepsWorkspaceBindings 測試儲存其他設定時不會覆寫期間新增的目錄綁定
+func TestSaveSettings_KeepsWorkspaceBindings(t *testing.T) {
+	t.Setenv(datadir.EnvVar, t.TempDir())
+	if _, err := settings.LoadSettings(); err != nil {
+		t.Fatalf("LoadSettings failed: %v", err)
+	}
+	t.Cleanup(func() { settings.LoadSettings() })
+
+	app := NewApp()
+	// 前端在新增綁定前取得的設定
+	stale := app.GetSettings()
+
+	dir := t.TempDir()
+	if err := settings.SaveWorkspaceBindings([]workspace.Binding{{Path: dir, Backup: "work"}}); err != nil {
+		t.Fatalf("SaveWorkspaceBindings failed: %v", err)
+	}
+
+	stale.LowBalanceThreshold = 0.3
+	if result := app.SaveSettings(stale); !result.Success {
+		t.Fatalf("SaveSettings failed: %s", result.Message)
+	}
+
+	bindings := settings.GetWorkspaceBindings()
+	if len(bindings) != 1 || bindings[0].Backup != "work" {
+		t.Errorf("bindings = %+v, want the binding added before saving", bindings)
+	}
+	if got := settings.GetLowBalanceThreshold(); got != 0.3 {
+		t.Errorf("threshold = %v, want 0.3", got)
+	}
+
+	// 從磁碟重新載入後綁定仍存在
+	if _, err := settings.LoadSettings(); err != nil {
+		t.Fatalf("LoadSettings failed: %v", err)
+	}
+	if bindings := settings.GetWorkspaceBindings(); len(bindings) != 1 {
+		t.Errorf("bindings after reload = %+v, want 1", bindings)
+	}
+}
+
+// TestSaveSettings_MovesBackupsOnDataDirChange 測試變更資料目錄時搬移既有備份（GUI 與 CLI 共用的儲存流程）
+func TestSaveSettings_MovesBackupsOnDataDirChange(t *testing.T) {
+	// 不使用環境變數，讓設定中的 dataDir 生效
+	base := t.TempDir()
+	t.Setenv(datadir.EnvVar, "")
+	t.Setenv("XDG_DATA_HOME", base)
+	t.Setenv("HOME", base)
+	t.Setenv("APPDATA", base)
+	if _, err := settings.LoadSettings(); err != nil {
+		t.Fatalf("LoadSettings failed: %v", err)
+	}
+	t.Cleanup(func() { settings.LoadSettings() })
+
+	oldRoot, err := backup.GetBackupRootPath()
+	if err != nil {
+		t.Fatalf("GetBackupRootPath failed: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Join(oldRoot, "work"), 0700); err != nil {
+		t.Fatalf("Failed to create backup: %v", err)
+	}
+
+	s := *settings.GetCurrentSettings()
+	s.DataDir = filepath.Join(t.TempDir(), "data")
+	if err := NewApp().saveSettings(&s); err != nil {
+		t.Fatalf("saveSettings failed: %v", err)
+	}
+
+	newRoot, _ := backup.GetBackupRootPath()
+	if newRoot == oldRoot {
+		t.Fatalf("backup root not changed: %s", newRoot)
+	}
+	if _, err := os.Stat(filepath.Join(newRoot, "work")); err != nil {
+		t.Errorf("backup not moved to %s: %v", newRoot, err)
+	}
+}
+
+// TestSaveSettings_RefusesDataDirWithExistingBackups 測試新資料目錄已有備份時拒絕變更，既有備份仍可使用
+func TestSaveSettings_RefusesDataDirWithExistingBackups(t *testing.T) {
+	base := t.TempDir()
+	t.Setenv(datadir.EnvVar, "")
+	t.Setenv("XDG_DATA_HOME", base)
+	t.Setenv("HOME", base)
+	t.Setenv("APPDATA", base)
+	if _, err := settings.LoadSettings(); err != nil {
+		t.Fatalf("LoadSettings failed: %v", err)
+	}
+	t.Cleanup(func() { settings.LoadSettings() })
+
+	oldRoot, err := backup.GetBackupRootPath()
+	if err != nil {
+		t.Fatalf("GetBackupRootPath failed: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Join(oldRoot, "work"), 0700); err != nil {
+		t.Fatalf("Failed to create backup: %v", err)
+	}
+	newDataDir := filepath.Join(t.TempDir(), "data")
+	if err := os.MkdirAll(filepath.Join(newDataDir, backup.BackupDirName, "other"), 0700); err != nil {
+		t.Fatalf("Failed to create existing backup root: %v", err)
+	}
+
+	s := *settings.GetCurrentSettings()
+	s.DataDir = newDataDir
+	if err := NewApp().saveSettings(&s); err == nil {
+		t.Fatal("saveSettings should refuse a data directory that already has backups")
+	}
+
+	if got := settings.GetCurrentSettings().DataDir; got != "" {
+		t.Errorf("DataDir = %q, want unchanged", got)
+	}
+	if root, _ := backup.GetBackupRootPath(); root != oldRoot {
+		t.Errorf("backup root = %s, want %s", root, oldRoot)
+	}
+	if _, err := os.Stat(filepath.Join(oldRoot, "work")); err != nil {
+		t.Errorf("existing backup should stay in place: %v", err)
+	}
+}
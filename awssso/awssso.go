@@ -6,6 +6,8 @@ import (
 	"os"
 	"path/filepath"
 	"time"
+
+	"kiro-manager/internal/atomicfile"
 )
 
 const (
@@ -97,6 +99,24 @@ func ReadKiroAuthToken() (*KiroAuthToken, error) {
 	return &token, nil
 }
 
+// UpdateKiroAuthToken 更新 SSO cache 中 kiro-auth-token.json 的指定欄位
+// 保留其餘欄位與原始 key 順序，以原子方式寫回
+func UpdateKiroAuthToken(fields ...TokenField) error {
+	tokenPath, err := GetKiroAuthTokenPath()
+	if err != nil {
+		return err
+	}
+
+	// 持有鎖完成讀取-修改-寫入，避免多個 Kiro Manager 行程互相覆蓋；
+	// 以 rename 取代檔案，Kiro 不會讀到寫到一半的內容
+	return atomicfile.Update(tokenPath, 0600, func(data []byte, exists bool) ([]byte, error) {
+		if !exists {
+			return nil, ErrTokenNotFound
+		}
+		return UpdateTokenJSON(data, fields...)
+	})
+}
+
 // ListCacheFiles 列出 SSO 快取目錄中的所有 JSON 檔案
 func ListCacheFiles() ([]string, error) {
 	cachePath, err := GetSSOCachePath()
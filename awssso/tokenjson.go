@@ -0,0 +1,121 @@
+package awssso
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+var ErrNotJSONObject = errors.New("token file is not a JSON object")
+
+// TokenField 代表 token JSON 中的一個欄位更新
+type TokenField struct {
+	Key   string
+	Value string
+}
+
+// tokenMember 保留原始順序與原始值的 JSON 成員
+type tokenMember struct {
+	Key   string
+	Value json.RawMessage
+}
+
+// UpdateTokenJSON 更新 token JSON 的指定欄位，並保留其餘所有欄位與原始順序
+// 已存在的欄位就地替換值，不存在的欄位依傳入順序附加在最後
+// 未知欄位（包含巢狀物件與數字）保持原始內容不變
+func UpdateTokenJSON(data []byte, fields ...TokenField) ([]byte, error) {
+	members, err := parseTokenMembers(data)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, field := range fields {
+		value, err := json.Marshal(field.Value)
+		if err != nil {
+			return nil, err
+		}
+
+		found := false
+		for i := range members {
+			if members[i].Key == field.Key {
+				members[i].Value = value
+				found = true
+			}
+		}
+		if !found {
+			members = append(members, tokenMember{Key: field.Key, Value: value})
+		}
+	}
+
+	return marshalTokenMembers(members)
+}
+
+// parseTokenMembers 依原始順序解析 JSON 物件的所有成員
+func parseTokenMembers(data []byte) ([]tokenMember, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	start, err := decoder.Token()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse token JSON: %w", err)
+	}
+	if delim, ok := start.(json.Delim); !ok || delim != '{' {
+		return nil, ErrNotJSONObject
+	}
+
+	var members []tokenMember
+	for decoder.More() {
+		keyToken, err := decoder.Token()
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse token JSON: %w", err)
+		}
+		key, ok := keyToken.(string)
+		if !ok {
+			return nil, ErrNotJSONObject
+		}
+
+		var value json.RawMessage
+		if err := decoder.Decode(&value); err != nil {
+			return nil, fmt.Errorf("failed to parse token JSON: %w", err)
+		}
+		members = append(members, tokenMember{Key: key, Value: value})
+	}
+
+	if _, err := decoder.Token(); err != nil {
+		return nil, fmt.Errorf("failed to parse token JSON: %w", err)
+	}
+
+	return members, nil
+}
+
+// marshalTokenMembers 依成員順序輸出縮排兩格的 JSON 物件
+func marshalTokenMembers(members []tokenMember) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString("{")
+
+	for i, member := range members {
+		if i > 0 {
+			buf.WriteString(",")
+		}
+		buf.WriteString("\n  ")
+
+		key, err := json.Marshal(member.Key)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(key)
+		buf.WriteString(": ")
+
+		if err := json.Indent(&buf, member.Value, "  ", "  "); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(members) > 0 {
+		buf.WriteString("\n")
+	}
+	buf.WriteString("}")
+
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,71 @@
+package awssso
+
+import (
+	"errors"
+	"testing"
+)
+
+// TestUpdateTokenJSON_AppendsMissingFields 測試不存在的欄位依序附加在最後
+func TestUpdateTokenJSON_AppendsMissingFields(t *testing.T) {
+	data := []byte(`{"refreshToken":"r","accessToken":"old"}`)
+
+	updated, err := UpdateTokenJSON(data,
+		TokenField{Key: "accessToken", Value: "new"},
+		TokenField{Key: "expiresAt", Value: "2025-12-09T15:30:00.000Z"},
+		TokenField{Key: "profileArn", Value: "arn"},
+	)
+	if err != nil {
+		t.Fatalf("UpdateTokenJSON failed: %v", err)
+	}
+
+	expected := `{
+  "refreshToken": "r",
+  "accessToken": "new",
+  "expiresAt": "2025-12-09T15:30:00.000Z",
+  "profileArn": "arn"
+}`
+	if string(updated) != expected {
+		t.Errorf("UpdateTokenJSON() =\n%s\nwant\n%s", updated, expected)
+	}
+}
+
+// TestUpdateTokenJSON_PreservesRawValues 測試未知欄位的原始值（數字精度、巢狀結構）不變
+func TestUpdateTokenJSON_PreservesRawValues(t *testing.T) {
+	data := []byte(`{"accessToken":"a","big":12345678901234567890,"ratio":1.50,"nested":{"b":[1,{"c":null}]}}`)
+
+	updated, err := UpdateTokenJSON(data, TokenField{Key: "accessToken", Value: "b"})
+	if err != nil {
+		t.Fatalf("UpdateTokenJSON failed: %v", err)
+	}
+
+	expected := `{
+  "accessToken": "b",
+  "big": 12345678901234567890,
+  "ratio": 1.50,
+  "nested": {
+    "b": [
+      1,
+      {
+        "c": null
+      }
+    ]
+  }
+}`
+	if string(updated) != expected {
+		t.Errorf("UpdateTokenJSON() =\n%s\nwant\n%s", updated, expected)
+	}
+}
+
+// TestUpdateTokenJSON_RejectsNonObject 測試非 JSON 物件的輸入
+func TestUpdateTokenJSON_RejectsNonObject(t *testing.T) {
+	for _, input := range []string{`[]`, `"token"`, `null`} {
+		_, err := UpdateTokenJSON([]byte(input), TokenField{Key: "accessToken", Value: "a"})
+		if !errors.Is(err, ErrNotJSONObject) {
+			t.Errorf("UpdateTokenJSON(%s) error = %v, want ErrNotJSONObject", input, err)
+		}
+	}
+
+	if _, err := UpdateTokenJSON([]byte(`{"a":`)); err == nil {
+		t.Error("Expected error for truncated JSON")
+	}
+}
@@ -4,14 +4,18 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"io"
+	"log/slog"
 	"os"
 	"path/filepath"
 	"strings"
 	"time"
 
 	"kiro-manager/awssso"
+	"kiro-manager/internal/atomicfile"
+	"kiro-manager/internal/datadir"
 	"kiro-manager/machineid"
+	"kiro-manager/settings"
+	"kiro-manager/usage"
 )
 
 const (
@@ -21,11 +25,15 @@ const (
 	UsageCacheFileName  = "usage-cache.json"
 )
 
+// AlertStateFileName 用量警示狀態檔（記錄本計費週期已觸發的警示等級）
+const AlertStateFileName = "alert-state.json"
+
 var (
 	ErrBackupNotFound    = errors.New("backup not found")
 	ErrBackupExists      = errors.New("backup already exists")
 	ErrInvalidBackupName = errors.New("invalid backup name")
 	ErrNoTokenToBackup   = errors.New("no kiro auth token to backup")
+	ErrBackupRootExists  = errors.New("backup root already exists in the new data directory")
 )
 
 // MachineIDBackup 代表備份的 Machine ID 結構
@@ -41,26 +49,76 @@ type BackupInfo struct {
 	BackupTime time.Time `json:"backupTime"`
 	HasToken   bool      `json:"hasToken"`
 	HasMachineID bool    `json:"hasMachineId"`
+	// 以下欄位來自 manifest.json，舊版備份遷移失敗時為零值
+	SchemaVersion int    `json:"schemaVersion"`
+	AuthType      string `json:"authType"`
+	Provider      string `json:"provider"`
 }
 
 // UsageCache 餘額緩存結構
 type UsageCache struct {
-	SubscriptionTitle string    `json:"subscriptionTitle"`
-	UsageLimit        float64   `json:"usageLimit"`
-	CurrentUsage      float64   `json:"currentUsage"`
-	Balance           float64   `json:"balance"`
-	IsLowBalance      bool      `json:"isLowBalance"`
-	CachedAt          time.Time `json:"cachedAt"`
+	SubscriptionTitle string            `json:"subscriptionTitle"`
+	UsageLimit        float64           `json:"usageLimit"`
+	CurrentUsage      float64           `json:"currentUsage"`
+	Balance           float64           `json:"balance"`
+	IsLowBalance      bool              `json:"isLowBalance"`
+	CachedAt          time.Time         `json:"cachedAt"`
+	Breakdown         []usage.Breakdown `json:"breakdown,omitempty"`   // 基本、試用與獎勵額度明細（舊版緩存沒有此欄位）
+	NextResetAt       string            `json:"nextResetAt,omitempty"` // 下次重置時間（RFC3339）
+	Raw               json.RawMessage   `json:"raw,omitempty"`         // 原始 API 回應
 }
 
-// GetBackupRootPath 取得備份根目錄（執行檔同層的 backups 資料夾）
+// GetBackupRootPath 取得備份根目錄（資料目錄下的 backups 資料夾）
 func GetBackupRootPath() (string, error) {
-	execPath, err := os.Executable()
+	dataDir, err := settings.GetDataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dataDir, BackupDirName), nil
+}
+
+// MigrateLegacyBackups 將舊版執行檔同層的 backups 目錄搬移至目前的備份根目錄
+// 回傳是否已搬移；目標已存在時保留目標不覆蓋
+func MigrateLegacyBackups() (bool, error) {
+	rootPath, err := GetBackupRootPath()
+	if err != nil {
+		return false, err
+	}
+	return datadir.MigrateLegacy(BackupDirName, rootPath)
+}
+
+// BackupRootPathFor 取得設定中的 dataDir 為 dataDir 時的備份根目錄（尚未套用設定前使用）
+func BackupRootPathFor(dataDir string) (string, error) {
+	resolved, err := datadir.Resolve(dataDir)
 	if err != nil {
 		return "", err
 	}
-	execDir := filepath.Dir(execPath)
-	return filepath.Join(execDir, BackupDirName), nil
+	return filepath.Join(resolved, BackupDirName), nil
+}
+
+// CheckBackupRootMove 確認備份根目錄可從 oldRoot 搬移至 newRoot
+// 兩者皆已存在時回傳 ErrBackupRootExists（搬移會被略過，既有備份將不再顯示）
+func CheckBackupRootMove(oldRoot, newRoot string) error {
+	if oldRoot == newRoot {
+		return nil
+	}
+	if _, err := os.Lstat(oldRoot); err != nil {
+		return nil
+	}
+	if _, err := os.Lstat(newRoot); err == nil {
+		return ErrBackupRootExists
+	}
+	return nil
+}
+
+// MoveBackupRoot 將備份根目錄從 oldRoot 搬移至 newRoot（變更資料目錄時使用）
+// oldRoot 不存在時不做任何事；newRoot 已存在時不覆蓋，回傳 ErrBackupRootExists
+func MoveBackupRoot(oldRoot, newRoot string) error {
+	if err := CheckBackupRootMove(oldRoot, newRoot); err != nil {
+		return err
+	}
+	_, err := datadir.Move(oldRoot, newRoot)
+	return err
 }
 
 
@@ -70,7 +128,7 @@ func ensureBackupRoot() (string, error) {
 	if err != nil {
 		return "", err
 	}
-	if err := os.MkdirAll(rootPath, 0755); err != nil {
+	if err := os.MkdirAll(rootPath, 0700); err != nil {
 		return "", err
 	}
 	return rootPath, nil
@@ -78,14 +136,23 @@ func ensureBackupRoot() (string, error) {
 
 // GetBackupPath 取得指定備份的完整路徑
 func GetBackupPath(name string) (string, error) {
-	if name == "" {
-		return "", ErrInvalidBackupName
+	if err := ValidateBackupName(name); err != nil {
+		return "", err
+	}
+	return resolveBackupPath(name)
+}
+
+// resolveBackupPath 取得備份資料夾路徑，僅確認名稱為單一路徑元件且位於備份根目錄之下
+// 不套用完整的名稱規則，供處理舊版已存在但名稱不符規則的備份（如重新命名）
+func resolveBackupPath(name string) (string, error) {
+	if name == "" || name == "." || name == ".." || strings.ContainsAny(name, `/\`) {
+		return "", &InvalidNameError{Name: name, Reason: "name is not a single path element"}
 	}
 	rootPath, err := GetBackupRootPath()
 	if err != nil {
 		return "", err
 	}
-	return filepath.Join(rootPath, name), nil
+	return safeJoin(rootPath, name)
 }
 
 // BackupExists 檢查指定名稱的備份是否存在
@@ -120,41 +187,75 @@ func ListBackups() ([]BackupInfo, error) {
 			continue
 		}
 
-		backupPath := filepath.Join(rootPath, entry.Name())
-		info := BackupInfo{
-			Name: entry.Name(),
-			Path: backupPath,
-		}
+		backups = append(backups, loadBackupInfo(entry.Name(), filepath.Join(rootPath, entry.Name())))
+	}
+
+	return backups, nil
+}
 
-		// 檢查是否有 token 檔案
-		tokenPath := filepath.Join(backupPath, KiroAuthTokenFile)
-		if _, err := os.Stat(tokenPath); err == nil {
-			info.HasToken = true
+// loadBackupInfo 讀取備份資訊
+// 首次讀取舊版備份時會依遷移步驟升級格式並建立 manifest.json
+// 升級失敗（如金鑰未解鎖）時改以檔案是否存在推斷內容，下次讀取時再重試
+func loadBackupInfo(name, backupPath string) BackupInfo {
+	info := BackupInfo{
+		Name: name,
+		Path: backupPath,
+	}
+
+	// 讀取備份時間（machine-id.json 為明文）
+	if mid, err := readMachineIDBackup(backupPath); err == nil {
+		info.HasMachineID = true
+		if t, err := time.Parse(time.RFC3339, mid.BackupTime); err == nil {
+			info.BackupTime = t
 		}
+	}
 
-		// 檢查是否有 machine-id 檔案並讀取備份時間
-		machineIDPath := filepath.Join(backupPath, MachineIDFileName)
-		if data, err := os.ReadFile(machineIDPath); err == nil {
-			info.HasMachineID = true
-			var mid MachineIDBackup
-			if json.Unmarshal(data, &mid) == nil && mid.BackupTime != "" {
-				if t, err := time.Parse(time.RFC3339, mid.BackupTime); err == nil {
-					info.BackupTime = t
-				}
-			}
+	manifest, err := upgradeBackup(backupPath)
+	if err == nil {
+		info.SchemaVersion = manifest.SchemaVersion
+		info.AuthType = manifest.AuthType
+		info.Provider = manifest.Provider
+		info.HasToken = manifest.HasFile(KiroAuthTokenFile)
+		info.HasMachineID = manifest.HasFile(MachineIDFileName)
+		if info.BackupTime.IsZero() {
+			info.BackupTime = manifest.CreatedAt
 		}
+		return info
+	}
 
-		backups = append(backups, info)
+	if manifest != nil {
+		info.SchemaVersion = manifest.SchemaVersion
 	}
 
-	return backups, nil
+	// 檢查是否有 token 檔案
+	tokenPath := filepath.Join(backupPath, KiroAuthTokenFile)
+	if _, err := os.Stat(tokenPath); err == nil {
+		info.HasToken = true
+	}
+
+	return info
 }
 
+// readMachineIDBackup 讀取備份目錄中的 machine-id.json
+func readMachineIDBackup(backupPath string) (*MachineIDBackup, error) {
+	machineIDPath := filepath.Join(backupPath, MachineIDFileName)
+	data, err := os.ReadFile(machineIDPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read machine id file: %w", err)
+	}
+
+	var mid MachineIDBackup
+	if err := json.Unmarshal(data, &mid); err != nil {
+		return nil, fmt.Errorf("failed to parse machine id file: %w", err)
+	}
+
+	return &mid, nil
+}
 
 // CreateBackup 創建一個新的備份
 func CreateBackup(name string) error {
-	if name == "" {
-		return ErrInvalidBackupName
+	if err := ValidateBackupName(name); err != nil {
+		return err
 	}
 
 	if BackupExists(name) {
@@ -173,7 +274,7 @@ func CreateBackup(name string) error {
 		return err
 	}
 
-	if err := os.MkdirAll(backupPath, 0755); err != nil {
+	if err := os.MkdirAll(backupPath, 0700); err != nil {
 		return fmt.Errorf("failed to create backup directory: %w", err)
 	}
 
@@ -191,7 +292,7 @@ func CreateBackup(name string) error {
 	}
 
 	tokenDstPath := filepath.Join(backupPath, KiroAuthTokenFile)
-	if err := copyFile(tokenSrcPath, tokenDstPath); err != nil {
+	if err := encryptFile(tokenSrcPath, tokenDstPath); err != nil {
 		os.RemoveAll(backupPath)
 		return fmt.Errorf("failed to backup token: %w", err)
 	}
@@ -201,15 +302,19 @@ func CreateBackup(name string) error {
 	if err == nil && token != nil {
 		// 如果是 IdC 認證且有 clientIdHash，備份對應的 clientId/clientSecret 文件
 		if isIdCAuth(token.AuthMethod) && token.ClientIdHash != "" {
-			clientIdHashFile := token.ClientIdHash + ".json"
+			clientIdHashFile, err := clientIdHashFileName(token.ClientIdHash)
+			if err != nil {
+				os.RemoveAll(backupPath)
+				return fmt.Errorf("failed to backup clientIdHash file: %w", err)
+			}
 			ssoCachePath, err := awssso.GetSSOCachePath()
 			if err == nil {
 				clientIdHashSrcPath := filepath.Join(ssoCachePath, clientIdHashFile)
 				if _, err := os.Stat(clientIdHashSrcPath); err == nil {
 					clientIdHashDstPath := filepath.Join(backupPath, clientIdHashFile)
-					if err := copyFile(clientIdHashSrcPath, clientIdHashDstPath); err != nil {
+					if err := encryptFile(clientIdHashSrcPath, clientIdHashDstPath); err != nil {
 						// 備份 clientIdHash 文件失敗不應該阻止整個備份流程，只記錄警告
-						fmt.Printf("Warning: failed to backup clientIdHash file: %v\n", err)
+						slog.Warn("failed to backup clientIdHash file", "backup", name, "error", err)
 					}
 				}
 			}
@@ -223,9 +328,10 @@ func CreateBackup(name string) error {
 		return fmt.Errorf("failed to get machine id: %w", err)
 	}
 
+	createdAt := time.Now()
 	machineIDBackup := MachineIDBackup{
 		MachineID:  rawMachineID,
-		BackupTime: time.Now().Format(time.RFC3339),
+		BackupTime: createdAt.Format(time.RFC3339),
 	}
 
 	machineIDData, err := json.MarshalIndent(machineIDBackup, "", "  ")
@@ -235,11 +341,28 @@ func CreateBackup(name string) error {
 	}
 
 	machineIDPath := filepath.Join(backupPath, MachineIDFileName)
-	if err := os.WriteFile(machineIDPath, machineIDData, 0644); err != nil {
+	if err := atomicfile.WriteFile(machineIDPath, machineIDData, 0600); err != nil {
 		os.RemoveAll(backupPath)
 		return fmt.Errorf("failed to write machine id: %w", err)
 	}
 
+	if err := createManifest(backupPath, token, createdAt); err != nil {
+		os.RemoveAll(backupPath)
+		return err
+	}
+
+	return nil
+}
+
+// createManifest 為新建立的備份寫入 manifest.json
+func createManifest(backupPath string, token *awssso.KiroAuthToken, createdAt time.Time) error {
+	manifest, err := newManifest(backupPath, token, createdAt)
+	if err != nil {
+		return fmt.Errorf("failed to build manifest: %w", err)
+	}
+	if err := writeManifest(backupPath, manifest); err != nil {
+		return fmt.Errorf("failed to write manifest: %w", err)
+	}
 	return nil
 }
 
@@ -252,32 +375,19 @@ func isIdCAuth(authMethod string) bool {
 	return lower == "idc" || lower == "identitycenter"
 }
 
-// copyFile 複製檔案
-func copyFile(src, dst string) error {
-	srcFile, err := os.Open(src)
+// encryptFile 讀取明文檔案並加密寫入備份
+func encryptFile(src, dst string) error {
+	data, err := os.ReadFile(src)
 	if err != nil {
 		return err
 	}
-	defer srcFile.Close()
-
-	dstFile, err := os.Create(dst)
-	if err != nil {
-		return err
-	}
-	defer dstFile.Close()
-
-	if _, err := io.Copy(dstFile, srcFile); err != nil {
-		return err
-	}
-
-	return dstFile.Sync()
+	return writeSecretFile(dst, data)
 }
 
-
-// RestoreBackup 恢復指定的備份
-func RestoreBackup(name string) error {
-	if name == "" {
-		return ErrInvalidBackupName
+// DeleteBackup 刪除指定的備份
+func DeleteBackup(name string) error {
+	if err := ValidateBackupName(name); err != nil {
+		return err
 	}
 
 	if !BackupExists(name) {
@@ -289,72 +399,49 @@ func RestoreBackup(name string) error {
 		return err
 	}
 
-	// 恢復 kiro-auth-token.json
-	tokenSrcPath := filepath.Join(backupPath, KiroAuthTokenFile)
-	if _, err := os.Stat(tokenSrcPath); os.IsNotExist(err) {
-		return fmt.Errorf("backup token file not found")
-	}
-
-	tokenDstPath, err := awssso.GetKiroAuthTokenPath()
-	if err != nil {
-		return fmt.Errorf("failed to get token destination path: %w", err)
-	}
-
-	// 確保目標目錄存在
-	tokenDstDir := filepath.Dir(tokenDstPath)
-	if err := os.MkdirAll(tokenDstDir, 0755); err != nil {
-		return fmt.Errorf("failed to create token directory: %w", err)
+	// 鎖定檔位於資料夾之外，需一併清除
+	if err := atomicfile.RemoveLocks(backupPath); err != nil {
+		return err
 	}
+	return os.RemoveAll(backupPath)
+}
 
-	if err := copyFile(tokenSrcPath, tokenDstPath); err != nil {
-		return fmt.Errorf("failed to restore token: %w", err)
+// RenameBackup 重新命名備份
+// 目標名稱已存在時返回 ErrBackupExists
+func RenameBackup(oldName, newName string) error {
+	if err := ValidateBackupName(newName); err != nil {
+		return err
 	}
 
-	// 讀取備份的 token 以檢查是否需要恢復 IdC 的 clientIdHash 文件
-	token, err := ReadBackupToken(name)
-	if err == nil && token != nil {
-		// 如果是 IdC 認證且有 clientIdHash，恢復對應的 clientId/clientSecret 文件
-		if isIdCAuth(token.AuthMethod) && token.ClientIdHash != "" {
-			clientIdHashFile := token.ClientIdHash + ".json"
-			clientIdHashSrcPath := filepath.Join(backupPath, clientIdHashFile)
-			if _, err := os.Stat(clientIdHashSrcPath); err == nil {
-				ssoCachePath, err := awssso.GetSSOCachePath()
-				if err == nil {
-					clientIdHashDstPath := filepath.Join(ssoCachePath, clientIdHashFile)
-					if err := copyFile(clientIdHashSrcPath, clientIdHashDstPath); err != nil {
-						// 恢復 clientIdHash 文件失敗不應該阻止整個恢復流程，只記錄警告
-						fmt.Printf("Warning: failed to restore clientIdHash file: %v\n", err)
-					}
-				}
-			}
-		}
+	// 來源名稱僅檢查路徑安全性，讓舊版名稱不符規則的備份可以改為合法名稱
+	oldPath, err := resolveBackupPath(oldName)
+	if err != nil {
+		return err
 	}
-
-	return nil
-}
-
-// DeleteBackup 刪除指定的備份
-func DeleteBackup(name string) error {
-	if name == "" {
-		return ErrInvalidBackupName
+	if info, err := os.Stat(oldPath); err != nil || !info.IsDir() {
+		return ErrBackupNotFound
 	}
 
-	if !BackupExists(name) {
-		return ErrBackupNotFound
+	if BackupExists(newName) {
+		return ErrBackupExists
 	}
 
-	backupPath, err := GetBackupPath(name)
+	newPath, err := GetBackupPath(newName)
 	if err != nil {
 		return err
 	}
 
-	return os.RemoveAll(backupPath)
+	// 舊路徑的鎖定檔在重新命名後不再使用
+	if err := atomicfile.RemoveLocks(oldPath); err != nil {
+		return err
+	}
+	return os.Rename(oldPath, newPath)
 }
 
 // GetBackupInfo 取得指定備份的詳細資訊
 func GetBackupInfo(name string) (*BackupInfo, error) {
-	if name == "" {
-		return nil, ErrInvalidBackupName
+	if err := ValidateBackupName(name); err != nil {
+		return nil, err
 	}
 
 	if !BackupExists(name) {
@@ -366,36 +453,14 @@ func GetBackupInfo(name string) (*BackupInfo, error) {
 		return nil, err
 	}
 
-	info := &BackupInfo{
-		Name: name,
-		Path: backupPath,
-	}
-
-	// 檢查 token 檔案
-	tokenPath := filepath.Join(backupPath, KiroAuthTokenFile)
-	if _, err := os.Stat(tokenPath); err == nil {
-		info.HasToken = true
-	}
-
-	// 檢查 machine-id 檔案
-	machineIDPath := filepath.Join(backupPath, MachineIDFileName)
-	if data, err := os.ReadFile(machineIDPath); err == nil {
-		info.HasMachineID = true
-		var mid MachineIDBackup
-		if json.Unmarshal(data, &mid) == nil && mid.BackupTime != "" {
-			if t, err := time.Parse(time.RFC3339, mid.BackupTime); err == nil {
-				info.BackupTime = t
-			}
-		}
-	}
-
-	return info, nil
+	info := loadBackupInfo(name, backupPath)
+	return &info, nil
 }
 
 // ReadBackupMachineID 讀取備份中的 Machine ID
 func ReadBackupMachineID(name string) (*MachineIDBackup, error) {
-	if name == "" {
-		return nil, ErrInvalidBackupName
+	if err := ValidateBackupName(name); err != nil {
+		return nil, err
 	}
 
 	if !BackupExists(name) {
@@ -407,18 +472,7 @@ func ReadBackupMachineID(name string) (*MachineIDBackup, error) {
 		return nil, err
 	}
 
-	machineIDPath := filepath.Join(backupPath, MachineIDFileName)
-	data, err := os.ReadFile(machineIDPath)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read machine id file: %w", err)
-	}
-
-	var mid MachineIDBackup
-	if err := json.Unmarshal(data, &mid); err != nil {
-		return nil, fmt.Errorf("failed to parse machine id file: %w", err)
-	}
-
-	return &mid, nil
+	return readMachineIDBackup(backupPath)
 }
 
 // OriginalBackupName 原始備份的固定名稱
@@ -427,8 +481,8 @@ const OriginalBackupName = "original"
 // CreateMachineIDOnlyBackup 僅備份 Machine ID（不備份 token）
 // 用於軟體啟動時確保原始 Machine ID 被保存
 func CreateMachineIDOnlyBackup(name string) error {
-	if name == "" {
-		return ErrInvalidBackupName
+	if err := ValidateBackupName(name); err != nil {
+		return err
 	}
 
 	if BackupExists(name) {
@@ -447,7 +501,7 @@ func CreateMachineIDOnlyBackup(name string) error {
 		return err
 	}
 
-	if err := os.MkdirAll(backupPath, 0755); err != nil {
+	if err := os.MkdirAll(backupPath, 0700); err != nil {
 		return fmt.Errorf("failed to create backup directory: %w", err)
 	}
 
@@ -458,9 +512,10 @@ func CreateMachineIDOnlyBackup(name string) error {
 		return fmt.Errorf("failed to get machine id: %w", err)
 	}
 
+	createdAt := time.Now()
 	machineIDBackup := MachineIDBackup{
 		MachineID:  rawMachineID,
-		BackupTime: time.Now().Format(time.RFC3339),
+		BackupTime: createdAt.Format(time.RFC3339),
 	}
 
 	machineIDData, err := json.MarshalIndent(machineIDBackup, "", "  ")
@@ -470,11 +525,16 @@ func CreateMachineIDOnlyBackup(name string) error {
 	}
 
 	machineIDPath := filepath.Join(backupPath, MachineIDFileName)
-	if err := os.WriteFile(machineIDPath, machineIDData, 0644); err != nil {
+	if err := atomicfile.WriteFile(machineIDPath, machineIDData, 0600); err != nil {
 		os.RemoveAll(backupPath)
 		return fmt.Errorf("failed to write machine id: %w", err)
 	}
 
+	if err := createManifest(backupPath, nil, createdAt); err != nil {
+		os.RemoveAll(backupPath)
+		return err
+	}
+
 	return nil
 }
 
@@ -495,10 +555,19 @@ func EnsureOriginalBackup() (bool, error) {
 	return true, nil
 }
 
+// commitFile 以原子方式寫入檔案（呼叫端需已持有該檔案的鎖）
+func commitFile(path string, data []byte) error {
+	staged, err := atomicfile.Stage(path, data, 0600)
+	if err != nil {
+		return err
+	}
+	return staged.Commit()
+}
+
 // ReadBackupToken 讀取備份中的 kiro-auth-token.json
 func ReadBackupToken(name string) (*awssso.KiroAuthToken, error) {
-	if name == "" {
-		return nil, ErrInvalidBackupName
+	if err := ValidateBackupName(name); err != nil {
+		return nil, err
 	}
 
 	if !BackupExists(name) {
@@ -511,7 +580,7 @@ func ReadBackupToken(name string) (*awssso.KiroAuthToken, error) {
 	}
 
 	tokenPath := filepath.Join(backupPath, KiroAuthTokenFile)
-	data, err := os.ReadFile(tokenPath)
+	data, err := readSecretFile(tokenPath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read token file: %w", err)
 	}
@@ -527,12 +596,13 @@ func ReadBackupToken(name string) (*awssso.KiroAuthToken, error) {
 // ReadBackupIdCCredentials 從備份目錄讀取 IdC 的 clientId 和 clientSecret
 // 根據 token 中的 clientIdHash 查找對應的 JSON 文件
 func ReadBackupIdCCredentials(name string, clientIdHash string) (clientID, clientSecret string, err error) {
-	if name == "" {
-		return "", "", ErrInvalidBackupName
+	if err := ValidateBackupName(name); err != nil {
+		return "", "", err
 	}
 
-	if clientIdHash == "" {
-		return "", "", fmt.Errorf("clientIdHash is empty")
+	clientIdHashFile, err := clientIdHashFileName(clientIdHash)
+	if err != nil {
+		return "", "", err
 	}
 
 	if !BackupExists(name) {
@@ -545,10 +615,9 @@ func ReadBackupIdCCredentials(name string, clientIdHash string) (clientID, clien
 	}
 
 	// 讀取 clientIdHash 對應的 JSON 文件
-	clientIdHashFile := clientIdHash + ".json"
 	clientIdHashPath := filepath.Join(backupPath, clientIdHashFile)
 
-	data, err := os.ReadFile(clientIdHashPath)
+	data, err := readSecretFile(clientIdHashPath)
 	if err != nil {
 		return "", "", fmt.Errorf("failed to read clientIdHash file: %w", err)
 	}
@@ -571,8 +640,8 @@ func ReadBackupIdCCredentials(name string, clientIdHash string) (clientID, clien
 
 // ReadUsageCache 讀取備份的餘額緩存
 func ReadUsageCache(name string) (*UsageCache, error) {
-	if name == "" {
-		return nil, ErrInvalidBackupName
+	if err := ValidateBackupName(name); err != nil {
+		return nil, err
 	}
 
 	if !BackupExists(name) {
@@ -600,8 +669,8 @@ func ReadUsageCache(name string) (*UsageCache, error) {
 
 // WriteUsageCache 寫入備份的餘額緩存
 func WriteUsageCache(name string, cache *UsageCache) error {
-	if name == "" {
-		return ErrInvalidBackupName
+	if err := ValidateBackupName(name); err != nil {
+		return err
 	}
 
 	if cache == nil {
@@ -626,7 +695,7 @@ func WriteUsageCache(name string, cache *UsageCache) error {
 	}
 
 	cachePath := filepath.Join(backupPath, UsageCacheFileName)
-	if err := os.WriteFile(cachePath, cacheData, 0644); err != nil {
+	if err := atomicfile.WriteFile(cachePath, cacheData, 0600); err != nil {
 		return fmt.Errorf("failed to write usage cache: %w", err)
 	}
 
@@ -634,24 +703,48 @@ func WriteUsageCache(name string, cache *UsageCache) error {
 }
 
 
-// orderedKiroAuthToken 用於確保 JSON 輸出時 key 的順序
-// 順序: accessToken, refreshToken, profileArn, expiresAt, authMethod, provider
-type orderedKiroAuthToken struct {
-	AccessToken  string `json:"accessToken"`
-	RefreshToken string `json:"refreshToken"`
-	ProfileArn   string `json:"profileArn"`
-	ExpiresAt    string `json:"expiresAt"`
-	AuthMethod   string `json:"authMethod"`
-	Provider     string `json:"provider"`
+// TokenUpdate 刷新後需要寫回 token 檔案的欄位
+// 空字串代表該欄位不更新（例如伺服器未輪替 RefreshToken）
+type TokenUpdate struct {
+	AccessToken  string
+	ExpiresAt    string
+	RefreshToken string
+	ProfileArn   string
+}
+
+// Fields 將非空欄位轉換為 awssso.TokenField 列表
+func (u TokenUpdate) Fields() []awssso.TokenField {
+	var fields []awssso.TokenField
+	if u.AccessToken != "" {
+		fields = append(fields, awssso.TokenField{Key: "accessToken", Value: u.AccessToken})
+	}
+	if u.RefreshToken != "" {
+		fields = append(fields, awssso.TokenField{Key: "refreshToken", Value: u.RefreshToken})
+	}
+	if u.ProfileArn != "" {
+		fields = append(fields, awssso.TokenField{Key: "profileArn", Value: u.ProfileArn})
+	}
+	if u.ExpiresAt != "" {
+		fields = append(fields, awssso.TokenField{Key: "expiresAt", Value: u.ExpiresAt})
+	}
+	return fields
 }
 
 // WriteBackupToken 將刷新後的 Token 寫入備份檔案
-// 保留原有欄位，僅更新 accessToken、expiresAt
-// 確保 JSON key 順序: accessToken, refreshToken, profileArn, expiresAt, authMethod, provider
+// 僅更新 accessToken、expiresAt，其餘欄位（含未知欄位）與原始 key 順序完整保留
 // 需求: 3.1, 3.2, 3.3
 func WriteBackupToken(name string, accessToken string, expiresAt string) error {
-	if name == "" {
-		return ErrInvalidBackupName
+	return UpdateBackupToken(name, TokenUpdate{
+		AccessToken: accessToken,
+		ExpiresAt:   expiresAt,
+	})
+}
+
+// UpdateBackupToken 將刷新結果（含輪替後的 RefreshToken 與 ProfileArn）寫入備份檔案
+// 其餘欄位（含未知欄位）與原始 key 順序完整保留
+func UpdateBackupToken(name string, update TokenUpdate) error {
+	if err := ValidateBackupName(name); err != nil {
+		return err
 	}
 
 	if !BackupExists(name) {
@@ -665,47 +758,42 @@ func WriteBackupToken(name string, accessToken string, expiresAt string) error {
 
 	tokenPath := filepath.Join(backupPath, KiroAuthTokenFile)
 
-	// 讀取現有 token 檔案以保留原始欄位
-	data, err := os.ReadFile(tokenPath)
+	// token 與清單中的校驗值在同一把鎖內更新，避免並行刷新或清單寫入失敗留下不符的校驗值
+	unlock, err := atomicfile.Lock(tokenPath)
 	if err != nil {
-		return fmt.Errorf("failed to read existing token file: %w", err)
+		return err
 	}
+	defer unlock()
 
-	// 先解析到 map 以讀取原始值
-	var tokenMap map[string]interface{}
-	if err := json.Unmarshal(data, &tokenMap); err != nil {
-		return fmt.Errorf("failed to parse existing token file: %w", err)
+	// 讀取現有 token 檔案（透明解密）以保留原始欄位
+	old, err := os.ReadFile(tokenPath)
+	if err != nil {
+		return fmt.Errorf("failed to read existing token file: %w", err)
 	}
-
-	// 使用有序結構體來確保 key 順序
-	orderedToken := orderedKiroAuthToken{
-		AccessToken:  accessToken,
-		RefreshToken: getStringFromMap(tokenMap, "refreshToken"),
-		ProfileArn:   getStringFromMap(tokenMap, "profileArn"),
-		ExpiresAt:    expiresAt,
-		AuthMethod:   getStringFromMap(tokenMap, "authMethod"),
-		Provider:     getStringFromMap(tokenMap, "provider"),
+	data, err := decryptSecretData(old)
+	if err != nil {
+		return fmt.Errorf("failed to read existing token file: %w", err)
 	}
-
-	// 將更新後的 token 寫回檔案
-	updatedData, err := json.MarshalIndent(orderedToken, "", "  ")
+	updatedData, err := awssso.UpdateTokenJSON(data, update.Fields()...)
 	if err != nil {
-		return fmt.Errorf("failed to marshal updated token: %w", err)
+		return fmt.Errorf("failed to update token file: %w", err)
 	}
-
-	if err := os.WriteFile(tokenPath, updatedData, 0644); err != nil {
-		return fmt.Errorf("failed to write token file: %w", err)
+	encrypted, err := encryptSecretData(updatedData)
+	if err != nil {
+		return err
 	}
 
-	return nil
-}
+	if err := commitFile(tokenPath, encrypted); err != nil {
+		return err
+	}
 
-// getStringFromMap 從 map 中安全地取得字串值
-func getStringFromMap(m map[string]interface{}, key string) string {
-	if v, ok := m[key]; ok {
-		if s, ok := v.(string); ok {
-			return s
+	// token 內容變更，更新清單中的校驗值；失敗時還原 token，讓兩者保持一致
+	if err := setManifestEntry(backupPath, KiroAuthTokenFile, encrypted); err != nil {
+		if restoreErr := commitFile(tokenPath, old); restoreErr != nil {
+			return fmt.Errorf("failed to update manifest: %w (token rollback failed: %v)", err, restoreErr)
 		}
+		return fmt.Errorf("failed to update manifest: %w", err)
 	}
-	return ""
+
+	return nil
 }
@@ -1,12 +1,19 @@
 package backup
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"math/rand"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"testing/quick"
+
+	"kiro-manager/awssso"
+	"kiro-manager/internal/datadir"
 )
 
 // generateRandomString 生成指定長度的隨機字串
@@ -72,12 +79,7 @@ func generateRandomKiroAuthTokenMap(r *rand.Rand) map[string]interface{} {
 // all original fields except accessToken, expiresAt, and expiresIn SHALL remain unchanged.
 // **Validates: Requirements 3.2**
 func TestProperty_TokenUpdatePreservesOriginalFields(t *testing.T) {
-	// 建立臨時測試目錄
-	tempDir, err := os.MkdirTemp("", "backup_test_*")
-	if err != nil {
-		t.Fatalf("Failed to create temp dir: %v", err)
-	}
-	defer os.RemoveAll(tempDir)
+	useTestBackupRoot(t)
 
 	f := func(seed int64) bool {
 		r := rand.New(rand.NewSource(seed))
@@ -85,22 +87,14 @@ func TestProperty_TokenUpdatePreservesOriginalFields(t *testing.T) {
 		// 生成隨機的 token map
 		originalTokenMap := generateRandomKiroAuthTokenMap(r)
 
-		// 建立測試備份目錄
+		// 建立測試備份（token 以正式流程加密寫入）
 		backupName := "test_backup_" + generateRandomString(r, 8)
-		backupPath := filepath.Join(tempDir, backupName)
-		if err := os.MkdirAll(backupPath, 0755); err != nil {
-			t.Logf("Failed to create backup dir: %v", err)
-			return false
-		}
-
-		// 寫入原始 token 檔案
-		tokenPath := filepath.Join(backupPath, KiroAuthTokenFile)
 		originalData, err := json.MarshalIndent(originalTokenMap, "", "  ")
 		if err != nil {
 			t.Logf("Failed to marshal original token: %v", err)
 			return false
 		}
-		if err := os.WriteFile(tokenPath, originalData, 0644); err != nil {
+		if err := writeTestBackupToken(backupName, originalData); err != nil {
 			t.Logf("Failed to write original token: %v", err)
 			return false
 		}
@@ -109,15 +103,13 @@ func TestProperty_TokenUpdatePreservesOriginalFields(t *testing.T) {
 		newAccessToken := generateRandomString(r, r.Intn(100)+10)
 		newExpiresAt := "2025-12-09T15:30:00Z"
 
-		// 呼叫 WriteBackupToken（使用自訂路徑版本）
-		err = writeBackupTokenToPath(tokenPath, newAccessToken, newExpiresAt)
-		if err != nil {
+		if err := WriteBackupToken(backupName, newAccessToken, newExpiresAt); err != nil {
 			t.Logf("Failed to write backup token: %v", err)
 			return false
 		}
 
-		// 讀取更新後的 token
-		updatedData, err := os.ReadFile(tokenPath)
+		// 讀取更新後的 token（解密後的內容）
+		updatedData, err := readTestBackupToken(backupName)
 		if err != nil {
 			t.Logf("Failed to read updated token: %v", err)
 			return false
@@ -162,7 +154,9 @@ func TestProperty_TokenUpdatePreservesOriginalFields(t *testing.T) {
 		}
 
 		// 清理測試備份
-		os.RemoveAll(backupPath)
+		if backupPath, err := GetBackupPath(backupName); err == nil {
+			os.RemoveAll(backupPath)
+		}
 
 		return true
 	}
@@ -176,32 +170,54 @@ func TestProperty_TokenUpdatePreservesOriginalFields(t *testing.T) {
 	}
 }
 
-// writeBackupTokenToPath 是 WriteBackupToken 的內部版本，直接操作指定路徑
-// 用於測試時避免依賴 GetBackupPath
-func writeBackupTokenToPath(tokenPath string, accessToken string, expiresAt string) error {
-	// 讀取現有 token 檔案以保留原始欄位
-	data, err := os.ReadFile(tokenPath)
+// useTestBackupRoot 將資料目錄指向暫存目錄並注入測試金鑰，讓 WriteBackupToken 等正式流程可在測試中執行
+// 不會讀寫使用者的 OS keyring
+func useTestBackupRoot(t *testing.T) string {
+	t.Helper()
+	t.Setenv(datadir.EnvVar, t.TempDir())
+
+	keyMutex.Lock()
+	cachedKey = generateTestKey(rand.New(rand.NewSource(1)))
+	keyMutex.Unlock()
+	t.Cleanup(func() { SetPassphrase("") })
+
+	rootPath, err := GetBackupRootPath()
 	if err != nil {
-		return err
+		t.Fatalf("GetBackupRootPath failed: %v", err)
 	}
-
-	// 使用 map 來保留所有原始欄位
-	var tokenMap map[string]interface{}
-	if err := json.Unmarshal(data, &tokenMap); err != nil {
-		return err
+	if err := os.MkdirAll(rootPath, 0700); err != nil {
+		t.Fatalf("Failed to create backup root: %v", err)
 	}
+	return rootPath
+}
 
-	// 僅更新 accessToken 和 expiresAt 欄位
-	tokenMap["accessToken"] = accessToken
-	tokenMap["expiresAt"] = expiresAt
-
-	// 將更新後的 token 寫回檔案
-	updatedData, err := json.MarshalIndent(tokenMap, "", "  ")
+// writeTestBackupToken 建立備份目錄並以正式流程加密寫入 token
+func writeTestBackupToken(name string, data []byte) error {
+	backupPath, err := GetBackupPath(name)
 	if err != nil {
 		return err
 	}
+	if err := os.MkdirAll(backupPath, 0700); err != nil {
+		return err
+	}
+	return writeSecretFile(filepath.Join(backupPath, KiroAuthTokenFile), data)
+}
 
-	return os.WriteFile(tokenPath, updatedData, 0644)
+// readTestBackupToken 讀取並解密備份中的 token，確認磁碟上的內容仍為加密格式
+func readTestBackupToken(name string) ([]byte, error) {
+	backupPath, err := GetBackupPath(name)
+	if err != nil {
+		return nil, err
+	}
+	tokenPath := filepath.Join(backupPath, KiroAuthTokenFile)
+	raw, err := os.ReadFile(tokenPath)
+	if err != nil {
+		return nil, err
+	}
+	if !IsEncryptedData(raw) {
+		return nil, errors.New("token file is not encrypted on disk")
+	}
+	return readSecretFile(tokenPath)
 }
 
 // compareValues 比較兩個值是否相等（處理 map 和其他類型）
@@ -218,7 +234,7 @@ func compareValues(a, b interface{}) bool {
 // TestWriteBackupToken_InvalidBackupName 測試無效備份名稱的處理
 func TestWriteBackupToken_InvalidBackupName(t *testing.T) {
 	err := WriteBackupToken("", "new-token", "2025-12-09T15:30:00Z")
-	if err != ErrInvalidBackupName {
+	if !errors.Is(err, ErrInvalidBackupName) {
 		t.Errorf("Expected ErrInvalidBackupName, got %v", err)
 	}
 }
@@ -233,18 +249,7 @@ func TestWriteBackupToken_BackupNotFound(t *testing.T) {
 
 // TestWriteBackupToken_PreservesAllFields 測試欄位保留功能
 func TestWriteBackupToken_PreservesAllFields(t *testing.T) {
-	// 建立臨時測試目錄
-	tempDir, err := os.MkdirTemp("", "backup_test_*")
-	if err != nil {
-		t.Fatalf("Failed to create temp dir: %v", err)
-	}
-	defer os.RemoveAll(tempDir)
-
-	// 建立測試備份目錄
-	backupPath := filepath.Join(tempDir, "test_backup")
-	if err := os.MkdirAll(backupPath, 0755); err != nil {
-		t.Fatalf("Failed to create backup dir: %v", err)
-	}
+	useTestBackupRoot(t)
 
 	// 建立包含多個欄位的原始 token
 	originalToken := map[string]interface{}{
@@ -258,22 +263,20 @@ func TestWriteBackupToken_PreservesAllFields(t *testing.T) {
 	}
 
 	// 寫入原始 token
-	tokenPath := filepath.Join(backupPath, KiroAuthTokenFile)
 	originalData, _ := json.MarshalIndent(originalToken, "", "  ")
-	if err := os.WriteFile(tokenPath, originalData, 0644); err != nil {
+	if err := writeTestBackupToken("test_backup", originalData); err != nil {
 		t.Fatalf("Failed to write original token: %v", err)
 	}
 
 	// 更新 token
 	newAccessToken := "new-access-token-12345"
 	newExpiresAt := "2025-12-09T18:00:00Z"
-	err = writeBackupTokenToPath(tokenPath, newAccessToken, newExpiresAt)
-	if err != nil {
+	if err := WriteBackupToken("test_backup", newAccessToken, newExpiresAt); err != nil {
 		t.Fatalf("Failed to write backup token: %v", err)
 	}
 
 	// 讀取更新後的 token
-	updatedData, err := os.ReadFile(tokenPath)
+	updatedData, err := readTestBackupToken("test_backup")
 	if err != nil {
 		t.Fatalf("Failed to read updated token: %v", err)
 	}
@@ -310,3 +313,272 @@ func TestWriteBackupToken_PreservesAllFields(t *testing.T) {
 		t.Errorf("customField changed: got %v", updatedToken["customField"])
 	}
 }
+
+// orderedMember 依原始順序保存的 JSON 成員（測試用）
+type orderedMember struct {
+	key   string
+	value json.RawMessage
+}
+
+// readOrderedMembers 依原始順序解析 JSON 物件的成員
+func readOrderedMembers(data []byte) ([]orderedMember, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	if _, err := decoder.Token(); err != nil {
+		return nil, err
+	}
+
+	var members []orderedMember
+	for decoder.More() {
+		keyToken, err := decoder.Token()
+		if err != nil {
+			return nil, err
+		}
+		var value json.RawMessage
+		if err := decoder.Decode(&value); err != nil {
+			return nil, err
+		}
+		members = append(members, orderedMember{key: keyToken.(string), value: value})
+	}
+	return members, nil
+}
+
+// writeOrderedMembers 依成員順序輸出 JSON 物件
+func writeOrderedMembers(members []orderedMember) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("{\n")
+	for i, m := range members {
+		key, _ := json.Marshal(m.key)
+		fmt.Fprintf(&buf, "  %s: %s", key, m.value)
+		if i < len(members)-1 {
+			buf.WriteString(",")
+		}
+		buf.WriteString("\n")
+	}
+	buf.WriteString("}")
+	return buf.Bytes()
+}
+
+// compactJSON 壓縮 JSON 以便比較值
+func compactJSON(raw json.RawMessage) string {
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, raw); err != nil {
+		return string(raw)
+	}
+	return buf.String()
+}
+
+// **Feature: token-refresh, Property 2: Lossless Token Rewrite**
+// *For any* real Social or IdC token fixture extended with unknown fields at random positions,
+// rewriting accessToken and expiresAt SHALL keep every other field, its value and the original key order.
+// **Validates: Requirements 3.2**
+func TestProperty_TokenRewritePreservesFieldsAndOrder(t *testing.T) {
+	fixtures := []string{
+		"testdata/kiro-auth-token-social.json",
+		"testdata/kiro-auth-token-idc.json",
+	}
+
+	for _, fixture := range fixtures {
+		t.Run(filepath.Base(fixture), func(t *testing.T) {
+			fixtureData, err := os.ReadFile(fixture)
+			if err != nil {
+				t.Fatalf("Failed to read fixture: %v", err)
+			}
+			fixtureMembers, err := readOrderedMembers(fixtureData)
+			if err != nil {
+				t.Fatalf("Failed to parse fixture: %v", err)
+			}
+
+			useTestBackupRoot(t)
+			backupName := "test_" + strings.TrimSuffix(filepath.Base(fixture), ".json")
+
+			f := func(seed int64) bool {
+				r := rand.New(rand.NewSource(seed))
+
+				// 在隨機位置插入未知欄位（模擬 Kiro 日後新增的欄位）
+				members := append([]orderedMember(nil), fixtureMembers...)
+				extraCount := r.Intn(4)
+				for i := 0; i < extraCount; i++ {
+					var value json.RawMessage
+					switch r.Intn(3) {
+					case 0:
+						value, _ = json.Marshal(generateRandomString(r, 16))
+					case 1:
+						value = json.RawMessage(fmt.Sprintf("%d", r.Intn(100000)))
+					default:
+						value = json.RawMessage(fmt.Sprintf(`{"nested":%q,"list":[1,2.50,true]}`, generateRandomString(r, 8)))
+					}
+					member := orderedMember{key: "futureField" + generateRandomString(r, 6), value: value}
+					pos := r.Intn(len(members) + 1)
+					members = append(members[:pos], append([]orderedMember{member}, members[pos:]...)...)
+				}
+
+				if err := writeTestBackupToken(backupName, writeOrderedMembers(members)); err != nil {
+					t.Logf("Failed to write token: %v", err)
+					return false
+				}
+
+				newAccessToken := generateRandomString(r, r.Intn(100)+10)
+				newExpiresAt := "2025-12-09T15:30:00.000Z"
+				if err := WriteBackupToken(backupName, newAccessToken, newExpiresAt); err != nil {
+					t.Logf("Failed to rewrite token: %v", err)
+					return false
+				}
+
+				updatedData, err := readTestBackupToken(backupName)
+				if err != nil {
+					t.Logf("Failed to read rewritten token: %v", err)
+					return false
+				}
+				updatedMembers, err := readOrderedMembers(updatedData)
+				if err != nil {
+					t.Logf("Failed to parse rewritten token: %v", err)
+					return false
+				}
+
+				// 欄位數量與順序必須一致
+				if len(updatedMembers) != len(members) {
+					t.Logf("Member count changed: got %d, expected %d", len(updatedMembers), len(members))
+					return false
+				}
+				for i, original := range members {
+					updated := updatedMembers[i]
+					if updated.key != original.key {
+						t.Logf("Key order changed at %d: got %q, expected %q", i, updated.key, original.key)
+						return false
+					}
+
+					switch original.key {
+					case "accessToken":
+						if compactJSON(updated.value) != fmt.Sprintf("%q", newAccessToken) {
+							t.Logf("accessToken not updated: %s", updated.value)
+							return false
+						}
+					case "expiresAt":
+						if compactJSON(updated.value) != fmt.Sprintf("%q", newExpiresAt) {
+							t.Logf("expiresAt not updated: %s", updated.value)
+							return false
+						}
+					default:
+						if compactJSON(updated.value) != compactJSON(original.value) {
+							t.Logf("Field %q changed: original=%s, updated=%s", original.key, original.value, updated.value)
+							return false
+						}
+					}
+				}
+
+				// 改寫後 ReadBackupToken 仍能解析出 IdC 關聯所需的欄位
+				var original awssso.KiroAuthToken
+				if json.Unmarshal(fixtureData, &original) != nil {
+					t.Logf("Failed to unmarshal fixture")
+					return false
+				}
+				updated, err := ReadBackupToken(backupName)
+				if err != nil {
+					t.Logf("ReadBackupToken failed: %v", err)
+					return false
+				}
+				if updated.ClientIdHash != original.ClientIdHash || updated.Region != original.Region ||
+					updated.StartURL != original.StartURL || updated.TokenType != original.TokenType ||
+					updated.RefreshToken != original.RefreshToken || updated.ProfileArn != original.ProfileArn {
+					t.Logf("KiroAuthToken fields lost after rewrite: %+v", updated)
+					return false
+				}
+
+				return true
+			}
+
+			config := &quick.Config{
+				MaxCount: 100,
+			}
+
+			if err := quick.Check(f, config); err != nil {
+				t.Errorf("Property test failed: %v", err)
+			}
+		})
+	}
+}
+
+// TestTokenUpdate_RotatedRefreshTokenPersisted 測試輪替後的 refreshToken 寫回且不影響 IdC 關聯欄位
+func TestTokenUpdate_RotatedRefreshTokenPersisted(t *testing.T) {
+	data, err := os.ReadFile("testdata/kiro-auth-token-idc.json")
+	if err != nil {
+		t.Fatalf("Failed to read fixture: %v", err)
+	}
+
+	update := TokenUpdate{
+		AccessToken:  "new-access-token",
+		ExpiresAt:    "2025-12-09T18:00:00.000Z",
+		RefreshToken: "rotated-refresh-token",
+	}
+	updatedData, err := awssso.UpdateTokenJSON(data, update.Fields()...)
+	if err != nil {
+		t.Fatalf("UpdateTokenJSON failed: %v", err)
+	}
+
+	var original, updated awssso.KiroAuthToken
+	if err := json.Unmarshal(data, &original); err != nil {
+		t.Fatalf("Failed to unmarshal fixture: %v", err)
+	}
+	if err := json.Unmarshal(updatedData, &updated); err != nil {
+		t.Fatalf("Failed to unmarshal updated token: %v", err)
+	}
+
+	if updated.RefreshToken != "rotated-refresh-token" {
+		t.Errorf("refreshToken not rotated: got %q", updated.RefreshToken)
+	}
+	if updated.ClientIdHash != original.ClientIdHash {
+		t.Errorf("clientIdHash changed: got %q, expected %q", updated.ClientIdHash, original.ClientIdHash)
+	}
+	// IdC 回應不含 profileArn，空值不應寫入
+	if _, exists := updatedMembersByKey(t, updatedData)["profileArn"]; exists {
+		t.Error("empty profileArn should not be written")
+	}
+}
+
+// updatedMembersByKey 將 JSON 物件轉為 key 集合（測試用）
+func updatedMembersByKey(t *testing.T, data []byte) map[string]json.RawMessage {
+	members, err := readOrderedMembers(data)
+	if err != nil {
+		t.Fatalf("Failed to parse JSON: %v", err)
+	}
+	result := make(map[string]json.RawMessage, len(members))
+	for _, m := range members {
+		result[m.key] = m.value
+	}
+	return result
+}
+
+// TestDeleteAndRenameBackup_RemoveLocks 測試刪除與重新命名備份時清除舊路徑的鎖定檔
+func TestDeleteAndRenameBackup_RemoveLocks(t *testing.T) {
+	useTestBackupRoot(t)
+	lockDir := filepath.Join(os.Getenv(datadir.EnvVar), "locks")
+	countLocks := func() int {
+		entries, _ := os.ReadDir(lockDir)
+		return len(entries)
+	}
+	before := countLocks()
+
+	if err := writeTestBackupToken("old", []byte(`{"accessToken":"a"}`)); err != nil {
+		t.Fatalf("writeTestBackupToken failed: %v", err)
+	}
+	if countLocks() == before {
+		t.Fatal("writing a backup file should create a lock file")
+	}
+
+	if err := RenameBackup("old", "new"); err != nil {
+		t.Fatalf("RenameBackup failed: %v", err)
+	}
+	if got := countLocks(); got != before {
+		t.Errorf("lock files after rename = %d, want %d", got, before)
+	}
+
+	if err := WriteBackupToken("new", "b", "2099-01-01T00:00:00Z"); err != nil {
+		t.Fatalf("WriteBackupToken failed: %v", err)
+	}
+	if err := DeleteBackup("new"); err != nil {
+		t.Fatalf("DeleteBackup failed: %v", err)
+	}
+	if got := countLocks(); got != before {
+		t.Errorf("lock files after delete = %d, want %d", got, before)
+	}
+}
@@ -0,0 +1,498 @@
+package backup
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"kiro-manager/awssso"
+	"kiro-manager/internal/atomicfile"
+)
+
+const (
+	// EncryptionConfigFile 備份根目錄下的加密設定檔（記錄金鑰來源與驗證資料）
+	EncryptionConfigFile = "encryption.json"
+	// PassphraseEnvVar 無 OS keyring 時使用的密碼環境變數
+	PassphraseEnvVar = "KIRO_MANAGER_BACKUP_PASSPHRASE"
+
+	encryptedFormatVersion = 1
+	encryptionAlgorithm    = "AES-256-GCM"
+	encryptionKeySize      = 32
+	pbkdf2Iterations       = 600000
+
+	keySourceKeyring    = "keyring"
+	keySourcePassphrase = "passphrase"
+
+	// keyCheckPlaintext 用於驗證金鑰是否正確的固定明文
+	keyCheckPlaintext = "kiro-manager-backup-key-check"
+)
+
+var (
+	ErrEncryptionKeyUnavailable = errors.New("backup encryption key unavailable: no OS keyring and no passphrase set")
+	ErrWrongEncryptionKey       = errors.New("backup encryption key does not match existing backups")
+	ErrDecryptFailed            = errors.New("failed to decrypt backup file")
+)
+
+// encryptedFile 加密檔案的 JSON 外殼
+// 保留 .json 副檔名與 JSON 格式，讓 ListBackups 等以檔名判斷的邏輯不受影響
+type encryptedFile struct {
+	Encrypted  int    `json:"kiroManagerEncrypted"`
+	Algorithm  string `json:"algorithm"`
+	Nonce      string `json:"nonce"`
+	Ciphertext string `json:"ciphertext"`
+}
+
+// encryptionConfig 記錄在備份根目錄的加密設定
+type encryptionConfig struct {
+	KeySource         string `json:"keySource"`
+	Salt              string `json:"salt,omitempty"`
+	Iterations        int    `json:"iterations,omitempty"`
+	KeyCheck          string `json:"keyCheck"`
+	PlaintextMigrated bool   `json:"plaintextMigrated"`
+}
+
+var (
+	keyMutex   sync.Mutex
+	cachedKey  []byte
+	passphrase string
+)
+
+// SetPassphrase 設定備份加密密碼（OS keyring 不可用時的後備方案）
+// 會清除已快取的金鑰，下次讀寫時重新推導
+func SetPassphrase(p string) {
+	keyMutex.Lock()
+	defer keyMutex.Unlock()
+	passphrase = p
+	cachedKey = nil
+}
+
+// getPassphrase 取得密碼（優先使用 SetPassphrase 設定的值，其次為環境變數）
+func getPassphrase() string {
+	if passphrase != "" {
+		return passphrase
+	}
+	return os.Getenv(PassphraseEnvVar)
+}
+
+// IsEncryptedData 檢查資料是否為加密外殼格式
+func IsEncryptedData(data []byte) bool {
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) == 0 || trimmed[0] != '{' {
+		return false
+	}
+	var probe struct {
+		Encrypted int `json:"kiroManagerEncrypted"`
+	}
+	if err := json.Unmarshal(trimmed, &probe); err != nil {
+		return false
+	}
+	return probe.Encrypted > 0
+}
+
+// encryptWithKey 使用 AES-256-GCM 加密資料並包裝為 JSON 外殼
+func encryptWithKey(key, plaintext []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	sealed := gcm.Seal(nil, nonce, plaintext, nil)
+	envelope := encryptedFile{
+		Encrypted:  encryptedFormatVersion,
+		Algorithm:  encryptionAlgorithm,
+		Nonce:      base64.StdEncoding.EncodeToString(nonce),
+		Ciphertext: base64.StdEncoding.EncodeToString(sealed),
+	}
+	return json.MarshalIndent(envelope, "", "  ")
+}
+
+// decryptWithKey 解開 JSON 外殼並解密
+func decryptWithKey(key, data []byte) ([]byte, error) {
+	var envelope encryptedFile
+	if err := json.Unmarshal(data, &envelope); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDecryptFailed, err)
+	}
+	if envelope.Encrypted != encryptedFormatVersion || envelope.Algorithm != encryptionAlgorithm {
+		return nil, fmt.Errorf("%w: unsupported format %d/%s", ErrDecryptFailed, envelope.Encrypted, envelope.Algorithm)
+	}
+
+	nonce, err := base64.StdEncoding.DecodeString(envelope.Nonce)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid nonce", ErrDecryptFailed)
+	}
+	sealed, err := base64.StdEncoding.DecodeString(envelope.Ciphertext)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid ciphertext", ErrDecryptFailed)
+	}
+
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(nonce) != gcm.NonceSize() {
+		return nil, fmt.Errorf("%w: invalid nonce size", ErrDecryptFailed)
+	}
+
+	plaintext, err := gcm.Open(nil, nonce, sealed, nil)
+	if err != nil {
+		return nil, ErrDecryptFailed
+	}
+	return plaintext, nil
+}
+
+// newGCM 建立 AES-GCM 加密器
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// deriveKeyFromPassphrase 使用 PBKDF2-SHA256 從密碼推導金鑰
+func deriveKeyFromPassphrase(p string, salt []byte, iterations int) ([]byte, error) {
+	return pbkdf2.Key(sha256.New, p, salt, iterations, encryptionKeySize)
+}
+
+// getEncryptionKey 取得備份加密金鑰
+// 優先順序：已快取 → encryption.json 指定的來源 → OS keyring → 密碼
+// 首次使用時會產生金鑰並寫入 encryption.json
+func getEncryptionKey() ([]byte, error) {
+	keyMutex.Lock()
+	defer keyMutex.Unlock()
+
+	if cachedKey != nil {
+		return cachedKey, nil
+	}
+
+	rootPath, err := ensureBackupRoot()
+	if err != nil {
+		return nil, err
+	}
+
+	config, err := readEncryptionConfig(rootPath)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	var key []byte
+	if config != nil {
+		key, err = loadExistingKey(config)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		key, config, err = createNewKey()
+		if err != nil {
+			return nil, err
+		}
+		if err := writeEncryptionConfig(rootPath, config); err != nil {
+			return nil, err
+		}
+	}
+
+	cachedKey = key
+	return key, nil
+}
+
+// loadExistingKey 依照 encryption.json 記錄的來源載入金鑰並驗證
+func loadExistingKey(config *encryptionConfig) ([]byte, error) {
+	var key []byte
+	switch config.KeySource {
+	case keySourceKeyring:
+		encoded, err := keyringGet()
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrEncryptionKeyUnavailable, err)
+		}
+		key, err = base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+		if err != nil {
+			return nil, fmt.Errorf("invalid key in keyring: %w", err)
+		}
+	case keySourcePassphrase:
+		p := getPassphrase()
+		if p == "" {
+			return nil, ErrEncryptionKeyUnavailable
+		}
+		salt, err := base64.StdEncoding.DecodeString(config.Salt)
+		if err != nil {
+			return nil, fmt.Errorf("invalid salt in %s: %w", EncryptionConfigFile, err)
+		}
+		key, err = deriveKeyFromPassphrase(p, salt, config.Iterations)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unknown key source in %s: %q", EncryptionConfigFile, config.KeySource)
+	}
+
+	if _, err := decryptWithKey(key, []byte(config.KeyCheck)); err != nil {
+		return nil, ErrWrongEncryptionKey
+	}
+	return key, nil
+}
+
+// createNewKey 為尚無 encryption.json 的備份根目錄建立金鑰設定
+// keyring 中已有金鑰時沿用（keyring 只有一個項目，覆寫會讓其他備份根目錄無法解密）；
+// 否則產生隨機金鑰存入 keyring，keyring 不可用時從密碼推導
+func createNewKey() ([]byte, *encryptionConfig, error) {
+	config := &encryptionConfig{}
+
+	key, err := existingKeyringKey()
+	if err == nil {
+		config.KeySource = keySourceKeyring
+	} else if key, err = randomKeyringKey(); err == nil {
+		config.KeySource = keySourceKeyring
+	} else {
+		p := getPassphrase()
+		if p == "" {
+			return nil, nil, ErrEncryptionKeyUnavailable
+		}
+		salt := make([]byte, 16)
+		if _, err := rand.Read(salt); err != nil {
+			return nil, nil, err
+		}
+		key, err = deriveKeyFromPassphrase(p, salt, pbkdf2Iterations)
+		if err != nil {
+			return nil, nil, err
+		}
+		config.KeySource = keySourcePassphrase
+		config.Salt = base64.StdEncoding.EncodeToString(salt)
+		config.Iterations = pbkdf2Iterations
+	}
+
+	check, err := encryptWithKey(key, []byte(keyCheckPlaintext))
+	if err != nil {
+		return nil, nil, err
+	}
+	config.KeyCheck = string(check)
+
+	return key, config, nil
+}
+
+// existingKeyringKey 讀取 keyring 中既有的金鑰
+func existingKeyringKey() ([]byte, error) {
+	encoded, err := keyringGet()
+	if err != nil {
+		return nil, err
+	}
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil {
+		return nil, fmt.Errorf("invalid key in keyring: %w", err)
+	}
+	if len(key) != encryptionKeySize {
+		return nil, fmt.Errorf("invalid key size in keyring: %d", len(key))
+	}
+	return key, nil
+}
+
+// randomKeyringKey 產生隨機金鑰並存入 keyring
+func randomKeyringKey() ([]byte, error) {
+	key := make([]byte, encryptionKeySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	if err := keyringSet(base64.StdEncoding.EncodeToString(key)); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// readEncryptionConfig 讀取備份根目錄的 encryption.json
+func readEncryptionConfig(rootPath string) (*encryptionConfig, error) {
+	data, err := os.ReadFile(filepath.Join(rootPath, EncryptionConfigFile))
+	if err != nil {
+		return nil, err
+	}
+	var config encryptionConfig
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", EncryptionConfigFile, err)
+	}
+	return &config, nil
+}
+
+// writeEncryptionConfig 寫入備份根目錄的 encryption.json
+func writeEncryptionConfig(rootPath string, config *encryptionConfig) error {
+	data, err := json.MarshalIndent(config, "", "  ")
+	if err != nil {
+		return err
+	}
+	return atomicfile.WriteFile(filepath.Join(rootPath, EncryptionConfigFile), data, 0600)
+}
+
+// readSecretFile 讀取備份中的敏感檔案，若為加密格式則透明解密
+// 舊版明文檔案直接返回原始內容（由 MigratePlaintextBackups 負責遷移）
+func readSecretFile(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return decryptSecretData(data)
+}
+
+// writeSecretFile 加密後以原子方式寫入備份中的敏感檔案（權限 0600）
+func writeSecretFile(path string, plaintext []byte) error {
+	data, err := encryptSecretData(plaintext)
+	if err != nil {
+		return err
+	}
+	return atomicfile.WriteFile(path, data, 0600)
+}
+
+// decryptSecretData 解密敏感檔案內容，明文內容原樣返回
+func decryptSecretData(data []byte) ([]byte, error) {
+	if !IsEncryptedData(data) {
+		return data, nil
+	}
+
+	key, err := getEncryptionKey()
+	if err != nil {
+		return nil, err
+	}
+	return decryptWithKey(key, data)
+}
+
+// encryptSecretData 以備份加密金鑰加密敏感檔案內容
+func encryptSecretData(plaintext []byte) ([]byte, error) {
+	key, err := getEncryptionKey()
+	if err != nil {
+		return nil, err
+	}
+	return encryptWithKey(key, plaintext)
+}
+
+// secretFileNames 取得備份中包含憑證、需要加密的檔案（白名單）
+// 只有 token 與 token 的 clientIdHash 對應的 IdC client 註冊檔，其他檔案（含日後新增的）一律維持明文
+func secretFileNames(backupPath string, key []byte) map[string]bool {
+	names := map[string]bool{KiroAuthTokenFile: true}
+
+	data, err := os.ReadFile(filepath.Join(backupPath, KiroAuthTokenFile))
+	if err != nil {
+		return names
+	}
+	if IsEncryptedData(data) {
+		if data, err = decryptWithKey(key, data); err != nil {
+			return names
+		}
+	}
+
+	var token awssso.KiroAuthToken
+	if json.Unmarshal(data, &token) == nil && token.ClientIdHash != "" {
+		if name, err := clientIdHashFileName(token.ClientIdHash); err == nil {
+			names[name] = true
+		}
+	}
+	return names
+}
+
+// MigratePlaintextBackups 將舊版明文備份中的憑證檔案加密（一次性遷移）
+// 遷移完成後會在 encryption.json 中記錄，之後呼叫直接返回
+// 回傳本次加密的檔案數量
+func MigratePlaintextBackups() (int, error) {
+	rootPath, err := GetBackupRootPath()
+	if err != nil {
+		return 0, err
+	}
+	if _, err := os.Stat(rootPath); os.IsNotExist(err) {
+		return 0, nil
+	}
+
+	if config, err := readEncryptionConfig(rootPath); err == nil && config.PlaintextMigrated {
+		return 0, nil
+	}
+
+	key, err := getEncryptionKey()
+	if err != nil {
+		return 0, err
+	}
+
+	entries, err := os.ReadDir(rootPath)
+	if err != nil {
+		return 0, err
+	}
+
+	migrated := 0
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		n, err := migrateBackupDir(filepath.Join(rootPath, entry.Name()), key)
+		migrated += n
+		if err != nil {
+			return migrated, fmt.Errorf("failed to migrate backup %s: %w", entry.Name(), err)
+		}
+	}
+
+	config, err := readEncryptionConfig(rootPath)
+	if err != nil {
+		return migrated, err
+	}
+	config.PlaintextMigrated = true
+	if err := writeEncryptionConfig(rootPath, config); err != nil {
+		return migrated, err
+	}
+
+	return migrated, nil
+}
+
+// migrateBackupDir 加密單一備份目錄中的明文憑證檔案，並收緊權限
+func migrateBackupDir(backupPath string, key []byte) (int, error) {
+	if err := os.Chmod(backupPath, 0700); err != nil {
+		return 0, err
+	}
+
+	entries, err := os.ReadDir(backupPath)
+	if err != nil {
+		return 0, err
+	}
+
+	secrets := secretFileNames(backupPath, key)
+	migrated := 0
+	for _, entry := range entries {
+		if entry.IsDir() || !secrets[entry.Name()] {
+			continue
+		}
+
+		filePath := filepath.Join(backupPath, entry.Name())
+		data, err := os.ReadFile(filePath)
+		if err != nil {
+			return migrated, err
+		}
+		if IsEncryptedData(data) {
+			continue
+		}
+
+		encrypted, err := encryptWithKey(key, data)
+		if err != nil {
+			return migrated, err
+		}
+		if err := atomicfile.WriteFile(filePath, encrypted, 0600); err != nil {
+			return migrated, err
+		}
+		migrated++
+	}
+
+	// 加密後檔案內容改變，更新清單中的校驗值
+	if migrated > 0 {
+		if err := refreshManifest(backupPath); err != nil {
+			return migrated, err
+		}
+	}
+
+	return migrated, nil
+}
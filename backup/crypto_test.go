@@ -0,0 +1,209 @@
+package backup
+
+import (
+	"bytes"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"testing/quick"
+
+	"kiro-manager/internal/datadir"
+)
+
+// generateTestKey 生成測試用的 AES-256 金鑰
+func generateTestKey(r *rand.Rand) []byte {
+	key := make([]byte, encryptionKeySize)
+	r.Read(key)
+	return key
+}
+
+// **Feature: backup-encryption, Property 1: Encryption Round Trip**
+// *For any* plaintext and key, decryptWithKey(encryptWithKey(plaintext)) SHALL equal the plaintext,
+// and the encrypted output SHALL be recognised by IsEncryptedData without containing the plaintext.
+func TestProperty_EncryptionRoundTrip(t *testing.T) {
+	f := func(seed int64) bool {
+		r := rand.New(rand.NewSource(seed))
+		key := generateTestKey(r)
+		plaintext := []byte(`{"refreshToken":"` + generateRandomString(r, r.Intn(100)+10) + `"}`)
+
+		encrypted, err := encryptWithKey(key, plaintext)
+		if err != nil {
+			t.Logf("encryptWithKey failed: %v", err)
+			return false
+		}
+
+		if !IsEncryptedData(encrypted) {
+			t.Logf("encrypted output not recognised as encrypted")
+			return false
+		}
+		if bytes.Contains(encrypted, plaintext) {
+			t.Logf("encrypted output contains plaintext")
+			return false
+		}
+
+		decrypted, err := decryptWithKey(key, encrypted)
+		if err != nil {
+			t.Logf("decryptWithKey failed: %v", err)
+			return false
+		}
+		return bytes.Equal(decrypted, plaintext)
+	}
+
+	config := &quick.Config{
+		MaxCount: 100,
+	}
+
+	if err := quick.Check(f, config); err != nil {
+		t.Errorf("Property test failed: %v", err)
+	}
+}
+
+// TestDecryptWithKey_WrongKey 測試錯誤金鑰無法解密
+func TestDecryptWithKey_WrongKey(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	key := generateTestKey(r)
+	otherKey := generateTestKey(r)
+
+	encrypted, err := encryptWithKey(key, []byte(`{"accessToken":"secret"}`))
+	if err != nil {
+		t.Fatalf("encryptWithKey failed: %v", err)
+	}
+
+	if _, err := decryptWithKey(otherKey, encrypted); err == nil {
+		t.Error("Expected error when decrypting with wrong key")
+	}
+}
+
+// TestIsEncryptedData_Plaintext 測試明文 token 不會被誤判為加密格式
+func TestIsEncryptedData_Plaintext(t *testing.T) {
+	testCases := []struct {
+		name string
+		data string
+	}{
+		{"Token JSON", `{"accessToken":"abc","refreshToken":"def"}`},
+		{"Empty", ""},
+		{"Not JSON", "hello"},
+		{"Zero marker", `{"kiroManagerEncrypted":0}`},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if IsEncryptedData([]byte(tc.data)) {
+				t.Errorf("IsEncryptedData(%q) = true, want false", tc.data)
+			}
+		})
+	}
+}
+
+// TestMigrateBackupDir_EncryptsCredentials 測試遷移只加密憑證檔案並收緊權限
+func TestMigrateBackupDir_EncryptsCredentials(t *testing.T) {
+	backupPath := t.TempDir()
+	key := generateTestKey(rand.New(rand.NewSource(2)))
+
+	files := map[string]string{
+		KiroAuthTokenFile:  `{"accessToken":"a","refreshToken":"r","clientIdHash":"abc123"}`,
+		"abc123.json":      `{"clientId":"id","clientSecret":"secret"}`,
+		MachineIDFileName:  `{"machineId":"m","backupTime":"2025-12-08T12:00:00Z"}`,
+		UsageCacheFileName: `{"subscriptionTitle":"KIRO FREE"}`,
+		// 不在白名單中的檔案（例如日後新增的狀態檔）不可被加密
+		"future-state.json": `{"level":1}`,
+	}
+	secrets := map[string]bool{KiroAuthTokenFile: true, "abc123.json": true}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(backupPath, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+
+	migrated, err := migrateBackupDir(backupPath, key)
+	if err != nil {
+		t.Fatalf("migrateBackupDir failed: %v", err)
+	}
+	if migrated != 2 {
+		t.Errorf("migrated = %d, want 2", migrated)
+	}
+
+	for name, content := range files {
+		data, err := os.ReadFile(filepath.Join(backupPath, name))
+		if err != nil {
+			t.Fatalf("Failed to read %s: %v", name, err)
+		}
+
+		if !secrets[name] {
+			if string(data) != content {
+				t.Errorf("%s should be left untouched", name)
+			}
+			continue
+		}
+
+		if !IsEncryptedData(data) {
+			t.Errorf("%s was not encrypted", name)
+			continue
+		}
+		decrypted, err := decryptWithKey(key, data)
+		if err != nil || string(decrypted) != content {
+			t.Errorf("%s did not round-trip: %v", name, err)
+		}
+		if runtime.GOOS != "windows" {
+			info, _ := os.Stat(filepath.Join(backupPath, name))
+			if info.Mode().Perm() != 0600 {
+				t.Errorf("%s permissions = %v, want 0600", name, info.Mode().Perm())
+			}
+		}
+	}
+
+	// 再次遷移不應重複加密
+	migrated, err = migrateBackupDir(backupPath, key)
+	if err != nil {
+		t.Fatalf("second migrateBackupDir failed: %v", err)
+	}
+	if migrated != 0 {
+		t.Errorf("second migration migrated %d files, want 0", migrated)
+	}
+}
+
+// TestGetEncryptionKey_ReusesKeyringKeyAcrossRoots 測試新的備份根目錄沿用 keyring 中既有的金鑰，
+// 不會覆寫 keyring 而讓先前根目錄的備份無法解密
+func TestGetEncryptionKey_ReusesKeyringKeyAcrossRoots(t *testing.T) {
+	var stored string
+	keyringGet = func() (string, error) {
+		if stored == "" {
+			return "", ErrKeyringUnavailable
+		}
+		return stored, nil
+	}
+	keyringSet = func(secret string) error {
+		stored = secret
+		return nil
+	}
+	t.Cleanup(func() {
+		keyringGet, keyringSet = osKeyringGet, osKeyringSet
+		SetPassphrase("")
+	})
+
+	first := t.TempDir()
+	t.Setenv(datadir.EnvVar, first)
+	SetPassphrase("")
+	if err := writeTestBackupToken("first", []byte(`{"accessToken":"a"}`)); err != nil {
+		t.Fatalf("writeTestBackupToken failed: %v", err)
+	}
+
+	// 第二個資料目錄（例如環境變數或可攜模式）首次使用
+	t.Setenv(datadir.EnvVar, t.TempDir())
+	SetPassphrase("")
+	if _, err := getEncryptionKey(); err != nil {
+		t.Fatalf("getEncryptionKey for second root failed: %v", err)
+	}
+
+	t.Setenv(datadir.EnvVar, first)
+	SetPassphrase("")
+	token, err := ReadBackupToken("first")
+	if err != nil {
+		t.Fatalf("ReadBackupToken from first root failed: %v", err)
+	}
+	if token.AccessToken != "a" {
+		t.Errorf("AccessToken = %q, want a", token.AccessToken)
+	}
+}
@@ -0,0 +1,241 @@
+package backup
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"kiro-manager/awssso"
+)
+
+// MatchKind 判定備份與目前登入帳號相同的依據
+type MatchKind string
+
+const (
+	// MatchRefreshToken refresh token 指紋相同（同一登入工作階段）
+	MatchRefreshToken MatchKind = "refreshToken"
+	// MatchClientRegistration IdC client 註冊（clientIdHash）相同且唯一
+	MatchClientRegistration MatchKind = "clientRegistration"
+	// MatchProfileArn profile ARN 相同且唯一
+	MatchProfileArn MatchKind = "profileArn"
+	// MatchMachineID 僅 Machine ID 相同（最後的備援）
+	MatchMachineID MatchKind = "machineId"
+)
+
+// AccountIdentity 從 token 取得的穩定帳號識別資訊
+type AccountIdentity struct {
+	RefreshTokenFingerprint string // refresh token 的 SHA-256 指紋（不保存原文）
+	ClientIdHash            string
+	StartURL                string
+	ProfileArn              string
+	Provider                string
+}
+
+// AccountMatch 帳號解析結果
+type AccountMatch struct {
+	Name      string    `json:"name"`
+	MatchedBy MatchKind `json:"matchedBy"`
+}
+
+// accountCandidate 參與比對的備份
+type accountCandidate struct {
+	Name       string
+	Identity   AccountIdentity
+	MachineID  string
+	BackupTime time.Time
+}
+
+// TokenSummary 備份 token 中列出備份所需的非機密資訊
+type TokenSummary struct {
+	Identity  AccountIdentity
+	ExpiresAt string
+}
+
+// tokenSummaryEntry 快取的 token 摘要，token 檔案的修改時間或大小改變即失效
+type tokenSummaryEntry struct {
+	modTime time.Time
+	size    int64
+	summary TokenSummary
+}
+
+var (
+	// tokenSummaryCache 以 token 檔案路徑為鍵，避免每次列出備份都解密所有 token
+	tokenSummaryCache = make(map[string]tokenSummaryEntry)
+	tokenSummaryMutex sync.Mutex
+)
+
+// RefreshTokenFingerprint 計算 refresh token 的指紋，空字串回傳空字串
+func RefreshTokenFingerprint(refreshToken string) string {
+	if refreshToken == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(refreshToken))
+	return hex.EncodeToString(sum[:16])
+}
+
+// IdentityFromToken 取得 token 的帳號識別資訊
+func IdentityFromToken(token *awssso.KiroAuthToken) AccountIdentity {
+	if token == nil {
+		return AccountIdentity{}
+	}
+	return AccountIdentity{
+		RefreshTokenFingerprint: RefreshTokenFingerprint(token.RefreshToken),
+		ClientIdHash:            token.ClientIdHash,
+		StartURL:                token.StartURL,
+		ProfileArn:              token.ProfileArn,
+		Provider:                token.Provider,
+	}
+}
+
+// conflicts 判斷兩個識別資訊是否明確屬於不同帳號（雙方皆有值且不同）
+func (id AccountIdentity) conflicts(other AccountIdentity) bool {
+	differs := func(a, b string) bool { return a != "" && b != "" && a != b }
+	return differs(id.Provider, other.Provider) ||
+		differs(id.ClientIdHash, other.ClientIdHash) ||
+		differs(id.StartURL, other.StartURL) ||
+		differs(id.ProfileArn, other.ProfileArn)
+}
+
+// ResolveCurrentAccount 找出與 SSO cache 中目前登入帳號相同的備份
+// machineID 為目前使用中的 Machine ID，僅在其他識別資訊都無法判定時使用
+// 找不到或無法唯一判定時回傳 nil
+func ResolveCurrentAccount(machineID string) (*AccountMatch, error) {
+	live, err := awssso.ReadKiroAuthToken()
+	if err != nil && !errors.Is(err, awssso.ErrTokenNotFound) {
+		return nil, err
+	}
+	return ResolveAccount(live, machineID)
+}
+
+// ResolveAccount 找出與指定 token 屬於同一帳號的備份（不含原始備份）
+func ResolveAccount(token *awssso.KiroAuthToken, machineID string) (*AccountMatch, error) {
+	backups, err := ListBackups()
+	if err != nil {
+		return nil, err
+	}
+
+	var candidates []accountCandidate
+	for _, b := range backups {
+		if b.Name == OriginalBackupName {
+			continue
+		}
+
+		candidate := accountCandidate{Name: b.Name, BackupTime: b.BackupTime}
+		if b.HasToken {
+			if summary, err := ReadBackupTokenSummary(b.Name); err == nil {
+				candidate.Identity = summary.Identity
+			}
+		}
+		if b.HasMachineID {
+			if mid, err := ReadBackupMachineID(b.Name); err == nil {
+				candidate.MachineID = mid.MachineID
+			}
+		}
+		candidates = append(candidates, candidate)
+	}
+
+	return resolveAccount(IdentityFromToken(token), machineID, candidates), nil
+}
+
+// ReadBackupTokenSummary 取得備份 token 的識別資訊與到期時間
+// token 檔案未變更時直接使用快取，不重新解密
+func ReadBackupTokenSummary(name string) (TokenSummary, error) {
+	if err := ValidateBackupName(name); err != nil {
+		return TokenSummary{}, err
+	}
+	backupPath, err := GetBackupPath(name)
+	if err != nil {
+		return TokenSummary{}, err
+	}
+	tokenPath := filepath.Join(backupPath, KiroAuthTokenFile)
+	info, err := os.Stat(tokenPath)
+	if err != nil {
+		return TokenSummary{}, err
+	}
+
+	tokenSummaryMutex.Lock()
+	entry, ok := tokenSummaryCache[tokenPath]
+	tokenSummaryMutex.Unlock()
+	if ok && entry.modTime.Equal(info.ModTime()) && entry.size == info.Size() {
+		return entry.summary, nil
+	}
+
+	token, err := ReadBackupToken(name)
+	if err != nil {
+		return TokenSummary{}, err
+	}
+	summary := TokenSummary{Identity: IdentityFromToken(token), ExpiresAt: token.ExpiresAt}
+
+	tokenSummaryMutex.Lock()
+	tokenSummaryCache[tokenPath] = tokenSummaryEntry{modTime: info.ModTime(), size: info.Size(), summary: summary}
+	tokenSummaryMutex.Unlock()
+	return summary, nil
+}
+
+// resolveAccount 依序以 refresh token 指紋、clientIdHash、profile ARN、Machine ID 比對
+// 識別資訊與目前帳號衝突的備份一律排除；除 refresh token 外，各依據需唯一符合才採用，
+// 多個備份同時符合時交由下一個依據判定，避免同機器上的多個備份都被視為目前帳號
+// profile ARN 可能由多個社群帳號共用，多個備份符合時不作為縮小範圍的依據
+func resolveAccount(live AccountIdentity, machineID string, candidates []accountCandidate) *AccountMatch {
+	var compatible []accountCandidate
+	for _, c := range candidates {
+		if !live.conflicts(c.Identity) {
+			compatible = append(compatible, c)
+		}
+	}
+
+	filter := func(set []accountCandidate, match func(accountCandidate) bool) []accountCandidate {
+		var out []accountCandidate
+		for _, c := range set {
+			if match(c) {
+				out = append(out, c)
+			}
+		}
+		return out
+	}
+
+	// 同一個 refresh token 必定是同一帳號；重複備份時取最新的一份
+	if live.RefreshTokenFingerprint != "" {
+		matched := filter(compatible, func(c accountCandidate) bool {
+			return c.Identity.RefreshTokenFingerprint == live.RefreshTokenFingerprint
+		})
+		if len(matched) > 0 {
+			sort.SliceStable(matched, func(i, j int) bool {
+				return matched[i].BackupTime.After(matched[j].BackupTime)
+			})
+			return &AccountMatch{Name: matched[0].Name, MatchedBy: MatchRefreshToken}
+		}
+	}
+
+	tiers := []struct {
+		kind   MatchKind
+		value  string
+		match  func(accountCandidate) string
+		narrow bool // 多個備份符合時是否縮小後續比對範圍
+	}{
+		{MatchClientRegistration, live.ClientIdHash, func(c accountCandidate) string { return c.Identity.ClientIdHash }, true},
+		{MatchProfileArn, live.ProfileArn, func(c accountCandidate) string { return c.Identity.ProfileArn }, false},
+		{MatchMachineID, machineID, func(c accountCandidate) string { return c.MachineID }, true},
+	}
+
+	remaining := compatible
+	for _, tier := range tiers {
+		if tier.value == "" {
+			continue
+		}
+		matched := filter(remaining, func(c accountCandidate) bool { return tier.match(c) == tier.value })
+		switch {
+		case len(matched) == 1:
+			return &AccountMatch{Name: matched[0].Name, MatchedBy: tier.kind}
+		case len(matched) > 1 && tier.narrow:
+			remaining = matched
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,230 @@
+package backup
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/quick"
+	"time"
+
+	"kiro-manager/awssso"
+)
+
+// TestResolveAccount 測試以穩定識別資訊比對目前登入帳號
+func TestResolveAccount(t *testing.T) {
+	now := time.Now()
+	social := func(refresh, arn string) AccountIdentity {
+		return AccountIdentity{RefreshTokenFingerprint: RefreshTokenFingerprint(refresh), ProfileArn: arn, Provider: "Github"}
+	}
+	idc := func(refresh, hash string) AccountIdentity {
+		return AccountIdentity{RefreshTokenFingerprint: RefreshTokenFingerprint(refresh), ClientIdHash: hash, StartURL: "https://corp.awsapps.com/start", Provider: "Enterprise"}
+	}
+
+	testCases := []struct {
+		name       string
+		live       AccountIdentity
+		machineID  string
+		candidates []accountCandidate
+		wantName   string
+		wantKind   MatchKind
+	}{
+		{
+			name:      "Same machine, different accounts resolved by refresh token",
+			live:      social("rt-b", "arn:shared"),
+			machineID: "m1",
+			candidates: []accountCandidate{
+				{Name: "a", Identity: social("rt-a", "arn:shared"), MachineID: "m1"},
+				{Name: "b", Identity: social("rt-b", "arn:shared"), MachineID: "m1"},
+			},
+			wantName: "b",
+			wantKind: MatchRefreshToken,
+		},
+		{
+			name:      "Duplicate backups of the same session pick the newest",
+			live:      social("rt-a", ""),
+			machineID: "m1",
+			candidates: []accountCandidate{
+				{Name: "old", Identity: social("rt-a", ""), BackupTime: now.Add(-time.Hour)},
+				{Name: "new", Identity: social("rt-a", ""), BackupTime: now},
+			},
+			wantName: "new",
+			wantKind: MatchRefreshToken,
+		},
+		{
+			name:      "Rotated refresh token falls back to unique client registration",
+			live:      idc("rt-rotated", "hash-2"),
+			machineID: "m1",
+			candidates: []accountCandidate{
+				{Name: "one", Identity: idc("rt-1", "hash-1"), MachineID: "m1"},
+				{Name: "two", Identity: idc("rt-2", "hash-2"), MachineID: "m1"},
+			},
+			wantName: "two",
+			wantKind: MatchClientRegistration,
+		},
+		{
+			name:      "Unique profile ARN",
+			live:      social("rt-rotated", "arn:b"),
+			machineID: "m1",
+			candidates: []accountCandidate{
+				{Name: "a", Identity: social("rt-a", "arn:a"), MachineID: "m1"},
+				{Name: "b", Identity: social("rt-b", "arn:b"), MachineID: "m1"},
+			},
+			wantName: "b",
+			wantKind: MatchProfileArn,
+		},
+		{
+			name:      "Shared profile ARN narrowed by machine ID",
+			live:      social("rt-rotated", "arn:shared"),
+			machineID: "m2",
+			candidates: []accountCandidate{
+				{Name: "a", Identity: social("rt-a", "arn:shared"), MachineID: "m1"},
+				{Name: "b", Identity: social("rt-b", "arn:shared"), MachineID: "m2"},
+			},
+			wantName: "b",
+			wantKind: MatchMachineID,
+		},
+		{
+			name:      "Shared profile ARN does not narrow the machine ID tier",
+			live:      social("rt-rotated", "arn:shared"),
+			machineID: "m1",
+			candidates: []accountCandidate{
+				{Name: "a", Identity: social("rt-a", "arn:shared"), MachineID: "m2"},
+				{Name: "b", Identity: social("rt-b", "arn:shared"), MachineID: "m3"},
+				{Name: "c", Identity: social("rt-c", ""), MachineID: "m1"},
+			},
+			wantName: "c",
+			wantKind: MatchMachineID,
+		},
+		{
+			name:      "Ambiguous on every tier resolves to nothing",
+			live:      social("rt-rotated", "arn:shared"),
+			machineID: "m1",
+			candidates: []accountCandidate{
+				{Name: "a", Identity: social("rt-a", "arn:shared"), MachineID: "m1"},
+				{Name: "b", Identity: social("rt-b", "arn:shared"), MachineID: "m1"},
+			},
+		},
+		{
+			name:      "Machine ID never matches a conflicting account",
+			live:      social("rt-rotated", "arn:a"),
+			machineID: "m1",
+			candidates: []accountCandidate{
+				{Name: "b", Identity: social("rt-b", "arn:b"), MachineID: "m1"},
+			},
+		},
+		{
+			name:      "No live token uses machine ID",
+			machineID: "m1",
+			candidates: []accountCandidate{
+				{Name: "a", Identity: social("rt-a", ""), MachineID: "m1"},
+				{Name: "b", Identity: social("rt-b", ""), MachineID: "m2"},
+			},
+			wantName: "a",
+			wantKind: MatchMachineID,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			match := resolveAccount(tc.live, tc.machineID, tc.candidates)
+			if tc.wantName == "" {
+				if match != nil {
+					t.Errorf("resolveAccount = %+v, want nil", match)
+				}
+				return
+			}
+			if match == nil {
+				t.Fatalf("resolveAccount = nil, want %s", tc.wantName)
+			}
+			if match.Name != tc.wantName || match.MatchedBy != tc.wantKind {
+				t.Errorf("resolveAccount = %+v, want {%s %s}", match, tc.wantName, tc.wantKind)
+			}
+		})
+	}
+}
+
+// **Feature: account-identity, Property 1: At Most One Current Account**
+// *For any* set of backups sharing one machine ID, the resolver SHALL never
+// select a backup whose identity conflicts with the live token.
+func TestProperty_ResolverNeverPicksConflictingAccount(t *testing.T) {
+	f := func(liveArn, otherArn uint8, sameRefresh bool) bool {
+		live := AccountIdentity{
+			RefreshTokenFingerprint: RefreshTokenFingerprint("live"),
+			ProfileArn:              string(rune('a' + liveArn%4)),
+		}
+		other := AccountIdentity{ProfileArn: string(rune('a' + otherArn%4))}
+		if sameRefresh {
+			other.RefreshTokenFingerprint = live.RefreshTokenFingerprint
+		}
+
+		match := resolveAccount(live, "m1", []accountCandidate{{Name: "other", Identity: other, MachineID: "m1"}})
+		if live.conflicts(other) {
+			return match == nil
+		}
+		return match != nil && match.Name == "other"
+	}
+
+	config := &quick.Config{
+		MaxCount: 100,
+	}
+
+	if err := quick.Check(f, config); err != nil {
+		t.Errorf("Property test failed: %v", err)
+	}
+}
+
+// TestIdentityFromToken 測試識別資訊不保存 refresh token 原文
+func TestIdentityFromToken(t *testing.T) {
+	token := &awssso.KiroAuthToken{RefreshToken: "secret-refresh", ClientIdHash: "abc", ProfileArn: "arn"}
+	id := IdentityFromToken(token)
+
+	if id.RefreshTokenFingerprint == "" || id.RefreshTokenFingerprint == token.RefreshToken {
+		t.Errorf("RefreshTokenFingerprint = %q, want a hash", id.RefreshTokenFingerprint)
+	}
+	if id.ClientIdHash != "abc" || id.ProfileArn != "arn" {
+		t.Errorf("IdentityFromToken = %+v", id)
+	}
+	if IdentityFromToken(nil) != (AccountIdentity{}) {
+		t.Error("IdentityFromToken(nil) should be empty")
+	}
+}
+
+// TestReadBackupTokenSummary_CachedUntilTokenChanges 測試 token 摘要快取在 token 檔案未變更時不重新解密
+func TestReadBackupTokenSummary_CachedUntilTokenChanges(t *testing.T) {
+	useTestBackupRoot(t)
+
+	data, _ := json.Marshal(map[string]string{"refreshToken": "rt-1", "profileArn": "arn:a", "expiresAt": "2099-01-01T00:00:00Z"})
+	if err := writeTestBackupToken("cached", data); err != nil {
+		t.Fatalf("writeTestBackupToken failed: %v", err)
+	}
+	summary, err := ReadBackupTokenSummary("cached")
+	if err != nil {
+		t.Fatalf("ReadBackupTokenSummary failed: %v", err)
+	}
+	if summary.Identity.RefreshTokenFingerprint != RefreshTokenFingerprint("rt-1") || summary.ExpiresAt != "2099-01-01T00:00:00Z" {
+		t.Fatalf("summary = %+v, want values from the token file", summary)
+	}
+
+	// 以假資料取代快取內容，未變更的檔案應直接回傳快取
+	backupPath, _ := GetBackupPath("cached")
+	tokenPath := filepath.Join(backupPath, KiroAuthTokenFile)
+	tokenSummaryMutex.Lock()
+	entry := tokenSummaryCache[tokenPath]
+	entry.summary.Identity.ProfileArn = "from-cache"
+	tokenSummaryCache[tokenPath] = entry
+	tokenSummaryMutex.Unlock()
+
+	if summary, _ := ReadBackupTokenSummary("cached"); summary.Identity.ProfileArn != "from-cache" {
+		t.Errorf("summary = %+v, want cached entry", summary)
+	}
+
+	// 修改時間改變後重新讀取 token
+	later := entry.modTime.Add(time.Minute)
+	if err := os.Chtimes(tokenPath, later, later); err != nil {
+		t.Fatalf("Chtimes failed: %v", err)
+	}
+	if summary, _ := ReadBackupTokenSummary("cached"); summary.Identity.ProfileArn != "arn:a" {
+		t.Errorf("summary = %+v, want re-read from token file", summary)
+	}
+}
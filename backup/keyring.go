@@ -0,0 +1,52 @@
+package backup
+
+import (
+	"errors"
+	"runtime"
+)
+
+const (
+	// keyringService OS keyring 中的服務名稱
+	keyringService = "kiro-manager"
+	// keyringAccount OS keyring 中的帳號名稱
+	keyringAccount = "backup-encryption-key"
+)
+
+var ErrKeyringUnavailable = errors.New("os keyring unavailable on " + runtime.GOOS)
+
+// keyringGet、keyringSet 存取 OS keyring 中的備份金鑰（測試時可替換）
+var (
+	keyringGet = osKeyringGet
+	keyringSet = osKeyringSet
+)
+
+// osKeyringGet 從 OS keyring 讀取備份金鑰
+// Windows: DPAPI 保護的金鑰檔
+// macOS: Keychain（security 命令）
+// Linux: Secret Service（secret-tool 命令）
+func osKeyringGet() (string, error) {
+	switch runtime.GOOS {
+	case "windows":
+		return getWindowsKeyring()
+	case "darwin":
+		return getDarwinKeyring()
+	case "linux":
+		return getLinuxKeyring()
+	default:
+		return "", ErrKeyringUnavailable
+	}
+}
+
+// osKeyringSet 將備份金鑰寫入 OS keyring
+func osKeyringSet(secret string) error {
+	switch runtime.GOOS {
+	case "windows":
+		return setWindowsKeyring(secret)
+	case "darwin":
+		return setDarwinKeyring(secret)
+	case "linux":
+		return setLinuxKeyring(secret)
+	default:
+		return ErrKeyringUnavailable
+	}
+}
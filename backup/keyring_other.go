@@ -0,0 +1,64 @@
+//go:build !windows
+
+package backup
+
+import (
+	"errors"
+	"os/exec"
+	"strings"
+)
+
+// getWindowsKeyring 非 Windows 平台不支援
+func getWindowsKeyring() (string, error) {
+	return "", ErrKeyringUnavailable
+}
+
+// setWindowsKeyring 非 Windows 平台不支援
+func setWindowsKeyring(secret string) error {
+	return ErrKeyringUnavailable
+}
+
+// getDarwinKeyring 使用 security 命令從 Keychain 讀取金鑰
+func getDarwinKeyring() (string, error) {
+	cmd := exec.Command("security", "find-generic-password", "-s", keyringService, "-a", keyringAccount, "-w")
+	output, err := cmd.Output()
+	if err != nil {
+		return "", err
+	}
+	secret := strings.TrimSpace(string(output))
+	if secret == "" {
+		return "", errors.New("empty secret in keychain")
+	}
+	return secret, nil
+}
+
+// setDarwinKeyring 使用 security 互動模式寫入 Keychain
+// 透過 stdin 傳遞命令，避免金鑰出現在進程參數列表中
+func setDarwinKeyring(secret string) error {
+	cmd := exec.Command("security", "-i")
+	cmd.Stdin = strings.NewReader("add-generic-password -U -s " + keyringService +
+		" -a " + keyringAccount + " -w " + secret + "\n")
+	return cmd.Run()
+}
+
+// getLinuxKeyring 使用 secret-tool 從 Secret Service 讀取金鑰
+func getLinuxKeyring() (string, error) {
+	cmd := exec.Command("secret-tool", "lookup", "service", keyringService, "account", keyringAccount)
+	output, err := cmd.Output()
+	if err != nil {
+		return "", err
+	}
+	secret := strings.TrimSpace(string(output))
+	if secret == "" {
+		return "", errors.New("empty secret in secret service")
+	}
+	return secret, nil
+}
+
+// setLinuxKeyring 使用 secret-tool 寫入 Secret Service（金鑰經由 stdin 傳遞）
+func setLinuxKeyring(secret string) error {
+	cmd := exec.Command("secret-tool", "store", "--label=Kiro Manager backup key",
+		"service", keyringService, "account", keyringAccount)
+	cmd.Stdin = strings.NewReader(secret)
+	return cmd.Run()
+}
@@ -0,0 +1,85 @@
+//go:build windows
+
+package backup
+
+import (
+	"os"
+	"path/filepath"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+
+	"kiro-manager/internal/atomicfile"
+)
+
+// windowsKeyFileName DPAPI 保護的金鑰檔（僅目前 Windows 使用者可解密）
+const windowsKeyFileName = "encryption-key.dpapi"
+
+// getWindowsKeyring 讀取並以 DPAPI 解密金鑰檔
+func getWindowsKeyring() (string, error) {
+	keyPath, err := getWindowsKeyFilePath()
+	if err != nil {
+		return "", err
+	}
+
+	blob, err := os.ReadFile(keyPath)
+	if err != nil {
+		return "", err
+	}
+
+	in := windows.DataBlob{Size: uint32(len(blob)), Data: &blob[0]}
+	var out windows.DataBlob
+	if err := windows.CryptUnprotectData(&in, nil, nil, 0, nil, windows.CRYPTPROTECT_UI_FORBIDDEN, &out); err != nil {
+		return "", err
+	}
+	defer windows.LocalFree(windows.Handle(unsafe.Pointer(out.Data)))
+
+	return string(unsafe.Slice(out.Data, out.Size)), nil
+}
+
+// setWindowsKeyring 以 DPAPI 加密金鑰後寫入金鑰檔
+func setWindowsKeyring(secret string) error {
+	keyPath, err := getWindowsKeyFilePath()
+	if err != nil {
+		return err
+	}
+
+	data := []byte(secret)
+	in := windows.DataBlob{Size: uint32(len(data)), Data: &data[0]}
+	var out windows.DataBlob
+	if err := windows.CryptProtectData(&in, nil, nil, 0, nil, windows.CRYPTPROTECT_UI_FORBIDDEN, &out); err != nil {
+		return err
+	}
+	defer windows.LocalFree(windows.Handle(unsafe.Pointer(out.Data)))
+
+	return atomicfile.WriteFile(keyPath, unsafe.Slice(out.Data, out.Size), 0600)
+}
+
+// getWindowsKeyFilePath 取得 DPAPI 金鑰檔路徑（位於備份根目錄）
+func getWindowsKeyFilePath() (string, error) {
+	rootPath, err := GetBackupRootPath()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(rootPath, windowsKeyFileName), nil
+}
+
+// getDarwinKeyring Windows 平台不支援
+func getDarwinKeyring() (string, error) {
+	return "", ErrKeyringUnavailable
+}
+
+// setDarwinKeyring Windows 平台不支援
+func setDarwinKeyring(secret string) error {
+	return ErrKeyringUnavailable
+}
+
+// getLinuxKeyring Windows 平台不支援
+func getLinuxKeyring() (string, error) {
+	return "", ErrKeyringUnavailable
+}
+
+// setLinuxKeyring Windows 平台不支援
+func setLinuxKeyring(secret string) error {
+	return ErrKeyringUnavailable
+}
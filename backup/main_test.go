@@ -0,0 +1,20 @@
+package backup
+
+import (
+	"os"
+	"testing"
+
+	"kiro-manager/internal/datadir"
+)
+
+// TestMain 將資料目錄（含寫入鎖定檔）指向暫存目錄，避免測試寫入使用者的資料目錄
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "backup-test-")
+	if err != nil {
+		panic(err)
+	}
+	os.Setenv(datadir.EnvVar, dir)
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
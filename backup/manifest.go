@@ -0,0 +1,365 @@
+package backup
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"kiro-manager/awssso"
+	"kiro-manager/internal/atomicfile"
+)
+
+const (
+	// ManifestFileName 備份清單檔案名稱
+	ManifestFileName = "manifest.json"
+	// ManifestSchemaVersion 目前的備份格式版本
+	// 格式變更時遞增此值，並在 backupMigrations 加入對應的遷移步驟
+	ManifestSchemaVersion = 1
+)
+
+var (
+	ErrManifestNotFound           = errors.New("backup manifest not found")
+	ErrUnsupportedManifestVersion = errors.New("backup manifest version is newer than supported")
+	ErrChecksumMismatch           = errors.New("backup file checksum mismatch")
+)
+
+// ManifestFile 備份清單中的單一檔案
+type ManifestFile struct {
+	Name   string `json:"name"`
+	SHA256 string `json:"sha256"` // 磁碟上內容（加密後）的 SHA-256
+	Size   int64  `json:"size"`
+}
+
+// Manifest 備份清單，描述備份的格式版本與內容
+type Manifest struct {
+	SchemaVersion int            `json:"schemaVersion"`
+	CreatedAt     time.Time      `json:"createdAt"`
+	AuthType      string         `json:"authType"` // social / idc / unknown
+	Provider      string         `json:"provider"`
+	Files         []ManifestFile `json:"files"`
+}
+
+// HasFile 檢查清單中是否包含指定檔案
+func (m *Manifest) HasFile(name string) bool {
+	for _, f := range m.Files {
+		if f.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// backupMigration 備份格式遷移步驟，將 From 版本的備份升級為 From+1
+type backupMigration struct {
+	From    int
+	Migrate func(backupPath string, manifest *Manifest) error
+}
+
+// backupMigrations 依版本排序的遷移步驟
+// 版本 0 代表沒有 manifest.json 的舊版備份
+var backupMigrations = []backupMigration{
+	{From: 0, Migrate: migrateLegacyLayout},
+}
+
+// ReadManifest 讀取指定備份的清單
+func ReadManifest(name string) (*Manifest, error) {
+	backupPath, err := GetBackupPath(name)
+	if err != nil {
+		return nil, err
+	}
+	if !BackupExists(name) {
+		return nil, ErrBackupNotFound
+	}
+	return readManifest(backupPath)
+}
+
+// VerifyBackup 以清單中的 SHA-256 驗證備份檔案是否完整
+func VerifyBackup(name string) error {
+	manifest, err := ReadManifest(name)
+	if err != nil {
+		return err
+	}
+
+	backupPath, err := GetBackupPath(name)
+	if err != nil {
+		return err
+	}
+	return verifyManifestFiles(backupPath, manifest)
+}
+
+// verifyManifestFiles 逐一比對清單中的檔案與磁碟上內容的 SHA-256
+func verifyManifestFiles(backupPath string, manifest *Manifest) error {
+	for _, f := range manifest.Files {
+		// 清單內容不可信任，檔名需確認仍位於備份資料夾內
+		filePath, err := safeJoin(backupPath, f.Name)
+		if err != nil {
+			return fmt.Errorf("%s: %w", f.Name, err)
+		}
+		sum, _, err := fileChecksum(filePath)
+		if err != nil {
+			return fmt.Errorf("%s: %w", f.Name, err)
+		}
+		if sum != f.SHA256 {
+			return fmt.Errorf("%s: %w", f.Name, ErrChecksumMismatch)
+		}
+	}
+	return nil
+}
+
+// readManifest 讀取備份目錄中的 manifest.json
+func readManifest(backupPath string) (*Manifest, error) {
+	data, err := os.ReadFile(filepath.Join(backupPath, ManifestFileName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, ErrManifestNotFound
+		}
+		return nil, err
+	}
+
+	var manifest Manifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("failed to parse manifest: %w", err)
+	}
+	return &manifest, nil
+}
+
+// writeManifest 寫入備份目錄中的 manifest.json
+func writeManifest(backupPath string, manifest *Manifest) error {
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	return atomicfile.WriteFile(filepath.Join(backupPath, ManifestFileName), data, 0600)
+}
+
+// upgradeBackup 依遷移步驟將備份升級至目前的格式版本
+// 已是最新版本時直接回傳清單；版本高於目前支援時回傳 ErrUnsupportedManifestVersion
+func upgradeBackup(backupPath string) (*Manifest, error) {
+	manifest, err := readManifest(backupPath)
+	if errors.Is(err, ErrManifestNotFound) {
+		manifest = &Manifest{SchemaVersion: 0}
+	} else if err != nil {
+		return nil, err
+	}
+
+	if manifest.SchemaVersion > ManifestSchemaVersion {
+		return manifest, ErrUnsupportedManifestVersion
+	}
+	if manifest.SchemaVersion == ManifestSchemaVersion {
+		return manifest, nil
+	}
+
+	for _, m := range backupMigrations {
+		if m.From != manifest.SchemaVersion {
+			continue
+		}
+		if err := m.Migrate(backupPath, manifest); err != nil {
+			return nil, fmt.Errorf("failed to migrate backup from schema %d: %w", m.From, err)
+		}
+		manifest.SchemaVersion = m.From + 1
+	}
+
+	if manifest.SchemaVersion != ManifestSchemaVersion {
+		return nil, fmt.Errorf("no migration path from schema %d", manifest.SchemaVersion)
+	}
+
+	if err := writeManifest(backupPath, manifest); err != nil {
+		return nil, err
+	}
+	return manifest, nil
+}
+
+// migrateLegacyLayout 為舊版（無 manifest.json）備份建立清單
+// 建立時間取自 machine-id.json 的 backupTime，無法取得時使用目錄修改時間
+// token 因金鑰未解鎖而無法讀取時回傳錯誤，待下次列出備份時再重試
+func migrateLegacyLayout(backupPath string, manifest *Manifest) error {
+	if mid, err := readMachineIDBackup(backupPath); err == nil {
+		if t, err := time.Parse(time.RFC3339, mid.BackupTime); err == nil {
+			manifest.CreatedAt = t
+		}
+	}
+	if manifest.CreatedAt.IsZero() {
+		if info, err := os.Stat(backupPath); err == nil {
+			manifest.CreatedAt = info.ModTime()
+		}
+	}
+
+	manifest.AuthType = "unknown"
+	tokenPath := filepath.Join(backupPath, KiroAuthTokenFile)
+	if _, err := os.Stat(tokenPath); err == nil {
+		data, err := readSecretFile(tokenPath)
+		if err != nil {
+			return err
+		}
+		var token awssso.KiroAuthToken
+		if err := json.Unmarshal(data, &token); err != nil {
+			return fmt.Errorf("failed to parse token: %w", err)
+		}
+		manifest.AuthType = detectAuthType(&token)
+		manifest.Provider = token.Provider
+	}
+
+	files, err := collectManifestFiles(backupPath)
+	if err != nil {
+		return err
+	}
+	manifest.Files = files
+	return nil
+}
+
+// newManifest 為新建立的備份產生清單
+func newManifest(backupPath string, token *awssso.KiroAuthToken, createdAt time.Time) (*Manifest, error) {
+	files, err := collectManifestFiles(backupPath)
+	if err != nil {
+		return nil, err
+	}
+
+	manifest := &Manifest{
+		SchemaVersion: ManifestSchemaVersion,
+		CreatedAt:     createdAt,
+		AuthType:      detectAuthType(token),
+		Files:         files,
+	}
+	if token != nil {
+		manifest.Provider = token.Provider
+	}
+	return manifest, nil
+}
+
+// refreshManifest 重新計算清單中的檔案列表與校驗值（備份內容變更後呼叫）
+// 尚無清單的舊版備份不處理，交由 upgradeBackup 建立
+// 以清單檔的鎖保護讀取與寫回，避免與 setManifestEntry 互相覆蓋
+func refreshManifest(backupPath string) error {
+	return updateManifest(backupPath, func(manifest *Manifest) error {
+		files, err := collectManifestFiles(backupPath)
+		if err != nil {
+			return err
+		}
+		manifest.Files = files
+		return nil
+	})
+}
+
+// setManifestEntry 將清單中 name 的校驗值設為 data 的 SHA-256（data 為即將寫入磁碟的內容）
+func setManifestEntry(backupPath, name string, data []byte) error {
+	sum := sha256.Sum256(data)
+	entry := ManifestFile{Name: name, SHA256: hex.EncodeToString(sum[:]), Size: int64(len(data))}
+
+	return updateManifest(backupPath, func(manifest *Manifest) error {
+		for i := range manifest.Files {
+			if manifest.Files[i].Name == name {
+				manifest.Files[i] = entry
+				return nil
+			}
+		}
+		manifest.Files = append(manifest.Files, entry)
+		sort.Slice(manifest.Files, func(i, j int) bool { return manifest.Files[i].Name < manifest.Files[j].Name })
+		return nil
+	})
+}
+
+// updateManifest 持有清單檔的鎖讀取、修改並寫回清單，尚無清單時不做任何事
+func updateManifest(backupPath string, fn func(manifest *Manifest) error) error {
+	manifestPath := filepath.Join(backupPath, ManifestFileName)
+	if _, err := os.Stat(manifestPath); os.IsNotExist(err) {
+		return nil
+	}
+
+	err := atomicfile.Update(manifestPath, 0600, func(old []byte, exists bool) ([]byte, error) {
+		if !exists {
+			return nil, ErrManifestNotFound
+		}
+		var manifest Manifest
+		if err := json.Unmarshal(old, &manifest); err != nil {
+			return nil, fmt.Errorf("failed to parse manifest: %w", err)
+		}
+		if err := fn(&manifest); err != nil {
+			return nil, err
+		}
+		return json.MarshalIndent(&manifest, "", "  ")
+	})
+	if errors.Is(err, ErrManifestNotFound) {
+		return nil
+	}
+	return err
+}
+
+// collectManifestFiles 列出備份目錄中需納入清單的檔案與校驗值
+func collectManifestFiles(backupPath string) ([]ManifestFile, error) {
+	entries, err := os.ReadDir(backupPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []ManifestFile
+	for _, entry := range entries {
+		if entry.IsDir() || !isManifestTracked(entry.Name()) {
+			continue
+		}
+		sum, size, err := fileChecksum(filepath.Join(backupPath, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, ManifestFile{Name: entry.Name(), SHA256: sum, Size: size})
+	}
+
+	sort.Slice(files, func(i, j int) bool { return files[i].Name < files[j].Name })
+	return files, nil
+}
+
+// isManifestTracked 判斷檔案是否納入清單
+// 清單本身與會頻繁變動的用量緩存、警示狀態不納入
+func isManifestTracked(name string) bool {
+	if filepath.Ext(name) != ".json" {
+		return false
+	}
+	switch name {
+	case ManifestFileName, UsageCacheFileName, AlertStateFileName:
+		return false
+	}
+	return true
+}
+
+// fileChecksum 計算檔案的 SHA-256 與大小
+func fileChecksum(path string) (string, int64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", 0, err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	size, err := io.Copy(h, f)
+	if err != nil {
+		return "", 0, err
+	}
+	return hex.EncodeToString(h.Sum(nil)), size, nil
+}
+
+// detectAuthType 判斷 token 的認證類型（social / idc / unknown）
+func detectAuthType(token *awssso.KiroAuthToken) string {
+	if token == nil {
+		return "unknown"
+	}
+	if isIdCAuth(token.AuthMethod) {
+		return "idc"
+	}
+	if strings.EqualFold(token.AuthMethod, "social") {
+		return "social"
+	}
+	if token.StartURL != "" && token.Region != "" {
+		return "idc"
+	}
+	if token.ProfileArn != "" {
+		return "social"
+	}
+	return "unknown"
+}
@@ -0,0 +1,161 @@
+package backup
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writeLegacyBackup 建立沒有 manifest.json 的舊版備份目錄
+func writeLegacyBackup(t *testing.T, tokenFixture string) string {
+	t.Helper()
+	backupPath := t.TempDir()
+
+	token, err := os.ReadFile(filepath.Join("testdata", tokenFixture))
+	if err != nil {
+		t.Fatalf("Failed to read fixture: %v", err)
+	}
+
+	files := map[string][]byte{
+		KiroAuthTokenFile:  token,
+		MachineIDFileName:  []byte(`{"machineId":"m","backupTime":"2025-12-08T12:00:00Z"}`),
+		UsageCacheFileName: []byte(`{"subscriptionTitle":"KIRO FREE"}`),
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(backupPath, name), content, 0600); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+	return backupPath
+}
+
+// TestUpgradeBackup_LegacyLayout 測試舊版備份會建立清單並記錄校驗值
+func TestUpgradeBackup_LegacyLayout(t *testing.T) {
+	backupPath := writeLegacyBackup(t, "kiro-auth-token-idc.json")
+
+	manifest, err := upgradeBackup(backupPath)
+	if err != nil {
+		t.Fatalf("upgradeBackup failed: %v", err)
+	}
+
+	if manifest.SchemaVersion != ManifestSchemaVersion {
+		t.Errorf("SchemaVersion = %d, want %d", manifest.SchemaVersion, ManifestSchemaVersion)
+	}
+	if manifest.AuthType != "idc" {
+		t.Errorf("AuthType = %q, want %q", manifest.AuthType, "idc")
+	}
+	wantCreated := time.Date(2025, 12, 8, 12, 0, 0, 0, time.UTC)
+	if !manifest.CreatedAt.Equal(wantCreated) {
+		t.Errorf("CreatedAt = %v, want %v", manifest.CreatedAt, wantCreated)
+	}
+
+	if !manifest.HasFile(KiroAuthTokenFile) || !manifest.HasFile(MachineIDFileName) {
+		t.Errorf("Manifest files missing token or machine id: %+v", manifest.Files)
+	}
+	if manifest.HasFile(UsageCacheFileName) {
+		t.Error("Usage cache should not be tracked in the manifest")
+	}
+
+	for _, f := range manifest.Files {
+		sum, size, err := fileChecksum(filepath.Join(backupPath, f.Name))
+		if err != nil {
+			t.Fatalf("fileChecksum failed: %v", err)
+		}
+		if sum != f.SHA256 || size != f.Size {
+			t.Errorf("%s checksum = %s/%d, want %s/%d", f.Name, f.SHA256, f.Size, sum, size)
+		}
+	}
+
+	// 清單已寫入磁碟，再次升級應直接讀取
+	stored, err := readManifest(backupPath)
+	if err != nil {
+		t.Fatalf("readManifest failed: %v", err)
+	}
+	if stored.SchemaVersion != ManifestSchemaVersion || len(stored.Files) != len(manifest.Files) {
+		t.Errorf("Stored manifest = %+v, want %+v", stored, manifest)
+	}
+}
+
+// TestUpgradeBackup_RunsRegisteredMigrations 測試遷移步驟依版本順序執行
+func TestUpgradeBackup_RunsRegisteredMigrations(t *testing.T) {
+	backupPath := writeLegacyBackup(t, "kiro-auth-token-social.json")
+	if err := writeManifest(backupPath, &Manifest{SchemaVersion: 0}); err != nil {
+		t.Fatalf("writeManifest failed: %v", err)
+	}
+
+	var calls []int
+	original := backupMigrations
+	backupMigrations = []backupMigration{
+		{From: 0, Migrate: func(path string, m *Manifest) error {
+			calls = append(calls, 0)
+			return migrateLegacyLayout(path, m)
+		}},
+	}
+	defer func() { backupMigrations = original }()
+
+	manifest, err := upgradeBackup(backupPath)
+	if err != nil {
+		t.Fatalf("upgradeBackup failed: %v", err)
+	}
+	if len(calls) != 1 {
+		t.Errorf("migration calls = %v, want exactly one", calls)
+	}
+	if manifest.AuthType != "social" {
+		t.Errorf("AuthType = %q, want %q", manifest.AuthType, "social")
+	}
+
+	// 已是最新版本，不應再次執行遷移
+	if _, err := upgradeBackup(backupPath); err != nil {
+		t.Fatalf("second upgradeBackup failed: %v", err)
+	}
+	if len(calls) != 1 {
+		t.Errorf("migration ran again on an up-to-date backup: %v", calls)
+	}
+}
+
+// TestUpgradeBackup_NewerSchema 測試較新版本的備份不會被改寫
+func TestUpgradeBackup_NewerSchema(t *testing.T) {
+	backupPath := t.TempDir()
+	if err := writeManifest(backupPath, &Manifest{SchemaVersion: ManifestSchemaVersion + 1}); err != nil {
+		t.Fatalf("writeManifest failed: %v", err)
+	}
+
+	_, err := upgradeBackup(backupPath)
+	if !errors.Is(err, ErrUnsupportedManifestVersion) {
+		t.Errorf("upgradeBackup error = %v, want ErrUnsupportedManifestVersion", err)
+	}
+}
+
+// TestRefreshManifest_UpdatesChecksum 測試備份內容變更後校驗值同步更新
+func TestRefreshManifest_UpdatesChecksum(t *testing.T) {
+	backupPath := writeLegacyBackup(t, "kiro-auth-token-social.json")
+	before, err := upgradeBackup(backupPath)
+	if err != nil {
+		t.Fatalf("upgradeBackup failed: %v", err)
+	}
+
+	tokenPath := filepath.Join(backupPath, KiroAuthTokenFile)
+	if err := os.WriteFile(tokenPath, []byte(`{"accessToken":"rotated"}`), 0600); err != nil {
+		t.Fatalf("Failed to rewrite token: %v", err)
+	}
+	if err := refreshManifest(backupPath); err != nil {
+		t.Fatalf("refreshManifest failed: %v", err)
+	}
+
+	after, err := readManifest(backupPath)
+	if err != nil {
+		t.Fatalf("readManifest failed: %v", err)
+	}
+	if !after.CreatedAt.Equal(before.CreatedAt) || after.Provider != before.Provider {
+		t.Error("refreshManifest should keep creation time and provider")
+	}
+
+	sum, _, _ := fileChecksum(tokenPath)
+	for _, f := range after.Files {
+		if f.Name == KiroAuthTokenFile && f.SHA256 != sum {
+			t.Errorf("token checksum not refreshed: %s, want %s", f.SHA256, sum)
+		}
+	}
+}
@@ -0,0 +1,156 @@
+package backup
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// MaxBackupNameLength 備份名稱的最大字元數
+const MaxBackupNameLength = 64
+
+var (
+	ErrInvalidClientIdHash = errors.New("invalid client id hash")
+	ErrPathEscapesRoot     = errors.New("path escapes backup root")
+)
+
+// windowsReservedNames Windows 保留的裝置名稱（不分大小寫，含副檔名亦無效）
+var windowsReservedNames = map[string]bool{
+	"CON": true, "PRN": true, "AUX": true, "NUL": true,
+	"COM1": true, "COM2": true, "COM3": true, "COM4": true, "COM5": true,
+	"COM6": true, "COM7": true, "COM8": true, "COM9": true,
+	"LPT1": true, "LPT2": true, "LPT3": true, "LPT4": true, "LPT5": true,
+	"LPT6": true, "LPT7": true, "LPT8": true, "LPT9": true,
+}
+
+// InvalidNameError 備份名稱不合法的詳細原因
+// errors.Is(err, ErrInvalidBackupName) 為 true
+type InvalidNameError struct {
+	Name   string
+	Reason string
+}
+
+// Error 實作 error 介面
+func (e *InvalidNameError) Error() string {
+	return fmt.Sprintf("invalid backup name %q: %s", e.Name, e.Reason)
+}
+
+// Is 讓 errors.Is 可以比對 ErrInvalidBackupName
+func (e *InvalidNameError) Is(target error) bool {
+	return target == ErrInvalidBackupName
+}
+
+// ValidateBackupName 驗證備份名稱
+// 名稱會直接作為資料夾名稱使用，因此在所有平台上都需為單一、安全的路徑元件：
+// 不可為空、不可含路徑分隔符號或 Windows 不允許的字元、不可為 "." / ".."、
+// 不可為 Windows 保留裝置名稱、不可以空白或句點結尾，且長度不超過 MaxBackupNameLength
+func ValidateBackupName(name string) error {
+	invalid := func(reason string) error {
+		return &InvalidNameError{Name: name, Reason: reason}
+	}
+
+	if name == "" {
+		return invalid("name is empty")
+	}
+	if !utf8.ValidString(name) {
+		return invalid("name is not valid UTF-8")
+	}
+	if utf8.RuneCountInString(name) > MaxBackupNameLength {
+		return invalid(fmt.Sprintf("name is longer than %d characters", MaxBackupNameLength))
+	}
+	if name == "." || name == ".." {
+		return invalid("name is a dot segment")
+	}
+	if strings.TrimSpace(name) != name {
+		return invalid("name has leading or trailing whitespace")
+	}
+	if strings.HasSuffix(name, ".") {
+		return invalid("name ends with a dot")
+	}
+
+	for _, r := range name {
+		switch {
+		case r == '/' || r == '\\':
+			return invalid("name contains a path separator")
+		case strings.ContainsRune(`<>:"|?*`, r):
+			return invalid(fmt.Sprintf("name contains reserved character %q", r))
+		case unicode.IsControl(r):
+			return invalid("name contains a control character")
+		}
+	}
+
+	base, _, _ := strings.Cut(name, ".")
+	if windowsReservedNames[strings.ToUpper(strings.TrimSpace(base))] {
+		return invalid("name is a reserved Windows device name")
+	}
+
+	return nil
+}
+
+// ValidateClientIdHash 驗證 IdC token 中的 clientIdHash
+// clientIdHash 會組成 SSO cache 與備份中的檔名，僅允許英數字、"-" 與 "_"
+func ValidateClientIdHash(clientIdHash string) error {
+	if clientIdHash == "" || len(clientIdHash) > 128 {
+		return ErrInvalidClientIdHash
+	}
+	for _, r := range clientIdHash {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_') {
+			return ErrInvalidClientIdHash
+		}
+	}
+	return nil
+}
+
+// clientIdHashFileName 取得 clientIdHash 對應的檔名（驗證後）
+func clientIdHashFileName(clientIdHash string) (string, error) {
+	if err := ValidateClientIdHash(clientIdHash); err != nil {
+		return "", err
+	}
+	return clientIdHash + ".json", nil
+}
+
+// safeJoin 組合路徑並確認結果位於 root 之下（不可等於 root 本身）
+// 路徑已存在時會解析符號連結，避免透過連結跳出 root
+func safeJoin(root string, elem ...string) (string, error) {
+	joined := filepath.Join(append([]string{root}, elem...)...)
+	if !isUnder(root, joined) {
+		return "", ErrPathEscapesRoot
+	}
+
+	resolvedRoot, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		// root 尚未建立時沒有連結可解析
+		if os.IsNotExist(err) {
+			return joined, nil
+		}
+		return "", err
+	}
+	resolved, err := filepath.EvalSymlinks(joined)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return joined, nil
+		}
+		return "", err
+	}
+	if !isUnder(resolvedRoot, resolved) {
+		return "", ErrPathEscapesRoot
+	}
+
+	return joined, nil
+}
+
+// isUnder 檢查 path 是否位於 root 之下
+func isUnder(root, path string) bool {
+	rel, err := filepath.Rel(filepath.Clean(root), filepath.Clean(path))
+	if err != nil {
+		return false
+	}
+	if rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return false
+	}
+	return !filepath.IsAbs(rel)
+}
@@ -0,0 +1,145 @@
+package backup
+
+import (
+	"errors"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"testing/quick"
+)
+
+// TestValidateBackupName 測試備份名稱驗證規則
+func TestValidateBackupName(t *testing.T) {
+	testCases := []struct {
+		name  string
+		input string
+		valid bool
+	}{
+		{"Simple", "work", true},
+		{"With spaces and dash", "my account-2", true},
+		{"Unicode", "工作帳號", true},
+		{"Inner dot", "acc.v2", true},
+		{"Empty", "", false},
+		{"Dot", ".", false},
+		{"Dot dot", "..", false},
+		{"Parent traversal", "../../.ssh", false},
+		{"Slash", "a/b", false},
+		{"Backslash", `a\b`, false},
+		{"Windows drive", "C:evil", false},
+		{"Reserved CON", "con", false},
+		{"Reserved with extension", "NUL.txt", false},
+		{"Reserved COM1", "Com1", false},
+		{"Trailing dot", "name.", false},
+		{"Leading space", " name", false},
+		{"Control character", "a\x00b", false},
+		{"Wildcard", "a*b", false},
+		{"Too long", strings.Repeat("a", MaxBackupNameLength+1), false},
+		{"Max length", strings.Repeat("界", MaxBackupNameLength), true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := ValidateBackupName(tc.input)
+			if tc.valid && err != nil {
+				t.Errorf("ValidateBackupName(%q) = %v, want nil", tc.input, err)
+			}
+			if !tc.valid {
+				if err == nil {
+					t.Errorf("ValidateBackupName(%q) = nil, want error", tc.input)
+				} else if !errors.Is(err, ErrInvalidBackupName) {
+					t.Errorf("ValidateBackupName(%q) error %v is not ErrInvalidBackupName", tc.input, err)
+				}
+			}
+		})
+	}
+}
+
+// **Feature: backup-name-validation, Property 1: Valid Names Stay Under Root**
+// *For any* name accepted by ValidateBackupName, joining it onto the backup root
+// SHALL yield a direct child of the root.
+func TestProperty_ValidNamesStayUnderRoot(t *testing.T) {
+	root := filepath.Join(os.TempDir(), "kiro-manager-backups")
+	alphabet := []rune("abc./\\:-_ 界\x00")
+
+	f := func(seed int64) bool {
+		r := rand.New(rand.NewSource(seed))
+		runes := make([]rune, r.Intn(12)+1)
+		for i := range runes {
+			runes[i] = alphabet[r.Intn(len(alphabet))]
+		}
+		name := string(runes)
+
+		if ValidateBackupName(name) != nil {
+			return true
+		}
+
+		joined := filepath.Join(root, name)
+		if filepath.Dir(joined) != root {
+			t.Logf("name %q escaped root: %s", name, joined)
+			return false
+		}
+		return true
+	}
+
+	config := &quick.Config{
+		MaxCount: 100,
+	}
+
+	if err := quick.Check(f, config); err != nil {
+		t.Errorf("Property test failed: %v", err)
+	}
+}
+
+// TestValidateClientIdHash 測試 clientIdHash 驗證
+func TestValidateClientIdHash(t *testing.T) {
+	valid := []string{"4c9a3f1e2b7d8c6a5e0f1a2b3c4d5e6f7a8b9c0d", "abc_DEF-123"}
+	invalid := []string{"", "../machine", "a/b", "a.json", strings.Repeat("a", 129)}
+
+	for _, v := range valid {
+		if err := ValidateClientIdHash(v); err != nil {
+			t.Errorf("ValidateClientIdHash(%q) = %v, want nil", v, err)
+		}
+	}
+	for _, v := range invalid {
+		if err := ValidateClientIdHash(v); !errors.Is(err, ErrInvalidClientIdHash) {
+			t.Errorf("ValidateClientIdHash(%q) = %v, want ErrInvalidClientIdHash", v, err)
+		}
+	}
+}
+
+// TestSafeJoin 測試路徑需位於 root 之下（含符號連結）
+func TestSafeJoin(t *testing.T) {
+	root := t.TempDir()
+	outside := t.TempDir()
+
+	if _, err := safeJoin(root, "work"); err != nil {
+		t.Errorf("safeJoin(work) = %v, want nil", err)
+	}
+	for _, elem := range []string{"..", "../x", ".", ""} {
+		if _, err := safeJoin(root, elem); !errors.Is(err, ErrPathEscapesRoot) {
+			t.Errorf("safeJoin(%q) = %v, want ErrPathEscapesRoot", elem, err)
+		}
+	}
+
+	if runtime.GOOS == "windows" {
+		return
+	}
+	if err := os.Symlink(outside, filepath.Join(root, "link")); err != nil {
+		t.Fatalf("Failed to create symlink: %v", err)
+	}
+	if _, err := safeJoin(root, "link"); !errors.Is(err, ErrPathEscapesRoot) {
+		t.Errorf("safeJoin(link) = %v, want ErrPathEscapesRoot", err)
+	}
+}
+
+// TestDeleteBackup_RejectsTraversal 測試刪除時拒絕跳出備份目錄的名稱
+func TestDeleteBackup_RejectsTraversal(t *testing.T) {
+	for _, name := range []string{"..", "../../.ssh", "a/../.."} {
+		if err := DeleteBackup(name); !errors.Is(err, ErrInvalidBackupName) {
+			t.Errorf("DeleteBackup(%q) = %v, want ErrInvalidBackupName", name, err)
+		}
+	}
+}
@@ -0,0 +1,253 @@
+package backup
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"kiro-manager/awssso"
+	"kiro-manager/internal/atomicfile"
+)
+
+// RestoreResult 恢復備份的結果
+type RestoreResult struct {
+	// Changed 內容實際被變更的檔案路徑（內容相同而略過的檔案不列入）
+	Changed []string `json:"changed"`
+}
+
+// restoreFile 恢復計畫中的單一檔案
+type restoreFile struct {
+	Path string // 目標路徑（SSO cache 中的檔案）
+	Data []byte // 解密後的內容
+}
+
+// restoreSnapshot 被覆蓋前的檔案狀態，用於回滾
+type restoreSnapshot struct {
+	Path    string
+	Existed bool
+	Data    []byte
+	Mode    os.FileMode
+}
+
+// renameFile 提交暫存檔時使用的 rename（測試時可替換以模擬失敗）
+var renameFile = os.Rename
+
+// RestoreBackup 恢復指定的備份
+// 先以清單中的 SHA-256 驗證備份，檔案被竄改或損毀時回傳 ErrChecksumMismatch，不會寫入 SSO cache
+// 所有檔案先寫入同目錄的暫存檔並快照即將被覆蓋的檔案，全部就緒後才以 rename 提交；
+// 任一步驟失敗時，已提交的檔案會回滾為原本的內容，SSO cache 維持恢復前的狀態
+func RestoreBackup(name string) (*RestoreResult, error) {
+	if err := ValidateBackupName(name); err != nil {
+		return nil, err
+	}
+
+	if !BackupExists(name) {
+		return nil, ErrBackupNotFound
+	}
+
+	backupPath, err := GetBackupPath(name)
+	if err != nil {
+		return nil, err
+	}
+
+	// 內容與清單的校驗值不符時拒絕恢復（尚無清單的舊版備份先升級）
+	manifest, err := upgradeBackup(backupPath)
+	if err != nil {
+		return nil, err
+	}
+	if err := verifyManifestFiles(backupPath, manifest); err != nil {
+		return nil, err
+	}
+
+	files, err := buildRestorePlan(backupPath)
+	if err != nil {
+		return nil, err
+	}
+
+	changed, err := applyRestorePlan(files)
+	if err != nil {
+		return nil, err
+	}
+	return &RestoreResult{Changed: changed}, nil
+}
+
+// buildRestorePlan 解密備份內容並決定要寫入 SSO cache 的檔案
+// IdC 備份含有 clientIdHash 檔案時，token 與 client 註冊資訊會一併恢復
+func buildRestorePlan(backupPath string) ([]restoreFile, error) {
+	tokenSrcPath := filepath.Join(backupPath, KiroAuthTokenFile)
+	if _, err := os.Stat(tokenSrcPath); os.IsNotExist(err) {
+		return nil, fmt.Errorf("backup token file not found")
+	}
+
+	tokenData, err := readSecretFile(tokenSrcPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read backup token: %w", err)
+	}
+
+	var token awssso.KiroAuthToken
+	if err := json.Unmarshal(tokenData, &token); err != nil {
+		return nil, fmt.Errorf("failed to parse backup token: %w", err)
+	}
+
+	tokenDstPath, err := awssso.GetKiroAuthTokenPath()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get token destination path: %w", err)
+	}
+
+	files := []restoreFile{{Path: tokenDstPath, Data: tokenData}}
+
+	// 如果是 IdC 認證且有 clientIdHash，恢復對應的 clientId/clientSecret 文件
+	if isIdCAuth(token.AuthMethod) && token.ClientIdHash != "" {
+		clientIdHashFile, err := clientIdHashFileName(token.ClientIdHash)
+		if err != nil {
+			return nil, fmt.Errorf("failed to restore clientIdHash file: %w", err)
+		}
+
+		clientIdHashSrcPath := filepath.Join(backupPath, clientIdHashFile)
+		if _, err := os.Stat(clientIdHashSrcPath); err == nil {
+			clientData, err := readSecretFile(clientIdHashSrcPath)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read clientIdHash file: %w", err)
+			}
+
+			ssoCachePath, err := awssso.GetSSOCachePath()
+			if err != nil {
+				return nil, fmt.Errorf("failed to get SSO cache path: %w", err)
+			}
+			files = append(files, restoreFile{Path: filepath.Join(ssoCachePath, clientIdHashFile), Data: clientData})
+		}
+	}
+
+	return files, nil
+}
+
+// applyRestorePlan 以交易方式寫入所有檔案，回傳實際變更的檔案路徑
+// 內容與現有檔案相同者略過；任一步驟失敗時回滾已提交的檔案並清除暫存檔
+func applyRestorePlan(files []restoreFile) ([]string, error) {
+	// 快照即將被覆蓋的檔案，並略過內容未變更者
+	var pending []restoreFile
+	var snapshots []restoreSnapshot
+	for _, f := range files {
+		snapshot, err := takeSnapshot(f.Path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to snapshot %s: %w", f.Path, err)
+		}
+		if snapshot.Existed && bytes.Equal(snapshot.Data, f.Data) {
+			continue
+		}
+		pending = append(pending, f)
+		snapshots = append(snapshots, snapshot)
+	}
+
+	// 寫入暫存檔（與目標同目錄，確保 rename 為原子操作）
+	var staged []string
+	cleanup := func() {
+		for _, tmp := range staged {
+			os.Remove(tmp)
+		}
+	}
+	for _, f := range pending {
+		if err := os.MkdirAll(filepath.Dir(f.Path), 0755); err != nil {
+			cleanup()
+			return nil, fmt.Errorf("failed to create directory for %s: %w", f.Path, err)
+		}
+		tmp, err := stageFile(f.Path, f.Data, 0600)
+		if err != nil {
+			cleanup()
+			return nil, fmt.Errorf("failed to stage %s: %w", f.Path, err)
+		}
+		staged = append(staged, tmp)
+	}
+
+	// 提交
+	var changed []string
+	for i, f := range pending {
+		if err := renameFile(staged[i], f.Path); err != nil {
+			cleanup()
+			if rbErr := rollbackRestore(snapshots[:i]); rbErr != nil {
+				return nil, fmt.Errorf("failed to restore %s: %w (rollback failed: %v)", f.Path, err, rbErr)
+			}
+			return nil, fmt.Errorf("failed to restore %s: %w", f.Path, err)
+		}
+		changed = append(changed, f.Path)
+	}
+
+	for _, dir := range uniqueDirs(pending) {
+		atomicfile.SyncDir(dir)
+	}
+	return changed, nil
+}
+
+// uniqueDirs 回傳檔案所在的目錄（去除重複）
+func uniqueDirs(files []restoreFile) []string {
+	seen := make(map[string]bool)
+	var dirs []string
+	for _, f := range files {
+		dir := filepath.Dir(f.Path)
+		if !seen[dir] {
+			seen[dir] = true
+			dirs = append(dirs, dir)
+		}
+	}
+	return dirs
+}
+
+// takeSnapshot 讀取檔案目前的內容與權限
+func takeSnapshot(path string) (restoreSnapshot, error) {
+	snapshot := restoreSnapshot{Path: path}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return snapshot, nil
+		}
+		return snapshot, err
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return snapshot, err
+	}
+
+	snapshot.Existed = true
+	snapshot.Data = data
+	snapshot.Mode = info.Mode().Perm()
+	return snapshot, nil
+}
+
+// rollbackRestore 將已提交的檔案恢復為快照內容（原本不存在的檔案則刪除）
+func rollbackRestore(snapshots []restoreSnapshot) error {
+	var errs []error
+	for i := len(snapshots) - 1; i >= 0; i-- {
+		s := snapshots[i]
+		if !s.Existed {
+			if err := os.Remove(s.Path); err != nil && !os.IsNotExist(err) {
+				errs = append(errs, err)
+			}
+			continue
+		}
+
+		tmp, err := stageFile(s.Path, s.Data, s.Mode)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		staged := atomicfile.Staged{Path: s.Path, TempPath: tmp}
+		if err := staged.Commit(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// stageFile 將內容寫入目標同目錄下的暫存檔並 fsync，回傳暫存檔路徑
+func stageFile(path string, data []byte, perm os.FileMode) (string, error) {
+	staged, err := atomicfile.Stage(path, data, perm)
+	if err != nil {
+		return "", err
+	}
+	return staged.TempPath, nil
+}
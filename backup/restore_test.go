@@ -0,0 +1,196 @@
+package backup
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"kiro-manager/internal/datadir"
+)
+
+// readFileString 讀取檔案內容，不存在時回傳空字串
+func readFileString(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return ""
+	}
+	if err != nil {
+		t.Fatalf("Failed to read %s: %v", path, err)
+	}
+	return string(data)
+}
+
+// assertNoStagedFiles 確認目錄中沒有殘留的暫存檔
+func assertNoStagedFiles(t *testing.T, dir string) {
+	t.Helper()
+	entries, _ := os.ReadDir(dir)
+	for _, entry := range entries {
+		if strings.Contains(entry.Name(), ".tmp-") {
+			t.Errorf("Staged file left behind: %s", entry.Name())
+		}
+	}
+}
+
+// TestApplyRestorePlan_ReportsChangedFiles 測試只回報內容實際變更的檔案
+func TestApplyRestorePlan_ReportsChangedFiles(t *testing.T) {
+	dir := t.TempDir()
+	tokenPath := filepath.Join(dir, KiroAuthTokenFile)
+	clientPath := filepath.Join(dir, "abc123.json")
+
+	os.WriteFile(tokenPath, []byte(`{"accessToken":"old"}`), 0600)
+	os.WriteFile(clientPath, []byte(`{"clientId":"same"}`), 0600)
+
+	changed, err := applyRestorePlan([]restoreFile{
+		{Path: tokenPath, Data: []byte(`{"accessToken":"new"}`)},
+		{Path: clientPath, Data: []byte(`{"clientId":"same"}`)},
+	})
+	if err != nil {
+		t.Fatalf("applyRestorePlan failed: %v", err)
+	}
+
+	if len(changed) != 1 || changed[0] != tokenPath {
+		t.Errorf("changed = %v, want [%s]", changed, tokenPath)
+	}
+	if got := readFileString(t, tokenPath); got != `{"accessToken":"new"}` {
+		t.Errorf("token content = %s", got)
+	}
+	assertNoStagedFiles(t, dir)
+}
+
+// TestApplyRestorePlan_RollsBackOnFailure 測試提交中途失敗時回滾所有已提交的檔案
+func TestApplyRestorePlan_RollsBackOnFailure(t *testing.T) {
+	dir := t.TempDir()
+	tokenPath := filepath.Join(dir, KiroAuthTokenFile)
+	newPath := filepath.Join(dir, "new-client.json")
+	clientPath := filepath.Join(dir, "abc123.json")
+
+	os.WriteFile(tokenPath, []byte(`{"accessToken":"live"}`), 0600)
+	os.WriteFile(clientPath, []byte(`{"clientId":"live"}`), 0600)
+
+	// 第三個檔案提交時失敗
+	original := renameFile
+	calls := 0
+	renameFile = func(oldPath, newPath string) error {
+		calls++
+		if calls == 3 {
+			return errors.New("disk full")
+		}
+		return os.Rename(oldPath, newPath)
+	}
+	defer func() { renameFile = original }()
+
+	_, err := applyRestorePlan([]restoreFile{
+		{Path: tokenPath, Data: []byte(`{"accessToken":"backup"}`)},
+		{Path: newPath, Data: []byte(`{"clientId":"created"}`)},
+		{Path: clientPath, Data: []byte(`{"clientId":"backup"}`)},
+	})
+	if err == nil {
+		t.Fatal("applyRestorePlan should fail when a rename fails")
+	}
+
+	if got := readFileString(t, tokenPath); got != `{"accessToken":"live"}` {
+		t.Errorf("token not rolled back: %s", got)
+	}
+	if got := readFileString(t, clientPath); got != `{"clientId":"live"}` {
+		t.Errorf("client file changed: %s", got)
+	}
+	if _, err := os.Stat(newPath); !os.IsNotExist(err) {
+		t.Error("File created during restore should be removed on rollback")
+	}
+	assertNoStagedFiles(t, dir)
+}
+
+// TestRestoreBackup_RejectsTamperedFile 測試備份檔案與清單校驗值不符時拒絕恢復，SSO cache 不受影響
+func TestRestoreBackup_RejectsTamperedFile(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	t.Setenv(datadir.EnvVar, t.TempDir())
+
+	legacyPath := writeLegacyBackup(t, "kiro-auth-token-social.json")
+	backupPath, err := GetBackupPath("work")
+	if err != nil {
+		t.Fatalf("GetBackupPath failed: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(backupPath), 0700); err != nil {
+		t.Fatalf("Failed to create backup root: %v", err)
+	}
+	if err := os.Rename(legacyPath, backupPath); err != nil {
+		t.Fatalf("Failed to move backup: %v", err)
+	}
+	if _, err := upgradeBackup(backupPath); err != nil {
+		t.Fatalf("upgradeBackup failed: %v", err)
+	}
+
+	tokenPath := filepath.Join(backupPath, KiroAuthTokenFile)
+	original, err := os.ReadFile(tokenPath)
+	if err != nil {
+		t.Fatalf("Failed to read token: %v", err)
+	}
+	if err := os.WriteFile(tokenPath, []byte(`{"accessToken":"tampered","refreshToken":"x"}`), 0600); err != nil {
+		t.Fatalf("Failed to tamper token: %v", err)
+	}
+
+	if _, err := RestoreBackup("work"); !errors.Is(err, ErrChecksumMismatch) {
+		t.Fatalf("RestoreBackup error = %v, want ErrChecksumMismatch", err)
+	}
+	if _, err := os.Stat(filepath.Join(home, ".aws")); !os.IsNotExist(err) {
+		t.Errorf("SSO cache should not be written when verification fails: %v", err)
+	}
+
+	// 還原為原本的內容後可正常恢復
+	if err := os.WriteFile(tokenPath, original, 0600); err != nil {
+		t.Fatalf("Failed to restore token: %v", err)
+	}
+	if _, err := RestoreBackup("work"); err != nil {
+		t.Errorf("RestoreBackup of intact backup failed: %v", err)
+	}
+}
+
+// TestUpdateBackupToken_KeepsManifestConsistent 測試並行刷新 token 後清單校驗值仍與內容一致，
+// 清單無法更新時 token 會還原，不會讓之後的恢復因校驗值不符而失敗
+func TestUpdateBackupToken_KeepsManifestConsistent(t *testing.T) {
+	useTestBackupRoot(t)
+
+	if err := writeTestBackupToken("work", []byte(`{"accessToken":"a","refreshToken":"r"}`)); err != nil {
+		t.Fatalf("writeTestBackupToken failed: %v", err)
+	}
+	backupPath, _ := GetBackupPath("work")
+	if _, err := upgradeBackup(backupPath); err != nil {
+		t.Fatalf("upgradeBackup failed: %v", err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if err := WriteBackupToken("work", fmt.Sprintf("access-%d", i), "2099-01-01T00:00:00Z"); err != nil {
+				t.Errorf("WriteBackupToken failed: %v", err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if err := VerifyBackup("work"); err != nil {
+		t.Fatalf("VerifyBackup after concurrent refreshes failed: %v", err)
+	}
+
+	// 清單損毀時更新失敗，token 維持原本內容
+	tokenPath := filepath.Join(backupPath, KiroAuthTokenFile)
+	before := readFileString(t, tokenPath)
+	if err := os.WriteFile(filepath.Join(backupPath, ManifestFileName), []byte("{"), 0600); err != nil {
+		t.Fatalf("Failed to corrupt manifest: %v", err)
+	}
+	if err := WriteBackupToken("work", "after-failure", "2099-01-01T00:00:00Z"); err == nil {
+		t.Fatal("WriteBackupToken should fail when the manifest cannot be updated")
+	}
+	if after := readFileString(t, tokenPath); after != before {
+		t.Error("token file should be rolled back when the manifest update fails")
+	}
+}
@@ -0,0 +1,253 @@
+//go:build cli
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"kiro-manager/backup"
+	"kiro-manager/kiroprocess"
+)
+
+// backupDetail backup show 的輸出結構
+type backupDetail struct {
+	BackupItem
+	Path          string `json:"path"`
+	SchemaVersion int    `json:"schemaVersion"`
+	AuthType      string `json:"authType"`
+}
+
+// restoreOutput backup restore 的輸出結構
+type restoreOutput struct {
+	Success    bool     `json:"success"`
+	Name       string   `json:"name"`
+	Changed    []string `json:"changed"`
+	Relaunched bool     `json:"relaunched"` // 是否已依設定重新啟動 Kiro
+}
+
+// runBackup 處理 backup 子命令
+func (c *cli) runBackup(args []string) error {
+	if len(args) == 0 {
+		return usageErrorf("用法: kiro-manager backup list|show|create|restore|delete|rename")
+	}
+
+	switch args[0] {
+	case "list":
+		return c.backupList()
+	case "show":
+		if err := requireArgs(args[1:], 1, "backup show <name>"); err != nil {
+			return err
+		}
+		return c.backupShow(args[1])
+	case "create":
+		if err := requireArgs(args[1:], 1, "backup create <name>"); err != nil {
+			return err
+		}
+		return c.backupCreate(args[1])
+	case "restore":
+		if err := requireArgs(args[1:], 1, "backup restore <name> [--force]"); err != nil {
+			return err
+		}
+		return c.backupRestore(args[1])
+	case "delete":
+		if err := requireArgs(args[1:], 1, "backup delete <name>"); err != nil {
+			return err
+		}
+		return c.backupDelete(args[1])
+	case "rename":
+		if err := requireArgs(args[1:], 2, "backup rename <old> <new>"); err != nil {
+			return err
+		}
+		return c.backupRename(args[1], args[2])
+	default:
+		return usageErrorf("未知的 backup 子命令: %s", args[0])
+	}
+}
+
+// backupList 列出所有備份
+func (c *cli) backupList() error {
+	items, err := c.app.GetBackupList()
+	if err != nil {
+		return err
+	}
+	if items == nil {
+		items = []BackupItem{}
+	}
+
+	if c.json {
+		return c.printJSON(items)
+	}
+
+	if len(items) == 0 {
+		c.printf("尚無備份\n")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(c.out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tPROVIDER\tSUBSCRIPTION\tBALANCE\tTOKEN\tBACKUP TIME")
+	for _, item := range items {
+		name := item.Name
+		if item.IsCurrent {
+			name += " *"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n",
+			name, dashIfEmpty(item.Provider), dashIfEmpty(item.SubscriptionTitle),
+			formatBalance(item.Balance, item.UsageLimit, item.CachedAt != ""),
+			tokenState(item), dashIfEmpty(item.BackupTime))
+	}
+	return w.Flush()
+}
+
+// backupShow 顯示單一備份的詳細資訊
+func (c *cli) backupShow(name string) error {
+	info, err := backup.GetBackupInfo(name)
+	if err != nil {
+		if errors.Is(err, backup.ErrBackupNotFound) {
+			return notFoundError(name)
+		}
+		return err
+	}
+
+	items, err := c.app.GetBackupList()
+	if err != nil {
+		return err
+	}
+
+	detail := backupDetail{
+		BackupItem:    BackupItem{Name: name},
+		Path:          info.Path,
+		SchemaVersion: info.SchemaVersion,
+		AuthType:      info.AuthType,
+	}
+	for _, item := range items {
+		if item.Name == name {
+			detail.BackupItem = item
+			break
+		}
+	}
+
+	if c.json {
+		return c.printJSON(detail)
+	}
+
+	w := tabwriter.NewWriter(c.out, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "Name:\t%s\n", detail.Name)
+	fmt.Fprintf(w, "Path:\t%s\n", detail.Path)
+	fmt.Fprintf(w, "Backup Time:\t%s\n", dashIfEmpty(detail.BackupTime))
+	fmt.Fprintf(w, "Provider:\t%s\n", dashIfEmpty(detail.Provider))
+	fmt.Fprintf(w, "Auth Type:\t%s\n", dashIfEmpty(detail.AuthType))
+	fmt.Fprintf(w, "Schema Version:\t%d\n", detail.SchemaVersion)
+	fmt.Fprintf(w, "Machine ID:\t%s\n", dashIfEmpty(detail.MachineID))
+	fmt.Fprintf(w, "Current:\t%v\n", detail.IsCurrent)
+	fmt.Fprintf(w, "Token:\t%s\n", tokenState(detail.BackupItem))
+	fmt.Fprintf(w, "Subscription:\t%s\n", dashIfEmpty(detail.SubscriptionTitle))
+	fmt.Fprintf(w, "Balance:\t%s\n", formatBalance(detail.Balance, detail.UsageLimit, detail.CachedAt != ""))
+	fmt.Fprintf(w, "Usage Cached At:\t%s\n", dashIfEmpty(detail.CachedAt))
+	return w.Flush()
+}
+
+// backupCreate 備份目前登入的帳號
+func (c *cli) backupCreate(name string) error {
+	if err := resultError(c.app.CreateBackup(name)); err != nil {
+		return err
+	}
+	return c.done(fmt.Sprintf("已建立備份 %s", name))
+}
+
+// backupRestore 切換至指定備份
+// Kiro 執行中時需要 --force 才會強制關閉，避免在排程中誤關使用者的編輯器
+func (c *cli) backupRestore(name string) error {
+	if !backup.BackupExists(name) {
+		return notFoundError(name)
+	}
+
+	if kiroprocess.IsKiroRunning() && !c.hasFlag("force") {
+		return errors.New("Kiro 正在執行，請先關閉或加上 --force 關閉")
+	}
+
+	result, closed, err := c.app.switchToBackup(name)
+	if err != nil {
+		return err
+	}
+	if result.Changed == nil {
+		result.Changed = []string{}
+	}
+
+	attempted, err := c.app.relaunchKiro(closed)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "警告: 重新啟動 Kiro 失敗: %v\n", err)
+	}
+	relaunched := attempted && err == nil
+
+	if c.json {
+		return c.printJSON(restoreOutput{Success: true, Name: name, Changed: result.Changed, Relaunched: relaunched})
+	}
+
+	if len(result.Changed) == 0 {
+		c.printf("已切換至 %s（SSO cache 已是此帳號，無需變更）\n", name)
+	} else {
+		c.printf("已切換至 %s，變更的檔案:\n", name)
+		for _, path := range result.Changed {
+			c.printf("  %s\n", path)
+		}
+	}
+	if relaunched {
+		c.printf("已重新啟動 Kiro\n")
+	}
+	return nil
+}
+
+// backupDelete 刪除備份
+func (c *cli) backupDelete(name string) error {
+	if !backup.BackupExists(name) {
+		return notFoundError(name)
+	}
+
+	if err := resultError(c.app.DeleteBackup(name)); err != nil {
+		return err
+	}
+	return c.done(fmt.Sprintf("已刪除備份 %s", name))
+}
+
+// backupRename 重新命名備份
+func (c *cli) backupRename(oldName, newName string) error {
+	if !backup.BackupExists(oldName) {
+		return notFoundError(oldName)
+	}
+
+	if err := resultError(c.app.RenameBackup(oldName, newName)); err != nil {
+		return err
+	}
+	return c.done(fmt.Sprintf("已將備份 %s 重新命名為 %s", oldName, newName))
+}
+
+// tokenState 回傳 token 狀態的顯示文字
+func tokenState(item BackupItem) string {
+	switch {
+	case !item.HasToken:
+		return "none"
+	case item.IsTokenExpired:
+		return "expired"
+	default:
+		return "valid"
+	}
+}
+
+// formatBalance 格式化餘額顯示（無緩存時顯示 "-"）
+func formatBalance(balance, limit float64, cached bool) string {
+	if !cached {
+		return "-"
+	}
+	return fmt.Sprintf("%.2f / %.2f", balance, limit)
+}
+
+// dashIfEmpty 空字串顯示為 "-"
+func dashIfEmpty(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
@@ -0,0 +1,65 @@
+//go:build cli
+
+package main
+
+import (
+	"fmt"
+	"text/tabwriter"
+)
+
+// runConnection 處理 connection 子命令
+func (c *cli) runConnection(args []string) error {
+	if len(args) == 0 || len(args) > 2 || args[0] != "test" {
+		return usageErrorf("用法: kiro-manager connection test [url]")
+	}
+
+	var result ConnectionTestResult
+	if len(args) == 2 {
+		result = testConnection(args[1:])
+	} else {
+		result = c.app.TestConnection()
+	}
+
+	if c.json {
+		if err := c.printJSON(result); err != nil {
+			return err
+		}
+	} else if err := c.printConnectionReports(result); err != nil {
+		return err
+	}
+
+	if !result.Success {
+		return &cliError{code: exitUnavailable, err: fmt.Errorf("%s", result.Message), printed: true}
+	}
+	return nil
+}
+
+// printConnectionReports 以表格輸出各端點的逐段測試結果
+func (c *cli) printConnectionReports(result ConnectionTestResult) error {
+	for i, report := range result.Reports {
+		if i > 0 {
+			c.printf("\n")
+		}
+		route := "直連"
+		if report.Proxy != "" {
+			route = "經由代理 " + report.Proxy
+		}
+		c.printf("%s（%s）\n", report.URL, route)
+
+		w := tabwriter.NewWriter(c.out, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "HOP\tTARGET\tRESULT\tTIME")
+		for _, hop := range report.Hops {
+			status := "OK " + hop.Detail
+			if !hop.OK {
+				status = "FAIL " + hop.Error
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%dms\n", hop.Hop, hop.Target, status, hop.DurationMs)
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+	}
+
+	c.printf("\n%s\n", result.Message)
+	return nil
+}
@@ -0,0 +1,89 @@
+//go:build cli
+
+package main
+
+import (
+	"fmt"
+	"text/tabwriter"
+
+	"kiro-manager/internal/endpoints"
+)
+
+// diagExportResult diag export 的輸出結構
+type diagExportResult struct {
+	Success bool   `json:"success"`
+	Path    string `json:"path"`
+}
+
+// runDiag 處理 diag 子命令
+func (c *cli) runDiag(args []string) error {
+	if len(args) > 0 && args[0] == "export" {
+		if len(args) > 2 {
+			return usageErrorf("用法: kiro-manager diag export [path]")
+		}
+		dest := ""
+		if len(args) == 2 {
+			dest = args[1]
+		}
+		return c.diagExport(dest)
+	}
+	if err := requireArgs(args, 0, "diag | diag export [path]"); err != nil {
+		return err
+	}
+	return c.diagShow()
+}
+
+// diagExport 匯出診斷記錄
+func (c *cli) diagExport(dest string) error {
+	path, err := c.app.exportDiagnostics(dest)
+	if err != nil {
+		return fmt.Errorf("匯出診斷記錄失敗: %w", err)
+	}
+	if c.json {
+		return c.printJSON(diagExportResult{Success: true, Path: path})
+	}
+	c.printf("診斷記錄已匯出至 %s\n", path)
+	return nil
+}
+
+// diagShow 顯示診斷資訊（資料路徑、目前帳號與解析後的 API 端點）
+func (c *cli) diagShow() error {
+	diag := c.app.GetDiagnostics()
+	if c.json {
+		return c.printJSON(diag)
+	}
+
+	region := diag.Endpoints.Region
+	if diag.Endpoints.RegionSource == endpoints.SourceDefault {
+		region += "（預設）"
+	}
+	usageRegion := diag.Endpoints.UsageRegion
+	if diag.Endpoints.UsageRegionSource == endpoints.SourceDefault {
+		usageRegion += "（預設）"
+	}
+
+	w := tabwriter.NewWriter(c.out, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "版本\t%s\n", diag.Version)
+	fmt.Fprintf(w, "平台\t%s\n", diag.Platform)
+	fmt.Fprintf(w, "資料目錄\t%s\n", diag.DataDir)
+	fmt.Fprintf(w, "設定檔\t%s\n", diag.SettingsPath)
+	fmt.Fprintf(w, "SSO 快取\t%s\n", diag.SSOCachePath)
+	fmt.Fprintf(w, "Kiro 執行中\t%t\n", diag.KiroRunning)
+	fmt.Fprintf(w, "認證類型\t%s\n", dashIfEmpty(diag.AuthType))
+	fmt.Fprintf(w, "Provider\t%s\n", dashIfEmpty(diag.Provider))
+	fmt.Fprintf(w, "Token 已過期\t%t\n", diag.TokenExpired)
+	fmt.Fprintf(w, "目前備份\t%s\n", dashIfEmpty(diag.CurrentBackup))
+	fmt.Fprintf(w, "SSO 區域\t%s\n", region)
+	fmt.Fprintf(w, "Profile 區域\t%s\n", usageRegion)
+	fmt.Fprintf(w, "IdC 刷新端點\t%s%s\n", diag.Endpoints.IdCRefreshURL, overriddenMark(diag.Endpoints.IdCOverridden))
+	fmt.Fprintf(w, "用量 API 端點\t%s%s\n", diag.Endpoints.UsageBaseURL, overriddenMark(diag.Endpoints.UsageOverridden))
+	return w.Flush()
+}
+
+// overriddenMark 端點來自設定覆寫時加上標記
+func overriddenMark(overridden bool) string {
+	if overridden {
+		return "（設定覆寫）"
+	}
+	return ""
+}
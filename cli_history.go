@@ -0,0 +1,128 @@
+//go:build cli
+
+package main
+
+import (
+	"fmt"
+	"text/tabwriter"
+	"time"
+
+	"kiro-manager/backup"
+	"kiro-manager/settings"
+	"kiro-manager/usagehistory"
+)
+
+// historyPruneResult history prune 的輸出結構
+type historyPruneResult struct {
+	Success       bool `json:"success"`
+	Removed       int  `json:"removed"`
+	RetentionDays int  `json:"retentionDays"`
+}
+
+// runHistory 處理 history 子命令
+func (c *cli) runHistory(args []string) error {
+	if len(args) == 0 {
+		return usageErrorf("用法: kiro-manager history show <name> | history forecast <name> | history prune")
+	}
+
+	switch args[0] {
+	case "show":
+		if err := requireArgs(args[1:], 1, "history show <name>"); err != nil {
+			return err
+		}
+		return c.historyShow(args[1])
+	case "forecast":
+		if err := requireArgs(args[1:], 1, "history forecast <name>"); err != nil {
+			return err
+		}
+		return c.historyForecast(args[1])
+	case "prune":
+		if err := requireArgs(args[1:], 0, "history prune"); err != nil {
+			return err
+		}
+		return c.historyPrune()
+	default:
+		return usageErrorf("未知的 history 子命令: %s", args[0])
+	}
+}
+
+// historyShow 列出備份的用量歷史
+func (c *cli) historyShow(name string) error {
+	if !backup.BackupExists(name) {
+		return notFoundError(name)
+	}
+
+	records, err := usagehistory.Read(name)
+	if err != nil {
+		return err
+	}
+
+	if c.json {
+		return c.printJSON(records)
+	}
+
+	if len(records) == 0 {
+		c.printf("尚無用量歷史\n")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(c.out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "TIME\tSUBSCRIPTION\tUSED\tLIMIT")
+	for _, r := range records {
+		fmt.Fprintf(w, "%s\t%s\t%.2f\t%.2f\n",
+			r.Time.Local().Format(time.RFC3339), dashIfEmpty(r.SubscriptionTitle), r.CurrentUsage, r.UsageLimit)
+	}
+	return w.Flush()
+}
+
+// historyForecast 依用量歷史顯示消耗速率、預估用完日期與每週用量
+func (c *cli) historyForecast(name string) error {
+	if !backup.BackupExists(name) {
+		return notFoundError(name)
+	}
+
+	forecast, err := usageForecast(name, time.Now())
+	if err != nil {
+		return err
+	}
+
+	if c.json {
+		return c.printJSON(forecast)
+	}
+
+	w := tabwriter.NewWriter(c.out, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "訂閱類型\t%s\n", dashIfEmpty(forecast.SubscriptionTitle))
+	fmt.Fprintf(w, "已使用\t%.2f / %.2f\n", forecast.CurrentUsage, forecast.UsageLimit)
+	fmt.Fprintf(w, "剩餘\t%.2f\n", forecast.Remaining)
+	fmt.Fprintf(w, "紀錄\t%d 筆（%s 起）\n", forecast.SampleCount, forecast.Since)
+	if !forecast.HasEnoughData {
+		fmt.Fprintf(w, "預測\t資料不足（需至少 2 筆且跨度 1 小時以上的紀錄）\n")
+	} else {
+		fmt.Fprintf(w, "每日用量\t%.2f\n", forecast.DailyBurnRate)
+		if forecast.WillExhaust {
+			fmt.Fprintf(w, "預估用完\t%s（%.1f 天後）\n", forecast.ExhaustsAt, forecast.DaysUntilExhausted)
+		} else {
+			fmt.Fprintf(w, "預估用完\t-\n")
+		}
+		fmt.Fprintf(w, "撐到月底\t%t\n", forecast.LastsUntilMonthEnd)
+	}
+	for _, week := range forecast.Weekly {
+		fmt.Fprintf(w, "週 %s\t%.2f\n", week.WeekStart, week.Used)
+	}
+	return w.Flush()
+}
+
+// historyPrune 依設定的保留天數清理所有備份的用量歷史
+func (c *cli) historyPrune() error {
+	retention := settings.GetHistoryRetentionDays()
+	removed, err := usagehistory.PruneAll(retention, time.Now())
+	if err != nil {
+		return fmt.Errorf("清理用量歷史失敗: %w", err)
+	}
+
+	if c.json {
+		return c.printJSON(historyPruneResult{Success: true, Removed: removed, RetentionDays: retention})
+	}
+	c.printf("已清除 %d 筆超過 %d 天的用量紀錄\n", removed, retention)
+	return nil
+}
@@ -0,0 +1,191 @@
+//go:build cli
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"kiro-manager/kiropath"
+	"kiro-manager/kiroprocess"
+	"kiro-manager/settings"
+)
+
+// kiroStatus kiro status 的輸出結構
+type kiroStatus struct {
+	Running   bool                      `json:"running"`
+	Processes []kiroprocess.ProcessInfo `json:"processes"`
+}
+
+// kiroStopResult kiro stop 的輸出結構
+type kiroStopResult struct {
+	Success  bool   `json:"success"`
+	Message  string `json:"message"`
+	Killed   int    `json:"killed"`   // 關閉的進程總數
+	Forced   int    `json:"forced"`   // 逾時後被強制終止的進程數
+	Graceful bool   `json:"graceful"` // 是否全部正常結束
+}
+
+// kiroStartResult kiro start 的輸出結構
+type kiroStartResult struct {
+	Success bool                    `json:"success"`
+	Message string                  `json:"message"`
+	Process kiroprocess.ProcessInfo `json:"process"`
+}
+
+// runKiro 處理 kiro 子命令
+func (c *cli) runKiro(args []string) error {
+	if len(args) == 0 {
+		return usageErrorf("用法: kiro-manager kiro status|stop|start|watch")
+	}
+	if args[0] != "start" && len(args) != 1 {
+		return usageErrorf("用法: kiro-manager kiro %s", args[0])
+	}
+
+	switch args[0] {
+	case "status":
+		return c.kiroStatus()
+	case "stop":
+		return c.kiroStop()
+	case "start":
+		return c.kiroStart(args[1:])
+	case "watch":
+		return c.kiroWatch()
+	default:
+		return usageErrorf("未知的 kiro 子命令: %s", args[0])
+	}
+}
+
+// kiroStatus 顯示 Kiro 進程狀態
+func (c *cli) kiroStatus() error {
+	processes, err := kiroprocess.GetKiroProcesses()
+	if err != nil {
+		return fmt.Errorf("無法取得 Kiro 進程: %w", err)
+	}
+	if processes == nil {
+		processes = []kiroprocess.ProcessInfo{}
+	}
+
+	status := kiroStatus{Running: len(processes) > 0, Processes: processes}
+	if c.json {
+		return c.printJSON(status)
+	}
+
+	if !status.Running {
+		c.printf("Kiro 未執行\n")
+		return nil
+	}
+
+	c.printf("Kiro 執行中（%d 個進程）\n", len(processes))
+	w := tabwriter.NewWriter(c.out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "PID\tPPID\tROLE\tEXECUTABLE")
+	for _, p := range processes {
+		fmt.Fprintf(w, "%d\t%d\t%s\t%s\n", p.PID, p.PPID, p.Role, p.ExePath)
+	}
+	return w.Flush()
+}
+
+// kiroStop 關閉所有 Kiro 進程
+// 先要求 Kiro 正常結束，超過 --timeout（預設為 shutdown.timeoutSeconds）秒後強制終止
+func (c *cli) kiroStop() error {
+	timeout := settings.GetShutdownTimeout()
+	if value := c.flagValue("timeout"); value != "" {
+		seconds, err := strconv.Atoi(value)
+		if err != nil || seconds <= 0 {
+			return usageErrorf("--timeout 需為正整數秒數: %s", value)
+		}
+		timeout = time.Duration(seconds) * time.Second
+	}
+
+	shutdown, err := kiroprocess.Shutdown(context.Background(), kiroprocess.ShutdownOptions{
+		Timeout: timeout,
+		OnProgress: func(p kiroprocess.Progress) {
+			emitShutdownProgress(nil, p)
+		},
+	})
+	if errors.Is(err, kiroprocess.ErrStillRunning) {
+		return errors.New("無法關閉 Kiro，請手動關閉後重試")
+	}
+	if err != nil {
+		return fmt.Errorf("關閉 Kiro 失敗: %w", err)
+	}
+
+	result := kiroStopResult{Success: true, Killed: shutdown.Total, Forced: shutdown.Forced}
+	switch {
+	case shutdown.Total == 0:
+		result.Message = "Kiro 未執行"
+	case shutdown.Graceful():
+		result.Graceful = true
+		result.Message = fmt.Sprintf("已關閉 %d 個 Kiro 進程", shutdown.Total)
+	default:
+		result.Message = fmt.Sprintf("已關閉 %d 個 Kiro 進程（%d 個逾時後強制終止）", shutdown.Total, shutdown.Forced)
+	}
+
+	if c.json {
+		return c.printJSON(result)
+	}
+	c.printf("%s\n", result.Message)
+	return nil
+}
+
+// kiroStart 啟動 Kiro（可指定要開啟的資料夾或檔案），並等待主進程出現
+func (c *cli) kiroStart(paths []string) error {
+	absPaths := make([]string, 0, len(paths))
+	for _, path := range paths {
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			return usageErrorf("無效的路徑: %s", path)
+		}
+		absPaths = append(absPaths, abs)
+	}
+
+	process, err := c.app.launchKiro(absPaths)
+	if err != nil {
+		if errors.Is(err, kiropath.ErrKiroNotFound) {
+			return errors.New("找不到 Kiro 安裝位置")
+		}
+		return fmt.Errorf("啟動 Kiro 失敗: %w", err)
+	}
+
+	result := kiroStartResult{Success: true, Message: fmt.Sprintf("已啟動 Kiro（PID %d）", process.PID), Process: process}
+	if c.json {
+		return c.printJSON(result)
+	}
+	c.printf("%s\n", result.Message)
+	return nil
+}
+
+// kiroWatch 持續輸出 Kiro 的啟動與結束，直到收到中斷信號
+// 第一行為目前的狀態；--json 時每個事件輸出為一行 JSON
+func (c *cli) kiroWatch() error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	encoder := json.NewEncoder(c.out)
+	encoder.SetEscapeHTML(false)
+	for e := range kiroprocess.Watch(ctx, kiroprocess.WatchOptions{EmitInitial: true}) {
+		if c.json {
+			if err := encoder.Encode(e); err != nil {
+				return err
+			}
+			continue
+		}
+
+		timestamp := e.Time.Format("2006-01-02 15:04:05")
+		if e.Type == kiroprocess.EventStarted {
+			c.printf("%s  Kiro 已啟動（%d 個進程）\n", timestamp, len(e.Processes))
+		} else {
+			c.printf("%s  Kiro 未執行\n", timestamp)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,37 @@
+//go:build cli
+
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"kiro-manager/backup"
+	"kiro-manager/kiropath"
+)
+
+// runOpen 處理 open 命令：切換至目錄綁定的帳號後以 Kiro 開啟目錄
+// 需要切換帳號且 Kiro 正在執行時，需要 --force 才會關閉 Kiro
+func (c *cli) runOpen(args []string) error {
+	if err := requireArgs(args, 1, "open <dir> [--force]"); err != nil {
+		return err
+	}
+
+	result, err := c.app.openWorkspace(args[0], c.hasFlag("force"))
+	switch {
+	case errors.Is(err, errKiroRunning):
+		return fmt.Errorf("Kiro 正在執行，切換至 %s 需要關閉 Kiro，請先關閉或加上 --force", result.Backup)
+	case errors.Is(err, backup.ErrBackupNotFound):
+		return notFoundError(result.Backup)
+	case errors.Is(err, kiropath.ErrKiroNotFound):
+		return errors.New("找不到 Kiro 安裝位置")
+	case err != nil:
+		return err
+	}
+
+	if c.json {
+		return c.printJSON(result)
+	}
+	c.printf("%s\n", result.Message)
+	return nil
+}
@@ -0,0 +1,59 @@
+//go:build cli
+
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"kiro-manager/report"
+)
+
+// reportExportResult report 寫入檔案時的輸出結構
+type reportExportResult struct {
+	Success  bool   `json:"success"`
+	Path     string `json:"path"`
+	RowCount int    `json:"rowCount"`
+}
+
+// runReport 處理 report 命令
+// 未指定 path 時報表輸出至 stdout（--json 且未指定 --format 時使用 JSON 格式）
+func (c *cli) runReport(args []string) error {
+	if len(args) > 1 {
+		return usageErrorf("用法: kiro-manager report [path] [--format csv|json|md] [--from YYYY-MM-DD] [--to YYYY-MM-DD]")
+	}
+
+	formatValue := c.flagValue("format")
+	if formatValue == "" {
+		formatValue = report.FormatCSV
+		if c.json && len(args) == 0 {
+			formatValue = report.FormatJSON
+		}
+	}
+	format, err := report.ParseFormat(formatValue)
+	if err != nil {
+		return usageErrorf("不支援的報表格式: %s（可用 csv、json、md）", formatValue)
+	}
+	r, err := report.ParseRange(c.flagValue("from"), c.flagValue("to"), time.Local)
+	if err != nil {
+		return usageErrorf("%v", err)
+	}
+
+	if len(args) == 0 {
+		usageReport, err := c.app.usageReport(r, time.Now())
+		if err != nil {
+			return err
+		}
+		return report.Write(c.out, format, *usageReport)
+	}
+
+	path, rows, err := c.app.exportUsageReport(format, r, args[0])
+	if err != nil {
+		return fmt.Errorf("匯出報表失敗: %w", err)
+	}
+	if c.json {
+		return c.printJSON(reportExportResult{Success: true, Path: path, RowCount: rows})
+	}
+	c.printf("已匯出 %d 個帳號的用量報表至 %s\n", rows, path)
+	return nil
+}
@@ -0,0 +1,190 @@
+//go:build cli
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"kiro-manager/settings"
+)
+
+// settingValue settings get <key> 的輸出結構
+type settingValue struct {
+	Key   string `json:"key"`
+	Value any    `json:"value"`
+}
+
+// runSettings 處理 settings 子命令
+func (c *cli) runSettings(args []string) error {
+	if len(args) == 0 {
+		return usageErrorf("用法: kiro-manager settings get [key] | settings set <key> <value>")
+	}
+
+	switch args[0] {
+	case "get":
+		if len(args) > 2 {
+			return usageErrorf("用法: kiro-manager settings get [key]")
+		}
+		if len(args) == 1 {
+			return c.settingsGetAll()
+		}
+		return c.settingsGet(args[1])
+	case "set":
+		if err := requireArgs(args[1:], 2, "settings set <key> <value>"); err != nil {
+			return err
+		}
+		return c.settingsSet(args[1], args[2])
+	default:
+		return usageErrorf("未知的 settings 子命令: %s", args[0])
+	}
+}
+
+// settingsGetAll 輸出所有設定（機密值已遮蔽）
+func (c *cli) settingsGetAll() error {
+	s := settings.GetCurrentSettings().Redacted()
+	if c.json {
+		return c.printJSON(s)
+	}
+
+	values := make(map[string]any)
+	flattenSettings(reflect.ValueOf(s), "", values)
+
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	w := tabwriter.NewWriter(c.out, 0, 0, 2, ' ', 0)
+	for _, key := range keys {
+		fmt.Fprintf(w, "%s\t%s\n", key, formatSettingValue(values[key]))
+	}
+	return w.Flush()
+}
+
+// settingsGet 輸出單一設定值（機密值已遮蔽）
+func (c *cli) settingsGet(key string) error {
+	s := settings.GetCurrentSettings().Redacted()
+	field, err := lookupSetting(reflect.ValueOf(&s).Elem(), key)
+	if err != nil {
+		return err
+	}
+
+	value := field.Interface()
+	if c.json {
+		return c.printJSON(settingValue{Key: key, Value: value})
+	}
+	c.printf("%s\n", formatSettingValue(value))
+	return nil
+}
+
+// settingsSet 寫入單一設定值
+// 值以 JSON 解析（數字、布林、陣列、物件）；無法解析且欄位為字串時視為純文字
+func (c *cli) settingsSet(key, raw string) error {
+	s := *settings.GetCurrentSettings()
+	field, err := lookupSetting(reflect.ValueOf(&s).Elem(), key)
+	if err != nil {
+		return err
+	}
+
+	target := reflect.New(field.Type())
+	if err := json.Unmarshal([]byte(raw), target.Interface()); err != nil {
+		if field.Kind() != reflect.String {
+			return usageErrorf("設定 %s 的值無效（需要 %s）: %s", key, field.Type(), raw)
+		}
+		target.Elem().SetString(raw)
+	}
+	field.Set(target.Elem())
+
+	// 與 GUI 相同的儲存流程（變更 dataDir 時會搬移既有備份）
+	if err := c.app.saveSettings(&s); err != nil {
+		return err
+	}
+
+	// SaveSettings 會修正超出範圍的值，輸出實際儲存的結果（機密值已遮蔽）
+	saved := settings.GetCurrentSettings().Redacted()
+	savedField, _ := lookupSetting(reflect.ValueOf(&saved).Elem(), key)
+
+	if c.json {
+		return c.printJSON(settingValue{Key: key, Value: savedField.Interface()})
+	}
+	c.printf("%s = %s\n", key, formatSettingValue(savedField.Interface()))
+	return nil
+}
+
+// lookupSetting 依 JSON 欄位名稱（以 "." 分隔巢狀欄位）取得設定欄位
+func lookupSetting(v reflect.Value, key string) (reflect.Value, error) {
+	for _, part := range strings.Split(key, ".") {
+		if v.Kind() != reflect.Struct {
+			return reflect.Value{}, usageErrorf("未知的設定: %s", key)
+		}
+
+		found := false
+		for i := 0; i < v.NumField(); i++ {
+			if jsonFieldName(v.Type().Field(i)) == part {
+				v = v.Field(i)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return reflect.Value{}, usageErrorf("未知的設定: %s", key)
+		}
+	}
+	return v, nil
+}
+
+// flattenSettings 將設定結構展開為 "a.b" 形式的鍵值
+func flattenSettings(v reflect.Value, prefix string, out map[string]any) {
+	for i := 0; i < v.NumField(); i++ {
+		name := jsonFieldName(v.Type().Field(i))
+		if name == "" {
+			continue
+		}
+		if prefix != "" {
+			name = prefix + "." + name
+		}
+
+		field := v.Field(i)
+		if field.Kind() == reflect.Struct {
+			flattenSettings(field, name, out)
+			continue
+		}
+		out[name] = field.Interface()
+	}
+}
+
+// jsonFieldName 取得欄位的 JSON 名稱（未匯出或 "-" 回傳空字串）
+func jsonFieldName(field reflect.StructField) string {
+	if !field.IsExported() {
+		return ""
+	}
+	tag := field.Tag.Get("json")
+	if tag == "-" {
+		return ""
+	}
+	name, _, _ := strings.Cut(tag, ",")
+	if name == "" {
+		return field.Name
+	}
+	return name
+}
+
+// formatSettingValue 格式化設定值供文字輸出（複合型別以 JSON 顯示）
+func formatSettingValue(value any) string {
+	switch reflect.ValueOf(value).Kind() {
+	case reflect.Slice, reflect.Map, reflect.Struct, reflect.Pointer:
+		data, err := json.Marshal(value)
+		if err != nil {
+			return fmt.Sprint(value)
+		}
+		return string(data)
+	default:
+		return fmt.Sprint(value)
+	}
+}
@@ -0,0 +1,253 @@
+//go:build cli
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"text/tabwriter"
+	"time"
+
+	"kiro-manager/backup"
+	"kiro-manager/machineid"
+	"kiro-manager/usage"
+)
+
+// usageEntry usage 列表的輸出結構
+type usageEntry struct {
+	Name              string  `json:"name"`
+	SubscriptionTitle string  `json:"subscriptionTitle"`
+	UsageLimit        float64 `json:"usageLimit"`
+	CurrentUsage      float64 `json:"currentUsage"`
+	Balance           float64 `json:"balance"`
+	IsLowBalance      bool    `json:"isLowBalance"`
+	CachedAt          string  `json:"cachedAt"`
+	NextResetAt       string  `json:"nextResetAt"`
+	DaysUntilReset    int     `json:"daysUntilReset"`
+}
+
+// tokenRefreshResult token refresh 的輸出結構
+type tokenRefreshResult struct {
+	Success   bool   `json:"success"`
+	Message   string `json:"message"`
+	Name      string `json:"name"`
+	ExpiresAt string `json:"expiresAt"`
+}
+
+// runUsage 處理 usage 子命令
+// 無參數時列出緩存用量；指定名稱時刷新該備份；--current 取得目前登入帳號
+func (c *cli) runUsage(args []string) error {
+	if c.hasFlag("current") {
+		if len(args) != 0 {
+			return usageErrorf("--current 不可與備份名稱同時使用")
+		}
+		return c.usageCurrent()
+	}
+
+	switch len(args) {
+	case 0:
+		return c.usageList()
+	case 1:
+		return c.usageRefresh(args[0])
+	default:
+		return usageErrorf("用法: kiro-manager usage [name|--current]")
+	}
+}
+
+// usageList 列出所有備份的緩存用量（不呼叫 API）
+func (c *cli) usageList() error {
+	items, err := c.app.GetBackupList()
+	if err != nil {
+		return err
+	}
+
+	entries := []usageEntry{}
+	for _, item := range items {
+		entries = append(entries, usageEntry{
+			Name:              item.Name,
+			SubscriptionTitle: item.SubscriptionTitle,
+			UsageLimit:        item.UsageLimit,
+			CurrentUsage:      item.CurrentUsage,
+			Balance:           item.Balance,
+			IsLowBalance:      item.IsLowBalance,
+			CachedAt:          item.CachedAt,
+			NextResetAt:       item.NextResetAt,
+			DaysUntilReset:    item.DaysUntilReset,
+		})
+	}
+
+	if c.json {
+		return c.printJSON(entries)
+	}
+
+	if len(entries) == 0 {
+		c.printf("尚無備份\n")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(c.out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tSUBSCRIPTION\tUSED\tBALANCE\tLOW\tRESETS IN\tCACHED AT")
+	for _, e := range entries {
+		if e.CachedAt == "" {
+			fmt.Fprintf(w, "%s\t-\t-\t-\t-\t-\t-\n", e.Name)
+			continue
+		}
+		fmt.Fprintf(w, "%s\t%s\t%.2f\t%s\t%v\t%s\t%s\n",
+			e.Name, dashIfEmpty(e.SubscriptionTitle), e.CurrentUsage,
+			formatBalance(e.Balance, e.UsageLimit, true), e.IsLowBalance,
+			formatDaysUntilReset(e.NextResetAt, e.DaysUntilReset), e.CachedAt)
+	}
+	return w.Flush()
+}
+
+// usageRefresh 刷新指定備份的用量（Token 過期時會自動刷新）
+func (c *cli) usageRefresh(name string) error {
+	if !backup.BackupExists(name) {
+		return notFoundError(name)
+	}
+
+	result, err := c.app.refreshBackupUsage(name)
+	if err != nil {
+		return err
+	}
+
+	if c.json {
+		return c.printJSON(result)
+	}
+
+	c.printUsage(name, result.SubscriptionTitle, result.CurrentUsage, result.Balance, result.UsageLimit, result.IsLowBalance,
+		result.NextResetAt, result.DaysUntilReset)
+	c.printBreakdown(result.Breakdown, result.Credits)
+	return nil
+}
+
+// usageCurrent 取得目前登入帳號的用量
+func (c *cli) usageCurrent() error {
+	info := c.app.GetCurrentUsageInfo()
+	if info == nil {
+		return errors.New("無法取得目前帳號的用量資訊")
+	}
+
+	if c.json {
+		return c.printJSON(info)
+	}
+
+	c.printUsage("(current)", info.SubscriptionTitle, info.CurrentUsage, info.Balance, info.UsageLimit, info.IsLowBalance,
+		info.NextResetAt, info.DaysUntilReset)
+	c.printBreakdown(info.Breakdown, info.Credits)
+	return nil
+}
+
+// printUsage 輸出單一帳號的用量
+func (c *cli) printUsage(name, title string, used, balance, limit float64, low bool, nextResetAt string, daysUntilReset int) {
+	w := tabwriter.NewWriter(c.out, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "Name:\t%s\n", name)
+	fmt.Fprintf(w, "Subscription:\t%s\n", dashIfEmpty(title))
+	fmt.Fprintf(w, "Used:\t%.2f\n", used)
+	fmt.Fprintf(w, "Balance:\t%s\n", formatBalance(balance, limit, true))
+	fmt.Fprintf(w, "Low Balance:\t%v\n", low)
+	if nextResetAt != "" {
+		fmt.Fprintf(w, "Resets:\t%s（%s後）\n", formatExpiresAt(nextResetAt), formatDaysUntilReset(nextResetAt, daysUntilReset))
+	}
+	w.Flush()
+}
+
+// formatDaysUntilReset 格式化距離重置的天數
+func formatDaysUntilReset(nextResetAt string, days int) string {
+	if nextResetAt == "" {
+		return "-"
+	}
+	return fmt.Sprintf("%d 天", days)
+}
+
+// printBreakdown 輸出基本、試用與獎勵額度明細，以及最先到期的額度
+func (c *cli) printBreakdown(breakdown []usage.Breakdown, credits []usage.Credit) {
+	if len(breakdown) == 0 {
+		return
+	}
+
+	c.printf("\n")
+	w := tabwriter.NewWriter(c.out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "CATEGORY\tKIND\tUSED\tLIMIT\tSTATUS\tEXPIRES")
+	for _, b := range breakdown {
+		name := dashIfEmpty(b.DisplayName)
+		fmt.Fprintf(w, "%s\t%s\t%.2f\t%.2f\t-\t-\n", name, usage.CreditBase, b.BaseUsage, b.BaseLimit)
+		if b.FreeTrialStatus != "" {
+			fmt.Fprintf(w, "%s\t%s\t%.2f\t%.2f\t%s\t%s\n", name, usage.CreditTrial, b.TrialUsage, b.TrialLimit,
+				b.FreeTrialStatus, formatCreditExpiry(b.FreeTrialExpiry))
+		}
+		for _, bonus := range b.Bonuses {
+			fmt.Fprintf(w, "%s\t%s:%s\t%.2f\t%.2f\t%s\t%s\n", name, usage.CreditBonus, dashIfEmpty(bonus.BonusCode),
+				bonus.CurrentUsage, bonus.UsageLimit, dashIfEmpty(bonus.Status), formatCreditExpiry(bonus.ExpiresAt))
+		}
+	}
+	w.Flush()
+
+	if len(credits) > 0 && credits[0].ExpiresAt != "" {
+		first := credits[0]
+		c.printf("\n最先到期: %s %s（剩餘 %.2f，%s 到期）\n", first.DisplayName, first.Kind, first.Remaining, formatCreditExpiry(first.ExpiresAt))
+	}
+}
+
+// formatCreditExpiry 將額度的到期時間轉為本地時間顯示
+func formatCreditExpiry(expiresAt string) string {
+	if expiresAt == "" {
+		return "-"
+	}
+	return formatExpiresAt(expiresAt)
+}
+
+// runToken 處理 token 子命令
+func (c *cli) runToken(args []string) error {
+	if len(args) == 0 || args[0] != "refresh" {
+		return usageErrorf("用法: kiro-manager token refresh <name>")
+	}
+	if err := requireArgs(args[1:], 1, "token refresh <name>"); err != nil {
+		return err
+	}
+	return c.tokenRefresh(args[1])
+}
+
+// tokenRefresh 強制刷新備份的 AccessToken（不論是否過期）
+func (c *cli) tokenRefresh(name string) error {
+	if !backup.BackupExists(name) {
+		return notFoundError(name)
+	}
+
+	mid, err := backup.ReadBackupMachineID(name)
+	if err != nil {
+		return fmt.Errorf("無法讀取備份的 Machine ID: %w", err)
+	}
+
+	token, err := backup.ReadBackupToken(name)
+	if err != nil {
+		return fmt.Errorf("無法讀取備份的 token: %w", err)
+	}
+
+	if err := refreshBackupToken(name, token, machineid.HashMachineID(mid.MachineID)); err != nil {
+		return err
+	}
+
+	result := tokenRefreshResult{
+		Success:   true,
+		Message:   "Token 刷新成功",
+		Name:      name,
+		ExpiresAt: token.ExpiresAt,
+	}
+
+	if c.json {
+		return c.printJSON(result)
+	}
+
+	c.printf("%s（%s，有效至 %s）\n", result.Message, name, formatExpiresAt(token.ExpiresAt))
+	return nil
+}
+
+// formatExpiresAt 將 token 的 UTC 過期時間轉為本地時間顯示
+func formatExpiresAt(expiresAt string) string {
+	t, err := time.Parse(time.RFC3339, expiresAt)
+	if err != nil {
+		return expiresAt
+	}
+	return t.Local().Format("2006-01-02 15:04:05")
+}
@@ -0,0 +1,52 @@
+//go:build !cli
+
+package main
+
+import (
+	"context"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+
+	"kiro-manager/alerts"
+	"kiro-manager/kiroprocess"
+)
+
+// 前端監聽的事件名稱
+const (
+	alertEventName    = "usage:alert"   // 用量警示
+	shutdownEventName = "kiro:shutdown" // 關閉 Kiro 的進度
+	kiroEventPrefix   = "kiro:"         // Kiro 啟動與結束（kiro:started、kiro:stopped）
+)
+
+// emitAlerts 將用量警示送往前端
+func emitAlerts(ctx context.Context, events []alerts.Event) {
+	if ctx == nil {
+		return
+	}
+	for _, e := range events {
+		runtime.EventsEmit(ctx, alertEventName, e)
+	}
+}
+
+// notifyAlerts 在背景送出桌面通知與執行使用者指令，不阻塞用量刷新
+func notifyAlerts(n alerts.Notifier, events []alerts.Event) {
+	go n.Send(events)
+}
+
+// emitShutdownProgress 將關閉 Kiro 的進度送往前端
+func emitShutdownProgress(ctx context.Context, p kiroprocess.Progress) {
+	if ctx == nil {
+		return
+	}
+	runtime.EventsEmit(ctx, shutdownEventName, p)
+}
+
+// watchKiro 在背景監看 Kiro 的啟動與結束並送往前端，ctx 結束時停止
+func watchKiro(ctx context.Context) {
+	events := kiroprocess.Watch(ctx, kiroprocess.WatchOptions{})
+	go func() {
+		for e := range events {
+			runtime.EventsEmit(ctx, kiroEventPrefix+e.Type, e)
+		}
+	}()
+}
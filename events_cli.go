@@ -0,0 +1,33 @@
+//go:build cli
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"kiro-manager/alerts"
+	"kiro-manager/kiroprocess"
+)
+
+// emitAlerts CLI 沒有前端可接收事件
+func emitAlerts(ctx context.Context, events []alerts.Event) {}
+
+// notifyAlerts 同步送出桌面通知與執行使用者指令（避免命令結束時中斷）
+func notifyAlerts(n alerts.Notifier, events []alerts.Event) {
+	n.Send(events)
+}
+
+// emitShutdownProgress 將關閉 Kiro 的進度輸出至 stderr（stdout 保留給命令結果）
+func emitShutdownProgress(ctx context.Context, p kiroprocess.Progress) {
+	switch p.Stage {
+	case kiroprocess.StageRequest:
+		fmt.Fprintf(os.Stderr, "已要求 Kiro 結束，等待 %d 個進程關閉...\n", p.Remaining)
+	case kiroprocess.StageEscalate:
+		fmt.Fprintf(os.Stderr, "Kiro 未在時限內結束，強制終止剩餘 %d 個進程\n", p.Remaining)
+	}
+}
+
+// watchKiro CLI 沒有前端可接收事件，由 kiro watch 命令自行監看
+func watchKiro(ctx context.Context) {}
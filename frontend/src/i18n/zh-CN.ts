@@ -8,6 +8,7 @@ export default {
     processing: '处理中...',
     kiroRunning: 'KIRO 运行中',
     kiroStopped: 'KIRO 未运行',
+    launchKiro: '启动 KIRO',
   },
   menu: {
     dashboard: '控制中心',
@@ -55,6 +56,13 @@ export default {
     local: 'Local',
     refresh: '刷新余额',
   },
+  usage: {
+    base: '基本额度',
+    trial: '试用',
+    bonus: '奖励',
+    expires: '到期',
+    resetsIn: '{days} 天后重置',
+  },
   restore: {
     original: '还原出厂',
     reset: '一键新机',
@@ -86,6 +94,13 @@ export default {
     detectVersion: '自动检测',
     detectVersionFailed: '检测失败',
     autoDetectActive: '自动检测中',
+    workspaceBindings: '工作区绑定',
+    workspaceBindingsDesc: '为项目文件夹绑定备份账号，打开时自动切换到该账号（子文件夹套用最深的绑定）',
+    noWorkspaceBindings: '暂无绑定',
+    workspacePathPlaceholder: '文件夹绝对路径',
+    selectBackup: '选择备份',
+    addWorkspaceBinding: '绑定',
+    openWorkspace: '打开',
   },
   dialog: {
     confirmTitle: '确认操作',
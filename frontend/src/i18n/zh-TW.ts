@@ -8,6 +8,7 @@ export default {
     processing: '處理中...',
     kiroRunning: 'KIRO 運行中',
     kiroStopped: 'KIRO 未運行',
+    launchKiro: '啟動 KIRO',
   },
   menu: {
     dashboard: '控制中心',
@@ -55,6 +56,13 @@ export default {
     local: 'Local',
     refresh: '刷新餘額',
   },
+  usage: {
+    base: '基本額度',
+    trial: '試用',
+    bonus: '獎勵',
+    expires: '到期',
+    resetsIn: '{days} 天後重置',
+  },
   restore: {
     original: '還原出廠',
     reset: '一鍵新機',
@@ -86,6 +94,13 @@ export default {
     detectVersion: '自動偵測',
     detectVersionFailed: '偵測失敗',
     autoDetectActive: '自動偵測中',
+    workspaceBindings: '工作區綁定',
+    workspaceBindingsDesc: '為專案資料夾綁定備份帳號，開啟時自動切換到該帳號（子資料夾套用最深的綁定）',
+    noWorkspaceBindings: '尚無綁定',
+    workspacePathPlaceholder: '資料夾絕對路徑',
+    selectBackup: '選擇備份',
+    addWorkspaceBinding: '綁定',
+    openWorkspace: '開啟',
   },
   dialog: {
     confirmTitle: '確認操作',
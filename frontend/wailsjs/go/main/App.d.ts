@@ -2,6 +2,7 @@
 // This file is automatically generated. DO NOT EDIT
 import {main} from '../models';
 import {kiroprocess} from '../models';
+import {workspace} from '../models';
 
 export function CreateBackup(arg1:string):Promise<main.Result>;
 
@@ -9,6 +10,10 @@ export function DeleteBackup(arg1:string):Promise<main.Result>;
 
 export function EnsureOriginalBackup():Promise<main.Result>;
 
+export function ExportDiagnosticsLog():Promise<main.Result>;
+
+export function ExportUsageReport(arg1:string,arg2:string,arg3:string):Promise<main.UsageReportResult>;
+
 export function GetAppInfo():Promise<Record<string, string>>;
 
 export function GetBackupList():Promise<Array<main.BackupItem>>;
@@ -21,22 +26,40 @@ export function GetCurrentUsageInfo():Promise<main.CurrentUsageInfo>;
 
 export function GetDetectedKiroVersion():Promise<main.Result>;
 
+export function GetDiagnostics():Promise<main.Diagnostics>;
+
 export function GetKiroProcesses():Promise<Array<kiroprocess.ProcessInfo>>;
 
 export function GetSettings():Promise<main.AppSettings>;
 
 export function GetSoftResetStatus():Promise<main.SoftResetStatus>;
 
+export function GetStartupWarning():Promise<string>;
+
+export function GetUsageForecast(arg1:string):Promise<main.UsageForecastResult>;
+
+export function GetWorkspaceBindings():Promise<Array<workspace.Binding>>;
+
 export function IsKiroRunning():Promise<boolean>;
 
+export function LaunchKiro():Promise<main.Result>;
+
 export function OpenExtensionFolder():Promise<main.Result>;
 
 export function OpenMachineIDFolder():Promise<main.Result>;
 
 export function OpenSSOCacheFolder():Promise<main.Result>;
 
+export function OpenWorkspace(arg1:string):Promise<main.OpenWorkspaceResult>;
+
+export function PruneUsageHistory():Promise<main.Result>;
+
 export function RefreshBackupUsage(arg1:string):Promise<main.UsageCacheResult>;
 
+export function RemoveWorkspaceBinding(arg1:string):Promise<main.Result>;
+
+export function RenameBackup(arg1:string,arg2:string):Promise<main.Result>;
+
 export function RepatchExtension():Promise<main.Result>;
 
 export function ResetToNewMachine():Promise<main.Result>;
@@ -47,8 +70,14 @@ export function RestoreSoftReset():Promise<main.Result>;
 
 export function SaveSettings(arg1:main.AppSettings):Promise<main.Result>;
 
+export function SetWorkspaceBinding(arg1:string,arg2:string):Promise<main.Result>;
+
 export function SoftResetToNewMachine():Promise<main.Result>;
 
 export function SwitchToBackup(arg1:string):Promise<main.Result>;
 
+export function TestConnection():Promise<main.ConnectionTestResult>;
+
+export function UnlockBackups(arg1:string):Promise<main.Result>;
+
 export function UnpatchExtension():Promise<main.Result>;
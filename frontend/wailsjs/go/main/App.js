@@ -14,6 +14,14 @@ export function EnsureOriginalBackup() {
   return window['go']['main']['App']['EnsureOriginalBackup']();
 }
 
+export function ExportDiagnosticsLog() {
+  return window['go']['main']['App']['ExportDiagnosticsLog']();
+}
+
+export function ExportUsageReport(arg1,arg2,arg3) {
+  return window['go']['main']['App']['ExportUsageReport'](arg1,arg2,arg3);
+}
+
 export function GetAppInfo() {
   return window['go']['main']['App']['GetAppInfo']();
 }
@@ -38,6 +46,10 @@ export function GetDetectedKiroVersion() {
   return window['go']['main']['App']['GetDetectedKiroVersion']();
 }
 
+export function GetDiagnostics() {
+  return window['go']['main']['App']['GetDiagnostics']();
+}
+
 export function GetKiroProcesses() {
   return window['go']['main']['App']['GetKiroProcesses']();
 }
@@ -50,10 +62,26 @@ export function GetSoftResetStatus() {
   return window['go']['main']['App']['GetSoftResetStatus']();
 }
 
+export function GetStartupWarning() {
+  return window['go']['main']['App']['GetStartupWarning']();
+}
+
+export function GetUsageForecast(arg1) {
+  return window['go']['main']['App']['GetUsageForecast'](arg1);
+}
+
+export function GetWorkspaceBindings() {
+  return window['go']['main']['App']['GetWorkspaceBindings']();
+}
+
 export function IsKiroRunning() {
   return window['go']['main']['App']['IsKiroRunning']();
 }
 
+export function LaunchKiro() {
+  return window['go']['main']['App']['LaunchKiro']();
+}
+
 export function OpenExtensionFolder() {
   return window['go']['main']['App']['OpenExtensionFolder']();
 }
@@ -66,10 +94,26 @@ export function OpenSSOCacheFolder() {
   return window['go']['main']['App']['OpenSSOCacheFolder']();
 }
 
+export function OpenWorkspace(arg1) {
+  return window['go']['main']['App']['OpenWorkspace'](arg1);
+}
+
+export function PruneUsageHistory() {
+  return window['go']['main']['App']['PruneUsageHistory']();
+}
+
 export function RefreshBackupUsage(arg1) {
   return window['go']['main']['App']['RefreshBackupUsage'](arg1);
 }
 
+export function RemoveWorkspaceBinding(arg1) {
+  return window['go']['main']['App']['RemoveWorkspaceBinding'](arg1);
+}
+
+export function RenameBackup(arg1,arg2) {
+  return window['go']['main']['App']['RenameBackup'](arg1,arg2);
+}
+
 export function RepatchExtension() {
   return window['go']['main']['App']['RepatchExtension']();
 }
@@ -90,6 +134,10 @@ export function SaveSettings(arg1) {
   return window['go']['main']['App']['SaveSettings'](arg1);
 }
 
+export function SetWorkspaceBinding(arg1,arg2) {
+  return window['go']['main']['App']['SetWorkspaceBinding'](arg1,arg2);
+}
+
 export function SoftResetToNewMachine() {
   return window['go']['main']['App']['SoftResetToNewMachine']();
 }
@@ -98,6 +146,14 @@ export function SwitchToBackup(arg1) {
   return window['go']['main']['App']['SwitchToBackup'](arg1);
 }
 
+export function TestConnection() {
+  return window['go']['main']['App']['TestConnection']();
+}
+
+export function UnlockBackups(arg1) {
+  return window['go']['main']['App']['UnlockBackups'](arg1);
+}
+
 export function UnpatchExtension() {
   return window['go']['main']['App']['UnpatchExtension']();
 }
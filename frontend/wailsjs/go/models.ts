@@ -1,8 +1,42 @@
+export namespace endpoints {
+	
+	export class Resolved {
+	    region: string;
+	    regionSource: string;
+	    usageRegion: string;
+	    usageRegionSource: string;
+	    idcRefreshUrl: string;
+	    usageBaseUrl: string;
+	    idcOverridden: boolean;
+	    usageOverridden: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new Resolved(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.region = source["region"];
+	        this.regionSource = source["regionSource"];
+	        this.usageRegion = source["usageRegion"];
+	        this.usageRegionSource = source["usageRegionSource"];
+	        this.idcRefreshUrl = source["idcRefreshUrl"];
+	        this.usageBaseUrl = source["usageBaseUrl"];
+	        this.idcOverridden = source["idcOverridden"];
+	        this.usageOverridden = source["usageOverridden"];
+	    }
+	}
+
+}
+
 export namespace kiroprocess {
 	
 	export class ProcessInfo {
 	    pid: number;
 	    name: string;
+	    exePath: string;
+	    ppid: number;
+	    role: string;
 	
 	    static createFrom(source: any = {}) {
 	        return new ProcessInfo(source);
@@ -12,6 +46,9 @@ export namespace kiroprocess {
 	        if ('string' === typeof source) source = JSON.parse(source);
 	        this.pid = source["pid"];
 	        this.name = source["name"];
+	        this.exePath = source["exePath"];
+	        this.ppid = source["ppid"];
+	        this.role = source["role"];
 	    }
 	}
 
@@ -23,6 +60,15 @@ export namespace main {
 	    lowBalanceThreshold: number;
 	    kiroVersion: string;
 	    useAutoDetect: boolean;
+	    dataDir: string;
+	    retry: settings.RetrySettings;
+	    endpoints: settings.EndpointSettings;
+	    network: settings.NetworkSettings;
+	    logging: settings.LoggingSettings;
+	    history: settings.HistorySettings;
+	    alerts: settings.AlertSettings;
+	    shutdown: settings.ShutdownSettings;
+	    relaunch: settings.RelaunchSettings;
 	
 	    static createFrom(source: any = {}) {
 	        return new AppSettings(source);
@@ -33,7 +79,34 @@ export namespace main {
 	        this.lowBalanceThreshold = source["lowBalanceThreshold"];
 	        this.kiroVersion = source["kiroVersion"];
 	        this.useAutoDetect = source["useAutoDetect"];
+	        this.dataDir = source["dataDir"];
+	        this.retry = this.convertValues(source["retry"], settings.RetrySettings);
+	        this.endpoints = this.convertValues(source["endpoints"], settings.EndpointSettings);
+	        this.network = this.convertValues(source["network"], settings.NetworkSettings);
+	        this.logging = this.convertValues(source["logging"], settings.LoggingSettings);
+	        this.history = this.convertValues(source["history"], settings.HistorySettings);
+	        this.alerts = this.convertValues(source["alerts"], settings.AlertSettings);
+	        this.shutdown = this.convertValues(source["shutdown"], settings.ShutdownSettings);
+	        this.relaunch = this.convertValues(source["relaunch"], settings.RelaunchSettings);
 	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
 	}
 	export class BackupItem {
 	    name: string;
@@ -51,6 +124,9 @@ export namespace main {
 	    balance: number;
 	    isLowBalance: boolean;
 	    cachedAt: string;
+	    breakdown: usage.Breakdown[];
+	    nextResetAt: string;
+	    daysUntilReset: number;
 	
 	    static createFrom(source: any = {}) {
 	        return new BackupItem(source);
@@ -73,7 +149,62 @@ export namespace main {
 	        this.balance = source["balance"];
 	        this.isLowBalance = source["isLowBalance"];
 	        this.cachedAt = source["cachedAt"];
+	        this.breakdown = this.convertValues(source["breakdown"], usage.Breakdown);
+	        this.nextResetAt = source["nextResetAt"];
+	        this.daysUntilReset = source["daysUntilReset"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class ConnectionTestResult {
+	    success: boolean;
+	    message: string;
+	    reports: transport.Report[];
+	
+	    static createFrom(source: any = {}) {
+	        return new ConnectionTestResult(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.success = source["success"];
+	        this.message = source["message"];
+	        this.reports = this.convertValues(source["reports"], transport.Report);
 	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
 	}
 	export class CurrentUsageInfo {
 	    subscriptionTitle: string;
@@ -81,6 +212,10 @@ export namespace main {
 	    currentUsage: number;
 	    balance: number;
 	    isLowBalance: boolean;
+	    breakdown: usage.Breakdown[];
+	    credits: usage.Credit[];
+	    nextResetAt: string;
+	    daysUntilReset: number;
 	
 	    static createFrom(source: any = {}) {
 	        return new CurrentUsageInfo(source);
@@ -93,6 +228,102 @@ export namespace main {
 	        this.currentUsage = source["currentUsage"];
 	        this.balance = source["balance"];
 	        this.isLowBalance = source["isLowBalance"];
+	        this.breakdown = this.convertValues(source["breakdown"], usage.Breakdown);
+	        this.credits = this.convertValues(source["credits"], usage.Credit);
+	        this.nextResetAt = source["nextResetAt"];
+	        this.daysUntilReset = source["daysUntilReset"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class Diagnostics {
+	    version: string;
+	    platform: string;
+	    dataDir: string;
+	    settingsPath: string;
+	    ssoCachePath: string;
+	    kiroRunning: boolean;
+	    authType: string;
+	    provider: string;
+	    tokenRegion: string;
+	    tokenExpired: boolean;
+	    currentBackup: string;
+	    endpoints: endpoints.Resolved;
+	
+	    static createFrom(source: any = {}) {
+	        return new Diagnostics(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.version = source["version"];
+	        this.platform = source["platform"];
+	        this.dataDir = source["dataDir"];
+	        this.settingsPath = source["settingsPath"];
+	        this.ssoCachePath = source["ssoCachePath"];
+	        this.kiroRunning = source["kiroRunning"];
+	        this.authType = source["authType"];
+	        this.provider = source["provider"];
+	        this.tokenRegion = source["tokenRegion"];
+	        this.tokenExpired = source["tokenExpired"];
+	        this.currentBackup = source["currentBackup"];
+	        this.endpoints = this.convertValues(source["endpoints"], endpoints.Resolved);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class OpenWorkspaceResult {
+	    success: boolean;
+	    message: string;
+	    path: string;
+	    backup: string;
+	    switched: boolean;
+	    pid: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new OpenWorkspaceResult(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.success = source["success"];
+	        this.message = source["message"];
+	        this.path = source["path"];
+	        this.backup = source["backup"];
+	        this.switched = source["switched"];
+	        this.pid = source["pid"];
 	    }
 	}
 	export class Result {
@@ -139,6 +370,10 @@ export namespace main {
 	    isLowBalance: boolean;
 	    isTokenExpired: boolean;
 	    cachedAt: string;
+	    breakdown: usage.Breakdown[];
+	    credits: usage.Credit[];
+	    nextResetAt: string;
+	    daysUntilReset: number;
 	
 	    static createFrom(source: any = {}) {
 	        return new UsageCacheResult(source);
@@ -155,6 +390,487 @@ export namespace main {
 	        this.isLowBalance = source["isLowBalance"];
 	        this.isTokenExpired = source["isTokenExpired"];
 	        this.cachedAt = source["cachedAt"];
+	        this.breakdown = this.convertValues(source["breakdown"], usage.Breakdown);
+	        this.credits = this.convertValues(source["credits"], usage.Credit);
+	        this.nextResetAt = source["nextResetAt"];
+	        this.daysUntilReset = source["daysUntilReset"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class UsageForecastResult {
+	    success: boolean;
+	    message: string;
+	    forecast: usagehistory.Forecast;
+	
+	    static createFrom(source: any = {}) {
+	        return new UsageForecastResult(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.success = source["success"];
+	        this.message = source["message"];
+	        this.forecast = this.convertValues(source["forecast"], usagehistory.Forecast);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class UsageReportResult {
+	    success: boolean;
+	    message: string;
+	    path: string;
+	    rowCount: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new UsageReportResult(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.success = source["success"];
+	        this.message = source["message"];
+	        this.path = source["path"];
+	        this.rowCount = source["rowCount"];
+	    }
+	}
+
+}
+
+export namespace settings {
+	
+	export class AlertRule {
+	    usedPercent?: number;
+	    minRemaining?: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new AlertRule(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.usedPercent = source["usedPercent"];
+	        this.minRemaining = source["minRemaining"];
+	    }
+	}
+	export class AlertSettings {
+	    rules?: AlertRule[];
+	    accounts?: Record<string, Array<AlertRule>>;
+	    disableDesktopNotification: boolean;
+	    command?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new AlertSettings(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.rules = this.convertValues(source["rules"], AlertRule);
+	        this.accounts = source["accounts"];
+	        this.disableDesktopNotification = source["disableDesktopNotification"];
+	        this.command = source["command"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class EndpointSettings {
+	    idcRefreshUrl?: string;
+	    usageBaseUrl?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new EndpointSettings(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.idcRefreshUrl = source["idcRefreshUrl"];
+	        this.usageBaseUrl = source["usageBaseUrl"];
+	    }
+	}
+	export class HistorySettings {
+	    retentionDays: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new HistorySettings(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.retentionDays = source["retentionDays"];
+	    }
+	}
+	export class LoggingSettings {
+	    level: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new LoggingSettings(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.level = source["level"];
+	    }
+	}
+	export class NetworkSettings {
+	    proxyUrl?: string;
+	    proxyUsername?: string;
+	    proxyPassword?: string;
+	    noProxy?: string;
+	    caBundle?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new NetworkSettings(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.proxyUrl = source["proxyUrl"];
+	        this.proxyUsername = source["proxyUsername"];
+	        this.proxyPassword = source["proxyPassword"];
+	        this.noProxy = source["noProxy"];
+	        this.caBundle = source["caBundle"];
+	    }
+	}
+	export class RelaunchSettings {
+	    afterSwitch: boolean;
+	    disableReopenWorkspaces: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new RelaunchSettings(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.afterSwitch = source["afterSwitch"];
+	        this.disableReopenWorkspaces = source["disableReopenWorkspaces"];
+	    }
+	}
+	export class RetrySettings {
+	    maxAttempts: number;
+	    baseDelayMs: number;
+	    maxDelayMs: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new RetrySettings(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.maxAttempts = source["maxAttempts"];
+	        this.baseDelayMs = source["baseDelayMs"];
+	        this.maxDelayMs = source["maxDelayMs"];
+	    }
+	}
+	export class ShutdownSettings {
+	    timeoutSeconds: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new ShutdownSettings(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.timeoutSeconds = source["timeoutSeconds"];
+	    }
+	}
+
+}
+
+export namespace transport {
+	
+	export class HopResult {
+	    hop: string;
+	    target: string;
+	    ok: boolean;
+	    detail?: string;
+	    error?: string;
+	    durationMs: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new HopResult(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.hop = source["hop"];
+	        this.target = source["target"];
+	        this.ok = source["ok"];
+	        this.detail = source["detail"];
+	        this.error = source["error"];
+	        this.durationMs = source["durationMs"];
+	    }
+	}
+	export class Report {
+	    url: string;
+	    proxy?: string;
+	    ok: boolean;
+	    failedHop?: string;
+	    hops: HopResult[];
+	
+	    static createFrom(source: any = {}) {
+	        return new Report(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.url = source["url"];
+	        this.proxy = source["proxy"];
+	        this.ok = source["ok"];
+	        this.failedHop = source["failedHop"];
+	        this.hops = this.convertValues(source["hops"], HopResult);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+
+}
+
+export namespace usage {
+	
+	export class BonusCredit {
+	    bonusCode: string;
+	    displayName?: string;
+	    usageLimit: number;
+	    currentUsage: number;
+	    status: string;
+	    expiresAt?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new BonusCredit(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.bonusCode = source["bonusCode"];
+	        this.displayName = source["displayName"];
+	        this.usageLimit = source["usageLimit"];
+	        this.currentUsage = source["currentUsage"];
+	        this.status = source["status"];
+	        this.expiresAt = source["expiresAt"];
+	    }
+	}
+	export class Breakdown {
+	    displayName: string;
+	    baseLimit: number;
+	    baseUsage: number;
+	    trialLimit: number;
+	    trialUsage: number;
+	    freeTrialStatus?: string;
+	    freeTrialExpiry?: string;
+	    bonuses?: BonusCredit[];
+	    nextResetAt?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new Breakdown(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.displayName = source["displayName"];
+	        this.baseLimit = source["baseLimit"];
+	        this.baseUsage = source["baseUsage"];
+	        this.trialLimit = source["trialLimit"];
+	        this.trialUsage = source["trialUsage"];
+	        this.freeTrialStatus = source["freeTrialStatus"];
+	        this.freeTrialExpiry = source["freeTrialExpiry"];
+	        this.bonuses = this.convertValues(source["bonuses"], BonusCredit);
+	        this.nextResetAt = source["nextResetAt"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class Credit {
+	    kind: string;
+	    displayName: string;
+	    bonusCode?: string;
+	    remaining: number;
+	    expiresAt?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new Credit(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.kind = source["kind"];
+	        this.displayName = source["displayName"];
+	        this.bonusCode = source["bonusCode"];
+	        this.remaining = source["remaining"];
+	        this.expiresAt = source["expiresAt"];
+	    }
+	}
+
+}
+
+export namespace usagehistory {
+	
+	export class WeekUsage {
+	    weekStart: string;
+	    used: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new WeekUsage(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.weekStart = source["weekStart"];
+	        this.used = source["used"];
+	    }
+	}
+	export class Forecast {
+	    sampleCount: number;
+	    since: string;
+	    latestAt: string;
+	    subscriptionTitle: string;
+	    usageLimit: number;
+	    currentUsage: number;
+	    remaining: number;
+	    hasEnoughData: boolean;
+	    dailyBurnRate: number;
+	    willExhaust: boolean;
+	    daysUntilExhausted: number;
+	    exhaustsAt: string;
+	    lastsUntilMonthEnd: boolean;
+	    weekly: WeekUsage[];
+	
+	    static createFrom(source: any = {}) {
+	        return new Forecast(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.sampleCount = source["sampleCount"];
+	        this.since = source["since"];
+	        this.latestAt = source["latestAt"];
+	        this.subscriptionTitle = source["subscriptionTitle"];
+	        this.usageLimit = source["usageLimit"];
+	        this.currentUsage = source["currentUsage"];
+	        this.remaining = source["remaining"];
+	        this.hasEnoughData = source["hasEnoughData"];
+	        this.dailyBurnRate = source["dailyBurnRate"];
+	        this.willExhaust = source["willExhaust"];
+	        this.daysUntilExhausted = source["daysUntilExhausted"];
+	        this.exhaustsAt = source["exhaustsAt"];
+	        this.lastsUntilMonthEnd = source["lastsUntilMonthEnd"];
+	        this.weekly = this.convertValues(source["weekly"], WeekUsage);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+
+}
+
+export namespace workspace {
+	
+	export class Binding {
+	    path: string;
+	    backup: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new Binding(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.path = source["path"];
+	        this.backup = source["backup"];
 	    }
 	}
 
@@ -0,0 +1,187 @@
+package atomicfile
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"kiro-manager/internal/datadir"
+)
+
+// lockDirName 基礎目錄下存放鎖定檔的資料夾
+const lockDirName = "locks"
+
+// rename 提交暫存檔時使用的 rename（測試時可替換以模擬失敗）
+var rename = os.Rename
+
+// Staged 已寫入並 fsync 的暫存檔，尚未取代目標檔案
+type Staged struct {
+	Path     string // 目標路徑
+	TempPath string // 與目標同目錄的暫存檔路徑
+}
+
+// Stage 將內容寫入目標同目錄下的暫存檔並 fsync
+// 暫存檔與目標位於同一目錄，確保之後的 rename 為原子操作
+func Stage(path string, data []byte, perm os.FileMode) (*Staged, error) {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return nil, err
+	}
+	tmpPath := tmp.Name()
+
+	fail := func(err error) (*Staged, error) {
+		tmp.Close()
+		os.Remove(tmpPath)
+		return nil, err
+	}
+
+	if _, err := tmp.Write(data); err != nil {
+		return fail(err)
+	}
+	if err := tmp.Sync(); err != nil {
+		return fail(err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmpPath)
+		return nil, err
+	}
+	// CreateTemp 建立的檔案權限為 0600，依需求調整
+	if err := os.Chmod(tmpPath, perm); err != nil {
+		os.Remove(tmpPath)
+		return nil, err
+	}
+
+	return &Staged{Path: path, TempPath: tmpPath}, nil
+}
+
+// Commit 以 rename 取代目標檔案並 fsync 所在目錄
+// rename 失敗時會刪除暫存檔，目標檔案維持原本內容
+func (s *Staged) Commit() error {
+	if err := rename(s.TempPath, s.Path); err != nil {
+		os.Remove(s.TempPath)
+		return err
+	}
+	return SyncDir(filepath.Dir(s.Path))
+}
+
+// Discard 刪除暫存檔（未提交時使用）
+func (s *Staged) Discard() {
+	os.Remove(s.TempPath)
+}
+
+// WriteFile 以原子方式寫入檔案：寫入暫存檔、fsync、rename、fsync 目錄
+// 寫入期間持有跨行程的建議鎖，中途當機或磁碟已滿時目標檔案不會被截斷
+func WriteFile(path string, data []byte, perm os.FileMode) error {
+	unlock, err := Lock(path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	return writeLocked(path, data, perm)
+}
+
+// Update 在持有鎖的情況下讀取、修改並以原子方式寫回檔案
+// 檔案不存在時 fn 收到的 old 為 nil 且 exists 為 false；fn 回傳錯誤時不寫入
+func Update(path string, perm os.FileMode, fn func(old []byte, exists bool) ([]byte, error)) error {
+	unlock, err := Lock(path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	old, err := os.ReadFile(path)
+	exists := err == nil
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	data, err := fn(old, exists)
+	if err != nil {
+		return err
+	}
+	return writeLocked(path, data, perm)
+}
+
+// writeLocked 寫入檔案（呼叫端需已持有鎖）
+func writeLocked(path string, data []byte, perm os.FileMode) error {
+	staged, err := Stage(path, data, perm)
+	if err != nil {
+		return err
+	}
+	return staged.Commit()
+}
+
+// Lock 取得 path 對應的跨行程建議鎖（阻塞直到取得），回傳解鎖函數
+// 鎖定的是本程式基礎目錄下 locks/<路徑雜湊>.lock，而非目標檔案本身，
+// 因為目標檔案會在 rename 後被取代；也避免在 SSO cache 等其他工具的目錄留下檔案
+func Lock(path string) (func(), error) {
+	lockPath, err := lockPathFor(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(lockPath), 0700); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(lockPath, os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := lockFile(f); err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	return func() {
+		unlockFile(f)
+		f.Close()
+	}, nil
+}
+
+// RemoveLocks 刪除 dir 中各檔案對應的鎖定檔（刪除或重新命名整個資料夾前呼叫）
+// 鎖定檔以路徑雜湊命名，資料夾移除後不會再被使用，不清除會一直留在 locks/ 中
+func RemoveLocks(dir string) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		lockPath, err := lockPathFor(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return err
+		}
+		if err := os.Remove(lockPath); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// lockPathFor 取得目標檔案對應的鎖定檔路徑
+// 使用基礎目錄（不受設定中 dataDir 影響），GUI 與 CLI 對同一檔案會取得同一把鎖
+func lockPathFor(path string) (string, error) {
+	baseDir, err := datadir.BaseDir()
+	if err != nil {
+		return "", err
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	if runtime.GOOS == "windows" {
+		abs = strings.ToLower(abs)
+	}
+	sum := sha256.Sum256([]byte(abs))
+	return filepath.Join(baseDir, lockDirName, hex.EncodeToString(sum[:16])+".lock"), nil
+}
@@ -0,0 +1,180 @@
+package atomicfile
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+
+	"kiro-manager/internal/datadir"
+)
+
+// assertOnlyFiles 確認目錄中只有指定的檔案（沒有殘留的暫存檔與鎖定檔）
+func assertOnlyFiles(t *testing.T, dir string, want ...string) {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("ReadDir failed: %v", err)
+	}
+
+	var got []string
+	for _, entry := range entries {
+		got = append(got, entry.Name())
+	}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("directory contents = %v, want %v", got, want)
+	}
+}
+
+// TestWriteFile_ReplacesContent 測試寫入新檔與覆蓋既有檔案
+func TestWriteFile_ReplacesContent(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "settings.json")
+
+	if err := WriteFile(path, []byte(`{"a":1}`), 0644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	if err := WriteFile(path, []byte(`{"a":2}`), 0600); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+
+	data, _ := os.ReadFile(path)
+	if string(data) != `{"a":2}` {
+		t.Errorf("content = %s, want {\"a\":2}", data)
+	}
+	if runtime.GOOS != "windows" {
+		info, _ := os.Stat(path)
+		if info.Mode().Perm() != 0600 {
+			t.Errorf("permissions = %v, want 0600", info.Mode().Perm())
+		}
+	}
+	assertOnlyFiles(t, dir, "settings.json")
+}
+
+// TestWriteFile_FailedRenameKeepsOriginal 測試 rename 失敗時原檔案不受影響且不殘留暫存檔
+func TestWriteFile_FailedRenameKeepsOriginal(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "usage-cache.json")
+	if err := os.WriteFile(path, []byte(`{"balance":10}`), 0600); err != nil {
+		t.Fatalf("Failed to write original: %v", err)
+	}
+
+	original := rename
+	rename = func(oldPath, newPath string) error {
+		return errors.New("simulated crash before rename")
+	}
+	defer func() { rename = original }()
+
+	if err := WriteFile(path, []byte(`{"balance":`), 0600); err == nil {
+		t.Fatal("WriteFile should fail when rename fails")
+	}
+
+	data, _ := os.ReadFile(path)
+	if string(data) != `{"balance":10}` {
+		t.Errorf("original content changed: %s", data)
+	}
+	assertOnlyFiles(t, dir, "usage-cache.json")
+}
+
+// TestUpdate_SerializesConcurrentWriters 測試並行的讀取-修改-寫入不會遺失更新
+func TestUpdate_SerializesConcurrentWriters(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "counter")
+
+	const writers = 20
+	var wg sync.WaitGroup
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := Update(path, 0600, func(old []byte, exists bool) ([]byte, error) {
+				return append(old, 'x'), nil
+			})
+			if err != nil {
+				t.Errorf("Update failed: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	data, _ := os.ReadFile(path)
+	if len(data) != writers {
+		t.Errorf("len(content) = %d, want %d (lost updates)", len(data), writers)
+	}
+}
+
+// TestUpdate_CallbackErrorSkipsWrite 測試 fn 回傳錯誤時不寫入
+func TestUpdate_CallbackErrorSkipsWrite(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "token.json")
+
+	wantErr := errors.New("parse failed")
+	err := Update(path, 0600, func(old []byte, exists bool) ([]byte, error) {
+		if exists {
+			t.Error("exists should be false for a missing file")
+		}
+		return nil, wantErr
+	})
+	if !errors.Is(err, wantErr) {
+		t.Errorf("Update error = %v, want %v", err, wantErr)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Error("File should not be created when fn fails")
+	}
+}
+
+// TestLock_UsesDataDir 測試鎖定檔位於基礎目錄的 locks 下，同一路徑取得同一把鎖
+func TestLock_UsesDataDir(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "token.json")
+
+	unlock, err := Lock(path)
+	if err != nil {
+		t.Fatalf("Lock failed: %v", err)
+	}
+	unlock()
+	assertOnlyFiles(t, dir)
+
+	lockPath, err := lockPathFor(path)
+	if err != nil {
+		t.Fatalf("lockPathFor failed: %v", err)
+	}
+	if want := filepath.Join(os.Getenv(datadir.EnvVar), lockDirName); filepath.Dir(lockPath) != want {
+		t.Errorf("lock dir = %s, want %s", filepath.Dir(lockPath), want)
+	}
+	if _, err := os.Stat(lockPath); err != nil {
+		t.Errorf("lock file not created: %v", err)
+	}
+
+	relative, _ := lockPathFor(filepath.Join(dir, ".", "token.json"))
+	other, _ := lockPathFor(filepath.Join(dir, "other.json"))
+	if relative != lockPath || other == lockPath {
+		t.Errorf("lock paths: same file %s vs %s, other file %s", lockPath, relative, other)
+	}
+}
+
+// TestRemoveLocks 測試刪除資料夾中各檔案對應的鎖定檔
+func TestRemoveLocks(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "history.json")
+	if err := WriteFile(path, []byte("{}"), 0600); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	lockPath, _ := lockPathFor(path)
+	if _, err := os.Stat(lockPath); err != nil {
+		t.Fatalf("lock file not created: %v", err)
+	}
+
+	if err := RemoveLocks(dir); err != nil {
+		t.Fatalf("RemoveLocks failed: %v", err)
+	}
+	if _, err := os.Stat(lockPath); !os.IsNotExist(err) {
+		t.Errorf("lock file should be removed: %v", err)
+	}
+	if err := RemoveLocks(filepath.Join(dir, "missing")); err != nil {
+		t.Errorf("RemoveLocks on a missing directory = %v, want nil", err)
+	}
+}
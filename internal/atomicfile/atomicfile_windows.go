@@ -0,0 +1,26 @@
+//go:build windows
+
+package atomicfile
+
+import (
+	"os"
+
+	"golang.org/x/sys/windows"
+)
+
+// lockFile 以 LockFileEx 取得排他鎖
+func lockFile(f *os.File) error {
+	var overlapped windows.Overlapped
+	return windows.LockFileEx(windows.Handle(f.Fd()), windows.LOCKFILE_EXCLUSIVE_LOCK, 0, 1, 0, &overlapped)
+}
+
+// unlockFile 釋放 LockFileEx 取得的鎖
+func unlockFile(f *os.File) error {
+	var overlapped windows.Overlapped
+	return windows.UnlockFileEx(windows.Handle(f.Fd()), 0, 1, 0, &overlapped)
+}
+
+// SyncDir Windows 無法對目錄 fsync，MoveFileEx 完成後即已持久化
+func SyncDir(dir string) error {
+	return nil
+}
@@ -0,0 +1,20 @@
+package atomicfile
+
+import (
+	"os"
+	"testing"
+
+	"kiro-manager/internal/datadir"
+)
+
+// TestMain 將鎖定檔放在暫存的基礎目錄，避免測試寫入使用者的資料目錄
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "atomicfile-test-")
+	if err != nil {
+		panic(err)
+	}
+	os.Setenv(datadir.EnvVar, dir)
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
@@ -2,9 +2,17 @@
 
 package cmdutil
 
-import "os/exec"
+import (
+	"os/exec"
+	"syscall"
+)
 
 // HideWindow 非 Windows 平台不需要處理
 func HideWindow(cmd *exec.Cmd) {
 	// no-op on non-Windows platforms
 }
+
+// Detach 讓子進程在新的 session 執行，不受本程式結束或終端機關閉影響
+func Detach(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+}
@@ -5,6 +5,8 @@ package cmdutil
 import (
 	"os/exec"
 	"syscall"
+
+	"golang.org/x/sys/windows"
 )
 
 // HideWindow 設定命令以隱藏視窗方式執行
@@ -15,3 +17,10 @@ func HideWindow(cmd *exec.Cmd) {
 		HideWindow: true,
 	}
 }
+
+// Detach 讓子進程脫離本程式的主控台與進程群組，不受本程式結束影響
+func Detach(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{
+		CreationFlags: windows.DETACHED_PROCESS | windows.CREATE_NEW_PROCESS_GROUP,
+	}
+}
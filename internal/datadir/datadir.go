@@ -0,0 +1,232 @@
+package datadir
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+)
+
+const (
+	// EnvVar 指定資料目錄的環境變數（優先權最高）
+	EnvVar = "KIRO_MANAGER_DATA_DIR"
+	// PortableMarker 執行檔同層存在此檔案時啟用可攜模式，資料存放於執行檔同層
+	PortableMarker = "portable"
+	// appDirName macOS / Windows 使用的應用資料夾名稱
+	appDirName = "Kiro Manager"
+	// xdgDirName Linux 使用的應用資料夾名稱
+	xdgDirName = "kiro-manager"
+)
+
+// executablePath 取得執行檔路徑（測試時可替換）
+var executablePath = os.Executable
+
+// ExecutableDir 取得執行檔所在目錄
+func ExecutableDir() (string, error) {
+	execPath, err := executablePath()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Dir(execPath), nil
+}
+
+// IsPortable 檢查是否啟用可攜模式（執行檔同層有 portable 標記檔）
+func IsPortable() bool {
+	execDir, err := ExecutableDir()
+	if err != nil {
+		return false
+	}
+	_, err = os.Stat(filepath.Join(execDir, PortableMarker))
+	return err == nil
+}
+
+// DefaultDir 取得使用者層級的預設資料目錄
+// Windows: %AppData%\Kiro Manager
+// macOS: ~/Library/Application Support/Kiro Manager
+// Linux: $XDG_DATA_HOME/kiro-manager（預設 ~/.local/share/kiro-manager）
+func DefaultDir() (string, error) {
+	switch runtime.GOOS {
+	case "windows", "darwin":
+		configDir, err := os.UserConfigDir()
+		if err != nil {
+			return "", err
+		}
+		return filepath.Join(configDir, appDirName), nil
+	default:
+		if xdgDataHome := os.Getenv("XDG_DATA_HOME"); filepath.IsAbs(xdgDataHome) {
+			return filepath.Join(xdgDataHome, xdgDirName), nil
+		}
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		return filepath.Join(homeDir, ".local", "share", xdgDirName), nil
+	}
+}
+
+// BaseDir 取得設定檔所在的基礎目錄
+// 優先順序：環境變數 > 可攜模式（執行檔同層）> 使用者預設目錄
+func BaseDir() (string, error) {
+	if dir := os.Getenv(EnvVar); dir != "" {
+		return filepath.Abs(dir)
+	}
+	if IsPortable() {
+		return ExecutableDir()
+	}
+	return DefaultDir()
+}
+
+// Resolve 取得資料目錄（備份等資料存放處）
+// 優先順序：環境變數 > 設定覆寫 > 可攜模式 > 使用者預設目錄
+// override 為設定中的 dataDir，空字串表示未覆寫
+func Resolve(override string) (string, error) {
+	if dir := os.Getenv(EnvVar); dir != "" {
+		return filepath.Abs(dir)
+	}
+	if override != "" {
+		return filepath.Abs(override)
+	}
+	return BaseDir()
+}
+
+// LegacyPath 取得舊版存放於執行檔同層的路徑
+func LegacyPath(name string) (string, error) {
+	execDir, err := ExecutableDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(execDir, name), nil
+}
+
+// MigrateLegacy 將執行檔同層的舊版資料搬移至 dst
+// 來源不存在、目標已存在或兩者相同時不做任何事，回傳是否已搬移
+func MigrateLegacy(name string, dst string) (bool, error) {
+	src, err := LegacyPath(name)
+	if err != nil {
+		return false, err
+	}
+	return Move(src, dst)
+}
+
+// Move 搬移檔案或目錄，跨裝置時改以複製後刪除
+// 來源不存在、目標已存在或兩者相同時不做任何事，回傳是否已搬移
+func Move(src, dst string) (bool, error) {
+	if samePath(src, dst) {
+		return false, nil
+	}
+	if _, err := os.Lstat(src); err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	if _, err := os.Lstat(dst); err == nil {
+		return false, nil
+	} else if !os.IsNotExist(err) {
+		return false, err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(dst), 0700); err != nil {
+		return false, err
+	}
+
+	if err := os.Rename(src, dst); err == nil {
+		return true, nil
+	}
+
+	// 跨裝置（如 /Applications 與家目錄位於不同磁碟）時無法直接 rename
+	// 先複製到同目錄的暫存位置，完成後才 rename 為 dst，中途當機不會留下不完整的 dst
+	staging := dst + ".migrating"
+	if err := os.RemoveAll(staging); err != nil {
+		return false, err
+	}
+	if err := copyAll(src, staging); err != nil {
+		os.RemoveAll(staging)
+		return false, err
+	}
+	if err := os.Rename(staging, dst); err != nil {
+		os.RemoveAll(staging)
+		return false, err
+	}
+	if err := syncDir(filepath.Dir(dst)); err != nil {
+		return true, err
+	}
+	if err := os.RemoveAll(src); err != nil {
+		return true, err
+	}
+	return true, nil
+}
+
+// samePath 檢查兩個路徑是否指向同一位置
+func samePath(a, b string) bool {
+	absA, errA := filepath.Abs(a)
+	absB, errB := filepath.Abs(b)
+	if errA != nil || errB != nil {
+		return a == b
+	}
+	return absA == absB
+}
+
+// copyAll 遞迴複製檔案或目錄，保留權限
+func copyAll(src, dst string) error {
+	info, err := os.Lstat(src)
+	if err != nil {
+		return err
+	}
+
+	if !info.IsDir() {
+		return copyFile(src, dst, info.Mode().Perm())
+	}
+
+	if err := os.MkdirAll(dst, info.Mode().Perm()); err != nil {
+		return err
+	}
+
+	entries, err := os.ReadDir(src)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if err := copyAll(filepath.Join(src, entry.Name()), filepath.Join(dst, entry.Name())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// copyFile 複製單一檔案
+func copyFile(src, dst string, perm os.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, perm)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	if err := out.Sync(); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// syncDir fsync 目錄，確保 rename 後的目錄項目已寫入磁碟（Windows 無法對目錄 fsync，略過）
+func syncDir(dir string) error {
+	if runtime.GOOS == "windows" {
+		return nil
+	}
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
@@ -0,0 +1,149 @@
+package datadir
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// useExecutableDir 將執行檔路徑替換為指定目錄下的假執行檔
+func useExecutableDir(t *testing.T, dir string) {
+	t.Helper()
+	original := executablePath
+	executablePath = func() (string, error) {
+		return filepath.Join(dir, "kiro-manager"), nil
+	}
+	t.Cleanup(func() { executablePath = original })
+}
+
+// TestResolve_Precedence 測試資料目錄的解析優先順序
+func TestResolve_Precedence(t *testing.T) {
+	execDir := t.TempDir()
+	envDir := t.TempDir()
+	overrideDir := t.TempDir()
+	useExecutableDir(t, execDir)
+
+	defaultDir, err := DefaultDir()
+	if err != nil {
+		t.Fatalf("DefaultDir failed: %v", err)
+	}
+
+	testCases := []struct {
+		name     string
+		env      string
+		override string
+		portable bool
+		want     string
+	}{
+		{"Default", "", "", false, defaultDir},
+		{"Portable", "", "", true, execDir},
+		{"Override beats portable", "", overrideDir, true, overrideDir},
+		{"Env beats override", envDir, overrideDir, true, envDir},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Setenv(EnvVar, tc.env)
+
+			marker := filepath.Join(execDir, PortableMarker)
+			if tc.portable {
+				if err := os.WriteFile(marker, nil, 0644); err != nil {
+					t.Fatalf("Failed to write portable marker: %v", err)
+				}
+				defer os.Remove(marker)
+			}
+
+			got, err := Resolve(tc.override)
+			if err != nil {
+				t.Fatalf("Resolve failed: %v", err)
+			}
+			if got != tc.want {
+				t.Errorf("Resolve(%q) = %q, want %q", tc.override, got, tc.want)
+			}
+		})
+	}
+}
+
+// TestBaseDir_IgnoresOverride 測試設定檔目錄不受 dataDir 覆寫影響
+func TestBaseDir_IgnoresOverride(t *testing.T) {
+	execDir := t.TempDir()
+	useExecutableDir(t, execDir)
+	t.Setenv(EnvVar, "")
+
+	if err := os.WriteFile(filepath.Join(execDir, PortableMarker), nil, 0644); err != nil {
+		t.Fatalf("Failed to write portable marker: %v", err)
+	}
+
+	got, err := BaseDir()
+	if err != nil {
+		t.Fatalf("BaseDir failed: %v", err)
+	}
+	if got != execDir {
+		t.Errorf("BaseDir() = %q, want %q", got, execDir)
+	}
+}
+
+// TestMigrateLegacy_MovesDirectory 測試執行檔同層的舊版目錄會被搬移
+func TestMigrateLegacy_MovesDirectory(t *testing.T) {
+	execDir := t.TempDir()
+	useExecutableDir(t, execDir)
+
+	legacyFile := filepath.Join(execDir, "backups", "work", "kiro-auth-token.json")
+	if err := os.MkdirAll(filepath.Dir(legacyFile), 0700); err != nil {
+		t.Fatalf("Failed to create legacy dir: %v", err)
+	}
+	if err := os.WriteFile(legacyFile, []byte("token"), 0600); err != nil {
+		t.Fatalf("Failed to write legacy file: %v", err)
+	}
+
+	dst := filepath.Join(t.TempDir(), "data", "backups")
+	migrated, err := MigrateLegacy("backups", dst)
+	if err != nil {
+		t.Fatalf("MigrateLegacy failed: %v", err)
+	}
+	if !migrated {
+		t.Fatal("MigrateLegacy reported no migration")
+	}
+
+	data, err := os.ReadFile(filepath.Join(dst, "work", "kiro-auth-token.json"))
+	if err != nil || string(data) != "token" {
+		t.Errorf("Migrated file missing or changed: %q, %v", data, err)
+	}
+	if _, err := os.Stat(filepath.Join(execDir, "backups")); !os.IsNotExist(err) {
+		t.Error("Legacy directory should be removed after migration")
+	}
+}
+
+// TestMove_KeepsExistingTarget 測試目標已存在時不覆蓋
+func TestMove_KeepsExistingTarget(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "settings-old.json")
+	dst := filepath.Join(dir, "settings.json")
+	os.WriteFile(src, []byte("old"), 0644)
+	os.WriteFile(dst, []byte("new"), 0644)
+
+	moved, err := Move(src, dst)
+	if err != nil {
+		t.Fatalf("Move failed: %v", err)
+	}
+	if moved {
+		t.Error("Move should not overwrite an existing target")
+	}
+
+	data, _ := os.ReadFile(dst)
+	if string(data) != "new" {
+		t.Errorf("Target content = %q, want %q", data, "new")
+	}
+	if _, err := os.Stat(src); err != nil {
+		t.Error("Source should be kept when target exists")
+	}
+}
+
+// TestMove_MissingSource 測試來源不存在時不做任何事
+func TestMove_MissingSource(t *testing.T) {
+	dir := t.TempDir()
+	moved, err := Move(filepath.Join(dir, "missing"), filepath.Join(dir, "dst"))
+	if err != nil || moved {
+		t.Errorf("Move(missing) = %v, %v; want false, nil", moved, err)
+	}
+}
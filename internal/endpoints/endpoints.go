@@ -0,0 +1,143 @@
+package endpoints
+
+import (
+	"net"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+const (
+	// DefaultRegion token 未指定區域（或區域格式不合法）時使用的區域
+	DefaultRegion = "us-east-1"
+	// RegionPlaceholder 覆寫 URL 中代表區域的佔位字串，例如 https://oidc-fips.{region}.amazonaws.com/token
+	RegionPlaceholder = "{region}"
+)
+
+// 區域來源
+const (
+	SourceToken      = "token"      // 取自 token 的 region 欄位
+	SourceProfileArn = "profileArn" // 取自 token 的 profile ARN
+	SourceDefault    = "default"    // token 未指定區域，使用預設值
+)
+
+// regionPattern AWS 區域格式（如 us-east-1、eu-central-1、us-gov-west-1、cn-north-1）
+// 只接受此格式，避免 token 中的內容被拼接進主機名稱
+var regionPattern = regexp.MustCompile(`^[a-z]{2}(-[a-z]+)+-\d+$`)
+
+// Overrides 端點覆寫（來自設定），空字串表示使用依區域推導的端點
+type Overrides struct {
+	IdCRefreshURL string // IdC token 刷新端點
+	UsageBaseURL  string // 用量 API 端點
+}
+
+// Resolved 解析後的端點
+// IdC 刷新端點使用 SSO 區域；用量 API 由 profile 所在區域提供，兩者可能不同
+type Resolved struct {
+	Region            string `json:"region"`       // SSO 區域（IdC 刷新端點）
+	RegionSource      string `json:"regionSource"` // SSO 區域來源
+	UsageRegion       string `json:"usageRegion"`  // profile 區域（用量 API）
+	UsageRegionSource string `json:"usageRegionSource"`
+	IdCRefreshURL     string `json:"idcRefreshUrl"`
+	UsageBaseURL      string `json:"usageBaseUrl"`
+	IdCOverridden     bool   `json:"idcOverridden"`
+	UsageOverridden   bool   `json:"usageOverridden"`
+}
+
+// ValidRegion 檢查區域名稱格式
+func ValidRegion(region string) bool {
+	return regionPattern.MatchString(region)
+}
+
+// Resolve 依 token 的 SSO 區域、profile ARN 與設定覆寫解析端點
+// IdC 刷新端點使用 SSO 區域；用量 API 使用 profile ARN 中的區域，無法取得時使用 DefaultRegion
+// 覆寫 URL 可包含 {region}，會被替換為該端點解析後的區域
+func Resolve(region, profileArn string, overrides Overrides) Resolved {
+	resolved := Resolved{
+		Region:            DefaultRegion,
+		RegionSource:      SourceDefault,
+		UsageRegion:       DefaultRegion,
+		UsageRegionSource: SourceDefault,
+	}
+	if ValidRegion(region) {
+		resolved.Region = region
+		resolved.RegionSource = SourceToken
+	}
+	if profileRegion, ok := ProfileRegion(profileArn); ok {
+		resolved.UsageRegion = profileRegion
+		resolved.UsageRegionSource = SourceProfileArn
+	}
+
+	resolved.IdCRefreshURL = IdCRefreshURL(resolved.Region)
+	if overrides.IdCRefreshURL != "" {
+		resolved.IdCRefreshURL = expand(overrides.IdCRefreshURL, resolved.Region)
+		resolved.IdCOverridden = true
+	}
+
+	resolved.UsageBaseURL = UsageBaseURL(resolved.UsageRegion)
+	if overrides.UsageBaseURL != "" {
+		resolved.UsageBaseURL = strings.TrimRight(expand(overrides.UsageBaseURL, resolved.UsageRegion), "/")
+		resolved.UsageOverridden = true
+	}
+
+	return resolved
+}
+
+// ProfileRegion 取得 profile ARN（arn:aws:codewhisperer:{region}:{account}:profile/{id}）中的區域
+// ARN 格式或區域不合法時回傳 false
+func ProfileRegion(profileArn string) (string, bool) {
+	parts := strings.SplitN(profileArn, ":", 6)
+	if len(parts) < 6 || parts[0] != "arn" || !ValidRegion(parts[3]) {
+		return "", false
+	}
+	return parts[3], true
+}
+
+// IdCRefreshURL 取得指定區域的 IdC (SSO OIDC) token 端點
+func IdCRefreshURL(region string) string {
+	return "https://oidc." + region + "." + dnsSuffix(region) + "/token"
+}
+
+// UsageBaseURL 取得指定區域的用量 API 端點
+func UsageBaseURL(region string) string {
+	return "https://q." + region + "." + dnsSuffix(region)
+}
+
+// ValidOverrideURL 檢查覆寫 URL 是否為 https 絕對網址
+// 端點會收到 refresh token 與 client secret，http 僅允許本機迴路位址（本機代理或測試伺服器）
+func ValidOverrideURL(raw string) bool {
+	u, err := url.Parse(strings.ReplaceAll(raw, RegionPlaceholder, DefaultRegion))
+	if err != nil || u.Host == "" {
+		return false
+	}
+	switch u.Scheme {
+	case "https":
+		return true
+	case "http":
+		return isLoopbackHost(u.Hostname())
+	default:
+		return false
+	}
+}
+
+// isLoopbackHost 主機是否為 localhost 或迴路 IP
+func isLoopbackHost(host string) bool {
+	if strings.EqualFold(host, "localhost") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// dnsSuffix 取得區域所屬分區的 DNS 後綴
+func dnsSuffix(region string) string {
+	if strings.HasPrefix(region, "cn-") {
+		return "amazonaws.com.cn"
+	}
+	return "amazonaws.com"
+}
+
+// expand 替換覆寫 URL 中的區域佔位字串
+func expand(raw, region string) string {
+	return strings.ReplaceAll(raw, RegionPlaceholder, region)
+}
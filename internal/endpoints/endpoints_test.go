@@ -0,0 +1,109 @@
+package endpoints
+
+import "testing"
+
+// TestResolve_RegionFromToken 測試依 token 的區域推導端點
+func TestResolve_RegionFromToken(t *testing.T) {
+	tests := []struct {
+		region     string
+		wantRegion string
+		wantSource string
+		wantIdC    string
+		wantUsage  string
+	}{
+		// 未提供 profile ARN 時用量 API 使用預設區域，不沿用 SSO 區域
+		{"eu-central-1", "eu-central-1", SourceToken, "https://oidc.eu-central-1.amazonaws.com/token", "https://q.us-east-1.amazonaws.com"},
+		{"us-gov-west-1", "us-gov-west-1", SourceToken, "https://oidc.us-gov-west-1.amazonaws.com/token", "https://q.us-east-1.amazonaws.com"},
+		{"cn-north-1", "cn-north-1", SourceToken, "https://oidc.cn-north-1.amazonaws.com.cn/token", "https://q.us-east-1.amazonaws.com"},
+		{"", DefaultRegion, SourceDefault, "https://oidc.us-east-1.amazonaws.com/token", "https://q.us-east-1.amazonaws.com"},
+		// 不合法的區域不可拼接進主機名稱
+		{"evil.example.com/x", DefaultRegion, SourceDefault, "https://oidc.us-east-1.amazonaws.com/token", "https://q.us-east-1.amazonaws.com"},
+		{"US-EAST-1", DefaultRegion, SourceDefault, "https://oidc.us-east-1.amazonaws.com/token", "https://q.us-east-1.amazonaws.com"},
+	}
+
+	for _, tt := range tests {
+		got := Resolve(tt.region, "", Overrides{})
+		if got.Region != tt.wantRegion || got.RegionSource != tt.wantSource {
+			t.Errorf("Resolve(%q) region = %s (%s), want %s (%s)", tt.region, got.Region, got.RegionSource, tt.wantRegion, tt.wantSource)
+		}
+		if got.IdCRefreshURL != tt.wantIdC || got.UsageBaseURL != tt.wantUsage {
+			t.Errorf("Resolve(%q) = %s, %s", tt.region, got.IdCRefreshURL, got.UsageBaseURL)
+		}
+		if got.IdCOverridden || got.UsageOverridden {
+			t.Errorf("Resolve(%q) should not be marked overridden", tt.region)
+		}
+	}
+}
+
+// TestResolve_ProfileRegionDiffersFromSSORegion 測試 SSO 與 profile 位於不同區域時，
+// IdC 刷新使用 SSO 區域、用量 API 使用 profile 區域
+func TestResolve_ProfileRegionDiffersFromSSORegion(t *testing.T) {
+	got := Resolve("eu-west-1", "arn:aws:codewhisperer:us-east-1:123456789012:profile/ABCDEF", Overrides{})
+
+	if got.IdCRefreshURL != "https://oidc.eu-west-1.amazonaws.com/token" {
+		t.Errorf("IdCRefreshURL = %s, want the SSO region", got.IdCRefreshURL)
+	}
+	if got.UsageBaseURL != "https://q.us-east-1.amazonaws.com" || got.UsageRegionSource != SourceProfileArn {
+		t.Errorf("UsageBaseURL = %s (%s), want the profile region", got.UsageBaseURL, got.UsageRegionSource)
+	}
+
+	got = Resolve("us-east-1", "arn:aws:codewhisperer:eu-central-1:123456789012:profile/ABCDEF", Overrides{UsageBaseURL: "https://q-fips.{region}.amazonaws.com"})
+	if got.UsageBaseURL != "https://q-fips.eu-central-1.amazonaws.com" {
+		t.Errorf("UsageBaseURL override = %s, want {region} expanded to the profile region", got.UsageBaseURL)
+	}
+}
+
+// TestProfileRegion 測試從 profile ARN 取得區域
+func TestProfileRegion(t *testing.T) {
+	tests := []struct {
+		arn    string
+		want   string
+		wantOK bool
+	}{
+		{"arn:aws:codewhisperer:us-east-1:699475941385:profile/EHGA3GRVQMUK", "us-east-1", true},
+		{"arn:aws:codewhisperer:eu-central-1:123:profile/X", "eu-central-1", true},
+		{"", "", false},
+		{"arn:aws:codewhisperer:evil.example.com:123:profile/X", "", false},
+		{"not-an-arn", "", false},
+	}
+
+	for _, tt := range tests {
+		got, ok := ProfileRegion(tt.arn)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("ProfileRegion(%q) = %q, %v, want %q, %v", tt.arn, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+// TestResolve_Overrides 測試設定覆寫與 {region} 替換
+func TestResolve_Overrides(t *testing.T) {
+	got := Resolve("eu-west-1", "", Overrides{
+		IdCRefreshURL: "https://oidc-fips.{region}.amazonaws.com/token",
+		UsageBaseURL:  "https://proxy.internal/q/",
+	})
+
+	if got.IdCRefreshURL != "https://oidc-fips.eu-west-1.amazonaws.com/token" || !got.IdCOverridden {
+		t.Errorf("IdCRefreshURL = %s (overridden %v)", got.IdCRefreshURL, got.IdCOverridden)
+	}
+	if got.UsageBaseURL != "https://proxy.internal/q" || !got.UsageOverridden {
+		t.Errorf("UsageBaseURL = %s (overridden %v)", got.UsageBaseURL, got.UsageOverridden)
+	}
+}
+
+// TestValidOverrideURL 測試覆寫 URL 驗證
+func TestValidOverrideURL(t *testing.T) {
+	valid := []string{"https://oidc-fips.{region}.amazonaws.com/token", "http://127.0.0.1:8080", "http://localhost:9000/token", "http://[::1]:8080"}
+	// http 只允許迴路位址，避免 refresh token 與 client secret 以明文傳送
+	invalid := []string{"", "oidc.example.com", "ftp://example.com", "https://", "http://oidc.{region}.amazonaws.com/token", "http://10.0.0.5:8080", "http://localhost.example.com"}
+
+	for _, raw := range valid {
+		if !ValidOverrideURL(raw) {
+			t.Errorf("ValidOverrideURL(%q) = false, want true", raw)
+		}
+	}
+	for _, raw := range invalid {
+		if ValidOverrideURL(raw) {
+			t.Errorf("ValidOverrideURL(%q) = true, want false", raw)
+		}
+	}
+}
@@ -0,0 +1,218 @@
+package logging
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"kiro-manager/internal/datadir"
+)
+
+const (
+	// DirName 記錄檔目錄名稱（位於使用者資料目錄下）
+	DirName = "logs"
+	// FileName 記錄檔名稱，輪替後的舊檔為 FileName.1 ~ FileName.N
+	FileName = "kiro-manager.log"
+	// MaxFileSize 單一記錄檔大小上限，超過時輪替
+	MaxFileSize = 5 << 20
+	// MaxBackups 保留的舊記錄檔數量
+	MaxBackups = 3
+)
+
+// 記錄等級名稱（settings.json 的 logging.level）
+const (
+	LevelDebug = "debug"
+	LevelInfo  = "info"
+	LevelWarn  = "warn"
+	LevelError = "error"
+)
+
+// Options 記錄設定
+type Options struct {
+	Dir   string     // 記錄檔目錄，空值時使用 Dir()
+	Level slog.Level // 記錄等級
+	Extra io.Writer  // 額外的輸出（例如 CLI --verbose 時的 stderr），選填
+}
+
+var (
+	level   slog.LevelVar
+	mu      sync.Mutex
+	current *RotatingFile
+)
+
+// Dir 取得記錄檔目錄（使用者資料目錄下的 logs/）
+func Dir() (string, error) {
+	baseDir, err := datadir.BaseDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(baseDir, DirName), nil
+}
+
+// Setup 初始化預設 logger：寫入輪替記錄檔，並在輸出前遮蔽機密值
+// 重複呼叫會關閉先前開啟的記錄檔
+func Setup(opts Options) error {
+	dir := opts.Dir
+	if dir == "" {
+		var err error
+		if dir, err = Dir(); err != nil {
+			return err
+		}
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return fmt.Errorf("無法建立記錄檔目錄: %w", err)
+	}
+
+	file, err := OpenRotatingFile(filepath.Join(dir, FileName), MaxFileSize, MaxBackups)
+	if err != nil {
+		return err
+	}
+
+	var w io.Writer = file
+	if opts.Extra != nil {
+		w = io.MultiWriter(file, opts.Extra)
+	}
+
+	mu.Lock()
+	if current != nil {
+		current.Close()
+	}
+	current = file
+	mu.Unlock()
+
+	level.Set(opts.Level)
+	handler := slog.NewTextHandler(w, &slog.HandlerOptions{Level: &level})
+	slog.SetDefault(slog.New(NewRedactHandler(handler)))
+	return nil
+}
+
+// SetLevel 變更記錄等級（設定儲存後立即生效）
+func SetLevel(l slog.Level) {
+	level.Set(l)
+}
+
+// ParseLevel 解析記錄等級名稱，無法辨識時回傳 info
+func ParseLevel(name string) slog.Level {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case LevelDebug:
+		return slog.LevelDebug
+	case LevelWarn, "warning":
+		return slog.LevelWarn
+	case LevelError:
+		return slog.LevelError
+	default:
+		return slog.LevelInfo
+	}
+}
+
+// ValidLevel 檢查記錄等級名稱
+func ValidLevel(name string) bool {
+	switch name {
+	case LevelDebug, LevelInfo, LevelWarn, LevelError:
+		return true
+	}
+	return false
+}
+
+// Files 取得目錄中現有的記錄檔（目前的記錄檔在前，舊檔依序在後）
+func Files(dir string) []string {
+	var files []string
+	for i := 0; i <= MaxBackups; i++ {
+		path := backupName(filepath.Join(dir, FileName), i)
+		if _, err := os.Stat(path); err == nil {
+			files = append(files, path)
+		}
+	}
+	return files
+}
+
+// RotatingFile 依大小輪替的記錄檔
+type RotatingFile struct {
+	mu         sync.Mutex
+	path       string
+	maxSize    int64
+	maxBackups int
+	file       *os.File
+	size       int64
+}
+
+// OpenRotatingFile 開啟（或建立）記錄檔，寫入超過 maxSize 時輪替，最多保留 maxBackups 個舊檔
+func OpenRotatingFile(path string, maxSize int64, maxBackups int) (*RotatingFile, error) {
+	r := &RotatingFile{path: path, maxSize: maxSize, maxBackups: maxBackups}
+	if err := r.open(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// open 以附加模式開啟記錄檔
+func (r *RotatingFile) open() error {
+	file, err := os.OpenFile(r.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return fmt.Errorf("無法開啟記錄檔: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("無法讀取記錄檔資訊: %w", err)
+	}
+	r.file = file
+	r.size = info.Size()
+	return nil
+}
+
+// Write 實作 io.Writer
+func (r *RotatingFile) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.file == nil {
+		return 0, os.ErrClosed
+	}
+	if r.size > 0 && r.size+int64(len(p)) > r.maxSize {
+		if err := r.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := r.file.Write(p)
+	r.size += int64(n)
+	return n, err
+}
+
+// rotate 將目前的記錄檔改名為 .1（既有舊檔依序後移），並開啟新檔
+func (r *RotatingFile) rotate() error {
+	r.file.Close()
+	r.file = nil
+
+	os.Remove(backupName(r.path, r.maxBackups))
+	for i := r.maxBackups - 1; i >= 0; i-- {
+		os.Rename(backupName(r.path, i), backupName(r.path, i+1))
+	}
+	return r.open()
+}
+
+// Close 關閉記錄檔
+func (r *RotatingFile) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.file == nil {
+		return nil
+	}
+	err := r.file.Close()
+	r.file = nil
+	return err
+}
+
+// backupName 取得第 n 個舊記錄檔的路徑（n 為 0 時為目前的記錄檔）
+func backupName(path string, n int) string {
+	if n == 0 {
+		return path
+	}
+	return fmt.Sprintf("%s.%d", path, n)
+}
@@ -0,0 +1,177 @@
+package logging
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/quick"
+)
+
+// newTestLogger 建立輸出至 buffer 的遮蔽 logger
+func newTestLogger(buf *bytes.Buffer) *slog.Logger {
+	return slog.New(NewRedactHandler(slog.NewTextHandler(buf, &slog.HandlerOptions{Level: slog.LevelDebug})))
+}
+
+// fakeToken 測試用的 token 結構（模擬 awssso.KiroAuthToken 以 %+v 輸出）
+type fakeToken struct {
+	AccessToken  string
+	RefreshToken string
+	Provider     string
+}
+
+// TestRedactHandler_Keys 測試依屬性名稱遮蔽機密與識別資訊
+func TestRedactHandler_Keys(t *testing.T) {
+	var buf bytes.Buffer
+	logger := newTestLogger(&buf)
+
+	machineID := strings.Repeat("ab12", 16)
+	logger.Info("refresh",
+		"accessToken", "aoaAAAAAGsecretaccess",
+		"refresh_token", "aorAAAAAGsecretrefresh",
+		"clientSecret", "eyJsecret",
+		"proxyPassword", "hunter2",
+		"machineId", machineID,
+		"profileArn", "arn:aws:codewhisperer:us-east-1:123456789012:profile/ABCDEF",
+		slog.Group("request", "Authorization", "Bearer abc.def.ghi"),
+		"provider", "Github",
+	)
+
+	out := buf.String()
+	for _, secret := range []string{"secretaccess", "secretrefresh", "eyJsecret", "hunter2", machineID, "123456789012", "abc.def.ghi"} {
+		if strings.Contains(out, secret) {
+			t.Errorf("output leaks %q: %s", secret, out)
+		}
+	}
+	for _, kept := range []string{"provider=Github", "machineId=ab12…", "accessToken=" + Redacted} {
+		if !strings.Contains(out, kept) {
+			t.Errorf("output should contain %q: %s", kept, out)
+		}
+	}
+}
+
+// TestRedactHandler_Values 測試掃描訊息、錯誤與結構中的 token 形式字串
+func TestRedactHandler_Values(t *testing.T) {
+	var buf bytes.Buffer
+	logger := newTestLogger(&buf).With("token", "should-not-appear")
+
+	access := "aoaAAAAAGhq3Kd8sLx0pQ2vW9mZrT5yB7nC4fE6gH1jK3lM"
+	logger.Warn("request failed with Authorization: Bearer "+access,
+		"error", errors.New(`API request failed with status 400: {"refreshToken":"aorSecretValue"}`),
+		"authToken", fakeToken{AccessToken: access, RefreshToken: "shortrefresh", Provider: "Google"},
+		"path", "/home/user/.local/share/kiro-manager/backups/my-account/kiro-auth-token.json",
+		"invocation", "5f0c6d2e-3b1a-4c8d-9e7f-0a1b2c3d4e5f",
+	)
+
+	out := buf.String()
+	for _, secret := range []string{access, "aorSecretValue", "shortrefresh", "should-not-appear", "5f0c6d2e-3b1a"} {
+		if strings.Contains(out, secret) {
+			t.Errorf("output leaks %q: %s", secret, out)
+		}
+	}
+	if !strings.Contains(out, "Provider:Google") {
+		t.Errorf("non-secret struct fields should be kept: %s", out)
+	}
+	if !strings.Contains(out, "/home/user/.local/share/kiro-manager/backups/my-account/kiro-auth-token.json") {
+		t.Errorf("file paths should not be redacted: %s", out)
+	}
+}
+
+// TestRotatingFile 測試超過大小上限時輪替，並只保留指定數量的舊檔
+func TestRotatingFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, FileName)
+
+	r, err := OpenRotatingFile(path, 100, 2)
+	if err != nil {
+		t.Fatalf("OpenRotatingFile failed: %v", err)
+	}
+	line := []byte(strings.Repeat("x", 59) + "\n")
+	for i := 0; i < 7; i++ {
+		if _, err := r.Write(line); err != nil {
+			t.Fatalf("Write failed: %v", err)
+		}
+	}
+	r.Close()
+
+	files := Files(dir)
+	if len(files) != 3 || files[0] != path || files[2] != path+".2" {
+		t.Fatalf("Files = %v, want current + 2 backups", files)
+	}
+	for _, f := range files {
+		info, _ := os.Stat(f)
+		if info.Size() > 100 {
+			t.Errorf("%s size = %d, want <= 100", f, info.Size())
+		}
+	}
+	if _, err := os.Stat(path + ".3"); !os.IsNotExist(err) {
+		t.Error("backups beyond MaxBackups should be removed")
+	}
+}
+
+// TestSetup 測試初始化後預設 logger 寫入記錄檔並遵循等級
+func TestSetup(t *testing.T) {
+	old := slog.Default()
+	defer slog.SetDefault(old)
+
+	dir := t.TempDir()
+	if err := Setup(Options{Dir: dir, Level: ParseLevel("warn")}); err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+	defer func() {
+		mu.Lock()
+		current.Close()
+		current = nil
+		mu.Unlock()
+	}()
+
+	slog.Info("hidden")
+	slog.Warn("visible", "accessToken", "secret")
+	SetLevel(slog.LevelDebug)
+	slog.Debug("now visible")
+
+	data, _ := os.ReadFile(filepath.Join(dir, FileName))
+	out := string(data)
+	if strings.Contains(out, "hidden") || !strings.Contains(out, "visible") || !strings.Contains(out, "now visible") {
+		t.Errorf("unexpected log content: %s", out)
+	}
+	if strings.Contains(out, "secret") {
+		t.Errorf("log file leaks secret: %s", out)
+	}
+}
+
+// **Feature: logging, Property 1: Secrets Never Reach Output**
+// *For any* random token value, logging it under a secret key, as a Bearer
+// header in the message, or inside an error SHALL NOT write the value to the log.
+func TestProperty_SecretsNeverReachOutput(t *testing.T) {
+	const alphabet = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789_-"
+
+	f := func(seed int64, n uint8) bool {
+		rng := rand.New(rand.NewSource(seed))
+		token := make([]byte, 12+int(n%64))
+		for i := range token {
+			token[i] = alphabet[rng.Intn(len(alphabet))]
+		}
+		secret := string(token)
+
+		var buf bytes.Buffer
+		logger := newTestLogger(&buf)
+		logger.Info("Authorization: Bearer "+secret,
+			"refreshToken", secret,
+			"error", errors.New(`{"accessToken":"`+secret+`"}`),
+		)
+		return !strings.Contains(buf.String(), secret)
+	}
+
+	config := &quick.Config{
+		MaxCount: 100,
+	}
+
+	if err := quick.Check(f, config); err != nil {
+		t.Errorf("Property test failed: %v", err)
+	}
+}
@@ -0,0 +1,196 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strings"
+)
+
+// Redacted 取代機密值的字串
+const Redacted = "[REDACTED]"
+
+// secretKeys 值一律完全隱藏的屬性名稱（正規化後：小寫、移除 _ 與 -）
+var secretKeys = map[string]bool{
+	"accesstoken":   true,
+	"refreshtoken":  true,
+	"clientsecret":  true,
+	"authorization": true,
+	"token":         true,
+	"idtoken":       true,
+}
+
+// secretKeyParts 名稱包含這些字串的屬性一律完全隱藏
+var secretKeyParts = []string{"password", "passphrase", "secret"}
+
+// identifierKeys 值只保留前 4 個字元的識別資訊屬性
+var identifierKeys = map[string]bool{
+	"machineid":    true,
+	"rawmachineid": true,
+	"clientid":     true,
+	"clientidhash": true,
+	"profilearn":   true,
+	"email":        true,
+	"fingerprint":  true,
+}
+
+var (
+	// bearerPattern Authorization header 中的 token
+	bearerPattern = regexp.MustCompile(`(?i)(bearer\s+)[A-Za-z0-9._~+/=-]+`)
+	// keyValuePattern 文字中以 key=value、key: value 或 JSON 形式出現的機密欄位
+	keyValuePattern = regexp.MustCompile(`(?i)("?\b(?:access_?token|refresh_?token|client_?secret|id_?token|password|passphrase)"?\s*[:=]\s*"?)[^\s"',}&]+`)
+	// arnPattern ARN 中的帳號與資源識別
+	arnPattern = regexp.MustCompile(`(arn:aws[a-z-]*:[a-z0-9-]*:[a-z0-9-]*:)\d{12}:[^\s"',}]+`)
+	// uuidPattern UUID 格式的識別（Machine ID、client id 等）
+	uuidPattern = regexp.MustCompile(`\b[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}\b`)
+	// hexIDPattern 32 字元以上的十六進位識別（雜湊後的 Machine ID、clientIdHash 等）
+	hexIDPattern = regexp.MustCompile(`\b[0-9a-fA-F]{32,}\b`)
+	// opaqueTokenPattern 40 字元以上、不含空白的 token 形式字串（JWT、Kiro access token 等）
+	opaqueTokenPattern = regexp.MustCompile(`[A-Za-z0-9_+/=-]{40,}(\.[A-Za-z0-9_+/=-]+)*`)
+)
+
+// RedactString 遮蔽文字中的 token、密碼與識別資訊
+func RedactString(s string) string {
+	s = bearerPattern.ReplaceAllString(s, "${1}"+Redacted)
+	s = keyValuePattern.ReplaceAllString(s, "${1}"+Redacted)
+	s = arnPattern.ReplaceAllString(s, "${1}****")
+	s = uuidPattern.ReplaceAllStringFunc(s, MaskIdentifier)
+	s = hexIDPattern.ReplaceAllStringFunc(s, MaskIdentifier)
+	s = opaqueTokenPattern.ReplaceAllStringFunc(s, func(m string) string {
+		if looksRandom(m) {
+			return Redacted
+		}
+		return m
+	})
+	return s
+}
+
+// looksRandom 判斷字串是否像隨機產生的 token（同時含大小寫與數字，且不像路徑）
+func looksRandom(s string) bool {
+	var upper, lower, digit bool
+	for _, c := range s {
+		switch {
+		case c >= 'A' && c <= 'Z':
+			upper = true
+		case c >= 'a' && c <= 'z':
+			lower = true
+		case c >= '0' && c <= '9':
+			digit = true
+		}
+	}
+	return upper && lower && digit && strings.Count(s, "/")*16 <= len(s)
+}
+
+// MaskIdentifier 只保留識別資訊的前 4 個字元，方便比對記錄又不洩漏完整值
+func MaskIdentifier(s string) string {
+	if len(s) <= 8 {
+		return "****"
+	}
+	return s[:4] + "…"
+}
+
+// normalizeKey 正規化屬性名稱（小寫、移除 _ 與 -）
+func normalizeKey(key string) string {
+	return strings.NewReplacer("_", "", "-", "").Replace(strings.ToLower(key))
+}
+
+// isSecretKey 檢查屬性名稱是否為機密欄位
+func isSecretKey(key string) bool {
+	if secretKeys[key] {
+		return true
+	}
+	for _, part := range secretKeyParts {
+		if strings.Contains(key, part) {
+			return true
+		}
+	}
+	return false
+}
+
+// redactAttr 遮蔽單一屬性（群組會遞迴處理）
+func redactAttr(a slog.Attr) slog.Attr {
+	a.Value = a.Value.Resolve()
+	key := normalizeKey(a.Key)
+
+	if a.Value.Kind() == slog.KindGroup {
+		attrs := a.Value.Group()
+		redacted := make([]slog.Attr, len(attrs))
+		for i, attr := range attrs {
+			redacted[i] = redactAttr(attr)
+		}
+		return slog.Attr{Key: a.Key, Value: slog.GroupValue(redacted...)}
+	}
+
+	switch {
+	case isSecretKey(key):
+		if a.Value.Kind() == slog.KindString && a.Value.String() == "" {
+			return a
+		}
+		return slog.String(a.Key, Redacted)
+	case identifierKeys[key]:
+		return slog.String(a.Key, RedactString(MaskIdentifier(valueString(a.Value))))
+	}
+
+	switch a.Value.Kind() {
+	case slog.KindString, slog.KindAny:
+		return slog.String(a.Key, RedactString(valueString(a.Value)))
+	default:
+		return a
+	}
+}
+
+// valueString 取得屬性值的文字形式（結構以 %+v 展開，以便遮蔽其中的欄位）
+func valueString(v slog.Value) string {
+	switch v.Kind() {
+	case slog.KindString:
+		return v.String()
+	case slog.KindAny:
+		if err, ok := v.Any().(error); ok {
+			return err.Error()
+		}
+		return fmt.Sprintf("%+v", v.Any())
+	default:
+		return v.String()
+	}
+}
+
+// RedactHandler 在輸出前遮蔽機密值的 slog.Handler
+// 依屬性名稱隱藏 token、密碼與識別資訊，並掃描訊息與文字值中的 token 形式字串
+type RedactHandler struct {
+	inner slog.Handler
+}
+
+// NewRedactHandler 包裝 handler，輸出前遮蔽機密值
+func NewRedactHandler(inner slog.Handler) *RedactHandler {
+	return &RedactHandler{inner: inner}
+}
+
+// Enabled 實作 slog.Handler
+func (h *RedactHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.inner.Enabled(ctx, level)
+}
+
+// Handle 實作 slog.Handler
+func (h *RedactHandler) Handle(ctx context.Context, r slog.Record) error {
+	redacted := slog.NewRecord(r.Time, r.Level, RedactString(r.Message), r.PC)
+	r.Attrs(func(a slog.Attr) bool {
+		redacted.AddAttrs(redactAttr(a))
+		return true
+	})
+	return h.inner.Handle(ctx, redacted)
+}
+
+// WithAttrs 實作 slog.Handler
+func (h *RedactHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	redacted := make([]slog.Attr, len(attrs))
+	for i, a := range attrs {
+		redacted[i] = redactAttr(a)
+	}
+	return &RedactHandler{inner: h.inner.WithAttrs(redacted)}
+}
+
+// WithGroup 實作 slog.Handler
+func (h *RedactHandler) WithGroup(name string) slog.Handler {
+	return &RedactHandler{inner: h.inner.WithGroup(name)}
+}
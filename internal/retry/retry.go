@@ -0,0 +1,221 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	// DefaultMaxAttempts 預設最多嘗試次數（含第一次）
+	DefaultMaxAttempts = 3
+	// DefaultBaseDelay 預設第一次重試前的等待時間
+	DefaultBaseDelay = 500 * time.Millisecond
+	// DefaultMaxDelay 預設單次等待上限；Retry-After 超過此值時不再重試
+	DefaultMaxDelay = 8 * time.Second
+)
+
+// Policy 重試策略：指數退避加隨機抖動，並遵循伺服器的 Retry-After
+// 只重試網路錯誤、408、429 與 5xx（501、505 除外）；401/403 一律不重試
+type Policy struct {
+	MaxAttempts int           // 最多嘗試次數（含第一次），小於 1 視為 1
+	BaseDelay   time.Duration // 第一次重試前的等待時間，之後每次加倍
+	MaxDelay    time.Duration // 單次等待上限
+
+	// Sleep 等待函數（測試時可替換），空值時使用可被 ctx 取消的計時器
+	Sleep func(ctx context.Context, d time.Duration) error
+	// Rand 回傳 [0, 1) 的亂數（測試時可替換），空值時使用 math/rand
+	Rand func() float64
+	// Now 解析 HTTP-date 格式的 Retry-After 使用的時鐘，空值時使用 time.Now
+	Now func() time.Time
+	// Retryable 自訂單次結果是否重試（非冪等請求使用，見 BeforeSendOnly），空值時使用上述預設規則
+	// context 已取消時一律不重試，不會呼叫此函數
+	Retryable func(resp *http.Response, err error) bool
+}
+
+// Default 回傳預設重試策略
+func Default() Policy {
+	return Policy{
+		MaxAttempts: DefaultMaxAttempts,
+		BaseDelay:   DefaultBaseDelay,
+		MaxDelay:    DefaultMaxDelay,
+	}
+}
+
+// Attempt 單次嘗試的編號資訊
+type Attempt struct {
+	Number int // 第幾次嘗試（從 1 開始）
+	Max    int // 最多嘗試次數
+}
+
+// SDKHeader 回傳 AWS SDK 格式的 amz-sdk-request header 值
+func (a Attempt) SDKHeader() string {
+	return fmt.Sprintf("attempt=%d; max=%d", a.Number, a.Max)
+}
+
+// Do 依策略發送請求，回傳最後一次的回應或錯誤以及實際嘗試次數
+// send 每次嘗試都會被呼叫（請求 body 需在 send 內重新建立）；
+// 被重試的回應 body 會被讀完並關閉，最後回傳的回應由呼叫端負責關閉
+func (p Policy) Do(ctx context.Context, send func(Attempt) (*http.Response, error)) (*http.Response, int, error) {
+	maxAttempts := p.MaxAttempts
+	if maxAttempts < 1 {
+		maxAttempts = 1
+	}
+
+	for n := 1; ; n++ {
+		resp, err := send(Attempt{Number: n, Max: maxAttempts})
+		if n >= maxAttempts || !p.shouldRetry(ctx, resp, err) {
+			return resp, n, err
+		}
+
+		delay := p.Backoff(n)
+		if resp != nil {
+			if retryAfter, ok := ParseRetryAfter(resp.Header.Get("Retry-After"), p.now()); ok {
+				// 伺服器要求的等待時間超過上限時直接放棄，交由呼叫端回報錯誤
+				if p.MaxDelay > 0 && retryAfter > p.MaxDelay {
+					return resp, n, err
+				}
+				delay = retryAfter
+			}
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+
+		slog.Debug("retrying request", "attempt", n, "maxAttempts", maxAttempts, "status", statusOf(resp), "error", err, "delay", delay)
+		if sleepErr := p.sleep(ctx, delay); sleepErr != nil {
+			return nil, n, sleepErr
+		}
+	}
+}
+
+// statusOf 取得回應的狀態碼（網路錯誤時為 0）
+func statusOf(resp *http.Response) int {
+	if resp == nil {
+		return 0
+	}
+	return resp.StatusCode
+}
+
+// Backoff 計算第 n 次嘗試失敗後的等待時間
+// 以 BaseDelay * 2^(n-1) 為上限（不超過 MaxDelay），實際值落在上限的 50% ~ 100%
+func (p Policy) Backoff(n int) time.Duration {
+	if p.BaseDelay <= 0 || n < 1 {
+		return 0
+	}
+
+	ceiling := p.BaseDelay
+	for i := 1; i < n; i++ {
+		ceiling *= 2
+		if p.MaxDelay > 0 && ceiling >= p.MaxDelay {
+			break
+		}
+	}
+	if p.MaxDelay > 0 && ceiling > p.MaxDelay {
+		ceiling = p.MaxDelay
+	}
+
+	half := ceiling / 2
+	return half + time.Duration(p.random()*float64(ceiling-half))
+}
+
+// IsRetryableStatus 判斷 HTTP 狀態碼是否值得重試
+func IsRetryableStatus(code int) bool {
+	switch code {
+	case http.StatusRequestTimeout, http.StatusTooManyRequests:
+		return true
+	case http.StatusNotImplemented, http.StatusHTTPVersionNotSupported:
+		return false
+	}
+	return code >= 500 && code < 600
+}
+
+// ParseRetryAfter 解析 Retry-After header（秒數或 HTTP-date）
+func ParseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		if d := at.Sub(now); d > 0 {
+			return d, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
+
+// shouldRetry 判斷單次嘗試的結果是否應重試
+func (p Policy) shouldRetry(ctx context.Context, resp *http.Response, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	if err != nil && (errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)) {
+		return false
+	}
+	if p.Retryable != nil {
+		return p.Retryable(resp, err)
+	}
+	if err != nil {
+		return true
+	}
+	return resp != nil && IsRetryableStatus(resp.StatusCode)
+}
+
+// BeforeSendOnly 非冪等請求（如會輪替 refresh token 的刷新請求）的重試判斷
+// 只重試請求送出前的失敗（sent 回傳 false，例如 DNS、連線、代理與 TLS 錯誤），
+// 以及附帶 Retry-After 的 429 與 503（伺服器明確表示未處理）；
+// 請求送出後的讀取錯誤與其他 5xx 無法確定伺服器是否已處理，不重試
+func BeforeSendOnly(sent func() bool) func(resp *http.Response, err error) bool {
+	return func(resp *http.Response, err error) bool {
+		if err != nil {
+			return !sent()
+		}
+		if resp == nil || resp.Header.Get("Retry-After") == "" {
+			return false
+		}
+		return resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusServiceUnavailable
+	}
+}
+
+// sleep 等待指定時間，ctx 取消時提前返回
+func (p Policy) sleep(ctx context.Context, d time.Duration) error {
+	if p.Sleep != nil {
+		return p.Sleep(ctx, d)
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// random 回傳 [0, 1) 的亂數
+func (p Policy) random() float64 {
+	if p.Rand != nil {
+		return p.Rand()
+	}
+	return rand.Float64()
+}
+
+// now 取得目前時間
+func (p Policy) now() time.Time {
+	if p.Now != nil {
+		return p.Now()
+	}
+	return time.Now()
+}
@@ -0,0 +1,210 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"testing/quick"
+	"time"
+)
+
+// response 建立指定狀態碼與 headers 的回應
+func response(code int, headers ...string) *http.Response {
+	resp := &http.Response{StatusCode: code, Header: http.Header{}, Body: io.NopCloser(strings.NewReader("body"))}
+	for i := 0; i+1 < len(headers); i += 2 {
+		resp.Header.Set(headers[i], headers[i+1])
+	}
+	return resp
+}
+
+// recordingPolicy 建立記錄等待時間且不實際等待的策略
+func recordingPolicy(maxAttempts int, delays *[]time.Duration) Policy {
+	return Policy{
+		MaxAttempts: maxAttempts,
+		BaseDelay:   100 * time.Millisecond,
+		MaxDelay:    2 * time.Second,
+		Rand:        func() float64 { return 1 },
+		Sleep: func(ctx context.Context, d time.Duration) error {
+			*delays = append(*delays, d)
+			return nil
+		},
+	}
+}
+
+// TestDo_RetriesRetryableStatus 測試 429/5xx 重試直到成功，並回報嘗試次數
+func TestDo_RetriesRetryableStatus(t *testing.T) {
+	var delays []time.Duration
+	codes := []int{503, 429, 200}
+	var seen []Attempt
+
+	resp, attempts, err := recordingPolicy(5, &delays).Do(context.Background(), func(a Attempt) (*http.Response, error) {
+		seen = append(seen, a)
+		return response(codes[a.Number-1]), nil
+	})
+	if err != nil {
+		t.Fatalf("Do failed: %v", err)
+	}
+	if resp.StatusCode != 200 || attempts != 3 {
+		t.Errorf("status = %d, attempts = %d, want 200 and 3", resp.StatusCode, attempts)
+	}
+	if seen[2].SDKHeader() != "attempt=3; max=5" {
+		t.Errorf("SDKHeader = %q", seen[2].SDKHeader())
+	}
+	if len(delays) != 2 || delays[0] != 100*time.Millisecond || delays[1] != 200*time.Millisecond {
+		t.Errorf("delays = %v, want [100ms 200ms]", delays)
+	}
+}
+
+// TestDo_NeverRetriesAuthErrors 測試 401/403 不重試
+func TestDo_NeverRetriesAuthErrors(t *testing.T) {
+	for _, code := range []int{401, 403, 400, 404} {
+		var delays []time.Duration
+		_, attempts, _ := recordingPolicy(5, &delays).Do(context.Background(), func(Attempt) (*http.Response, error) {
+			return response(code), nil
+		})
+		if attempts != 1 || len(delays) != 0 {
+			t.Errorf("status %d: attempts = %d, want 1", code, attempts)
+		}
+	}
+}
+
+// TestDo_StopsAtMaxAttempts 測試達到上限時回傳最後一次的回應
+func TestDo_StopsAtMaxAttempts(t *testing.T) {
+	var delays []time.Duration
+	resp, attempts, err := recordingPolicy(3, &delays).Do(context.Background(), func(Attempt) (*http.Response, error) {
+		return response(500), nil
+	})
+	if err != nil || resp.StatusCode != 500 || attempts != 3 {
+		t.Errorf("Do = (%v, %d, %v), want final 500 after 3 attempts", resp, attempts, err)
+	}
+}
+
+// TestDo_HonoursRetryAfter 測試遵循 Retry-After，超過上限時放棄
+func TestDo_HonoursRetryAfter(t *testing.T) {
+	var delays []time.Duration
+	_, attempts, _ := recordingPolicy(2, &delays).Do(context.Background(), func(a Attempt) (*http.Response, error) {
+		if a.Number == 1 {
+			return response(429, "Retry-After", "1"), nil
+		}
+		return response(200), nil
+	})
+	if attempts != 2 || len(delays) != 1 || delays[0] != time.Second {
+		t.Errorf("attempts = %d, delays = %v, want one 1s wait", attempts, delays)
+	}
+
+	delays = nil
+	resp, attempts, _ := recordingPolicy(5, &delays).Do(context.Background(), func(Attempt) (*http.Response, error) {
+		return response(503, "Retry-After", "3600"), nil
+	})
+	if attempts != 1 || len(delays) != 0 || resp.StatusCode != 503 {
+		t.Errorf("Retry-After beyond MaxDelay should stop retrying: attempts = %d, delays = %v", attempts, delays)
+	}
+}
+
+// TestDo_NetworkErrorsAndCancellation 測試網路錯誤會重試，取消的 context 不重試
+func TestDo_NetworkErrorsAndCancellation(t *testing.T) {
+	var delays []time.Duration
+	netErr := errors.New("connection reset")
+	_, attempts, err := recordingPolicy(3, &delays).Do(context.Background(), func(Attempt) (*http.Response, error) {
+		return nil, netErr
+	})
+	if !errors.Is(err, netErr) || attempts != 3 {
+		t.Errorf("Do = (%d, %v), want 3 attempts with network error", attempts, err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, attempts, _ = recordingPolicy(3, &delays).Do(ctx, func(Attempt) (*http.Response, error) {
+		return nil, context.Canceled
+	})
+	if attempts != 1 {
+		t.Errorf("canceled context: attempts = %d, want 1", attempts)
+	}
+}
+
+// TestDo_BeforeSendOnly 測試非冪等請求只重試送出前的失敗與附帶 Retry-After 的 429/503
+func TestDo_BeforeSendOnly(t *testing.T) {
+	tests := []struct {
+		name         string
+		sent         bool
+		resp         func() *http.Response
+		err          error
+		wantAttempts int
+	}{
+		{"dial error before send", false, nil, errors.New("connection refused"), 3},
+		{"read error after send", true, nil, errors.New("connection reset"), 1},
+		{"500 after send", true, func() *http.Response { return response(500) }, nil, 1},
+		{"503 without Retry-After", true, func() *http.Response { return response(503) }, nil, 1},
+		{"503 with Retry-After", true, func() *http.Response { return response(503, "Retry-After", "1") }, nil, 3},
+		{"429 with Retry-After", true, func() *http.Response { return response(429, "Retry-After", "1") }, nil, 3},
+		{"502 with Retry-After", true, func() *http.Response { return response(502, "Retry-After", "1") }, nil, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var delays []time.Duration
+			policy := recordingPolicy(3, &delays)
+			policy.Retryable = BeforeSendOnly(func() bool { return tt.sent })
+
+			_, attempts, _ := policy.Do(context.Background(), func(Attempt) (*http.Response, error) {
+				if tt.resp != nil {
+					return tt.resp(), nil
+				}
+				return nil, tt.err
+			})
+			if attempts != tt.wantAttempts {
+				t.Errorf("attempts = %d, want %d", attempts, tt.wantAttempts)
+			}
+		})
+	}
+}
+
+// TestParseRetryAfter 測試秒數與 HTTP-date 格式
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	if d, ok := ParseRetryAfter("5", now); !ok || d != 5*time.Second {
+		t.Errorf("ParseRetryAfter(5) = %v, %v", d, ok)
+	}
+	if d, ok := ParseRetryAfter(now.Add(30*time.Second).Format(http.TimeFormat), now); !ok || d != 30*time.Second {
+		t.Errorf("ParseRetryAfter(date) = %v, %v", d, ok)
+	}
+	for _, invalid := range []string{"", "-1", "soon"} {
+		if _, ok := ParseRetryAfter(invalid, now); ok {
+			t.Errorf("ParseRetryAfter(%q) should be invalid", invalid)
+		}
+	}
+}
+
+// **Feature: retry-policy, Property 1: Backoff Stays Within Bounds**
+// *For any* attempt number and jitter value, the backoff SHALL be between half
+// of the exponential ceiling and the ceiling, and never exceed MaxDelay.
+func TestProperty_BackoffWithinBounds(t *testing.T) {
+	f := func(n uint8, jitter uint16) bool {
+		attempt := int(n%20) + 1
+		p := Policy{
+			BaseDelay: 100 * time.Millisecond,
+			MaxDelay:  5 * time.Second,
+			Rand:      func() float64 { return float64(jitter) / 65536 },
+		}
+
+		ceiling := p.BaseDelay << (attempt - 1)
+		if attempt > 10 || ceiling > p.MaxDelay {
+			ceiling = p.MaxDelay
+		}
+
+		d := p.Backoff(attempt)
+		return d >= ceiling/2 && d <= ceiling
+	}
+
+	config := &quick.Config{
+		MaxCount: 100,
+	}
+
+	if err := quick.Check(f, config); err != nil {
+		t.Errorf("Property test failed: %v", err)
+	}
+}
@@ -0,0 +1,250 @@
+package transport
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// 連線測試的各個階段
+const (
+	HopConfig = "config" // 連線設定（代理 URL、CA 憑證檔）
+	HopDNS    = "dns"    // 解析主機名稱（有代理時為代理主機）
+	HopTCP    = "tcp"    // 建立 TCP 連線（有代理時為代理主機）
+	HopProxy  = "proxy"  // 透過代理建立 CONNECT 通道
+	HopTLS    = "tls"    // 與目標主機的 TLS 交握（驗證憑證）
+	HopHTTP   = "http"   // 發送 HTTP 請求（收到任何回應即視為連通）
+)
+
+// HopResult 單一階段的測試結果
+type HopResult struct {
+	Hop        string `json:"hop"`
+	Target     string `json:"target"`
+	OK         bool   `json:"ok"`
+	Detail     string `json:"detail,omitempty"`
+	Error      string `json:"error,omitempty"`
+	DurationMs int64  `json:"durationMs"`
+}
+
+// Report 連線測試報告
+type Report struct {
+	URL       string      `json:"url"`
+	Proxy     string      `json:"proxy,omitempty"` // 使用的代理（已隱藏密碼），直連時為空
+	OK        bool        `json:"ok"`
+	FailedHop string      `json:"failedHop,omitempty"` // 失敗的階段，成功時為空
+	Hops      []HopResult `json:"hops"`
+}
+
+// diagnosis 連線測試進行中的狀態
+type diagnosis struct {
+	report Report
+}
+
+// step 執行單一階段並記錄結果，失敗時回傳 false
+func (d *diagnosis) step(hop, target string, fn func() (string, error)) bool {
+	start := time.Now()
+	detail, err := fn()
+
+	result := HopResult{
+		Hop:        hop,
+		Target:     target,
+		OK:         err == nil,
+		Detail:     detail,
+		DurationMs: time.Since(start).Milliseconds(),
+	}
+	if err != nil {
+		result.Error = err.Error()
+		d.report.FailedHop = hop
+	}
+	d.report.Hops = append(d.report.Hops, result)
+	return err == nil
+}
+
+// Diagnose 依設定逐段測試至 rawURL 的連線，回報失敗的階段
+// 直連: dns → tcp → tls → http；經代理: dns → tcp（代理）→ proxy（CONNECT）→ tls → http
+func Diagnose(ctx context.Context, cfg Config, rawURL string) Report {
+	d := &diagnosis{report: Report{URL: rawURL}}
+
+	var target *url.URL
+	var proxy *url.URL
+	var tlsConfig *tls.Config
+	ok := d.step(HopConfig, rawURL, func() (string, error) {
+		var err error
+		target, err = url.Parse(rawURL)
+		if err != nil {
+			return "", fmt.Errorf("URL 無效: %w", err)
+		}
+		if target.Scheme != "http" && target.Scheme != "https" || target.Host == "" {
+			return "", fmt.Errorf("URL 需為 http(s) 絕對網址: %s", rawURL)
+		}
+		if proxy, err = ProxyForURL(cfg, target); err != nil {
+			return "", err
+		}
+		if tlsConfig, err = TLSConfig(cfg); err != nil {
+			return "", err
+		}
+		if proxy != nil {
+			return "經由代理 " + Redact(proxy), nil
+		}
+		return "直連", nil
+	})
+	if !ok {
+		return d.report
+	}
+	d.report.Proxy = Redact(proxy)
+
+	targetAddr := hostPort(target)
+	dialAddr := targetAddr
+	if proxy != nil {
+		dialAddr = hostPort(proxy)
+	}
+	dialHost, _, _ := net.SplitHostPort(dialAddr)
+
+	ok = d.step(HopDNS, dialHost, func() (string, error) {
+		if net.ParseIP(dialHost) != nil {
+			return "IP 位址，略過解析", nil
+		}
+		addrs, err := net.DefaultResolver.LookupHost(ctx, dialHost)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprint(addrs), nil
+	})
+	if !ok {
+		return d.report
+	}
+
+	var conn net.Conn
+	ok = d.step(HopTCP, dialAddr, func() (string, error) {
+		var err error
+		conn, err = (&net.Dialer{}).DialContext(ctx, "tcp", dialAddr)
+		if err != nil {
+			return "", err
+		}
+		return conn.RemoteAddr().String(), nil
+	})
+	if !ok {
+		return d.report
+	}
+	defer func() { conn.Close() }()
+	if deadline, has := ctx.Deadline(); has {
+		conn.SetDeadline(deadline)
+	}
+
+	if target.Scheme == "https" {
+		if proxy != nil {
+			ok = d.step(HopProxy, Redact(proxy), func() (string, error) {
+				tunnel, err := connectTunnel(ctx, conn, proxy, targetAddr)
+				if err != nil {
+					return "", err
+				}
+				conn = tunnel
+				return "CONNECT " + targetAddr, nil
+			})
+			if !ok {
+				return d.report
+			}
+		}
+
+		ok = d.step(HopTLS, targetAddr, func() (string, error) {
+			config := &tls.Config{ServerName: target.Hostname()}
+			if tlsConfig != nil {
+				config = tlsConfig.Clone()
+				config.ServerName = target.Hostname()
+			}
+			tlsConn := tls.Client(conn, config)
+			if err := tlsConn.HandshakeContext(ctx); err != nil {
+				return "", err
+			}
+			conn = tlsConn
+
+			state := tlsConn.ConnectionState()
+			if len(state.PeerCertificates) > 0 {
+				return "憑證簽發者: " + state.PeerCertificates[0].Issuer.CommonName, nil
+			}
+			return tls.VersionName(state.Version), nil
+		})
+		if !ok {
+			return d.report
+		}
+	}
+
+	d.report.OK = d.step(HopHTTP, rawURL, func() (string, error) {
+		t, err := New(cfg)
+		if err != nil {
+			return "", err
+		}
+		defer t.CloseIdleConnections()
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+		if err != nil {
+			return "", err
+		}
+		resp, err := (&http.Client{Transport: t}).Do(req)
+		if err != nil {
+			return "", err
+		}
+		resp.Body.Close()
+		return resp.Status, nil
+	})
+	return d.report
+}
+
+// connectTunnel 透過代理建立 CONNECT 通道
+func connectTunnel(ctx context.Context, conn net.Conn, proxy *url.URL, targetAddr string) (net.Conn, error) {
+	if proxy.Scheme == "https" {
+		tlsConn := tls.Client(conn, &tls.Config{ServerName: proxy.Hostname()})
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			return nil, fmt.Errorf("與代理的 TLS 交握失敗: %w", err)
+		}
+		conn = tlsConn
+	}
+
+	req := &http.Request{
+		Method: http.MethodConnect,
+		URL:    &url.URL{Opaque: targetAddr},
+		Host:   targetAddr,
+		Header: http.Header{},
+	}
+	if proxy.User != nil {
+		password, _ := proxy.User.Password()
+		credentials := base64.StdEncoding.EncodeToString([]byte(proxy.User.Username() + ":" + password))
+		req.Header.Set("Proxy-Authorization", "Basic "+credentials)
+	}
+	if err := req.Write(conn); err != nil {
+		return nil, fmt.Errorf("發送 CONNECT 失敗: %w", err)
+	}
+
+	resp, err := http.ReadResponse(bufio.NewReader(conn), req)
+	if err != nil {
+		return nil, fmt.Errorf("讀取代理回應失敗: %w", err)
+	}
+	resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return conn, nil
+	case http.StatusProxyAuthRequired:
+		return nil, fmt.Errorf("代理要求驗證（%s），請檢查代理帳號密碼", resp.Status)
+	default:
+		return nil, fmt.Errorf("代理拒絕 CONNECT: %s", resp.Status)
+	}
+}
+
+// hostPort 取得 URL 的 host:port（未指定 port 時依 scheme 補上預設值）
+func hostPort(u *url.URL) string {
+	port := u.Port()
+	if port == "" {
+		port = "80"
+		if u.Scheme == "https" {
+			port = "443"
+		}
+	}
+	return net.JoinHostPort(u.Hostname(), port)
+}
@@ -0,0 +1,107 @@
+package transport
+
+import (
+	"net"
+	"net/url"
+	"strings"
+)
+
+// noProxyList NO_PROXY 清單
+// 支援 "*"、主機名稱（含子網域）、".domain"（僅子網域）、"*.domain"、IP、CIDR，以及 ":port" 後綴
+type noProxyList struct {
+	all     bool
+	domains []noProxyDomain
+	ips     []net.IP
+	nets    []*net.IPNet
+}
+
+// noProxyDomain 網域項目
+type noProxyDomain struct {
+	name           string // 小寫、不含開頭的 "."
+	subdomainsOnly bool   // 以 "." 或 "*." 開頭時只比對子網域
+	port           string // 空字串表示任意 port
+}
+
+// parseNoProxy 解析逗號分隔的 NO_PROXY 清單
+func parseNoProxy(value string) *noProxyList {
+	list := &noProxyList{}
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.ToLower(strings.TrimSpace(entry))
+		if entry == "" {
+			continue
+		}
+		if entry == "*" {
+			list.all = true
+			continue
+		}
+
+		if _, ipNet, err := net.ParseCIDR(entry); err == nil {
+			list.nets = append(list.nets, ipNet)
+			continue
+		}
+
+		host, port := entry, ""
+		if h, p, err := net.SplitHostPort(entry); err == nil {
+			host, port = h, p
+		}
+		if ip := net.ParseIP(strings.Trim(host, "[]")); ip != nil {
+			list.ips = append(list.ips, ip)
+			continue
+		}
+
+		domain := noProxyDomain{port: port}
+		switch {
+		case strings.HasPrefix(host, "*."):
+			domain.name, domain.subdomainsOnly = host[2:], true
+		case strings.HasPrefix(host, "."):
+			domain.name, domain.subdomainsOnly = host[1:], true
+		default:
+			domain.name = host
+		}
+		list.domains = append(list.domains, domain)
+	}
+	return list
+}
+
+// useProxy 判斷 target 是否應經過代理
+// 與 Go 標準函式庫相同，localhost 與 loopback 位址一律不經代理
+func (l *noProxyList) useProxy(target *url.URL) bool {
+	host := strings.ToLower(target.Hostname())
+	if host == "" || host == "localhost" {
+		return false
+	}
+	if l.all {
+		return false
+	}
+
+	if ip := net.ParseIP(host); ip != nil {
+		if ip.IsLoopback() {
+			return false
+		}
+		for _, excluded := range l.ips {
+			if excluded.Equal(ip) {
+				return false
+			}
+		}
+		for _, ipNet := range l.nets {
+			if ipNet.Contains(ip) {
+				return false
+			}
+		}
+		return true
+	}
+
+	port := target.Port()
+	if port == "" {
+		port = map[string]string{"http": "80", "https": "443"}[target.Scheme]
+	}
+	for _, d := range l.domains {
+		if d.port != "" && d.port != port {
+			continue
+		}
+		if strings.HasSuffix(host, "."+d.name) || (!d.subdomainsOnly && host == d.name) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,227 @@
+package transport
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+)
+
+// Config 對外連線設定
+// 未指定 ProxyURL 時遵循環境變數 HTTPS_PROXY / HTTP_PROXY / NO_PROXY
+type Config struct {
+	ProxyURL      string // 明確指定的 HTTP(S) 代理，例如 http://proxy.corp:3128
+	ProxyUsername string // 代理驗證帳號（選填）
+	ProxyPassword string // 代理驗證密碼（選填）
+	NoProxy       string // 不經代理的主機清單（逗號分隔），與環境變數 NO_PROXY 合併
+	CABundle      string // 額外信任的 PEM CA 憑證檔路徑（選填），與系統根憑證一併使用
+}
+
+// getenv 讀取環境變數（測試時可替換）
+var getenv = os.Getenv
+
+// New 依設定建立 http.Transport
+// 代理設定或 CA 憑證檔無效時回傳錯誤
+func New(cfg Config) (*http.Transport, error) {
+	proxy, err := ProxyFunc(cfg)
+	if err != nil {
+		return nil, err
+	}
+	tlsConfig, err := TLSConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.Proxy = proxy
+	t.TLSClientConfig = tlsConfig
+	return t, nil
+}
+
+// TLSConfig 建立 TLS 設定（系統根憑證加上 CA 憑證檔）
+// 未指定 CA 憑證檔時回傳 nil（使用系統預設）
+func TLSConfig(cfg Config) (*tls.Config, error) {
+	if cfg.CABundle == "" {
+		return nil, nil
+	}
+
+	data, err := os.ReadFile(cfg.CABundle)
+	if err != nil {
+		return nil, fmt.Errorf("無法讀取 CA 憑證檔: %w", err)
+	}
+
+	pool, err := x509.SystemCertPool()
+	if err != nil || pool == nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("CA 憑證檔中沒有有效的 PEM 憑證: %s", cfg.CABundle)
+	}
+
+	return &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}, nil
+}
+
+// ProxyFunc 建立 http.Transport 使用的代理選擇函數
+func ProxyFunc(cfg Config) (func(*http.Request) (*url.URL, error), error) {
+	proxies, noProxy, err := resolveProxies(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(req *http.Request) (*url.URL, error) {
+		return proxyFor(req.URL, proxies, noProxy), nil
+	}, nil
+}
+
+// ProxyForURL 取得連線至 target 時使用的代理（不經代理時回傳 nil）
+func ProxyForURL(cfg Config, target *url.URL) (*url.URL, error) {
+	proxies, noProxy, err := resolveProxies(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return proxyFor(target, proxies, noProxy), nil
+}
+
+// proxySet 依 scheme 區分的代理
+type proxySet struct {
+	http  *url.URL
+	https *url.URL
+}
+
+// resolveProxies 解析明確設定或環境變數中的代理與 NO_PROXY 清單
+func resolveProxies(cfg Config) (proxySet, *noProxyList, error) {
+	noProxyValue := firstEnv("NO_PROXY", "no_proxy")
+	if cfg.NoProxy != "" {
+		noProxyValue = strings.Trim(noProxyValue+","+cfg.NoProxy, ",")
+	}
+	noProxy := parseNoProxy(noProxyValue)
+
+	if cfg.ProxyURL != "" {
+		proxy, err := parseProxyURL(cfg.ProxyURL)
+		if err != nil {
+			return proxySet{}, nil, err
+		}
+		if cfg.ProxyUsername != "" {
+			proxy.User = url.UserPassword(cfg.ProxyUsername, cfg.ProxyPassword)
+		}
+		return proxySet{http: proxy, https: proxy}, noProxy, nil
+	}
+
+	var proxies proxySet
+	if raw := firstEnv("HTTP_PROXY", "http_proxy"); raw != "" {
+		proxy, err := parseProxyURL(raw)
+		if err != nil {
+			return proxySet{}, nil, fmt.Errorf("環境變數 HTTP_PROXY 無效: %w", err)
+		}
+		proxies.http = proxy
+	}
+	if raw := firstEnv("HTTPS_PROXY", "https_proxy"); raw != "" {
+		proxy, err := parseProxyURL(raw)
+		if err != nil {
+			return proxySet{}, nil, fmt.Errorf("環境變數 HTTPS_PROXY 無效: %w", err)
+		}
+		proxies.https = proxy
+	}
+	return proxies, noProxy, nil
+}
+
+// proxyFor 選擇 target 使用的代理
+func proxyFor(target *url.URL, proxies proxySet, noProxy *noProxyList) *url.URL {
+	proxy := proxies.http
+	if target.Scheme == "https" {
+		proxy = proxies.https
+	}
+	if proxy == nil || !noProxy.useProxy(target) {
+		return nil
+	}
+	return proxy
+}
+
+// parseProxyURL 解析代理 URL，未指定 scheme 時視為 http://
+func parseProxyURL(raw string) (*url.URL, error) {
+	raw = strings.TrimSpace(raw)
+	if !strings.Contains(raw, "://") {
+		raw = "http://" + raw
+	}
+
+	proxy, err := url.Parse(raw)
+	if err != nil {
+		return nil, fmt.Errorf("代理 URL 無效: %w", err)
+	}
+	if proxy.Scheme != "http" && proxy.Scheme != "https" {
+		return nil, fmt.Errorf("不支援的代理協定: %s", proxy.Scheme)
+	}
+	if proxy.Host == "" {
+		return nil, fmt.Errorf("代理 URL 缺少主機: %s", Redact(proxy))
+	}
+	return proxy, nil
+}
+
+// ValidProxyURL 檢查代理 URL 格式
+func ValidProxyURL(raw string) bool {
+	_, err := parseProxyURL(raw)
+	return err == nil
+}
+
+// Redact 隱藏 URL 中的密碼，供顯示與記錄使用
+func Redact(u *url.URL) string {
+	if u == nil {
+		return ""
+	}
+	return u.Redacted()
+}
+
+// firstEnv 取得第一個非空的環境變數
+func firstEnv(names ...string) string {
+	for _, name := range names {
+		if value := getenv(name); value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// errorTransport 設定無效時使用的 RoundTripper，於每次請求回傳設定錯誤
+type errorTransport struct {
+	err error
+}
+
+func (t errorTransport) RoundTrip(*http.Request) (*http.Response, error) {
+	return nil, t.err
+}
+
+// shared 所有對外連線共用的 Transport（依設定快取，設定變更時重建）
+var shared struct {
+	mu        sync.Mutex
+	cfg       Config
+	transport http.RoundTripper
+}
+
+// Shared 取得依設定建立的共用 Transport
+// 相同設定重複呼叫會取得同一個 Transport（共用連線池）；
+// 設定無效時回傳的 RoundTripper 會在請求時回報設定錯誤
+func Shared(cfg Config) http.RoundTripper {
+	shared.mu.Lock()
+	defer shared.mu.Unlock()
+
+	if shared.transport != nil && shared.cfg == cfg {
+		return shared.transport
+	}
+
+	if old, ok := shared.transport.(*http.Transport); ok {
+		old.CloseIdleConnections()
+	}
+
+	t, err := New(cfg)
+	if err != nil {
+		shared.transport = errorTransport{err: fmt.Errorf("連線設定無效: %w", err)}
+	} else {
+		shared.transport = t
+	}
+	shared.cfg = cfg
+	return shared.transport
+}
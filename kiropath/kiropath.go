@@ -4,7 +4,9 @@ import (
 	"errors"
 	"os"
 	"path/filepath"
+	"regexp"
 	"runtime"
+	"strings"
 )
 
 var (
@@ -70,6 +72,47 @@ func GetKiroInstallPath() (string, error) {
 	}
 }
 
+// GetKiroExecutablePath 取得 Kiro 主程式的執行檔路徑
+// Windows: <安裝路徑>\Kiro.exe
+// macOS: Kiro.app/Contents/MacOS/ 下 Info.plist 的 CFBundleExecutable
+// Linux: <安裝路徑>/kiro
+func GetKiroExecutablePath() (string, error) {
+	installPath, err := GetKiroInstallPath()
+	if err != nil {
+		return "", err
+	}
+
+	var exePath string
+	switch runtime.GOOS {
+	case "windows":
+		exePath = filepath.Join(installPath, "Kiro.exe")
+	case "darwin":
+		exePath = filepath.Join(installPath, "Contents", "MacOS", darwinBundleExecutable(installPath))
+	default:
+		exePath = filepath.Join(installPath, "kiro")
+	}
+
+	if _, err := os.Stat(exePath); err != nil {
+		return "", ErrKiroNotFound
+	}
+	return exePath, nil
+}
+
+// bundleExecutablePattern Info.plist 中的 CFBundleExecutable
+var bundleExecutablePattern = regexp.MustCompile(`<key>CFBundleExecutable</key>\s*<string>([^<]+)</string>`)
+
+// darwinBundleExecutable 讀取 Kiro.app 的執行檔名稱，無法讀取時使用 Kiro
+func darwinBundleExecutable(appPath string) string {
+	data, err := os.ReadFile(filepath.Join(appPath, "Contents", "Info.plist"))
+	if err != nil {
+		return "Kiro"
+	}
+	if m := bundleExecutablePattern.FindSubmatch(data); m != nil {
+		return strings.TrimSpace(string(m[1]))
+	}
+	return "Kiro"
+}
+
 // IsKiroInstalled 檢查 Kiro 是否已安裝
 func IsKiroInstalled() bool {
 	path, err := GetKiroInstallPath()
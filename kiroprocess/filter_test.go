@@ -0,0 +1,78 @@
+package kiroprocess
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestClassifyRole 測試依 Electron 參數判斷進程角色
+func TestClassifyRole(t *testing.T) {
+	cases := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"/opt/kiro/kiro"}, RoleMain},
+		{[]string{"/opt/kiro/kiro", "--type=renderer", "--enable-sandbox"}, RoleRenderer},
+		{[]string{"/opt/kiro/kiro", "--type=utility", "--utility-sub-type=node.mojom.NodeService", "--vscode-utility-kind=extensionHost"}, RoleExtensionHost},
+		{[]string{"/opt/kiro/kiro", "--type=extensionHost"}, RoleExtensionHost},
+		{[]string{"/opt/kiro/kiro", "--type=utility", "--utility-sub-type=network.mojom.NetworkService"}, RoleHelper},
+		{[]string{"/opt/kiro/kiro", "--type=gpu-process"}, RoleHelper},
+		{nil, RoleMain},
+	}
+	for _, c := range cases {
+		if got := classifyRole(c.args); got != c.want {
+			t.Errorf("classifyRole(%v) = %q, want %q", c.args, got, c.want)
+		}
+	}
+}
+
+// TestFilterKiroProcesses 測試只保留執行檔位於安裝目錄內的進程，並排除自身
+func TestFilterKiroProcesses(t *testing.T) {
+	root := t.TempDir()
+	installPath := filepath.Join(root, "kiro")
+	exe := filepath.Join(installPath, "kiro")
+	if err := os.MkdirAll(installPath, 0755); err != nil {
+		t.Fatalf("MkdirAll failed: %v", err)
+	}
+	if err := os.WriteFile(exe, nil, 0755); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	// 透過符號連結啟動的執行檔（例如 /usr/bin/kiro）
+	link := filepath.Join(root, "kiro-link")
+	hasLink := os.Symlink(exe, link) == nil
+
+	processes := []process{
+		{PID: 100, PPID: 1, ExePath: exe},
+		{PID: 101, PPID: 100, ExePath: exe, Args: []string{exe, "--type=renderer"}},
+		{PID: 102, PPID: 1, ExePath: filepath.Join(root, "kiro-manager")},                         // 本工具
+		{PID: 103, PPID: 1, ExePath: "/usr/bin/vim", Args: []string{"vim", "kiro/settings.json"}}, // 參數含 kiro 的編輯器
+		{PID: 104, PPID: 1, ExePath: installPath + "-old/kiro"},                                   // 名稱相近的目錄
+		{PID: 105, PPID: 1},               // 無權讀取
+		{PID: 200, PPID: 1, ExePath: exe}, // 自身
+	}
+	if hasLink {
+		processes = append(processes, process{PID: 106, PPID: 1, ExePath: link})
+	}
+
+	got := filterKiroProcesses(processes, installPath, 200)
+	want := []int{100, 101}
+	if hasLink {
+		want = append(want, 106)
+	}
+	if len(got) != len(want) {
+		t.Fatalf("filterKiroProcesses = %+v, want PIDs %v", got, want)
+	}
+	for i, pid := range want {
+		if got[i].PID != pid {
+			t.Errorf("got[%d].PID = %d, want %d", i, got[i].PID, pid)
+		}
+	}
+
+	if got[0].Name != "kiro" || got[0].ExePath != exe || got[0].PPID != 1 || got[0].Role != RoleMain {
+		t.Errorf("unexpected main process: %+v", got[0])
+	}
+	if got[1].Role != RoleRenderer || got[1].PPID != 100 {
+		t.Errorf("unexpected renderer process: %+v", got[1])
+	}
+}
@@ -2,7 +2,12 @@ package kiroprocess
 
 import (
 	"errors"
+	"os"
+	"path/filepath"
 	"runtime"
+	"strings"
+
+	"kiro-manager/kiropath"
 )
 
 var (
@@ -10,10 +15,29 @@ var (
 	ErrProcessNotFound     = errors.New("kiro process not found")
 )
 
+// 進程角色（依 Electron 的 --type 參數判斷）
+const (
+	RoleMain          = "main"          // 主進程（沒有 --type 參數）
+	RoleRenderer      = "renderer"      // 視窗的渲染進程
+	RoleExtensionHost = "extensionHost" // 擴充功能主機
+	RoleHelper        = "helper"        // GPU、網路服務等其他輔助進程
+)
+
 // ProcessInfo 包含進程的基本資訊
 type ProcessInfo struct {
-	PID  int    `json:"pid"`
-	Name string `json:"name"`
+	PID     int    `json:"pid"`
+	Name    string `json:"name"`
+	ExePath string `json:"exePath"` // 執行檔的完整路徑
+	PPID    int    `json:"ppid"`    // 父進程 PID
+	Role    string `json:"role"`    // main、renderer、extensionHost 或 helper
+}
+
+// process 平台列舉到的進程（執行檔路徑無法讀取時為空）
+type process struct {
+	PID     int
+	PPID    int
+	ExePath string
+	Args    []string
 }
 
 // IsKiroRunning 檢查 Kiro 是否正在運行
@@ -26,16 +50,98 @@ func IsKiroRunning() bool {
 }
 
 // GetKiroProcesses 取得所有正在運行的 Kiro 進程
+// 只有執行檔位於 Kiro 安裝目錄內的進程才算數（排除 kiro-manager 本身與名稱含 kiro 的其他程式）
+// 找不到 Kiro 安裝目錄時回傳空列表
 func GetKiroProcesses() ([]ProcessInfo, error) {
-	switch runtime.GOOS {
-	case "windows":
-		return getWindowsKiroProcesses()
-	case "darwin":
-		return getDarwinKiroProcesses()
-	case "linux":
-		return getLinuxKiroProcesses()
+	installPath, err := kiropath.GetKiroInstallPath()
+	if err != nil {
+		if errors.Is(err, kiropath.ErrKiroNotFound) {
+			return []ProcessInfo{}, nil
+		}
+		return nil, err
+	}
+
+	processes, err := listProcesses()
+	if err != nil {
+		return nil, err
+	}
+	return filterKiroProcesses(processes, installPath, os.Getpid()), nil
+}
+
+// filterKiroProcesses 篩選執行檔位於安裝目錄內的進程（排除 selfPID），並判斷角色
+func filterKiroProcesses(processes []process, installPath string, selfPID int) []ProcessInfo {
+	dirs := []string{filepath.Clean(installPath)}
+	if resolved, err := filepath.EvalSymlinks(installPath); err == nil && resolved != dirs[0] {
+		dirs = append(dirs, resolved)
+	}
+
+	result := []ProcessInfo{}
+	for _, p := range processes {
+		if p.PID == selfPID || p.ExePath == "" || !withinAny(p.ExePath, dirs) {
+			continue
+		}
+		result = append(result, ProcessInfo{
+			PID:     p.PID,
+			Name:    filepath.Base(p.ExePath),
+			ExePath: p.ExePath,
+			PPID:    p.PPID,
+			Role:    classifyRole(p.Args),
+		})
+	}
+	return result
+}
+
+// withinAny 判斷執行檔是否位於任一目錄內（含符號連結解析後的路徑）
+func withinAny(exePath string, dirs []string) bool {
+	candidates := []string{exePath}
+	if resolved, err := filepath.EvalSymlinks(exePath); err == nil && resolved != exePath {
+		candidates = append(candidates, resolved)
+	}
+	for _, path := range candidates {
+		for _, dir := range dirs {
+			if isWithin(path, dir) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// isWithin 判斷 path 是否位於 dir 之內（Windows 不分大小寫）
+func isWithin(path, dir string) bool {
+	if runtime.GOOS == "windows" {
+		path, dir = strings.ToLower(path), strings.ToLower(dir)
+	}
+	rel, err := filepath.Rel(dir, path)
+	if err != nil {
+		return false
+	}
+	return rel != "." && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) && !filepath.IsAbs(rel)
+}
+
+// classifyRole 依命令列參數判斷 Electron 進程的角色
+// 新版的擴充功能主機為帶有 --vscode-utility-kind=extensionHost 的 utility 進程
+func classifyRole(args []string) string {
+	processType := ""
+	extensionHost := false
+	for _, arg := range args {
+		if value, ok := strings.CutPrefix(arg, "--type="); ok {
+			processType = value
+		}
+		if arg == "--vscode-utility-kind=extensionHost" {
+			extensionHost = true
+		}
+	}
+
+	switch {
+	case processType == "":
+		return RoleMain
+	case processType == "renderer":
+		return RoleRenderer
+	case processType == "extensionHost", processType == "utility" && extensionHost:
+		return RoleExtensionHost
 	default:
-		return nil, ErrUnsupportedPlatform
+		return RoleHelper
 	}
 }
 
@@ -48,8 +154,8 @@ func GetKiroProcessCount() int {
 	return len(processes)
 }
 
-// KillKiroProcesses 關閉所有 Kiro 進程
-// Windows 使用原生 API，其他平台使用 kill 命令
+// KillKiroProcesses 立即強制關閉所有 Kiro 進程（不等待正常結束，一般應使用 Shutdown）
+// Windows 使用 taskkill /F，其他平台送出 SIGKILL
 // 回傳被關閉的進程數量和錯誤
 func KillKiroProcesses() (int, error) {
 	processes, err := GetKiroProcesses()
@@ -63,15 +169,7 @@ func KillKiroProcesses() (int, error) {
 
 	killed := 0
 	for _, p := range processes {
-		var killErr error
-		switch runtime.GOOS {
-		case "windows":
-			killErr = killWindowsProcess(p.PID)
-		default:
-			killErr = killUnixProcess(p.PID)
-		}
-
-		if killErr == nil {
+		if killProcess(p.PID) == nil {
 			killed++
 		}
 	}
@@ -0,0 +1,62 @@
+//go:build darwin
+
+package kiroprocess
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+
+	"golang.org/x/sys/unix"
+)
+
+// listProcesses 以 sysctl 列舉所有進程（kern.proc.all 取得 PID 與父進程，kern.procargs2 取得執行檔與參數）
+// 無權讀取參數的進程（其他使用者的進程）ExePath 為空
+func listProcesses() ([]process, error) {
+	kinfos, err := unix.SysctlKinfoProcSlice("kern.proc.all")
+	if err != nil {
+		return nil, err
+	}
+
+	processes := make([]process, 0, len(kinfos))
+	for _, info := range kinfos {
+		p := process{PID: int(info.Proc.P_pid), PPID: int(info.Eproc.Ppid)}
+		if p.PID <= 0 {
+			continue
+		}
+		if raw, err := unix.SysctlRaw("kern.procargs2", p.PID); err == nil {
+			p.ExePath, p.Args, _ = parseProcArgs2(raw)
+		}
+		processes = append(processes, p)
+	}
+	return processes, nil
+}
+
+// parseProcArgs2 解析 kern.procargs2 的內容
+// 格式為 argc（int32）、執行檔路徑、補齊用的 NUL，之後為 argc 個以 NUL 結尾的參數
+func parseProcArgs2(raw []byte) (string, []string, error) {
+	if len(raw) < 4 {
+		return "", nil, errors.New("malformed procargs2")
+	}
+	argc := int(binary.LittleEndian.Uint32(raw[:4]))
+	rest := raw[4:]
+
+	end := bytes.IndexByte(rest, 0)
+	if end < 0 {
+		return "", nil, errors.New("malformed procargs2")
+	}
+	exePath := string(rest[:end])
+	rest = bytes.TrimLeft(rest[end:], "\x00")
+
+	args := make([]string, 0, argc)
+	for len(args) < argc && len(rest) > 0 {
+		end := bytes.IndexByte(rest, 0)
+		if end < 0 {
+			args = append(args, string(rest))
+			break
+		}
+		args = append(args, string(rest[:end]))
+		rest = rest[end+1:]
+	}
+	return exePath, args, nil
+}
@@ -0,0 +1,80 @@
+//go:build linux
+
+package kiroprocess
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// procRoot Linux 的 procfs 掛載點
+const procRoot = "/proc"
+
+// listProcesses 從 /proc 列舉所有進程
+func listProcesses() ([]process, error) {
+	return listProcessesIn(procRoot)
+}
+
+// listProcessesIn 從指定的 procfs 目錄列舉進程
+// 無權讀取執行檔路徑的進程（其他使用者的進程）ExePath 為空；列舉期間結束的進程會被略過
+func listProcessesIn(root string) ([]process, error) {
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return nil, err
+	}
+
+	var processes []process
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil || !entry.IsDir() {
+			continue
+		}
+		dir := filepath.Join(root, entry.Name())
+
+		stat, err := os.ReadFile(filepath.Join(dir, "stat"))
+		if err != nil {
+			continue
+		}
+		ppid, err := parseStatPPID(stat)
+		if err != nil {
+			continue
+		}
+
+		p := process{PID: pid, PPID: ppid}
+		if exe, err := os.Readlink(filepath.Join(dir, "exe")); err == nil {
+			p.ExePath = strings.TrimSuffix(exe, " (deleted)")
+		}
+		if cmdline, err := os.ReadFile(filepath.Join(dir, "cmdline")); err == nil {
+			p.Args = parseCmdline(cmdline)
+		}
+		processes = append(processes, p)
+	}
+	return processes, nil
+}
+
+// parseStatPPID 解析 /proc/<pid>/stat 的父進程 PID
+// 格式為 "pid (comm) state ppid ..."，comm 可能包含空白與括號，因此從最後一個 ')' 之後解析
+func parseStatPPID(stat []byte) (int, error) {
+	end := bytes.LastIndexByte(stat, ')')
+	if end < 0 {
+		return 0, errors.New("malformed stat")
+	}
+	fields := strings.Fields(string(stat[end+1:]))
+	if len(fields) < 2 {
+		return 0, errors.New("malformed stat")
+	}
+	return strconv.Atoi(fields[1])
+}
+
+// parseCmdline 解析以 NUL 分隔的 /proc/<pid>/cmdline
+func parseCmdline(cmdline []byte) []string {
+	cmdline = bytes.TrimRight(cmdline, "\x00")
+	if len(cmdline) == 0 {
+		return nil
+	}
+	return strings.Split(string(cmdline), "\x00")
+}
@@ -0,0 +1,115 @@
+//go:build linux
+
+package kiroprocess
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// TestListProcessesIn 測試從 procfs 解析 PID、父進程、執行檔與參數
+func TestListProcessesIn(t *testing.T) {
+	root := t.TempDir()
+	write := func(pid, name, content string) {
+		dir := filepath.Join(root, pid)
+		os.MkdirAll(dir, 0755)
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("WriteFile failed: %v", err)
+		}
+	}
+
+	// comm 含空白與括號
+	write("4242", "stat", "4242 (kiro (main) x) S 17 4242 4242 0 -1 4194560")
+	write("4242", "cmdline", "/opt/kiro/kiro\x00--type=renderer\x00")
+	if err := os.Symlink("/opt/kiro/kiro (deleted)", filepath.Join(root, "4242", "exe")); err != nil {
+		t.Fatalf("Symlink failed: %v", err)
+	}
+	// 無法讀取 exe 的進程
+	write("7", "stat", "7 (kthreadd) S 2 0 0 0 -1")
+	// 非進程目錄與格式錯誤的 stat
+	write("self-test", "stat", "1 (x) S 0")
+	write("99", "stat", "garbage")
+
+	processes, err := listProcessesIn(root)
+	if err != nil {
+		t.Fatalf("listProcessesIn failed: %v", err)
+	}
+	if len(processes) != 2 {
+		t.Fatalf("processes = %+v, want 2", processes)
+	}
+
+	byPID := map[int]process{}
+	for _, p := range processes {
+		byPID[p.PID] = p
+	}
+	kiro := byPID[4242]
+	if kiro.PPID != 17 || kiro.ExePath != "/opt/kiro/kiro" || len(kiro.Args) != 2 || kiro.Args[1] != "--type=renderer" {
+		t.Errorf("unexpected process: %+v", kiro)
+	}
+	if p := byPID[7]; p.PPID != 2 || p.ExePath != "" {
+		t.Errorf("unexpected kernel thread: %+v", p)
+	}
+}
+
+// TestListProcesses_FindsSelf 測試從實際的 /proc 找到目前的測試進程
+func TestListProcesses_FindsSelf(t *testing.T) {
+	processes, err := listProcesses()
+	if err != nil {
+		t.Fatalf("listProcesses failed: %v", err)
+	}
+	exe, _ := os.Executable()
+	for _, p := range processes {
+		if p.PID == os.Getpid() {
+			if p.PPID != os.Getppid() || p.ExePath != exe {
+				t.Errorf("own process = %+v, want PPID %d and ExePath %s", p, os.Getppid(), exe)
+			}
+			return
+		}
+	}
+	t.Fatal("own process not found")
+}
+
+// TestLaunch 測試以獨立 session 啟動安裝目錄內的執行檔，並等待主進程出現
+func TestLaunch(t *testing.T) {
+	sleepPath, err := exec.LookPath("sleep")
+	if err != nil {
+		t.Skip("sleep not found")
+	}
+	data, err := os.ReadFile(sleepPath)
+	if err != nil {
+		t.Fatalf("ReadFile failed: %v", err)
+	}
+	installPath := t.TempDir()
+	exePath := filepath.Join(installPath, "kiro")
+	if err := os.WriteFile(exePath, data, 0755); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+
+	list := func() ([]ProcessInfo, error) {
+		processes, err := listProcesses()
+		if err != nil {
+			return nil, err
+		}
+		return filterKiroProcesses(processes, installPath, os.Getpid()), nil
+	}
+
+	p, err := launch(context.Background(), exePath, LaunchOptions{Paths: []string{"30"}, Timeout: 5 * time.Second, PollInterval: 10 * time.Millisecond}, list)
+	if err != nil {
+		t.Fatalf("launch failed: %v", err)
+	}
+	defer syscall.Kill(p.PID, syscall.SIGKILL)
+
+	if p.ExePath != exePath || p.Role != RoleMain {
+		t.Errorf("launched process = %+v", p)
+	}
+	if sid, _ := unix.Getsid(p.PID); sid != p.PID {
+		t.Errorf("launched process session = %d, want its own session %d", sid, p.PID)
+	}
+}
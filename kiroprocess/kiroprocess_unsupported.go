@@ -0,0 +1,8 @@
+//go:build !linux && !darwin && !windows
+
+package kiroprocess
+
+// listProcesses 此平台不支援列舉進程
+func listProcesses() ([]process, error) {
+	return nil, ErrUnsupportedPlatform
+}
@@ -3,114 +3,119 @@
 package kiroprocess
 
 import (
+	"errors"
 	"strconv"
-	"strings"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
 
 	"kiro-manager/internal/shield"
 )
 
-// getWindowsKiroProcesses 使用 tasklist 命令取得 Kiro 進程列表
-// 使用 Shield 保護殼避免防毒軟體誤報
-func getWindowsKiroProcesses() ([]ProcessInfo, error) {
-	// tasklist /FI "IMAGENAME eq Kiro.exe" /FO CSV /NH
-	// 輸出格式: "Kiro.exe","12345","Console","1","123,456 K"
-	builder := shield.GetBuilder()
-	cmd := builder.Build(shield.CmdTaskList, shield.ArgFI, shield.ArgImageName, shield.ArgFO, shield.ArgCSV, shield.ArgNH)
-	builder.SetHidden(cmd)
-	output, err := cmd.Output()
+// listProcesses 以 Toolhelp32 快照列舉所有進程，並以 QueryFullProcessImageName 取得執行檔完整路徑
+// 無法開啟的進程（系統或其他使用者的進程）ExePath 為空
+func listProcesses() ([]process, error) {
+	snapshot, err := windows.CreateToolhelp32Snapshot(windows.TH32CS_SNAPPROCESS, 0)
 	if err != nil {
-		// tasklist 在找不到進程時會回傳錯誤，這是正常的
-		// 檢查輸出是否包含 "INFO: No tasks"
-		if strings.Contains(string(output), "INFO:") {
-			return []ProcessInfo{}, nil
-		}
-		// 如果是其他錯誤，嘗試解析輸出
-		if len(output) == 0 {
-			return []ProcessInfo{}, nil
-		}
+		return nil, err
 	}
+	defer windows.CloseHandle(snapshot)
 
-	return parseTasklistOutput(string(output))
-}
-
-// parseTasklistOutput 解析 tasklist CSV 輸出
-func parseTasklistOutput(output string) ([]ProcessInfo, error) {
-	var processes []ProcessInfo
-
-	lines := strings.Split(strings.TrimSpace(output), "\n")
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
-		if line == "" {
-			continue
+	var entry windows.ProcessEntry32
+	entry.Size = uint32(unsafe.Sizeof(entry))
+	if err := windows.Process32First(snapshot, &entry); err != nil {
+		if errors.Is(err, windows.ERROR_NO_MORE_FILES) {
+			return []process{}, nil
 		}
+		return nil, err
+	}
 
-		// 跳過 INFO 訊息（例如 "INFO: No tasks are running..."）
-		if strings.HasPrefix(line, "INFO:") {
-			continue
+	processes := []process{}
+	for {
+		p := process{PID: int(entry.ProcessID), PPID: int(entry.ParentProcessID)}
+		if p.PID > 0 {
+			p.ExePath, p.Args = queryProcess(entry.ProcessID)
+			processes = append(processes, p)
 		}
 
-		// CSV 格式: "Kiro.exe","12345","Console","1","123,456 K"
-		// 移除引號並分割
-		fields := parseCSVLine(line)
-		if len(fields) < 2 {
-			continue
+		if err := windows.Process32Next(snapshot, &entry); err != nil {
+			if errors.Is(err, windows.ERROR_NO_MORE_FILES) {
+				break
+			}
+			return nil, err
 		}
+	}
+	return processes, nil
+}
 
-		name := fields[0]
-		pidStr := fields[1]
+// queryProcess 取得進程的執行檔路徑與命令列參數（失敗時回傳空值）
+func queryProcess(pid uint32) (string, []string) {
+	handle, err := windows.OpenProcess(windows.PROCESS_QUERY_LIMITED_INFORMATION, false, pid)
+	if err != nil {
+		return "", nil
+	}
+	defer windows.CloseHandle(handle)
 
-		// 確認是 Kiro 進程
-		if !strings.EqualFold(name, "Kiro.exe") {
-			continue
-		}
+	buf := make([]uint16, windows.MAX_LONG_PATH)
+	size := uint32(len(buf))
+	if err := windows.QueryFullProcessImageName(handle, 0, &buf[0], &size); err != nil {
+		return "", nil
+	}
+	exePath := windows.UTF16ToString(buf[:size])
 
-		pid, err := strconv.Atoi(pidStr)
-		if err != nil {
-			continue
-		}
+	return exePath, queryCommandLine(handle)
+}
 
-		processes = append(processes, ProcessInfo{
-			PID:  pid,
-			Name: name,
-		})
+// queryCommandLine 以 NtQueryInformationProcess（ProcessCommandLineInformation）讀取命令列並拆分參數
+func queryCommandLine(handle windows.Handle) []string {
+	var size uint32
+	windows.NtQueryInformationProcess(handle, windows.ProcessCommandLineInformation, nil, 0, &size)
+	if size == 0 {
+		return nil
 	}
 
-	return processes, nil
-}
+	buf := make([]byte, size)
+	if err := windows.NtQueryInformationProcess(handle, windows.ProcessCommandLineInformation, unsafe.Pointer(&buf[0]), size, &size); err != nil {
+		return nil
+	}
+	str := (*windows.NTUnicodeString)(unsafe.Pointer(&buf[0]))
+	if str.Buffer == nil || str.Length == 0 {
+		return nil
+	}
+	cmdline := windows.UTF16ToString(unsafe.Slice(str.Buffer, str.Length/2))
 
-// parseCSVLine 解析 CSV 行，處理引號
-func parseCSVLine(line string) []string {
-	var fields []string
-	var current strings.Builder
-	inQuotes := false
-
-	for _, r := range line {
-		switch r {
-		case '"':
-			inQuotes = !inQuotes
-		case ',':
-			if inQuotes {
-				current.WriteRune(r)
-			} else {
-				fields = append(fields, current.String())
-				current.Reset()
-			}
-		default:
-			current.WriteRune(r)
-		}
+	args, err := windows.DecomposeCommandLine(cmdline)
+	if err != nil {
+		return nil
 	}
+	return args
+}
 
-	// 加入最後一個欄位
-	if current.Len() > 0 {
-		fields = append(fields, current.String())
+// processAlive 檢查進程是否仍在執行（進程 handle 尚未進入 signaled 狀態）
+func processAlive(pid int) bool {
+	handle, err := windows.OpenProcess(windows.SYNCHRONIZE|windows.PROCESS_QUERY_LIMITED_INFORMATION, false, uint32(pid))
+	if err != nil {
+		return false
 	}
+	defer windows.CloseHandle(handle)
+
+	event, err := windows.WaitForSingleObject(handle, 0)
+	return err == nil && event == uint32(windows.WAIT_TIMEOUT)
+}
 
-	return fields
+// terminateProcess 使用不帶 /F 的 taskkill 要求進程關閉視窗（等同使用者關閉視窗）
+// 使用 Shield 保護殼避免防毒軟體誤報
+func terminateProcess(pid int) error {
+	builder := shield.GetBuilder()
+	pidArg := shield.GetCodec().Decode(shield.ArgPID)
+	cmd := builder.BuildWithRawArgs(shield.CmdTaskKill, pidArg, strconv.Itoa(pid))
+	builder.SetHidden(cmd)
+	return cmd.Run()
 }
 
-// killWindowsProcess 使用 taskkill 命令終止指定 PID 的進程
+// killProcess 使用 taskkill 命令終止指定 PID 的進程
 // 使用 Shield 保護殼避免防毒軟體誤報
-func killWindowsProcess(pid int) error {
+func killProcess(pid int) error {
 	builder := shield.GetBuilder()
 	// 使用 BuildWithRawArgs 因為 PID 是動態值
 	codec := shield.GetCodec()
@@ -120,18 +125,3 @@ func killWindowsProcess(pid int) error {
 	builder.SetHidden(cmd)
 	return cmd.Run()
 }
-
-// getDarwinKiroProcesses Windows 平台不支援
-func getDarwinKiroProcesses() ([]ProcessInfo, error) {
-	return nil, ErrUnsupportedPlatform
-}
-
-// getLinuxKiroProcesses Windows 平台不支援
-func getLinuxKiroProcesses() ([]ProcessInfo, error) {
-	return nil, ErrUnsupportedPlatform
-}
-
-// killUnixProcess Windows 平台不支援
-func killUnixProcess(pid int) error {
-	return ErrUnsupportedPlatform
-}
@@ -0,0 +1,114 @@
+package kiroprocess
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"kiro-manager/internal/cmdutil"
+	"kiro-manager/kiropath"
+)
+
+// DefaultLaunchTimeout 啟動後等待 Kiro 主進程出現的預設時間
+const DefaultLaunchTimeout = 15 * time.Second
+
+// ErrLaunchTimeout 啟動後在時限內沒有偵測到 Kiro 主進程
+var ErrLaunchTimeout = errors.New("kiro process did not appear after launch")
+
+// LaunchOptions 啟動選項
+type LaunchOptions struct {
+	// Paths 要開啟的資料夾、工作區檔或檔案（絕對路徑）
+	Paths []string
+	// Timeout 等待主進程出現的時間，<= 0 時使用 DefaultLaunchTimeout
+	Timeout time.Duration
+	// PollInterval 檢查間隔，<= 0 時使用預設值
+	PollInterval time.Duration
+}
+
+// Launch 啟動偵測到的 Kiro 執行檔，並等待主進程出現
+// Kiro 以獨立的 session / 進程群組執行，不受本程式結束影響；
+// Kiro 已在執行時新進程會把路徑交給既有視窗後結束，此時回傳既有的主進程
+func Launch(ctx context.Context, opts LaunchOptions) (ProcessInfo, error) {
+	exePath, err := kiropath.GetKiroExecutablePath()
+	if err != nil {
+		return ProcessInfo{}, err
+	}
+	return launch(ctx, exePath, opts, GetKiroProcesses)
+}
+
+func launch(ctx context.Context, exePath string, opts LaunchOptions, list func() ([]ProcessInfo, error)) (ProcessInfo, error) {
+	if opts.Timeout <= 0 {
+		opts.Timeout = DefaultLaunchTimeout
+	}
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = defaultPollInterval
+	}
+
+	cmd := exec.Command(exePath, opts.Paths...)
+	cmd.Env = launchEnv(os.Environ())
+	cmdutil.Detach(cmd)
+	if err := cmd.Start(); err != nil {
+		return ProcessInfo{}, err
+	}
+	pid := cmd.Process.Pid
+	// 回收結束的子進程，避免留下殭屍進程
+	go cmd.Wait()
+
+	waitCtx, cancel := context.WithTimeout(ctx, opts.Timeout)
+	defer cancel()
+	ticker := time.NewTicker(opts.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		if processes, err := list(); err == nil {
+			if p, ok := mainProcess(processes, pid); ok {
+				return p, nil
+			}
+		}
+
+		select {
+		case <-waitCtx.Done():
+			if ctx.Err() != nil {
+				return ProcessInfo{}, ctx.Err()
+			}
+			return ProcessInfo{}, ErrLaunchTimeout
+		case <-ticker.C:
+		}
+	}
+}
+
+// mainProcess 找出 Kiro 主進程，優先回傳剛啟動的 PID
+func mainProcess(processes []ProcessInfo, pid int) (ProcessInfo, bool) {
+	var found *ProcessInfo
+	for i, p := range processes {
+		if p.Role != RoleMain {
+			continue
+		}
+		if p.PID == pid {
+			return p, true
+		}
+		if found == nil {
+			found = &processes[i]
+		}
+	}
+	if found == nil {
+		return ProcessInfo{}, false
+	}
+	return *found, true
+}
+
+// launchEnv 移除會讓 Electron 以 Node.js 模式執行的環境變數
+// （從 Kiro 的整合終端機執行本程式時可能繼承）
+func launchEnv(environ []string) []string {
+	env := make([]string, 0, len(environ))
+	for _, kv := range environ {
+		if strings.HasPrefix(strings.ToUpper(kv), "ELECTRON_RUN_AS_NODE=") {
+			continue
+		}
+		env = append(env, kv)
+	}
+	return env
+}
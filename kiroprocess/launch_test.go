@@ -0,0 +1,84 @@
+package kiroprocess
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestParseWindowsState 測試從 storage.json 取出本機資料夾與工作區檔，略過遠端與重複的視窗
+func TestParseWindowsState(t *testing.T) {
+	data := []byte(`{
+		"theme": "vs-dark",
+		"windowsState": {
+			"lastActiveWindow": {"folder": "file:///home/dev/work%20repo", "uiState": {}},
+			"openedWindows": [
+				{"folder": "file:///home/dev/work%20repo"},
+				{"workspaceIdentifier": {"id": "abc", "configURIPath": "file:///home/dev/all.code-workspace"}},
+				{"folder": "vscode-remote://ssh-remote%2Bbox/srv/app"},
+				{"backupPath": "/home/dev/.config/Kiro/Backups/123"}
+			]
+		}
+	}`)
+
+	got := parseWindowsState(data, false)
+	want := []string{"/home/dev/work repo", "/home/dev/all.code-workspace"}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("parseWindowsState = %q, want %q", got, want)
+	}
+
+	if got := parseWindowsState([]byte(`{}`), false); len(got) != 0 {
+		t.Errorf("missing windowsState = %q, want empty", got)
+	}
+	if got := parseWindowsState([]byte(`not json`), false); got != nil {
+		t.Errorf("invalid JSON = %q, want nil", got)
+	}
+}
+
+// TestFileURIToPath 測試 file URI 轉為各平台的本機路徑
+func TestFileURIToPath(t *testing.T) {
+	cases := []struct {
+		uri     string
+		windows bool
+		want    string
+		ok      bool
+	}{
+		{"file:///home/dev/repo", false, "/home/dev/repo", true},
+		{"file:///c%3A/Users/dev/repo", true, `c:\Users\dev\repo`, true},
+		{"file://server/share/repo", true, `\\server\share\repo`, true},
+		{"file://server/share/repo", false, "", false},
+		{"vscode-remote://wsl%2Bubuntu/home/dev", false, "", false},
+		{"", false, "", false},
+	}
+	for _, c := range cases {
+		got, ok := fileURIToPath(c.uri, c.windows)
+		if got != c.want || ok != c.ok {
+			t.Errorf("fileURIToPath(%q, %v) = %q, %v; want %q, %v", c.uri, c.windows, got, ok, c.want, c.ok)
+		}
+	}
+}
+
+// TestMainProcess 測試優先回傳剛啟動的主進程，否則回傳既有的主進程
+func TestMainProcess(t *testing.T) {
+	processes := []ProcessInfo{
+		{PID: 10, Role: RoleMain},
+		{PID: 11, Role: RoleRenderer},
+		{PID: 20, Role: RoleMain},
+	}
+	if p, ok := mainProcess(processes, 20); !ok || p.PID != 20 {
+		t.Errorf("mainProcess(20) = %+v, %v", p, ok)
+	}
+	if p, ok := mainProcess(processes, 99); !ok || p.PID != 10 {
+		t.Errorf("mainProcess(99) = %+v, %v; want existing main 10", p, ok)
+	}
+	if _, ok := mainProcess(processes[1:2], 11); ok {
+		t.Error("mainProcess should ignore non-main processes")
+	}
+}
+
+// TestLaunchEnv 測試移除 ELECTRON_RUN_AS_NODE
+func TestLaunchEnv(t *testing.T) {
+	env := launchEnv([]string{"PATH=/usr/bin", "ELECTRON_RUN_AS_NODE=1", "HOME=/home/dev"})
+	if strings.Join(env, " ") != "PATH=/usr/bin HOME=/home/dev" {
+		t.Errorf("launchEnv = %q", env)
+	}
+}
@@ -0,0 +1,191 @@
+package kiroprocess
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+const (
+	// DefaultShutdownTimeout 等待 Kiro 正常結束的預設時間
+	DefaultShutdownTimeout = 10 * time.Second
+	// defaultPollInterval 檢查進程是否結束的間隔
+	defaultPollInterval = 200 * time.Millisecond
+	// killWait 強制終止後等待進程消失的時間
+	killWait = 3 * time.Second
+)
+
+// ErrStillRunning 強制終止後仍有 Kiro 進程存在
+var ErrStillRunning = errors.New("kiro processes still running after kill")
+
+// 關閉階段
+const (
+	StageRequest  = "request"  // 已要求主進程結束（SIGTERM 或關閉視窗）
+	StageWaiting  = "waiting"  // 等待進程樹結束，Remaining 為剩餘進程數
+	StageEscalate = "escalate" // 逾時或無法要求結束，強制終止剩餘進程
+	StageDone     = "done"     // 所有 Kiro 進程皆已結束
+)
+
+// Progress 關閉進度
+type Progress struct {
+	Stage     string `json:"stage"`
+	Remaining int    `json:"remaining"` // 尚未結束的 Kiro 進程數
+}
+
+// ShutdownOptions 關閉選項
+type ShutdownOptions struct {
+	// Timeout 等待正常結束的時間，<= 0 時使用 DefaultShutdownTimeout
+	Timeout time.Duration
+	// PollInterval 檢查間隔，<= 0 時使用預設值
+	PollInterval time.Duration
+	// OnProgress 進度回呼（選填），於呼叫 Shutdown 的 goroutine 執行
+	OnProgress func(Progress)
+}
+
+// ShutdownResult 關閉結果
+type ShutdownResult struct {
+	Total  int `json:"total"`  // 開始關閉時的 Kiro 進程數
+	Forced int `json:"forced"` // 逾時後被強制終止的進程數
+}
+
+// Graceful 是否所有進程都在逾時前自行結束
+func (r ShutdownResult) Graceful() bool {
+	return r.Forced == 0
+}
+
+// processOps 關閉流程使用的進程操作（測試時替換）
+type processOps struct {
+	list      func() ([]ProcessInfo, error)
+	terminate func(pid int) error
+	kill      func(pid int) error
+}
+
+// systemOps 實際的進程操作
+var systemOps = processOps{
+	list:      GetKiroProcesses,
+	terminate: terminateProcess,
+	kill:      killProcess,
+}
+
+// Shutdown 分階段關閉 Kiro
+// 先要求進程樹的根進程結束（其他平台送出 SIGTERM，Windows 關閉視窗），
+// 在 ctx 與 Timeout 內等待所有 Kiro 進程結束，逾時後才強制終止剩餘的進程。
+// ctx 被取消時直接回傳 ctx 的錯誤，不會強制終止
+func Shutdown(ctx context.Context, opts ShutdownOptions) (ShutdownResult, error) {
+	return shutdown(ctx, opts, systemOps)
+}
+
+func shutdown(ctx context.Context, opts ShutdownOptions, ops processOps) (ShutdownResult, error) {
+	if opts.Timeout <= 0 {
+		opts.Timeout = DefaultShutdownTimeout
+	}
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = defaultPollInterval
+	}
+	report := func(stage string, remaining int) {
+		if opts.OnProgress != nil {
+			opts.OnProgress(Progress{Stage: stage, Remaining: remaining})
+		}
+	}
+
+	processes, err := ops.list()
+	if err != nil {
+		return ShutdownResult{}, err
+	}
+	result := ShutdownResult{Total: len(processes)}
+	if len(processes) == 0 {
+		return result, nil
+	}
+
+	// 只要求根進程結束，子進程由 Kiro 自行收尾
+	requested := 0
+	for _, p := range rootProcesses(processes) {
+		if ops.terminate(p.PID) == nil {
+			requested++
+		}
+	}
+
+	if requested > 0 {
+		report(StageRequest, len(processes))
+		waitCtx, cancel := context.WithTimeout(ctx, opts.Timeout)
+		processes, err = waitForExit(waitCtx, ops.list, opts.PollInterval, func(remaining int) {
+			if remaining > 0 {
+				report(StageWaiting, remaining)
+			}
+		})
+		cancel()
+		if err != nil {
+			return result, err
+		}
+		if len(processes) == 0 {
+			report(StageDone, 0)
+			return result, nil
+		}
+		if ctx.Err() != nil {
+			return result, ctx.Err()
+		}
+	}
+
+	report(StageEscalate, len(processes))
+	for _, p := range processes {
+		if ops.kill(p.PID) == nil {
+			result.Forced++
+		}
+	}
+
+	killCtx, cancel := context.WithTimeout(context.Background(), killWait)
+	defer cancel()
+	processes, err = waitForExit(killCtx, ops.list, opts.PollInterval, nil)
+	if err != nil {
+		return result, err
+	}
+	if len(processes) > 0 {
+		return result, ErrStillRunning
+	}
+	report(StageDone, 0)
+	return result, nil
+}
+
+// waitForExit 定期檢查 Kiro 進程，直到全部結束或 ctx 結束，回傳最後一次檢查時剩餘的進程
+// 剩餘進程數變化時呼叫 onChange
+func waitForExit(ctx context.Context, list func() ([]ProcessInfo, error), interval time.Duration, onChange func(int)) ([]ProcessInfo, error) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	last := -1
+	for {
+		processes, err := list()
+		if err != nil {
+			return nil, err
+		}
+		if len(processes) != last && onChange != nil {
+			onChange(len(processes))
+		}
+		last = len(processes)
+		if len(processes) == 0 {
+			return processes, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return processes, nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// rootProcesses 取得父進程不是 Kiro 進程的進程（通常是主進程）
+func rootProcesses(processes []ProcessInfo) []ProcessInfo {
+	pids := make(map[int]bool, len(processes))
+	for _, p := range processes {
+		pids[p.PID] = true
+	}
+
+	var roots []ProcessInfo
+	for _, p := range processes {
+		if !pids[p.PPID] {
+			roots = append(roots, p)
+		}
+	}
+	return roots
+}
@@ -0,0 +1,216 @@
+package kiroprocess
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeTree 模擬的 Kiro 進程樹
+// 根進程收到 terminate 後，經過 exitAfter 次列舉整棵樹才結束；exitAfter < 0 表示忽略要求
+type fakeTree struct {
+	mu         sync.Mutex
+	processes  []ProcessInfo
+	exitAfter  int
+	stubborn   bool // 連 kill 都無法終止
+	countdown  int
+	terminated []int
+	killed     []int
+}
+
+func newFakeTree(exitAfter int) *fakeTree {
+	return &fakeTree{
+		processes: []ProcessInfo{
+			{PID: 100, PPID: 1, Role: RoleMain},
+			{PID: 101, PPID: 100, Role: RoleRenderer},
+			{PID: 102, PPID: 100, Role: RoleExtensionHost},
+		},
+		exitAfter: exitAfter,
+		countdown: -1,
+	}
+}
+
+func (f *fakeTree) ops() processOps {
+	return processOps{list: f.list, terminate: f.terminate, kill: f.kill}
+}
+
+func (f *fakeTree) list() ([]ProcessInfo, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.countdown > 0 {
+		f.countdown--
+	} else if f.countdown == 0 {
+		f.processes = nil
+	}
+	return append([]ProcessInfo(nil), f.processes...), nil
+}
+
+func (f *fakeTree) terminate(pid int) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.terminated = append(f.terminated, pid)
+	if f.exitAfter >= 0 {
+		f.countdown = f.exitAfter
+	}
+	return nil
+}
+
+func (f *fakeTree) kill(pid int) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.killed = append(f.killed, pid)
+	if f.stubborn {
+		return nil
+	}
+	for i, p := range f.processes {
+		if p.PID == pid {
+			f.processes = append(f.processes[:i], f.processes[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+// stages 取得回報的階段順序
+func stages(progress []Progress) []string {
+	var result []string
+	for _, p := range progress {
+		result = append(result, p.Stage)
+	}
+	return result
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// TestShutdown_Graceful 測試只要求根進程結束，進程樹在逾時前結束時不強制終止
+func TestShutdown_Graceful(t *testing.T) {
+	tree := newFakeTree(2)
+	var progress []Progress
+	opts := ShutdownOptions{Timeout: time.Second, PollInterval: time.Millisecond, OnProgress: func(p Progress) {
+		progress = append(progress, p)
+	}}
+
+	result, err := shutdown(context.Background(), opts, tree.ops())
+	if err != nil {
+		t.Fatalf("shutdown failed: %v", err)
+	}
+	if result.Total != 3 || !result.Graceful() {
+		t.Errorf("result = %+v, want 3 processes closed gracefully", result)
+	}
+	if len(tree.terminated) != 1 || tree.terminated[0] != 100 || len(tree.killed) != 0 {
+		t.Errorf("terminated = %v, killed = %v; want only root 100 terminated", tree.terminated, tree.killed)
+	}
+	if got := stages(progress); !equalStrings(got, []string{StageRequest, StageWaiting, StageDone}) {
+		t.Errorf("stages = %v", got)
+	}
+}
+
+// TestShutdown_Escalate 測試逾時後強制終止所有剩餘進程
+func TestShutdown_Escalate(t *testing.T) {
+	tree := newFakeTree(-1)
+	var progress []Progress
+	opts := ShutdownOptions{Timeout: 20 * time.Millisecond, PollInterval: time.Millisecond, OnProgress: func(p Progress) {
+		progress = append(progress, p)
+	}}
+
+	result, err := shutdown(context.Background(), opts, tree.ops())
+	if err != nil {
+		t.Fatalf("shutdown failed: %v", err)
+	}
+	if result.Forced != 3 || result.Graceful() {
+		t.Errorf("result = %+v, want 3 forced", result)
+	}
+	if got := stages(progress); !equalStrings(got, []string{StageRequest, StageWaiting, StageEscalate, StageDone}) {
+		t.Errorf("stages = %v", got)
+	}
+	if progress[2].Remaining != 3 {
+		t.Errorf("escalate remaining = %d, want 3", progress[2].Remaining)
+	}
+}
+
+// TestShutdown_TerminateFailed 測試無法要求結束時不等待逾時，直接強制終止
+func TestShutdown_TerminateFailed(t *testing.T) {
+	tree := newFakeTree(-1)
+	ops := tree.ops()
+	ops.terminate = func(int) error { return errors.New("no window") }
+
+	start := time.Now()
+	result, err := shutdown(context.Background(), ShutdownOptions{Timeout: time.Minute, PollInterval: time.Millisecond}, ops)
+	if err != nil {
+		t.Fatalf("shutdown failed: %v", err)
+	}
+	if result.Forced != 3 {
+		t.Errorf("result = %+v, want 3 forced", result)
+	}
+	if time.Since(start) > 10*time.Second {
+		t.Error("shutdown should not wait for the timeout when no process accepted the request")
+	}
+}
+
+// TestShutdown_Canceled 測試 ctx 被取消時不強制終止
+func TestShutdown_Canceled(t *testing.T) {
+	tree := newFakeTree(-1)
+	ctx, cancel := context.WithCancel(context.Background())
+	opts := ShutdownOptions{Timeout: time.Minute, PollInterval: time.Millisecond, OnProgress: func(p Progress) {
+		if p.Stage == StageWaiting {
+			cancel()
+		}
+	}}
+
+	if _, err := shutdown(ctx, opts, tree.ops()); !errors.Is(err, context.Canceled) {
+		t.Errorf("err = %v, want context.Canceled", err)
+	}
+	if len(tree.killed) != 0 {
+		t.Errorf("killed = %v, want none", tree.killed)
+	}
+}
+
+// TestShutdown_StillRunning 測試強制終止後仍有進程時回傳 ErrStillRunning
+func TestShutdown_StillRunning(t *testing.T) {
+	if testing.Short() {
+		t.Skip("waits for killWait")
+	}
+	tree := newFakeTree(-1)
+	tree.stubborn = true
+
+	_, err := shutdown(context.Background(), ShutdownOptions{Timeout: time.Millisecond, PollInterval: 10 * time.Millisecond}, tree.ops())
+	if !errors.Is(err, ErrStillRunning) {
+		t.Errorf("err = %v, want ErrStillRunning", err)
+	}
+}
+
+// TestShutdown_NotRunning 測試沒有 Kiro 進程時直接回傳
+func TestShutdown_NotRunning(t *testing.T) {
+	tree := newFakeTree(0)
+	tree.processes = nil
+
+	result, err := shutdown(context.Background(), ShutdownOptions{}, tree.ops())
+	if err != nil || result.Total != 0 || len(tree.terminated) != 0 {
+		t.Errorf("result = %+v, err = %v, terminated = %v", result, err, tree.terminated)
+	}
+}
+
+// TestRootProcesses 測試只取得父進程不在 Kiro 進程中的進程
+func TestRootProcesses(t *testing.T) {
+	roots := rootProcesses([]ProcessInfo{
+		{PID: 10, PPID: 1},
+		{PID: 11, PPID: 10},
+		{PID: 12, PPID: 11},
+		{PID: 20, PPID: 5}, // 主進程結束後遺留的子進程
+	})
+	if len(roots) != 2 || roots[0].PID != 10 || roots[1].PID != 20 {
+		t.Errorf("roots = %+v, want PID 10 and 20", roots)
+	}
+}
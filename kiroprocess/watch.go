@@ -0,0 +1,95 @@
+package kiroprocess
+
+import (
+	"context"
+	"time"
+)
+
+const (
+	// DefaultWatchInterval 監看 Kiro 狀態的預設檢查間隔
+	DefaultWatchInterval = 2 * time.Second
+	// fullScanEvery 執行中時每隔幾次檢查重新列舉一次進程（避免 PID 被重用時誤判仍在執行）
+	fullScanEvery = 15
+)
+
+// 監看事件類型
+const (
+	EventStarted = "started" // Kiro 已啟動
+	EventStopped = "stopped" // Kiro 已結束
+)
+
+// Event Kiro 啟動或結束的事件
+type Event struct {
+	Type      string        `json:"type"`
+	Time      time.Time     `json:"time"`
+	Processes []ProcessInfo `json:"processes"` // 啟動時的 Kiro 進程（結束時為空）
+}
+
+// WatchOptions 監看選項
+type WatchOptions struct {
+	// Interval 檢查間隔，<= 0 時使用 DefaultWatchInterval
+	Interval time.Duration
+	// EmitInitial 第一次檢查時也送出目前的狀態（執行中為 started，否則為 stopped）
+	EmitInitial bool
+}
+
+// Watch 在背景監看 Kiro 的啟動與結束，狀態改變時送出事件
+// 執行中時只檢查主進程是否仍存在，不會每次都列舉所有進程；ctx 結束時停止並關閉 channel
+func Watch(ctx context.Context, opts WatchOptions) <-chan Event {
+	return watch(ctx, opts, GetKiroProcesses, processAlive)
+}
+
+func watch(ctx context.Context, opts WatchOptions, list func() ([]ProcessInfo, error), alive func(pid int) bool) <-chan Event {
+	if opts.Interval <= 0 {
+		opts.Interval = DefaultWatchInterval
+	}
+
+	events := make(chan Event)
+	go func() {
+		defer close(events)
+		ticker := time.NewTicker(opts.Interval)
+		defer ticker.Stop()
+
+		known := false   // 是否已取得第一次的狀態
+		running := false // 上次檢查時 Kiro 是否執行中
+		var roots []ProcessInfo
+		skipped := 0
+		for {
+			if running && skipped < fullScanEvery && anyAlive(roots, alive) {
+				skipped++
+			} else if processes, err := list(); err == nil {
+				skipped = 0
+				now := len(processes) > 0
+				if (!known && opts.EmitInitial) || (known && now != running) {
+					e := Event{Type: EventStopped, Time: time.Now(), Processes: []ProcessInfo{}}
+					if now {
+						e.Type, e.Processes = EventStarted, processes
+					}
+					select {
+					case events <- e:
+					case <-ctx.Done():
+						return
+					}
+				}
+				known, running, roots = true, now, rootProcesses(processes)
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	return events
+}
+
+// anyAlive 是否有任一進程仍存在
+func anyAlive(processes []ProcessInfo, alive func(pid int) bool) bool {
+	for _, p := range processes {
+		if alive(p.PID) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,122 @@
+package kiroprocess
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeSystem 模擬的進程狀態
+type fakeSystem struct {
+	mu        sync.Mutex
+	processes []ProcessInfo
+	lists     int
+}
+
+func (f *fakeSystem) set(processes ...ProcessInfo) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.processes = processes
+}
+
+func (f *fakeSystem) list() ([]ProcessInfo, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.lists++
+	return append([]ProcessInfo(nil), f.processes...), nil
+}
+
+func (f *fakeSystem) alive(pid int) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, p := range f.processes {
+		if p.PID == pid {
+			return true
+		}
+	}
+	return false
+}
+
+// next 等待下一個事件
+func next(t *testing.T, events <-chan Event) Event {
+	t.Helper()
+	select {
+	case e, ok := <-events:
+		if !ok {
+			t.Fatal("events channel closed")
+		}
+		return e
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for event")
+		return Event{}
+	}
+}
+
+// TestWatch_Transitions 測試送出初始狀態與啟動、結束的轉換，執行中時不重複列舉進程
+func TestWatch_Transitions(t *testing.T) {
+	system := &fakeSystem{}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events := watch(ctx, WatchOptions{Interval: time.Millisecond, EmitInitial: true}, system.list, system.alive)
+
+	if e := next(t, events); e.Type != EventStopped || len(e.Processes) != 0 {
+		t.Errorf("initial event = %+v, want stopped", e)
+	}
+
+	system.set(ProcessInfo{PID: 100, PPID: 1, Role: RoleMain}, ProcessInfo{PID: 101, PPID: 100, Role: RoleRenderer})
+	e := next(t, events)
+	if e.Type != EventStarted || len(e.Processes) != 2 {
+		t.Errorf("event = %+v, want started with 2 processes", e)
+	}
+
+	// 執行中時只檢查主進程是否存在，每 fullScanEvery 次才重新列舉
+	system.mu.Lock()
+	before := system.lists
+	system.mu.Unlock()
+	start := time.Now()
+	time.Sleep(5 * time.Millisecond)
+	system.mu.Lock()
+	limit := int(time.Since(start)/(fullScanEvery*time.Millisecond)) + 1
+	if listed := system.lists - before; listed > limit {
+		t.Errorf("listed %d times while running, want at most %d full scans", listed, limit)
+	}
+	system.mu.Unlock()
+
+	system.set()
+	if e := next(t, events); e.Type != EventStopped {
+		t.Errorf("event = %+v, want stopped", e)
+	}
+}
+
+// TestWatch_NoInitial 測試未要求初始狀態時只送出轉換
+func TestWatch_NoInitial(t *testing.T) {
+	system := &fakeSystem{}
+	system.set(ProcessInfo{PID: 100, PPID: 1, Role: RoleMain})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events := watch(ctx, WatchOptions{Interval: time.Millisecond}, system.list, system.alive)
+
+	time.Sleep(5 * time.Millisecond)
+	system.set()
+	if e := next(t, events); e.Type != EventStopped {
+		t.Errorf("first event = %+v, want stopped", e)
+	}
+}
+
+// TestWatch_StopsOnCancel 測試 ctx 結束時關閉 channel
+func TestWatch_StopsOnCancel(t *testing.T) {
+	system := &fakeSystem{}
+	ctx, cancel := context.WithCancel(context.Background())
+	events := watch(ctx, WatchOptions{Interval: time.Millisecond}, system.list, system.alive)
+	cancel()
+
+	select {
+	case _, ok := <-events:
+		if ok {
+			t.Error("unexpected event after cancel")
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("channel not closed after cancel")
+	}
+}
@@ -0,0 +1,107 @@
+package kiroprocess
+
+import (
+	"encoding/json"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"kiro-manager/kiropath"
+)
+
+// windowsState Kiro（VS Code）記錄於 User/globalStorage/storage.json 的視窗狀態
+type windowsState struct {
+	LastActiveWindow *windowState  `json:"lastActiveWindow"`
+	OpenedWindows    []windowState `json:"openedWindows"`
+}
+
+// windowState 單一視窗開啟的資料夾或工作區
+type windowState struct {
+	Folder              string `json:"folder"`
+	WorkspaceIdentifier *struct {
+		ConfigURIPath string `json:"configURIPath"`
+	} `json:"workspaceIdentifier"`
+}
+
+// OpenedWorkspaces 取得 Kiro 上次關閉時開啟的資料夾與工作區檔（本機路徑）
+// Kiro 正常結束時才會寫入視窗狀態；遠端工作區與已不存在的路徑會被略過
+func OpenedWorkspaces() ([]string, error) {
+	configPath, err := kiropath.GetKiroConfigPath()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(filepath.Join(configPath, "User", "globalStorage", "storage.json"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []string{}, nil
+		}
+		return nil, err
+	}
+
+	var workspaces []string
+	for _, path := range parseWindowsState(data, runtime.GOOS == "windows") {
+		if _, err := os.Stat(path); err == nil {
+			workspaces = append(workspaces, path)
+		}
+	}
+	return workspaces, nil
+}
+
+// parseWindowsState 從 storage.json 取出視窗開啟的本機路徑（依序、去除重複）
+func parseWindowsState(data []byte, windows bool) []string {
+	var storage struct {
+		WindowsState windowsState `json:"windowsState"`
+	}
+	if err := json.Unmarshal(data, &storage); err != nil {
+		return nil
+	}
+
+	windowList := storage.WindowsState.OpenedWindows
+	if last := storage.WindowsState.LastActiveWindow; last != nil {
+		windowList = append([]windowState{*last}, windowList...)
+	}
+
+	seen := make(map[string]bool)
+	var paths []string
+	for _, w := range windowList {
+		uri := w.Folder
+		if uri == "" && w.WorkspaceIdentifier != nil {
+			uri = w.WorkspaceIdentifier.ConfigURIPath
+		}
+		path, ok := fileURIToPath(uri, windows)
+		if !ok || seen[path] {
+			continue
+		}
+		seen[path] = true
+		paths = append(paths, path)
+	}
+	return paths
+}
+
+// fileURIToPath 將 file:// URI 轉為本機路徑（其他 scheme 回傳 false）
+// Windows 的磁碟機路徑為 file:///c%3A/Users/...，網路路徑為 file://server/share/...
+func fileURIToPath(uri string, windows bool) (string, bool) {
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme != "file" || u.Path == "" {
+		return "", false
+	}
+	if !windows {
+		if u.Host != "" {
+			return "", false
+		}
+		return u.Path, true
+	}
+
+	path := u.Path
+	if u.Host != "" {
+		return `\\` + u.Host + strings.ReplaceAll(path, "/", `\`), true
+	}
+	// /c:/Users/... → c:\Users\...
+	if len(path) >= 3 && path[0] == '/' && path[2] == ':' {
+		path = path[1:]
+	}
+	return strings.ReplaceAll(path, "/", `\`), true
+}
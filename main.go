@@ -1,3 +1,5 @@
+//go:build !cli
+
 package main
 
 import (
@@ -26,6 +28,7 @@ func main() {
 		},
 		BackgroundColour: &options.RGBA{R: 9, G: 9, B: 11, A: 1},
 		OnStartup:        app.startup,
+		OnShutdown:       app.shutdown,
 		Bind: []interface{}{
 			app,
 		},
@@ -3,143 +3,316 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
-	"kiro-manager/awssso"
+	"io"
+	"log/slog"
+	"net"
+	"os"
+	"strings"
+
 	"kiro-manager/backup"
-	"kiro-manager/kiropath"
-	"kiro-manager/machineid"
+	"kiro-manager/tokenrefresh"
+	"kiro-manager/usage"
 )
 
-func main() {
-	// Machine ID 示範
-	rawId, err := machineid.GetRawMachineId()
-	if err != nil {
-		fmt.Printf("Error getting raw machine id: %v\n", err)
-		return
-	}
-	fmt.Printf("Raw Machine ID: %s\n", rawId)
+// CLI 結束碼
+const (
+	exitOK          = 0 // 成功
+	exitError       = 1 // 一般錯誤
+	exitUsage       = 2 // 參數錯誤
+	exitNotFound    = 3 // 備份不存在
+	exitAuth        = 4 // Token 已失效（HTTP 401/403），需重新登入
+	exitUnavailable = 5 // 網路錯誤或伺服器暫時無法使用（可稍後重試）
+)
+
+const cliUsage = `用法: kiro-manager <command> [arguments] [--json]
+
+Commands:
+  backup list                       列出所有備份
+  backup show <name>                顯示備份詳細資訊
+  backup create <name>              備份目前登入的帳號
+  backup restore <name> [--force]   切換至指定備份（--force 會關閉執行中的 Kiro）
+  backup delete <name>              刪除備份
+  backup rename <old> <new>         重新命名備份
+  usage                             列出所有備份的緩存用量
+  usage <name>                      刷新指定備份的用量
+  usage --current                   取得目前登入帳號的用量
+  history show <name>               列出備份的用量歷史
+  history forecast <name>           依用量歷史計算每日用量、預估用完日期與每週用量
+  history prune                     依保留天數（history.retentionDays）清理用量歷史
+  token refresh <name>              強制刷新備份的 AccessToken
+  kiro status                       顯示 Kiro 進程狀態
+  kiro stop [--timeout N]           關閉所有 Kiro 進程（先要求正常結束，N 秒後強制終止）
+  kiro start [path...]              啟動 Kiro（可指定要開啟的資料夾或檔案）
+  kiro watch                        持續輸出 Kiro 的啟動與結束（--json 時每行一個事件），Ctrl+C 結束
+  settings get [key]                讀取設定
+  settings set <key> <value>        寫入設定
+  connection test [url]             以目前的代理與 CA 設定測試 API 端點連線，回報失敗的階段
+  diag                              顯示診斷資訊（資料路徑、目前帳號、區域與 API 端點）
+  diag export [path]                匯出診斷記錄（診斷資訊、設定與已遮蔽機密的記錄檔）為 zip
+  report [path] [--format csv|json|md] [--from YYYY-MM-DD] [--to YYYY-MM-DD]
+                                    匯出帳號用量報表（未指定 path 時輸出至 stdout）
+  open <dir> [--force]              切換至目錄綁定的帳號（workspaces.bindings）後以 Kiro 開啟目錄
+                                    （需要切換帳號且 Kiro 執行中時，--force 會關閉 Kiro）
+
+Flags:
+  --json      以 JSON 格式輸出（錯誤同樣以 JSON 輸出至 stdout）
+  --verbose   將 debug 等級的記錄同時輸出至 stderr
+  --format    報表格式（csv、json、md，預設 csv）
+  --from/--to 報表期間（YYYY-MM-DD 或 RFC3339，結束日包含當天）
+  --timeout   等待 Kiro 正常結束的秒數（預設為設定 shutdown.timeoutSeconds）
+
+Exit codes:
+  0 成功  1 一般錯誤  2 參數錯誤  3 備份不存在  4 Token 已失效  5 網路或伺服器暫時無法使用
+`
+
+// cliError 帶有結束碼的 CLI 錯誤
+type cliError struct {
+	code    int
+	err     error
+	printed bool // 結果已輸出（例如 --json 的完整報告），結束時不再另外輸出錯誤
+}
+
+func (e *cliError) Error() string {
+	return e.err.Error()
+}
+
+func (e *cliError) Unwrap() error {
+	return e.err
+}
+
+// usageErrorf 建立參數錯誤
+func usageErrorf(format string, args ...any) error {
+	return &cliError{code: exitUsage, err: fmt.Errorf(format, args...)}
+}
 
-	hashedId, err := machineid.GetMachineId()
-	if err != nil {
-		fmt.Printf("Error getting hashed machine id: %v\n", err)
-		return
+// notFoundError 建立備份不存在錯誤
+func notFoundError(name string) error {
+	return &cliError{code: exitNotFound, err: fmt.Errorf("備份不存在: %s", name)}
+}
+
+// resultError 將 App 回傳的失敗 Result 轉為錯誤
+func resultError(result Result) error {
+	if result.Success {
+		return nil
 	}
-	fmt.Printf("Hashed Machine ID (SHA-256): %s\n", hashedId)
+	return errors.New(result.Message)
+}
 
-	fmt.Println()
+// exitCodeFor 依錯誤類型決定結束碼
+func exitCodeFor(err error) int {
+	if err == nil {
+		return exitOK
+	}
 
-	// Kiro 路徑偵測示範
-	fmt.Println("=== Kiro Path Detection ===")
+	var ce *cliError
+	if errors.As(err, &ce) {
+		return ce.code
+	}
 
-	kiroHome, err := kiropath.GetKiroHomePath()
-	if err != nil {
-		fmt.Printf("Error getting Kiro home path: %v\n", err)
-	} else {
-		exists := ""
-		if kiropath.KiroHomeExists() {
-			exists = " [EXISTS]"
+	var refreshErr *tokenrefresh.RefreshError
+	if errors.As(err, &refreshErr) {
+		switch {
+		case refreshErr.Code == 401 || refreshErr.Code == 403:
+			return exitAuth
+		case refreshErr.Code == 429 || refreshErr.Code >= 500:
+			return exitUnavailable
 		}
-		fmt.Printf("Kiro Home (~/.kiro): %s%s\n", kiroHome, exists)
 	}
 
-	kiroConfig, err := kiropath.GetKiroConfigPath()
-	if err != nil {
-		fmt.Printf("Error getting Kiro config path: %v\n", err)
-	} else {
-		exists := ""
-		if kiropath.KiroConfigExists() {
-			exists = " [EXISTS]"
+	var statusErr *usage.StatusError
+	if errors.As(err, &statusErr) {
+		switch {
+		case statusErr.StatusCode == 401 || statusErr.StatusCode == 403:
+			return exitAuth
+		case statusErr.StatusCode == 429 || statusErr.StatusCode >= 500:
+			return exitUnavailable
 		}
-		fmt.Printf("Kiro Config: %s%s\n", kiroConfig, exists)
 	}
 
-	kiroInstall, err := kiropath.GetKiroInstallPath()
-	if err != nil {
-		fmt.Printf("Kiro Install: %v\n", err)
-	} else {
-		fmt.Printf("Kiro Install: %s [INSTALLED]\n", kiroInstall)
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return exitUnavailable
 	}
 
-	fmt.Printf("Kiro Installed: %v\n", kiropath.IsKiroInstalled())
+	switch {
+	case errors.Is(err, backup.ErrBackupNotFound):
+		return exitNotFound
+	case errors.Is(err, backup.ErrInvalidBackupName):
+		return exitUsage
+	}
 
-	fmt.Println()
+	return exitError
+}
 
-	// AWS 路徑偵測示範
-	fmt.Println("=== AWS Path Detection ===")
+// cli 命令執行環境
+type cli struct {
+	app    *App
+	out    io.Writer
+	json   bool
+	flags  map[string]bool
+	values map[string]string
+}
 
-	awsConfig, err := kiropath.GetAWSConfigPath()
-	if err != nil {
-		fmt.Printf("Error getting AWS config path: %v\n", err)
-	} else {
-		exists := ""
-		if kiropath.AWSConfigExists() {
-			exists = " [EXISTS]"
-		}
-		fmt.Printf("AWS Config (~/.aws): %s%s\n", awsConfig, exists)
-	}
+// hasFlag 檢查是否指定了旗標
+func (c *cli) hasFlag(name string) bool {
+	return c.flags[name]
+}
 
-	fmt.Println()
+// flagValue 取得帶值旗標的值（未指定時為空字串）
+func (c *cli) flagValue(name string) string {
+	return c.values[name]
+}
 
-	// AWS SSO Cache 示範
-	fmt.Println("=== AWS SSO Cache ===")
+// printJSON 以縮排 JSON 輸出
+func (c *cli) printJSON(v any) error {
+	encoder := json.NewEncoder(c.out)
+	encoder.SetIndent("", "  ")
+	encoder.SetEscapeHTML(false)
+	return encoder.Encode(v)
+}
 
-	ssoCachePath, err := awssso.GetSSOCachePath()
-	if err != nil {
-		fmt.Printf("Error getting SSO cache path: %v\n", err)
-	} else {
-		exists := ""
-		if awssso.SSOCacheExists() {
-			exists = " [EXISTS]"
-		}
-		fmt.Printf("SSO Cache Path: %s%s\n", ssoCachePath, exists)
+// printf 輸出一般文字
+func (c *cli) printf(format string, args ...any) {
+	fmt.Fprintf(c.out, format, args...)
+}
+
+// done 輸出成功訊息（JSON 模式輸出 Result 結構）
+func (c *cli) done(message string) error {
+	if c.json {
+		return c.printJSON(Result{Success: true, Message: message})
 	}
+	c.printf("%s\n", message)
+	return nil
+}
 
-	// 列出快取檔案
-	cacheFiles, err := awssso.ListCacheFiles()
-	if err != nil {
-		fmt.Printf("Error listing cache files: %v\n", err)
-	} else {
-		fmt.Printf("Cache Files (%d):\n", len(cacheFiles))
-		for _, file := range cacheFiles {
-			fmt.Printf("  - %s\n", file)
+// splitArgs 將參數拆分為位置參數、旗標與帶值旗標，旗標可出現在任意位置
+// 帶值旗標可寫成 --name value 或 --name=value
+func splitArgs(args []string) (positional []string, flags map[string]bool, values map[string]string, err error) {
+	known := map[string]bool{"json": true, "force": true, "current": true, "help": true, "verbose": true}
+	knownValues := map[string]bool{"format": true, "from": true, "to": true, "timeout": true}
+	flags = make(map[string]bool)
+	values = make(map[string]string)
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			positional = append(positional, args[i+1:]...)
+			break
+		}
+		if arg == "-h" {
+			flags["help"] = true
+			continue
+		}
+		if strings.HasPrefix(arg, "--") {
+			name, value, hasValue := strings.Cut(strings.TrimPrefix(arg, "--"), "=")
+			switch {
+			case knownValues[name] && hasValue:
+				values[name] = value
+			case knownValues[name]:
+				if i+1 >= len(args) {
+					return nil, nil, nil, usageErrorf("旗標 %s 需要指定值", arg)
+				}
+				i++
+				values[name] = args[i]
+			case known[name] && !hasValue:
+				flags[name] = true
+			default:
+				return nil, nil, nil, usageErrorf("未知的旗標: %s", arg)
+			}
+			continue
 		}
+		positional = append(positional, arg)
 	}
 
-	// 讀取 Kiro Auth Token
-	token, err := awssso.ReadKiroAuthToken()
-	if err != nil {
-		fmt.Printf("Kiro Auth Token: %v\n", err)
-	} else {
-		fmt.Println("Kiro Auth Token:")
-		fmt.Printf("  Provider: %s\n", token.Provider)
-		fmt.Printf("  AuthMethod: %s\n", token.AuthMethod)
-		fmt.Printf("  ExpiresAt: %s\n", token.ExpiresAt)
-		if token.AccessToken != "" {
-			fmt.Printf("  AccessToken: %s...\n", token.AccessToken[:min(20, len(token.AccessToken))])
-		}
+	return positional, flags, values, nil
+}
+
+// requireArgs 檢查位置參數數量
+func requireArgs(args []string, n int, usage string) error {
+	if len(args) != n {
+		return usageErrorf("用法: kiro-manager %s", usage)
 	}
+	return nil
+}
+
+// run 分派子命令
+func (c *cli) run(args []string) error {
+	if len(args) == 0 {
+		return usageErrorf("缺少命令，執行 kiro-manager --help 查看用法")
+	}
+
+	switch args[0] {
+	case "backup":
+		return c.runBackup(args[1:])
+	case "usage":
+		return c.runUsage(args[1:])
+	case "history":
+		return c.runHistory(args[1:])
+	case "token":
+		return c.runToken(args[1:])
+	case "kiro":
+		return c.runKiro(args[1:])
+	case "settings":
+		return c.runSettings(args[1:])
+	case "connection":
+		return c.runConnection(args[1:])
+	case "diag":
+		return c.runDiag(args[1:])
+	case "report":
+		return c.runReport(args[1:])
+	case "open":
+		return c.runOpen(args[1:])
+	case "help":
+		c.printf("%s", cliUsage)
+		return nil
+	default:
+		return usageErrorf("未知的命令: %s", args[0])
+	}
+}
 
-	fmt.Println()
+func main() {
+	positional, flags, values, err := splitArgs(os.Args[1:])
+	jsonMode := flags["json"]
+
+	c := &cli{app: NewApp(), out: os.Stdout, json: jsonMode, flags: flags, values: values}
+
+	// 記錄一律寫入記錄檔；--verbose 時另以 debug 等級輸出至 stderr（不影響 stdout 的命令輸出）
+	if flags["verbose"] {
+		debug := slog.LevelDebug
+		c.app.logLevel = &debug
+		c.app.logOutput = os.Stderr
+	}
 
-	// Backup 模組示範
-	fmt.Println("=== Backup Module ===")
+	if err == nil {
+		if flags["help"] {
+			fmt.Fprint(os.Stdout, cliUsage)
+			os.Exit(exitOK)
+		}
 
-	backupRoot, err := backup.GetBackupRootPath()
-	if err != nil {
-		fmt.Printf("Error getting backup root path: %v\n", err)
-	} else {
-		fmt.Printf("Backup Root: %s\n", backupRoot)
+		// 與 GUI 相同的啟動流程（Shield 初始化、舊版備份加密遷移）
+		c.app.startup(context.Background())
+		if warning := c.app.GetStartupWarning(); warning != "" {
+			fmt.Fprintf(os.Stderr, "警告: %s\n", warning)
+		}
+		err = c.run(positional)
 	}
 
-	// 列出所有備份
-	backups, err := backup.ListBackups()
-	if err != nil {
-		fmt.Printf("Error listing backups: %v\n", err)
-	} else {
-		fmt.Printf("Backups (%d):\n", len(backups))
-		for _, b := range backups {
-			fmt.Printf("  - %s (Token: %v, MachineID: %v, Time: %s)\n",
-				b.Name, b.HasToken, b.HasMachineID, b.BackupTime.Format("2006-01-02 15:04:05"))
+	code := exitCodeFor(err)
+	var ce *cliError
+	if err != nil && !(errors.As(err, &ce) && ce.printed) {
+		if jsonMode {
+			c.printJSON(map[string]any{
+				"success":  false,
+				"error":    err.Error(),
+				"exitCode": code,
+			})
+		} else {
+			fmt.Fprintf(os.Stderr, "錯誤: %v\n", err)
 		}
 	}
+	os.Exit(code)
 }
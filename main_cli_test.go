@@ -0,0 +1,32 @@
+//go:build cli
+
+package main
+
+import (
+	"fmt"
+	"testing"
+
+	"kiro-manager/tokenrefresh"
+	"kiro-manager/usage"
+)
+
+// TestExitCodeFor 測試刷新與用量查詢的 HTTP 錯誤對應到文件記載的結束碼
+func TestExitCodeFor(t *testing.T) {
+	tests := []struct {
+		err  error
+		want int
+	}{
+		{nil, exitOK},
+		{&tokenrefresh.RefreshError{Code: 401}, exitAuth},
+		{fmt.Errorf("API 呼叫失敗: %w", &usage.StatusError{StatusCode: 403}), exitAuth},
+		{fmt.Errorf("API 呼叫失敗: %w", &usage.StatusError{StatusCode: 401}), exitAuth},
+		{&usage.StatusError{StatusCode: 503}, exitUnavailable},
+		{&usage.StatusError{StatusCode: 400}, exitError},
+	}
+
+	for _, tt := range tests {
+		if got := exitCodeFor(tt.err); got != tt.want {
+			t.Errorf("exitCodeFor(%v) = %d, want %d", tt.err, got, tt.want)
+		}
+	}
+}
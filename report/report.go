@@ -0,0 +1,251 @@
+package report
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"kiro-manager/usagehistory"
+)
+
+// 報表格式
+const (
+	FormatCSV      = "csv"
+	FormatJSON     = "json"
+	FormatMarkdown = "md"
+)
+
+// dateLayout 日期範圍參數的格式（只有日期時依本地時區解析）
+const dateLayout = "2006-01-02"
+
+var (
+	ErrUnknownFormat = errors.New("unknown report format")
+	ErrInvalidRange  = errors.New("invalid date range")
+)
+
+// Row 單一帳號的報表資料
+type Row struct {
+	Name              string  `json:"name"`
+	Provider          string  `json:"provider"`
+	AuthType          string  `json:"authType"`
+	SubscriptionTitle string  `json:"subscriptionTitle"`
+	UsageLimit        float64 `json:"usageLimit"`
+	CurrentUsage      float64 `json:"currentUsage"`
+	Balance           float64 `json:"balance"`
+	PeriodUsage       float64 `json:"periodUsage"`     // 報表期間內的用量（依用量歷史計算）
+	TokenExpiresAt    string  `json:"tokenExpiresAt"`  // Token 到期時間（RFC3339），未知時為空
+	LastRefreshedAt   string  `json:"lastRefreshedAt"` // 用量最後刷新時間（RFC3339），未知時為空
+}
+
+// Report 用量報表
+type Report struct {
+	GeneratedAt string `json:"generatedAt"`    // 產生時間（RFC3339）
+	From        string `json:"from,omitempty"` // 期間開始（RFC3339），不限時為空
+	To          string `json:"to,omitempty"`   // 期間結束（RFC3339，不含），不限時為空
+	Rows        []Row  `json:"rows"`
+}
+
+// columns 報表欄位標題（CSV 與 Markdown 共用）
+var columns = []string{
+	"name", "provider", "authType", "subscriptionTitle", "usageLimit", "currentUsage",
+	"balance", "periodUsage", "tokenExpiresAt", "lastRefreshedAt",
+}
+
+// Range 報表期間 [From, To)；零值表示不限
+type Range struct {
+	From time.Time
+	To   time.Time
+}
+
+// IsZero 是否不限期間
+func (r Range) IsZero() bool {
+	return r.From.IsZero() && r.To.IsZero()
+}
+
+// Contains 判斷時間是否落在期間內
+func (r Range) Contains(t time.Time) bool {
+	if !r.From.IsZero() && t.Before(r.From) {
+		return false
+	}
+	return r.To.IsZero() || t.Before(r.To)
+}
+
+// ParseRange 解析期間參數（YYYY-MM-DD 或 RFC3339，空字串表示不限）
+// 只有日期的結束日包含當天整天
+func ParseRange(from, to string, loc *time.Location) (Range, error) {
+	var r Range
+	var err error
+	if r.From, err = parseBound(from, loc, false); err != nil {
+		return Range{}, err
+	}
+	if r.To, err = parseBound(to, loc, true); err != nil {
+		return Range{}, err
+	}
+	if !r.From.IsZero() && !r.To.IsZero() && !r.From.Before(r.To) {
+		return Range{}, fmt.Errorf("%w: %s 不早於 %s", ErrInvalidRange, from, to)
+	}
+	return r, nil
+}
+
+// parseBound 解析單一期間邊界
+func parseBound(value string, loc *time.Location, end bool) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	t, err := time.ParseInLocation(dateLayout, value, loc)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w: %q（請使用 YYYY-MM-DD 或 RFC3339）", ErrInvalidRange, value)
+	}
+	if end {
+		t = t.AddDate(0, 0, 1)
+	}
+	return t, nil
+}
+
+// ParseFormat 解析報表格式（csv、json、md / markdown）
+func ParseFormat(format string) (string, error) {
+	switch strings.ToLower(strings.TrimSpace(format)) {
+	case FormatCSV:
+		return FormatCSV, nil
+	case FormatJSON:
+		return FormatJSON, nil
+	case FormatMarkdown, "markdown":
+		return FormatMarkdown, nil
+	default:
+		return "", fmt.Errorf("%w: %s", ErrUnknownFormat, format)
+	}
+}
+
+// ApplyHistory 以期間內的用量歷史填入 row 的用量欄位
+// 期間不限時只計算 PeriodUsage（其餘欄位沿用緩存）；期間內沒有紀錄時回傳 false
+func ApplyHistory(row *Row, records []usagehistory.Record, r Range) bool {
+	var inRange []usagehistory.Record
+	for i, record := range records {
+		if !r.Contains(record.Time) {
+			continue
+		}
+		// 以期間開始前的最後一筆作為基準，計入期間內第一筆之前的用量
+		if len(inRange) == 0 && i > 0 {
+			inRange = append(inRange, records[i-1])
+		}
+		inRange = append(inRange, record)
+	}
+	row.PeriodUsage = usagehistory.Consumed(inRange)
+
+	if r.IsZero() {
+		return true
+	}
+	if len(inRange) == 0 {
+		return false
+	}
+	latest := inRange[len(inRange)-1]
+	row.SubscriptionTitle = latest.SubscriptionTitle
+	row.UsageLimit = latest.UsageLimit
+	row.CurrentUsage = latest.CurrentUsage
+	row.Balance = latest.UsageLimit - latest.CurrentUsage
+	row.LastRefreshedAt = latest.Time.Format(time.RFC3339)
+	return true
+}
+
+// Write 以指定格式輸出報表
+func Write(w io.Writer, format string, report Report) error {
+	switch format {
+	case FormatCSV:
+		return writeCSV(w, report)
+	case FormatJSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		encoder.SetEscapeHTML(false)
+		return encoder.Encode(report)
+	case FormatMarkdown:
+		return writeMarkdown(w, report)
+	default:
+		return fmt.Errorf("%w: %s", ErrUnknownFormat, format)
+	}
+}
+
+// values 取得 row 的欄位值（順序同 columns）
+func (row Row) values() []string {
+	number := func(v float64) string {
+		return strconv.FormatFloat(v, 'f', 2, 64)
+	}
+	return []string{
+		row.Name, row.Provider, row.AuthType, row.SubscriptionTitle,
+		number(row.UsageLimit), number(row.CurrentUsage), number(row.Balance), number(row.PeriodUsage),
+		row.TokenExpiresAt, row.LastRefreshedAt,
+	}
+}
+
+// writeCSV 輸出 CSV（第一行為欄位標題）
+func writeCSV(w io.Writer, report Report) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(columns); err != nil {
+		return err
+	}
+	for _, row := range report.Rows {
+		if err := cw.Write(row.values()); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// writeMarkdown 輸出 Markdown 表格
+func writeMarkdown(w io.Writer, report Report) error {
+	var b strings.Builder
+	b.WriteString("# Kiro 用量報表\n\n")
+	fmt.Fprintf(&b, "- 產生時間：%s\n", report.GeneratedAt)
+	if report.From != "" || report.To != "" {
+		fmt.Fprintf(&b, "- 期間：%s ~ %s\n", dashIfEmpty(report.From), dashIfEmpty(report.To))
+	}
+	b.WriteString("\n")
+
+	writeRow := func(cells []string) {
+		b.WriteString("|")
+		for _, cell := range cells {
+			b.WriteString(" " + markdownEscape(cell) + " |")
+		}
+		b.WriteString("\n")
+	}
+	writeRow(columns)
+	// 數值欄位（usageLimit ~ periodUsage）靠右對齊
+	b.WriteString("|")
+	for i := range columns {
+		if i >= 4 && i <= 7 {
+			b.WriteString("---:|")
+		} else {
+			b.WriteString("---|")
+		}
+	}
+	b.WriteString("\n")
+	for _, row := range report.Rows {
+		writeRow(row.values())
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// markdownEscape 跳脫表格儲存格中的特殊字元
+func markdownEscape(s string) string {
+	s = strings.ReplaceAll(s, "|", `\|`)
+	return strings.NewReplacer("\r\n", " ", "\n", " ").Replace(s)
+}
+
+// dashIfEmpty 空字串顯示為 "-"
+func dashIfEmpty(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
@@ -0,0 +1,161 @@
+package report
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"kiro-manager/usagehistory"
+)
+
+// testReport 測試用報表
+func testReport() Report {
+	return Report{
+		GeneratedAt: "2025-12-10T08:00:00Z",
+		Rows: []Row{
+			{Name: "work", Provider: "Github", AuthType: "social", SubscriptionTitle: "KIRO PRO", UsageLimit: 1000, CurrentUsage: 250.5, Balance: 749.5, PeriodUsage: 40, TokenExpiresAt: "2025-12-10T09:00:00Z", LastRefreshedAt: "2025-12-10T07:55:00Z"},
+			{Name: "a|b", AuthType: "idc"},
+		},
+	}
+}
+
+// TestParseRange 測試日期與 RFC3339 期間解析，結束日包含當天
+func TestParseRange(t *testing.T) {
+	loc := time.FixedZone("UTC+8", 8*3600)
+
+	r, err := ParseRange("2025-12-01", "2025-12-31", loc)
+	if err != nil {
+		t.Fatalf("ParseRange failed: %v", err)
+	}
+	if !r.From.Equal(time.Date(2025, 12, 1, 0, 0, 0, 0, loc)) || !r.To.Equal(time.Date(2026, 1, 1, 0, 0, 0, 0, loc)) {
+		t.Errorf("range = %v ~ %v", r.From, r.To)
+	}
+	if !r.Contains(time.Date(2025, 12, 31, 23, 59, 0, 0, loc)) || r.Contains(time.Date(2025, 11, 30, 23, 59, 0, 0, loc)) {
+		t.Error("Contains should include the whole end day and exclude days before From")
+	}
+
+	r, err = ParseRange("2025-12-01T00:00:00Z", "", loc)
+	if err != nil || !r.From.Equal(time.Date(2025, 12, 1, 0, 0, 0, 0, time.UTC)) || !r.To.IsZero() {
+		t.Errorf("RFC3339 from: range = %+v, err = %v", r, err)
+	}
+
+	if r, err := ParseRange("", "", loc); err != nil || !r.IsZero() {
+		t.Errorf("empty range = %+v, err = %v", r, err)
+	}
+	for _, c := range [][2]string{{"2025-13-01", ""}, {"", "yesterday"}, {"2025-12-02", "2025-12-01"}} {
+		if _, err := ParseRange(c[0], c[1], loc); !errors.Is(err, ErrInvalidRange) {
+			t.Errorf("ParseRange(%q, %q) err = %v, want ErrInvalidRange", c[0], c[1], err)
+		}
+	}
+}
+
+// TestParseFormat 測試格式名稱解析
+func TestParseFormat(t *testing.T) {
+	for input, want := range map[string]string{"csv": FormatCSV, "JSON": FormatJSON, "md": FormatMarkdown, "markdown": FormatMarkdown} {
+		if got, err := ParseFormat(input); err != nil || got != want {
+			t.Errorf("ParseFormat(%q) = %q, %v; want %q", input, got, err, want)
+		}
+	}
+	if _, err := ParseFormat("xlsx"); !errors.Is(err, ErrUnknownFormat) {
+		t.Errorf("ParseFormat(xlsx) err = %v, want ErrUnknownFormat", err)
+	}
+}
+
+// TestApplyHistory 測試以期間內最後一筆紀錄填入用量，並計入期間開始前一筆之後的用量
+func TestApplyHistory(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2025, 12, d, 12, 0, 0, 0, time.UTC) }
+	records := []usagehistory.Record{
+		{Time: day(1), SubscriptionTitle: "KIRO PRO", UsageLimit: 1000, CurrentUsage: 100},
+		{Time: day(5), SubscriptionTitle: "KIRO PRO", UsageLimit: 1000, CurrentUsage: 160},
+		{Time: day(8), SubscriptionTitle: "KIRO PRO", UsageLimit: 1000, CurrentUsage: 200},
+		{Time: day(20), SubscriptionTitle: "KIRO PRO+", UsageLimit: 2000, CurrentUsage: 300},
+	}
+	r := Range{From: time.Date(2025, 12, 3, 0, 0, 0, 0, time.UTC), To: time.Date(2025, 12, 10, 0, 0, 0, 0, time.UTC)}
+
+	row := Row{Name: "work", UsageLimit: 2000, CurrentUsage: 300}
+	if !ApplyHistory(&row, records, r) {
+		t.Fatal("ApplyHistory should report records in range")
+	}
+	if row.CurrentUsage != 200 || row.Balance != 800 || row.SubscriptionTitle != "KIRO PRO" || row.LastRefreshedAt != day(8).Format(time.RFC3339) {
+		t.Errorf("unexpected row: %+v", row)
+	}
+	if math.Abs(row.PeriodUsage-100) > 1e-9 {
+		t.Errorf("PeriodUsage = %v, want 100", row.PeriodUsage)
+	}
+
+	row = Row{Name: "work", CurrentUsage: 300}
+	if !ApplyHistory(&row, records, Range{}) || row.CurrentUsage != 300 || math.Abs(row.PeriodUsage-200) > 1e-9 {
+		t.Errorf("unbounded range should keep cached values and count all history: %+v", row)
+	}
+
+	empty := Range{From: time.Date(2025, 12, 10, 0, 0, 0, 0, time.UTC), To: time.Date(2025, 12, 15, 0, 0, 0, 0, time.UTC)}
+	if ApplyHistory(&Row{}, records, empty) {
+		t.Error("ApplyHistory should report no records in an empty range")
+	}
+}
+
+// TestWrite_CSV 測試 CSV 輸出可被解析回相同欄位
+func TestWrite_CSV(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Write(&buf, FormatCSV, testReport()); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("CSV output is not parseable: %v", err)
+	}
+	if len(records) != 3 || strings.Join(records[0], ",") != strings.Join(columns, ",") {
+		t.Fatalf("unexpected CSV: %v", records)
+	}
+	if got := strings.Join(records[1], ","); got != "work,Github,social,KIRO PRO,1000.00,250.50,749.50,40.00,2025-12-10T09:00:00Z,2025-12-10T07:55:00Z" {
+		t.Errorf("row = %s", got)
+	}
+}
+
+// TestWrite_JSON 測試 JSON 輸出包含報表資訊與所有帳號
+func TestWrite_JSON(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Write(&buf, FormatJSON, testReport()); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+
+	var got Report
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("JSON output is not parseable: %v", err)
+	}
+	if got.GeneratedAt != "2025-12-10T08:00:00Z" || len(got.Rows) != 2 || got.Rows[0] != testReport().Rows[0] {
+		t.Errorf("unexpected report: %+v", got)
+	}
+}
+
+// TestWrite_Markdown 測試 Markdown 表格的欄位數一致且跳脫 |
+func TestWrite_Markdown(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Write(&buf, FormatMarkdown, testReport()); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+
+	var table []string
+	for _, line := range strings.Split(buf.String(), "\n") {
+		if strings.HasPrefix(line, "|") {
+			table = append(table, line)
+		}
+	}
+	if len(table) != 4 {
+		t.Fatalf("table lines = %d, want header + separator + 2 rows:\n%s", len(table), buf.String())
+	}
+	for _, line := range table {
+		if cells := strings.Count(strings.ReplaceAll(line, `\|`, ""), "|"); cells != len(columns)+1 {
+			t.Errorf("line has %d separators, want %d: %s", cells, len(columns)+1, line)
+		}
+	}
+	if !strings.Contains(table[3], `a\|b`) {
+		t.Errorf("pipe in cell should be escaped: %s", table[3])
+	}
+}
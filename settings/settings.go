@@ -2,9 +2,21 @@ package settings
 
 import (
 	"encoding/json"
+	"log/slog"
+	"net/http"
 	"os"
 	"path/filepath"
+	"strings"
 	"sync"
+	"time"
+
+	"kiro-manager/internal/atomicfile"
+	"kiro-manager/internal/datadir"
+	"kiro-manager/internal/endpoints"
+	"kiro-manager/internal/logging"
+	"kiro-manager/internal/retry"
+	"kiro-manager/internal/transport"
+	"kiro-manager/workspace"
 )
 
 const (
@@ -14,6 +26,16 @@ const (
 	DefaultLowBalanceThreshold = 0.2
 	// 預設 Kiro IDE 版本號
 	DefaultKiroVersion = "0.7.5"
+	// 重試次數上限（含第一次）
+	MaxRetryAttempts = 10
+	// 預設用量歷史保留天數
+	DefaultHistoryRetentionDays = 90
+	// 用量歷史保留天數上限
+	MaxHistoryRetentionDays = 3650
+	// 預設等待 Kiro 正常結束的秒數
+	DefaultShutdownTimeoutSeconds = 10
+	// 等待 Kiro 正常結束的秒數上限
+	MaxShutdownTimeoutSeconds = 300
 )
 
 // Settings 全域設定結構
@@ -28,6 +50,130 @@ type Settings struct {
 	// true: 每次 API 請求時自動偵測 Kiro 執行檔版本
 	// false: 使用 KiroVersion 欄位的自定義值
 	UseAutoDetect bool `json:"useAutoDetect"`
+	// DataDir 資料目錄覆寫（備份存放位置）
+	// 空字串表示使用預設目錄；環境變數 KIRO_MANAGER_DATA_DIR 優先於此設定
+	DataDir string `json:"dataDir,omitempty"`
+	// Retry Token 刷新與用量查詢的重試策略
+	Retry RetrySettings `json:"retry"`
+	// Endpoints API 端點覆寫（自訂或 FIPS 端點）；空值時依 token 的區域推導
+	Endpoints EndpointSettings `json:"endpoints"`
+	// Network 對外連線設定（代理與額外的 CA 憑證）
+	Network NetworkSettings `json:"network"`
+	// Logging 記錄設定
+	Logging LoggingSettings `json:"logging"`
+	// History 用量歷史設定
+	History HistorySettings `json:"history"`
+	// Alerts 用量警示設定
+	Alerts AlertSettings `json:"alerts"`
+	// Shutdown 關閉 Kiro 的設定
+	Shutdown ShutdownSettings `json:"shutdown"`
+	// Relaunch 切換帳號後重新啟動 Kiro 的設定
+	Relaunch RelaunchSettings `json:"relaunch"`
+	// Workspaces 目錄與帳號的綁定
+	Workspaces WorkspaceSettings `json:"workspaces"`
+}
+
+// Redacted 回傳機密值（代理密碼）已遮蔽的副本，供顯示或匯出使用
+func (s Settings) Redacted() Settings {
+	if s.Network.ProxyPassword != "" {
+		s.Network.ProxyPassword = logging.Redacted
+	}
+	return s
+}
+
+// AlertSettings 用量警示設定
+// 每次刷新用量時評估規則，每個等級在同一計費週期內只觸發一次
+type AlertSettings struct {
+	// Rules 預設規則（套用於沒有個別規則的帳號）
+	// 為空時以 LowBalanceThreshold 換算為單一已使用百分比規則
+	Rules []AlertRule `json:"rules,omitempty"`
+	// Accounts 個別帳號（備份名稱）的規則，覆蓋預設規則
+	Accounts map[string][]AlertRule `json:"accounts,omitempty"`
+	// DisableDesktopNotification 不顯示桌面通知
+	DisableDesktopNotification bool `json:"disableDesktopNotification"`
+	// Command 警示觸發時執行的指令（選填），事件以 JSON 由標準輸入傳入
+	Command string `json:"command,omitempty"`
+}
+
+// AlertRule 單一警示等級，UsedPercent 與 MinRemaining 需擇一設定
+type AlertRule struct {
+	// UsedPercent 已使用百分比達到此值時觸發（0 < x <= 100）
+	UsedPercent float64 `json:"usedPercent,omitempty"`
+	// MinRemaining 剩餘額度低於此值時觸發
+	MinRemaining float64 `json:"minRemaining,omitempty"`
+}
+
+// Valid 規則是否有效（恰好設定一種條件且數值合理）
+func (r AlertRule) Valid() bool {
+	if r.UsedPercent != 0 && r.MinRemaining != 0 {
+		return false
+	}
+	if r.UsedPercent != 0 {
+		return r.UsedPercent > 0 && r.UsedPercent <= 100
+	}
+	return r.MinRemaining > 0
+}
+
+// HistorySettings 用量歷史設定
+type HistorySettings struct {
+	// RetentionDays 用量歷史保留天數（1 ~ 3650），超過的紀錄會在寫入新紀錄時清除
+	RetentionDays int `json:"retentionDays"`
+}
+
+// ShutdownSettings 關閉 Kiro 的設定
+// 切換帳號或 Patch 前會先要求 Kiro 正常結束，逾時後才強制終止
+type ShutdownSettings struct {
+	// TimeoutSeconds 等待 Kiro 正常結束的秒數（1 ~ 300）
+	TimeoutSeconds int `json:"timeoutSeconds"`
+}
+
+// RelaunchSettings 切換帳號後重新啟動 Kiro 的設定
+// 只有切換前 Kiro 正在執行（被本程式關閉）時才會重新啟動
+type RelaunchSettings struct {
+	// AfterSwitch 切換帳號或還原原始機器後，自動重新啟動 Kiro
+	AfterSwitch bool `json:"afterSwitch"`
+	// DisableReopenWorkspaces 重新啟動時不重新開啟原本的資料夾與工作區
+	DisableReopenWorkspaces bool `json:"disableReopenWorkspaces"`
+}
+
+// WorkspaceSettings 目錄與帳號（備份）的綁定
+// kiro-manager open <dir> 依綁定切換帳號後以 Kiro 開啟目錄
+type WorkspaceSettings struct {
+	// Bindings 綁定清單；子目錄沿用上層目錄的綁定，多筆符合時取最深的目錄
+	Bindings []workspace.Binding `json:"bindings,omitempty"`
+}
+
+// LoggingSettings 記錄設定
+// 記錄檔位於使用者資料目錄的 logs/，輸出前會遮蔽 token、密碼與識別資訊
+type LoggingSettings struct {
+	// Level 記錄等級（debug、info、warn、error）
+	Level string `json:"level"`
+}
+
+// NetworkSettings 對外連線設定
+// 未指定 ProxyURL 時遵循環境變數 HTTPS_PROXY / HTTP_PROXY / NO_PROXY
+type NetworkSettings struct {
+	// ProxyURL HTTP(S) 代理，例如 http://proxy.corp:3128
+	ProxyURL string `json:"proxyUrl,omitempty"`
+	// ProxyUsername 代理驗證帳號（選填）
+	ProxyUsername string `json:"proxyUsername,omitempty"`
+	// ProxyPassword 代理驗證密碼（選填）
+	ProxyPassword string `json:"proxyPassword,omitempty"`
+	// NoProxy 不經代理的主機清單（逗號分隔），與環境變數 NO_PROXY 合併
+	NoProxy string `json:"noProxy,omitempty"`
+	// CABundle 額外信任的 PEM CA 憑證檔路徑（例如 TLS 攔截代理的根憑證）
+	CABundle string `json:"caBundle,omitempty"`
+}
+
+// RetrySettings 重試策略設定
+// 網路錯誤、429 與 5xx 會以指數退避加隨機抖動重試；401/403 不重試
+type RetrySettings struct {
+	// MaxAttempts 最多嘗試次數（含第一次，1 ~ 10；1 表示不重試）
+	MaxAttempts int `json:"maxAttempts"`
+	// BaseDelayMs 第一次重試前的等待毫秒數，之後每次加倍
+	BaseDelayMs int `json:"baseDelayMs"`
+	// MaxDelayMs 單次等待上限（毫秒）；伺服器 Retry-After 超過此值時不再重試
+	MaxDelayMs int `json:"maxDelayMs"`
 }
 
 var (
@@ -35,14 +181,30 @@ var (
 	settingsMutex   sync.RWMutex
 )
 
-// GetSettingsPath 取得設定檔路徑（執行檔同層）
+// GetSettingsPath 取得設定檔路徑
+// 位於使用者資料目錄（可攜模式時為執行檔同層），不受 DataDir 覆寫影響
 func GetSettingsPath() (string, error) {
-	execPath, err := os.Executable()
+	baseDir, err := datadir.BaseDir()
 	if err != nil {
 		return "", err
 	}
-	execDir := filepath.Dir(execPath)
-	return filepath.Join(execDir, SettingsFileName), nil
+	return filepath.Join(baseDir, SettingsFileName), nil
+}
+
+// MigrateLegacySettings 將舊版執行檔同層的 settings.json 搬移至目前的設定檔路徑
+// 回傳是否已搬移；目標已存在時保留目標不覆蓋
+func MigrateLegacySettings() (bool, error) {
+	settingsPath, err := GetSettingsPath()
+	if err != nil {
+		return false, err
+	}
+
+	migrated, err := datadir.MigrateLegacy(SettingsFileName, settingsPath)
+	if migrated {
+		// 重新載入搬移後的設定
+		LoadSettings()
+	}
+	return migrated, err
 }
 
 // LoadSettings 載入設定
@@ -99,7 +261,12 @@ func SaveSettings(settings *Settings) error {
 		return err
 	}
 
-	if err := os.WriteFile(settingsPath, data, 0644); err != nil {
+	if err := os.MkdirAll(filepath.Dir(settingsPath), 0700); err != nil {
+		return err
+	}
+
+	// 可能包含代理密碼，僅限目前使用者讀取
+	if err := atomicfile.WriteFile(settingsPath, data, 0600); err != nil {
 		return err
 	}
 
@@ -151,15 +318,240 @@ func IsAutoDetectEnabled() bool {
 	return settings.UseAutoDetect
 }
 
+// EndpointSettings API 端點覆寫
+// URL 可包含 {region}，會被替換為 token 的區域，例如 https://oidc-fips.{region}.amazonaws.com/token
+type EndpointSettings struct {
+	// IdCRefreshURL IdC token 刷新端點
+	IdCRefreshURL string `json:"idcRefreshUrl,omitempty"`
+	// UsageBaseURL 用量 API 端點
+	UsageBaseURL string `json:"usageBaseUrl,omitempty"`
+}
+
+// ResolveEndpoints 依 token 的 SSO 區域、profile ARN 與設定中的覆寫解析 API 端點
+func ResolveEndpoints(region, profileArn string) endpoints.Resolved {
+	var overrides endpoints.Overrides
+	if settings := GetCurrentSettings(); settings != nil {
+		overrides = endpoints.Overrides{
+			IdCRefreshURL: settings.Endpoints.IdCRefreshURL,
+			UsageBaseURL:  settings.Endpoints.UsageBaseURL,
+		}
+	}
+	return endpoints.Resolve(region, profileArn, overrides)
+}
+
+// GetRetryPolicy 取得設定中的重試策略
+func GetRetryPolicy() retry.Policy {
+	settings := GetCurrentSettings()
+	if settings == nil {
+		return retry.Default()
+	}
+	return retry.Policy{
+		MaxAttempts: settings.Retry.MaxAttempts,
+		BaseDelay:   time.Duration(settings.Retry.BaseDelayMs) * time.Millisecond,
+		MaxDelay:    time.Duration(settings.Retry.MaxDelayMs) * time.Millisecond,
+	}
+}
+
+// TransportConfig 取得設定中的對外連線設定
+func TransportConfig() transport.Config {
+	settings := GetCurrentSettings()
+	if settings == nil {
+		return transport.Config{}
+	}
+	return transport.Config{
+		ProxyURL:      settings.Network.ProxyURL,
+		ProxyUsername: settings.Network.ProxyUsername,
+		ProxyPassword: settings.Network.ProxyPassword,
+		NoProxy:       settings.Network.NoProxy,
+		CABundle:      settings.Network.CABundle,
+	}
+}
+
+// GetHistoryRetentionDays 取得用量歷史保留天數
+func GetHistoryRetentionDays() int {
+	settings := GetCurrentSettings()
+	if settings == nil {
+		return DefaultHistoryRetentionDays
+	}
+	return settings.History.RetentionDays
+}
+
+// GetShutdownTimeout 取得等待 Kiro 正常結束的時間
+func GetShutdownTimeout() time.Duration {
+	settings := GetCurrentSettings()
+	if settings == nil {
+		return DefaultShutdownTimeoutSeconds * time.Second
+	}
+	return time.Duration(settings.Shutdown.TimeoutSeconds) * time.Second
+}
+
+// GetRelaunchOptions 取得切換帳號後是否重新啟動 Kiro，以及是否重新開啟原本的工作區
+func GetRelaunchOptions() (afterSwitch bool, reopenWorkspaces bool) {
+	settings := GetCurrentSettings()
+	if settings == nil {
+		return false, true
+	}
+	return settings.Relaunch.AfterSwitch, !settings.Relaunch.DisableReopenWorkspaces
+}
+
+// GetAlertRules 取得帳號適用的警示規則
+// 優先使用帳號的個別規則，其次為預設規則，都沒有時依 LowBalanceThreshold 換算
+func GetAlertRules(name string) []AlertRule {
+	settings := GetCurrentSettings()
+	if settings == nil {
+		return thresholdRules(DefaultLowBalanceThreshold)
+	}
+	if rules, ok := settings.Alerts.Accounts[name]; ok && len(rules) > 0 {
+		return rules
+	}
+	if len(settings.Alerts.Rules) > 0 {
+		return settings.Alerts.Rules
+	}
+	return thresholdRules(settings.LowBalanceThreshold)
+}
+
+// thresholdRules 將低餘額閾值換算為已使用百分比規則（閾值為 0 時不警示）
+func thresholdRules(threshold float64) []AlertRule {
+	if threshold <= 0 {
+		return nil
+	}
+	return []AlertRule{{UsedPercent: (1 - threshold) * 100}}
+}
+
+// GetAlertOptions 取得警示的通知方式（是否顯示桌面通知、觸發時執行的指令）
+func GetAlertOptions() (desktop bool, command string) {
+	settings := GetCurrentSettings()
+	if settings == nil {
+		return true, ""
+	}
+	return !settings.Alerts.DisableDesktopNotification, settings.Alerts.Command
+}
+
+// RenameAlertAccount 將帳號的個別警示規則移至新名稱；newName 為空時刪除
+// 帳號沒有個別規則時不改寫設定檔
+func RenameAlertAccount(oldName, newName string) error {
+	current := GetCurrentSettings()
+	if current == nil {
+		return nil
+	}
+	rules, ok := current.Alerts.Accounts[oldName]
+	if !ok {
+		return nil
+	}
+
+	updated := *current
+	updated.Alerts.Accounts = make(map[string][]AlertRule, len(current.Alerts.Accounts))
+	for name, r := range current.Alerts.Accounts {
+		if name != oldName {
+			updated.Alerts.Accounts[name] = r
+		}
+	}
+	if newName != "" {
+		updated.Alerts.Accounts[newName] = rules
+	}
+	return SaveSettings(&updated)
+}
+
+// GetWorkspaceBindings 取得目錄與帳號的綁定
+func GetWorkspaceBindings() []workspace.Binding {
+	settings := GetCurrentSettings()
+	if settings == nil {
+		return nil
+	}
+	return settings.Workspaces.Bindings
+}
+
+// SaveWorkspaceBindings 儲存目錄與帳號的綁定（其他設定不變）
+func SaveWorkspaceBindings(bindings []workspace.Binding) error {
+	current := GetCurrentSettings()
+	if current == nil {
+		return nil
+	}
+	updated := *current
+	updated.Workspaces.Bindings = bindings
+	return SaveSettings(&updated)
+}
+
+// RenameWorkspaceBackup 將綁定到 oldName 的目錄改綁 newName；newName 為空時移除這些綁定
+// 沒有目錄綁定此帳號時不改寫設定檔
+func RenameWorkspaceBackup(oldName, newName string) error {
+	bindings, changed := workspace.RenameBackup(GetWorkspaceBindings(), oldName, newName)
+	if !changed {
+		return nil
+	}
+	return SaveWorkspaceBindings(bindings)
+}
+
+// GetLogLevel 取得設定中的記錄等級
+func GetLogLevel() slog.Level {
+	settings := GetCurrentSettings()
+	if settings == nil {
+		return slog.LevelInfo
+	}
+	return logging.ParseLevel(settings.Logging.Level)
+}
+
+// HTTPTransport 取得所有對外連線共用的 Transport（依目前的網路設定建立）
+func HTTPTransport() http.RoundTripper {
+	return transport.Shared(TransportConfig())
+}
+
+// liveTransport 每次請求時才依目前的網路設定取得共用 Transport
+type liveTransport struct{}
+
+// RoundTrip 實作 http.RoundTripper 介面
+func (liveTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return HTTPTransport().RoundTrip(req)
+}
+
+// LiveHTTPTransport 取得長期保留的 http.Client 使用的 Transport
+// 網路設定（代理與 CA）變更後下一個請求即套用，不需重建用戶端
+func LiveHTTPTransport() http.RoundTripper {
+	return liveTransport{}
+}
+
+// GetDataDir 取得資料目錄（依環境變數、設定覆寫、預設目錄的優先順序解析）
+func GetDataDir() (string, error) {
+	override := ""
+	if settings := GetCurrentSettings(); settings != nil {
+		override = settings.DataDir
+	}
+	return datadir.Resolve(override)
+}
+
 // getDefaultSettings 取得預設設定
 func getDefaultSettings() *Settings {
 	return &Settings{
 		LowBalanceThreshold: DefaultLowBalanceThreshold,
 		KiroVersion:         DefaultKiroVersion,
 		UseAutoDetect:       true, // 預設使用自動偵測
+		Retry:               defaultRetrySettings(),
+		Logging:             LoggingSettings{Level: logging.LevelInfo},
+		History:             HistorySettings{RetentionDays: DefaultHistoryRetentionDays},
+		Shutdown:            ShutdownSettings{TimeoutSeconds: DefaultShutdownTimeoutSeconds},
+	}
+}
+
+// defaultRetrySettings 取得預設重試設定
+func defaultRetrySettings() RetrySettings {
+	return RetrySettings{
+		MaxAttempts: retry.DefaultMaxAttempts,
+		BaseDelayMs: int(retry.DefaultBaseDelay / time.Millisecond),
+		MaxDelayMs:  int(retry.DefaultMaxDelay / time.Millisecond),
 	}
 }
 
+// validAlertRules 過濾無效的警示規則
+func validAlertRules(rules []AlertRule) []AlertRule {
+	var valid []AlertRule
+	for _, rule := range rules {
+		if rule.Valid() {
+			valid = append(valid, rule)
+		}
+	}
+	return valid
+}
+
 // validateSettings 驗證並修正設定值
 func validateSettings(settings Settings) Settings {
 	// LowBalanceThreshold 必須在 0.0 ~ 1.0 之間
@@ -173,5 +565,67 @@ func validateSettings(settings Settings) Settings {
 	if settings.KiroVersion == "" {
 		settings.KiroVersion = DefaultKiroVersion
 	}
+	// 舊版設定檔沒有 retry 區段時使用預設值
+	defaults := defaultRetrySettings()
+	if settings.Retry.MaxAttempts <= 0 {
+		settings.Retry.MaxAttempts = defaults.MaxAttempts
+	}
+	if settings.Retry.MaxAttempts > MaxRetryAttempts {
+		settings.Retry.MaxAttempts = MaxRetryAttempts
+	}
+	if settings.Retry.BaseDelayMs <= 0 {
+		settings.Retry.BaseDelayMs = defaults.BaseDelayMs
+	}
+	if settings.Retry.MaxDelayMs < settings.Retry.BaseDelayMs {
+		settings.Retry.MaxDelayMs = max(defaults.MaxDelayMs, settings.Retry.BaseDelayMs)
+	}
+	// 端點覆寫需為 http(s) 絕對網址，否則忽略
+	settings.Endpoints.IdCRefreshURL = strings.TrimSpace(settings.Endpoints.IdCRefreshURL)
+	if settings.Endpoints.IdCRefreshURL != "" && !endpoints.ValidOverrideURL(settings.Endpoints.IdCRefreshURL) {
+		settings.Endpoints.IdCRefreshURL = ""
+	}
+	settings.Endpoints.UsageBaseURL = strings.TrimSpace(settings.Endpoints.UsageBaseURL)
+	if settings.Endpoints.UsageBaseURL != "" && !endpoints.ValidOverrideURL(settings.Endpoints.UsageBaseURL) {
+		settings.Endpoints.UsageBaseURL = ""
+	}
+	// 代理 URL 格式無效時忽略（改為遵循環境變數）
+	settings.Network.ProxyURL = strings.TrimSpace(settings.Network.ProxyURL)
+	if settings.Network.ProxyURL != "" && !transport.ValidProxyURL(settings.Network.ProxyURL) {
+		settings.Network.ProxyURL = ""
+	}
+	settings.Network.CABundle = strings.TrimSpace(settings.Network.CABundle)
+	// 記錄等級無法辨識時使用 info
+	settings.Logging.Level = strings.ToLower(strings.TrimSpace(settings.Logging.Level))
+	if !logging.ValidLevel(settings.Logging.Level) {
+		settings.Logging.Level = logging.LevelInfo
+	}
+	// 舊版設定檔沒有 history 區段時使用預設保留天數
+	if settings.History.RetentionDays <= 0 {
+		settings.History.RetentionDays = DefaultHistoryRetentionDays
+	}
+	if settings.History.RetentionDays > MaxHistoryRetentionDays {
+		settings.History.RetentionDays = MaxHistoryRetentionDays
+	}
+	// 無效的警示規則直接捨棄
+	settings.Alerts.Rules = validAlertRules(settings.Alerts.Rules)
+	if len(settings.Alerts.Accounts) > 0 {
+		accounts := make(map[string][]AlertRule, len(settings.Alerts.Accounts))
+		for name, rules := range settings.Alerts.Accounts {
+			if rules = validAlertRules(rules); len(rules) > 0 {
+				accounts[name] = rules
+			}
+		}
+		settings.Alerts.Accounts = accounts
+	}
+	settings.Alerts.Command = strings.TrimSpace(settings.Alerts.Command)
+	// 舊版設定檔沒有 shutdown 區段時使用預設等待時間
+	if settings.Shutdown.TimeoutSeconds <= 0 {
+		settings.Shutdown.TimeoutSeconds = DefaultShutdownTimeoutSeconds
+	}
+	if settings.Shutdown.TimeoutSeconds > MaxShutdownTimeoutSeconds {
+		settings.Shutdown.TimeoutSeconds = MaxShutdownTimeoutSeconds
+	}
+	// 路徑不是絕對路徑或沒有指定備份的綁定直接捨棄
+	settings.Workspaces.Bindings = workspace.Normalize(settings.Workspaces.Bindings)
 	return settings
 }
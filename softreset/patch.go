@@ -8,6 +8,7 @@ import (
 	"runtime"
 	"strings"
 
+	"kiro-manager/internal/atomicfile"
 	"kiro-manager/kiropath"
 )
 
@@ -294,7 +295,7 @@ func PatchExtensionJS() error {
 	newContent := patchCode + string(content)
 
 	// 寫回檔案
-	return os.WriteFile(extPath, []byte(newContent), 0644)
+	return writeKeepingMode(extPath, []byte(newContent))
 }
 
 // UnpatchExtensionJS 移除注入的程式碼
@@ -340,26 +341,29 @@ func UnpatchExtensionJS() error {
 
 	newContent := contentStr[endIdx:]
 
-	return os.WriteFile(extPath, []byte(newContent), 0644)
+	return writeKeepingMode(extPath, []byte(newContent))
 }
 
-// copyFile 複製檔案
-func copyFile(src, dst string) error {
-	srcFile, err := os.Open(src)
+// writeKeepingMode 以原子方式寫回既有檔案，保留原本的權限（rename 會以暫存檔的權限取代）
+func writeKeepingMode(path string, data []byte) error {
+	info, err := os.Stat(path)
 	if err != nil {
 		return err
 	}
-	defer srcFile.Close()
+	return atomicfile.WriteFile(path, data, info.Mode().Perm())
+}
 
-	dstFile, err := os.Create(dst)
+// copyFile 複製檔案（以原子方式寫入，中途失敗時目標檔案維持原本內容）
+func copyFile(src, dst string) error {
+	info, err := os.Stat(src)
 	if err != nil {
 		return err
 	}
-	defer dstFile.Close()
 
-	if _, err := io.Copy(dstFile, srcFile); err != nil {
+	data, err := os.ReadFile(src)
+	if err != nil {
 		return err
 	}
 
-	return dstFile.Sync()
+	return atomicfile.WriteFile(dst, data, info.Mode().Perm())
 }
@@ -9,6 +9,7 @@ import (
 	"github.com/google/uuid"
 
 	"kiro-manager/awssso"
+	"kiro-manager/internal/atomicfile"
 	"kiro-manager/kiropath"
 	"kiro-manager/machineid"
 )
@@ -93,7 +94,7 @@ func WriteCustomMachineID(machineID string) error {
 		return err
 	}
 
-	return os.WriteFile(idPath, []byte(machineID), 0644)
+	return atomicfile.WriteFile(idPath, []byte(machineID), 0644)
 }
 
 // ReadCustomMachineIDRaw 讀取原始 Machine ID（UUID 格式，用於 UI 顯示）
@@ -132,7 +133,7 @@ func WriteCustomMachineIDRaw(machineID string) error {
 		return err
 	}
 
-	return os.WriteFile(idPath, []byte(machineID), 0644)
+	return atomicfile.WriteFile(idPath, []byte(machineID), 0644)
 }
 
 // GenerateNewMachineID 生成新的 UUID v4
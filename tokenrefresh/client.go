@@ -0,0 +1,286 @@
+package tokenrefresh
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptrace"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"kiro-manager/awssso"
+	"kiro-manager/internal/retry"
+	"kiro-manager/settings"
+)
+
+// defaultTimeout 預設 HTTP 請求逾時
+const defaultTimeout = 30 * time.Second
+
+// Client Token 刷新用戶端，依認證類型向 Social 或 IdC 端點換發 access token
+// 測試時可指定兩個刷新端點，並固定時鐘以驗證換算出的 ExpiresAt
+type Client struct {
+	SocialURL  string           // Social 刷新端點，空值時使用 SocialRefreshURL
+	IdCURL     string           // IdC 刷新端點，空值時依 token 的區域與設定覆寫解析
+	HTTPClient *http.Client     // 空值時使用逾時 30 秒、共用連線設定（代理與 CA）的 http.Client
+	Now        func() time.Time // 計算 ExpiresAt 使用的時鐘，空值時使用 time.Now
+	Retry      *retry.Policy    // 重試策略，空值時使用設定中的策略
+
+	defaultHTTPOnce   sync.Once
+	defaultHTTPClient *http.Client
+}
+
+// DefaultClient 套件層級函數使用的預設用戶端
+var DefaultClient = &Client{}
+
+// socialURL 取得 Social 刷新端點
+func (c *Client) socialURL() string {
+	if c.SocialURL != "" {
+		return c.SocialURL
+	}
+	return SocialRefreshURL
+}
+
+// idcURL 取得指定區域的 IdC 刷新端點
+func (c *Client) idcURL(region string) string {
+	if c.IdCURL != "" {
+		return c.IdCURL
+	}
+	return settings.ResolveEndpoints(region, "").IdCRefreshURL
+}
+
+// httpClient 取得 HTTP 用戶端，未指定時建立一次預設用戶端後重複使用
+func (c *Client) httpClient() *http.Client {
+	if c.HTTPClient != nil {
+		return c.HTTPClient
+	}
+	c.defaultHTTPOnce.Do(func() {
+		c.defaultHTTPClient = &http.Client{Timeout: defaultTimeout, Transport: settings.LiveHTTPTransport()}
+	})
+	return c.defaultHTTPClient
+}
+
+// now 取得目前時間
+func (c *Client) now() time.Time {
+	if c.Now != nil {
+		return c.Now()
+	}
+	return time.Now()
+}
+
+// retryPolicy 取得重試策略
+func (c *Client) retryPolicy() retry.Policy {
+	if c.Retry != nil {
+		return *c.Retry
+	}
+	return settings.GetRetryPolicy()
+}
+
+// RefreshSocialToken 使用 Social 認證方式刷新 Token
+// 發送 POST 請求到 Social 刷新端點，解析回應並返回新的 Token 資訊
+// machineId 參數應為對應環境快照的 Machine ID 的 SHA256 雜湊值
+func (c *Client) RefreshSocialToken(ctx context.Context, refreshToken string, machineId string) (*TokenInfo, error) {
+	// 驗證參數
+	if machineId == "" {
+		return nil, &RefreshError{
+			Code:    0,
+			Message: "machineId 不可為空",
+		}
+	}
+
+	// 設定必要的 Headers（與 Kiro IDE 一致）
+	headers := map[string]string{
+		"User-Agent":      "KiroIDE-" + getEffectiveKiroVersion() + "-" + machineId,
+		"Accept":          "application/json, text/plain, */*",
+		"Accept-Encoding": "br, gzip, deflate",
+		"Content-Type":    "application/json",
+		"Accept-Language": "*",
+		"Sec-Fetch-Mode":  "cors",
+	}
+
+	body, err := c.post(ctx, c.socialURL(), SocialRefreshRequest{RefreshToken: refreshToken}, headers)
+	if err != nil {
+		return nil, err
+	}
+
+	// 解析 JSON 回應
+	var socialResp SocialRefreshResponse
+	if err := json.Unmarshal(body, &socialResp); err != nil {
+		return nil, &RefreshError{
+			Code:    0,
+			Message: "無法解析伺服器回應",
+			Cause:   err,
+		}
+	}
+
+	// 建立 TokenInfo 並計算 ExpiresAt
+	return newSocialTokenInfo(&socialResp, c.now()), nil
+}
+
+// RefreshIdCToken 使用 IdC 認證方式刷新 Token（預設區域）
+// 需求: 2.2, 2.3, 5.2, 5.3
+func (c *Client) RefreshIdCToken(ctx context.Context, refreshToken, clientID, clientSecret string) (*TokenInfo, error) {
+	return c.RefreshIdCTokenInRegion(ctx, "", refreshToken, clientID, clientSecret)
+}
+
+// RefreshIdCTokenInRegion 使用 IdC 認證方式刷新 Token
+// 發送 POST 請求到 region 對應的 IdC 刷新端點，包含必要的 Headers；region 為空時使用預設區域
+func (c *Client) RefreshIdCTokenInRegion(ctx context.Context, region, refreshToken, clientID, clientSecret string) (*TokenInfo, error) {
+	reqBody := IdCRefreshRequest{
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		GrantType:    "refresh_token",
+		RefreshToken: refreshToken,
+	}
+
+	// 設定必要的 Headers（需求 2.3）；Host 由端點 URL 決定
+	headers := map[string]string{
+		"Content-Type":     "application/json",
+		"x-amz-user-agent": "aws-sdk-js/3.738.0 ua/2.1 os/other lang/js api/sso-oidc#3.738.0 m/E KiroIDE",
+		"User-Agent":       "node",
+		"Accept":           "*/*",
+		"Connection":       "keep-alive",
+	}
+
+	body, err := c.post(ctx, c.idcURL(region), reqBody, headers)
+	if err != nil {
+		return nil, err
+	}
+
+	// 解析 JSON 回應
+	var idcResp IdCRefreshResponse
+	if err := json.Unmarshal(body, &idcResp); err != nil {
+		return nil, &RefreshError{
+			Code:    0,
+			Message: "無法解析伺服器回應",
+			Cause:   err,
+		}
+	}
+
+	// 建立 TokenInfo 並計算 ExpiresAt（需求 5.2, 5.3）
+	return newIdCTokenInfo(&idcResp, c.now()), nil
+}
+
+// RefreshAccessToken 刷新 AccessToken
+// 根據 token 中的 AuthMethod 判斷使用 Social 或 IdC 刷新方式
+// 如果提供了 clientID 和 clientSecret，IdC 認證時會直接使用，否則會從 SSO cache 讀取
+// 需求: 2.4
+func (c *Client) RefreshAccessToken(ctx context.Context, token *awssso.KiroAuthToken, machineId string, clientID, clientSecret string) (*TokenInfo, error) {
+	if token == nil {
+		return nil, &RefreshError{
+			Code:    0,
+			Message: "Token 不可為空",
+		}
+	}
+
+	if machineId == "" {
+		return nil, &RefreshError{
+			Code:    0,
+			Message: "machineId 不可為空",
+		}
+	}
+
+	// 偵測認證類型
+	authType := DetectAuthType(token)
+
+	switch authType {
+	case "social":
+		if token.RefreshToken == "" {
+			return nil, &RefreshError{
+				Code:    0,
+				Message: "RefreshToken 不可為空",
+			}
+		}
+		return c.RefreshSocialToken(ctx, token.RefreshToken, machineId)
+
+	case "idc":
+		if token.RefreshToken == "" {
+			return nil, &RefreshError{
+				Code:    0,
+				Message: "RefreshToken 不可為空",
+			}
+		}
+		// 如果沒有提供 clientID 和 clientSecret，從 SSO cache 讀取
+		if clientID == "" || clientSecret == "" {
+			var err error
+			clientID, clientSecret, err = getIdCCredentials(token)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return c.RefreshIdCTokenInRegion(ctx, token.Region, token.RefreshToken, clientID, clientSecret)
+
+	default:
+		return nil, &RefreshError{
+			Code:    0,
+			Message: "不支援的認證類型: " + authType,
+		}
+	}
+}
+
+// post 發送 JSON POST 請求並回傳 200 回應的 body
+// 刷新會輪替 refresh token，伺服器可能已處理卻未收到回應的請求不可重送（舊 token 已失效）；
+// 因此只重試連線建立前的失敗與附帶 Retry-After 的 429/503；最終失敗的非 200 回應經 MapHTTPError 轉換為 *RefreshError
+func (c *Client) post(ctx context.Context, url string, payload any, headers map[string]string) ([]byte, error) {
+	jsonBody, err := json.Marshal(payload)
+	if err != nil {
+		return nil, &RefreshError{
+			Code:    0,
+			Message: "無法序列化請求",
+			Cause:   err,
+		}
+	}
+
+	slog.Debug("refreshing token", "url", url)
+
+	client := c.httpClient()
+	policy := c.retryPolicy()
+	// 取得連線（DNS、TCP、代理、TLS 皆已完成）後即視為請求可能已送出
+	var sent atomic.Bool
+	policy.Retryable = retry.BeforeSendOnly(sent.Load)
+	resp, attempts, err := policy.Do(ctx, func(retry.Attempt) (*http.Response, error) {
+		sent.Store(false)
+		trace := &httptrace.ClientTrace{GotConn: func(httptrace.GotConnInfo) { sent.Store(true) }}
+		req, err := http.NewRequestWithContext(httptrace.WithClientTrace(ctx, trace), http.MethodPost, url, bytes.NewReader(jsonBody))
+		if err != nil {
+			return nil, err
+		}
+		for key, value := range headers {
+			req.Header.Set(key, value)
+		}
+		return client.Do(req)
+	})
+	if err != nil {
+		slog.Warn("token refresh request failed", "url", url, "attempts", attempts, "error", err)
+		return nil, &RefreshError{
+			Code:     0,
+			Message:  "網路連線失敗: " + err.Error(),
+			Cause:    err,
+			Attempts: attempts,
+		}
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, &RefreshError{
+			Code:     0,
+			Message:  "無法讀取回應",
+			Cause:    err,
+			Attempts: attempts,
+		}
+	}
+
+	// 處理 HTTP 錯誤（需求 4.1, 4.2, 4.3）
+	if resp.StatusCode != http.StatusOK {
+		slog.Warn("token refresh rejected", "url", url, "status", resp.StatusCode, "attempts", attempts, "body", string(body))
+		refreshErr := MapHTTPError(resp.StatusCode, string(body))
+		refreshErr.Attempts = attempts
+		return nil, refreshErr
+	}
+
+	return body, nil
+}
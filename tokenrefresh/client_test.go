@@ -0,0 +1,302 @@
+package tokenrefresh
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"kiro-manager/awssso"
+	"kiro-manager/internal/datadir"
+	"kiro-manager/internal/retry"
+	"kiro-manager/settings"
+	"kiro-manager/usage"
+)
+
+// fixedNow 測試用的固定時鐘
+var fixedNow = time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+
+// newTestClient 建立指向 httptest.Server 的用戶端
+func newTestClient(server *httptest.Server) *Client {
+	return &Client{
+		SocialURL:  server.URL + "/refreshToken",
+		IdCURL:     server.URL + "/token",
+		HTTPClient: server.Client(),
+		Now:        func() time.Time { return fixedNow },
+	}
+}
+
+// TestClient_RefreshSocialToken 測試 Social 刷新的請求內容與回應解析
+func TestClient_RefreshSocialToken(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/refreshToken" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		if ua := r.Header.Get("User-Agent"); !strings.HasSuffix(ua, "-hashed-machine") {
+			t.Errorf("User-Agent = %q, want machine id suffix", ua)
+		}
+
+		var req SocialRefreshRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		if req.RefreshToken != "old-refresh" {
+			t.Errorf("refreshToken = %q, want old-refresh", req.RefreshToken)
+		}
+
+		json.NewEncoder(w).Encode(SocialRefreshResponse{
+			AccessToken:  "new-access",
+			ExpiresIn:    3600,
+			RefreshToken: "rotated-refresh",
+			ProfileArn:   "arn:aws:codewhisperer:us-east-1:123:profile/ABC",
+		})
+	}))
+	defer server.Close()
+
+	info, err := newTestClient(server).RefreshSocialToken(context.Background(), "old-refresh", "hashed-machine")
+	if err != nil {
+		t.Fatalf("RefreshSocialToken failed: %v", err)
+	}
+
+	if info.AccessToken != "new-access" || info.RefreshToken != "rotated-refresh" {
+		t.Errorf("TokenInfo = %+v", info)
+	}
+	if !info.ExpiresAt.Equal(fixedNow.Add(time.Hour)) {
+		t.Errorf("ExpiresAt = %v, want %v", info.ExpiresAt, fixedNow.Add(time.Hour))
+	}
+}
+
+// TestClient_RefreshIdCToken 測試 IdC 刷新的請求內容與回應解析
+func TestClient_RefreshIdCToken(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req IdCRefreshRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		if req.ClientID != "client-id" || req.ClientSecret != "client-secret" || req.GrantType != "refresh_token" {
+			t.Errorf("unexpected IdC request: %+v", req)
+		}
+
+		w.Write([]byte(`{"access_token":"idc-access","expires_in":600,"token_type":"Bearer"}`))
+	}))
+	defer server.Close()
+
+	info, err := newTestClient(server).RefreshIdCToken(context.Background(), "idc-refresh", "client-id", "client-secret")
+	if err != nil {
+		t.Fatalf("RefreshIdCToken failed: %v", err)
+	}
+
+	if info.AccessToken != "idc-access" || info.TokenType != "Bearer" || info.RefreshToken != "" {
+		t.Errorf("TokenInfo = %+v", info)
+	}
+	if !info.ExpiresAt.Equal(fixedNow.Add(10 * time.Minute)) {
+		t.Errorf("ExpiresAt = %v", info.ExpiresAt)
+	}
+}
+
+// TestClient_IdCRefreshUsesTokenRegion 測試 IdC 刷新依 token 的區域解析端點（經由設定覆寫導向測試伺服器）
+func TestClient_IdCRefreshUsesTokenRegion(t *testing.T) {
+	var gotPath string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		w.Write([]byte(`{"access_token":"idc-access","expires_in":600,"token_type":"Bearer"}`))
+	}))
+	defer server.Close()
+
+	// 結束時（環境變數還原後）重新載入設定
+	t.Cleanup(func() { settings.LoadSettings() })
+	t.Setenv(datadir.EnvVar, t.TempDir())
+	s := *settings.GetCurrentSettings()
+	s.Endpoints.IdCRefreshURL = server.URL + "/{region}/token"
+	if err := settings.SaveSettings(&s); err != nil {
+		t.Fatalf("SaveSettings failed: %v", err)
+	}
+
+	client := &Client{HTTPClient: server.Client(), Now: func() time.Time { return fixedNow }}
+	token := &awssso.KiroAuthToken{RefreshToken: "idc-refresh", AuthMethod: "IdC", Region: "eu-west-1"}
+
+	if _, err := client.RefreshAccessToken(context.Background(), token, "hashed-machine", "client-id", "client-secret"); err != nil {
+		t.Fatalf("RefreshAccessToken failed: %v", err)
+	}
+	if gotPath != "/eu-west-1/token" {
+		t.Errorf("path = %s, want /eu-west-1/token", gotPath)
+	}
+}
+
+// TestClient_HTTPErrorMapsToRefreshError 測試非 200 回應轉換為 RefreshError
+func TestClient_HTTPErrorMapsToRefreshError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "invalid grant", http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	_, err := newTestClient(server).RefreshSocialToken(context.Background(), "revoked", "hashed-machine")
+
+	var refreshErr *RefreshError
+	if !errors.As(err, &refreshErr) {
+		t.Fatalf("error = %v, want *RefreshError", err)
+	}
+	if refreshErr.Code != http.StatusUnauthorized {
+		t.Errorf("Code = %d, want 401", refreshErr.Code)
+	}
+}
+
+// TestClient_RetriesOnlyThrottledResponses 測試刷新請求只重試附帶 Retry-After 的 503，
+// 其他 5xx 可能已被伺服器處理（refresh token 已輪替），不可重送
+func TestClient_RetriesOnlyThrottledResponses(t *testing.T) {
+	tests := []struct {
+		name       string
+		code       int
+		retryAfter string
+		wantCalls  int
+	}{
+		{"503 with Retry-After", http.StatusServiceUnavailable, "0", 3},
+		{"503 without Retry-After", http.StatusServiceUnavailable, "", 1},
+		{"500", http.StatusInternalServerError, "", 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls++
+				if tt.retryAfter != "" {
+					w.Header().Set("Retry-After", tt.retryAfter)
+				}
+				http.Error(w, "unavailable", tt.code)
+			}))
+			defer server.Close()
+
+			client := newTestClient(server)
+			client.Retry = &retry.Policy{
+				MaxAttempts: 3,
+				BaseDelay:   time.Millisecond,
+				Sleep:       func(ctx context.Context, d time.Duration) error { return nil },
+			}
+
+			_, err := client.RefreshSocialToken(context.Background(), "refresh", "hashed-machine")
+
+			var refreshErr *RefreshError
+			if !errors.As(err, &refreshErr) {
+				t.Fatalf("error = %v, want *RefreshError", err)
+			}
+			if calls != tt.wantCalls || refreshErr.Attempts != tt.wantCalls || refreshErr.Code != tt.code {
+				t.Errorf("calls = %d, Attempts = %d, Code = %d, want %d/%d/%d", calls, refreshErr.Attempts, refreshErr.Code, tt.wantCalls, tt.wantCalls, tt.code)
+			}
+		})
+	}
+}
+
+// TestClient_RetriesConnectionFailures 測試連線建立前的失敗（請求未送出）會重試
+func TestClient_RetriesConnectionFailures(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	url := server.URL
+	server.Close()
+
+	client := &Client{
+		SocialURL: url + "/refreshToken",
+		Retry: &retry.Policy{
+			MaxAttempts: 3,
+			Sleep:       func(ctx context.Context, d time.Duration) error { return nil },
+		},
+	}
+	_, err := client.RefreshSocialToken(context.Background(), "refresh", "hashed-machine")
+
+	var refreshErr *RefreshError
+	if !errors.As(err, &refreshErr) {
+		t.Fatalf("error = %v, want *RefreshError", err)
+	}
+	if refreshErr.Attempts != 3 {
+		t.Errorf("Attempts = %d, want 3", refreshErr.Attempts)
+	}
+}
+
+// TestClient_ContextCanceled 測試取消 context 時中止請求
+func TestClient_ContextCanceled(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := newTestClient(server).RefreshSocialToken(ctx, "refresh", "hashed-machine")
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("error = %v, want context.Canceled", err)
+	}
+}
+
+// TestIntegration_RefreshThenUsageOffline 以本機假伺服器測試刷新 → 查詢用量的完整流程
+// 需求 1.4: WHEN the token refresh succeeds, THE system SHALL proceed with the original balance query operation
+func TestIntegration_RefreshThenUsageOffline(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/refreshToken", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(SocialRefreshResponse{
+			AccessToken:  "fresh-access",
+			ExpiresIn:    3600,
+			RefreshToken: "rotated-refresh",
+			ProfileArn:   "arn:profile",
+		})
+	})
+	mux.HandleFunc("/getUsageLimits", func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Authorization"); got != "Bearer fresh-access" {
+			http.Error(w, "expired token", http.StatusForbidden)
+			return
+		}
+		if got := r.URL.Query().Get("profileArn"); got != "arn:profile" {
+			t.Errorf("profileArn = %q, want arn:profile", got)
+		}
+		w.Write([]byte(`{"subscriptionInfo":{"subscriptionTitle":"KIRO PRO"},"usageBreakdownList":[{"usageLimitWithPrecision":1000,"currentUsageWithPrecision":250}]}`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	expired := &awssso.KiroAuthToken{
+		AccessToken:  "expired-access",
+		ExpiresAt:    fixedNow.Add(-time.Hour).Format(time.RFC3339),
+		RefreshToken: "old-refresh",
+		AuthMethod:   "social",
+		Provider:     "Github",
+		ProfileArn:   "arn:profile",
+	}
+
+	usageClient := &usage.Client{BaseURL: server.URL, HTTPClient: server.Client(), Now: func() time.Time { return fixedNow }}
+
+	// 過期的 token 查詢失敗
+	if _, err := usageClient.GetUsageLimits(context.Background(), expired, "hashed-machine"); err == nil {
+		t.Fatal("Usage query with expired token should fail")
+	}
+
+	info, err := newTestClient(server).RefreshAccessToken(context.Background(), expired, "hashed-machine", "", "")
+	if err != nil {
+		t.Fatalf("RefreshAccessToken failed: %v", err)
+	}
+
+	refreshed := *expired
+	refreshed.AccessToken = info.AccessToken
+	refreshed.RefreshToken = info.RefreshToken
+
+	usageInfo, err := usageClient.GetUsageLimits(context.Background(), &refreshed, "hashed-machine")
+	if err != nil {
+		t.Fatalf("GetUsageLimits after refresh failed: %v", err)
+	}
+	if usageInfo.SubscriptionTitle != "KIRO PRO" || usageInfo.Balance != 750 {
+		t.Errorf("UsageInfo = %+v", usageInfo)
+	}
+	if !usageInfo.FetchedAt.Equal(fixedNow) {
+		t.Errorf("FetchedAt = %v, want %v", usageInfo.FetchedAt, fixedNow)
+	}
+}
+
+// TestClient_ReusesDefaultHTTPClient 測試未指定 HTTPClient 時重複使用同一個預設用戶端
+func TestClient_ReusesDefaultHTTPClient(t *testing.T) {
+	client := &Client{}
+	first := client.httpClient()
+	if first != client.httpClient() {
+		t.Error("httpClient() built a new client on the second call")
+	}
+	if first.Timeout != defaultTimeout {
+		t.Errorf("Timeout = %v, want %v", first.Timeout, defaultTimeout)
+	}
+}
@@ -1,11 +1,9 @@
 package tokenrefresh
 
 import (
-	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
-	"io"
-	"net/http"
 	"strings"
 	"time"
 
@@ -15,6 +13,7 @@ import (
 )
 
 // API 端點常數
+// IdCRefreshURL 為預設區域的端點；實際刷新時依 token 的區域與設定覆寫解析（見 settings.ResolveEndpoints）
 const (
 	SocialRefreshURL = "https://prod.us-east-1.auth.desktop.kiro.dev/refreshToken"
 	IdCRefreshURL    = "https://oidc.us-east-1.amazonaws.com/token"
@@ -35,18 +34,20 @@ func getEffectiveKiroVersion() string {
 
 // TokenInfo 刷新後的 Token 資訊
 type TokenInfo struct {
-	AccessToken string    `json:"accessToken"` // 新的 AccessToken
-	ExpiresAt   time.Time `json:"expiresAt"`   // 過期時間（計算後）
-	ExpiresIn   int       `json:"expiresIn"`   // 有效期（秒）
-	ProfileArn  string    `json:"profileArn"`  // Profile ARN（僅 Social）
-	TokenType   string    `json:"tokenType"`   // Token 類型（僅 IdC）
+	AccessToken  string    `json:"accessToken"`            // 新的 AccessToken
+	RefreshToken string    `json:"refreshToken,omitempty"` // 伺服器輪替後的新 RefreshToken（未輪替時為空）
+	ExpiresAt    time.Time `json:"expiresAt"`              // 過期時間（計算後）
+	ExpiresIn    int       `json:"expiresIn"`              // 有效期（秒）
+	ProfileArn   string    `json:"profileArn"`             // Profile ARN（僅 Social）
+	TokenType    string    `json:"tokenType"`              // Token 類型（僅 IdC）
 }
 
 // RefreshError 刷新錯誤類型
 type RefreshError struct {
-	Code    int    // HTTP 狀態碼（0 表示非 HTTP 錯誤）
-	Message string // 使用者友善的錯誤訊息
-	Cause   error  // 底層錯誤（用於除錯）
+	Code     int    // HTTP 狀態碼（0 表示非 HTTP 錯誤）
+	Message  string // 使用者友善的錯誤訊息
+	Cause    error  // 底層錯誤（用於除錯）
+	Attempts int    // 實際發送的請求次數（含重試；0 表示未發送）
 }
 
 // Error 實作 error 介面
@@ -137,93 +138,22 @@ func truncateString(s string, maxLen int) string {
 	return s[:maxLen] + "..."
 }
 
-// RefreshSocialToken 使用 Social 認證方式刷新 Token
-// 發送 POST 請求到 Social 刷新端點，解析回應並返回新的 Token 資訊
+// RefreshSocialToken 使用 Social 認證方式刷新 Token（使用預設用戶端）
 // machineId 參數應為對應環境快照的 Machine ID 的 SHA256 雜湊值
 func RefreshSocialToken(refreshToken string, machineId string) (*TokenInfo, error) {
-	// 驗證參數
-	if machineId == "" {
-		return nil, &RefreshError{
-			Code:    0,
-			Message: "machineId 不可為空",
-		}
-	}
-
-	// 建立請求 body
-	reqBody := SocialRefreshRequest{
-		RefreshToken: refreshToken,
-	}
-	jsonBody, err := json.Marshal(reqBody)
-	if err != nil {
-		return nil, &RefreshError{
-			Code:    0,
-			Message: "無法序列化請求",
-			Cause:   err,
-		}
-	}
-
-	// 建立 HTTP 請求
-	req, err := http.NewRequest("POST", SocialRefreshURL, bytes.NewBuffer(jsonBody))
-	if err != nil {
-		return nil, &RefreshError{
-			Code:    0,
-			Message: "無法建立請求",
-			Cause:   err,
-		}
-	}
-
-	// 設定必要的 Headers（與 Kiro IDE 一致）
-	req.Header.Set("User-Agent", "KiroIDE-"+getEffectiveKiroVersion()+"-"+machineId)
-	req.Header.Set("Accept", "application/json, text/plain, */*")
-	req.Header.Set("Accept-Encoding", "br, gzip, deflate")
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Accept-Language", "*")
-	req.Header.Set("Sec-Fetch-Mode", "cors")
-
-	// 發送請求
-	client := &http.Client{Timeout: 30 * time.Second}
-	resp, err := client.Do(req)
-	if err != nil {
-		return nil, &RefreshError{
-			Code:    0,
-			Message: "網路連線失敗: " + err.Error(),
-			Cause:   err,
-		}
-	}
-	defer resp.Body.Close()
-
-	// 讀取回應 body
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, &RefreshError{
-			Code:    0,
-			Message: "無法讀取回應",
-			Cause:   err,
-		}
-	}
-
-	// 處理 HTTP 錯誤（需求 4.1, 4.2, 4.3）
-	if resp.StatusCode != http.StatusOK {
-		return nil, MapHTTPError(resp.StatusCode, string(body))
-	}
-
-	// 解析 JSON 回應
-	var socialResp SocialRefreshResponse
-	if err := json.Unmarshal(body, &socialResp); err != nil {
-		return nil, &RefreshError{
-			Code:    0,
-			Message: "無法解析伺服器回應",
-			Cause:   err,
-		}
-	}
+	return DefaultClient.RefreshSocialToken(context.Background(), refreshToken, machineId)
+}
 
-	// 建立 TokenInfo 並計算 ExpiresAt
+// newSocialTokenInfo 從 Social 刷新回應建立 TokenInfo
+// 包含輪替後的 RefreshToken 與 ProfileArn，供呼叫端寫回備份
+func newSocialTokenInfo(resp *SocialRefreshResponse, now time.Time) *TokenInfo {
 	return &TokenInfo{
-		AccessToken: socialResp.AccessToken,
-		ExpiresIn:   socialResp.ExpiresIn,
-		ExpiresAt:   CalculateExpiresAt(socialResp.ExpiresIn),
-		ProfileArn:  socialResp.ProfileArn,
-	}, nil
+		AccessToken:  resp.AccessToken,
+		RefreshToken: resp.RefreshToken,
+		ExpiresIn:    resp.ExpiresIn,
+		ExpiresAt:    now.Add(time.Duration(resp.ExpiresIn) * time.Second),
+		ProfileArn:   resp.ProfileArn,
+	}
 }
 
 // ParseSocialResponse 解析 Social 刷新回應 JSON
@@ -238,97 +168,25 @@ func ParseSocialResponse(jsonData []byte) (*TokenInfo, error) {
 		}
 	}
 
-	return &TokenInfo{
-		AccessToken: socialResp.AccessToken,
-		ExpiresIn:   socialResp.ExpiresIn,
-		ExpiresAt:   CalculateExpiresAt(socialResp.ExpiresIn),
-		ProfileArn:  socialResp.ProfileArn,
-	}, nil
+	return newSocialTokenInfo(&socialResp, time.Now()), nil
 }
 
-
-// RefreshIdCToken 使用 IdC 認證方式刷新 Token
-// 發送 POST 請求到 IdC 刷新端點，包含必要的 Headers
+// RefreshIdCToken 使用 IdC 認證方式刷新 Token（使用預設用戶端與預設區域）
 // 需求: 2.2, 2.3, 5.2, 5.3
 func RefreshIdCToken(refreshToken, clientID, clientSecret string) (*TokenInfo, error) {
-	// 建立請求 body
-	reqBody := IdCRefreshRequest{
-		ClientID:     clientID,
-		ClientSecret: clientSecret,
-		GrantType:    "refresh_token",
-		RefreshToken: refreshToken,
-	}
-	jsonBody, err := json.Marshal(reqBody)
-	if err != nil {
-		return nil, &RefreshError{
-			Code:    0,
-			Message: "無法序列化請求",
-			Cause:   err,
-		}
-	}
-
-	// 建立 HTTP 請求
-	req, err := http.NewRequest("POST", IdCRefreshURL, bytes.NewBuffer(jsonBody))
-	if err != nil {
-		return nil, &RefreshError{
-			Code:    0,
-			Message: "無法建立請求",
-			Cause:   err,
-		}
-	}
-
-	// 設定必要的 Headers（需求 2.3）
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Host", "oidc.us-east-1.amazonaws.com")
-	req.Header.Set("x-amz-user-agent", "aws-sdk-js/3.738.0 ua/2.1 os/other lang/js api/sso-oidc#3.738.0 m/E KiroIDE")
-	req.Header.Set("User-Agent", "node")
-	req.Header.Set("Accept", "*/*")
-	req.Header.Set("Connection", "keep-alive")
-
-	// 發送請求
-	client := &http.Client{Timeout: 30 * time.Second}
-	resp, err := client.Do(req)
-	if err != nil {
-		return nil, &RefreshError{
-			Code:    0,
-			Message: "網路連線失敗: " + err.Error(),
-			Cause:   err,
-		}
-	}
-	defer resp.Body.Close()
-
-	// 讀取回應 body
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, &RefreshError{
-			Code:    0,
-			Message: "無法讀取回應",
-			Cause:   err,
-		}
-	}
-
-	// 處理 HTTP 錯誤（需求 4.1, 4.2, 4.3）
-	if resp.StatusCode != http.StatusOK {
-		return nil, MapHTTPError(resp.StatusCode, string(body))
-	}
-
-	// 解析 JSON 回應
-	var idcResp IdCRefreshResponse
-	if err := json.Unmarshal(body, &idcResp); err != nil {
-		return nil, &RefreshError{
-			Code:    0,
-			Message: "無法解析伺服器回應",
-			Cause:   err,
-		}
-	}
+	return DefaultClient.RefreshIdCToken(context.Background(), refreshToken, clientID, clientSecret)
+}
 
-	// 建立 TokenInfo 並計算 ExpiresAt（需求 5.2, 5.3）
+// newIdCTokenInfo 從 IdC 刷新回應建立 TokenInfo
+// refresh_token 僅在伺服器輪替時出現
+func newIdCTokenInfo(resp *IdCRefreshResponse, now time.Time) *TokenInfo {
 	return &TokenInfo{
-		AccessToken: idcResp.AccessToken,
-		ExpiresIn:   idcResp.ExpiresIn,
-		ExpiresAt:   CalculateExpiresAt(idcResp.ExpiresIn),
-		TokenType:   idcResp.TokenType,
-	}, nil
+		AccessToken:  resp.AccessToken,
+		RefreshToken: resp.RefreshToken,
+		ExpiresIn:    resp.ExpiresIn,
+		ExpiresAt:    now.Add(time.Duration(resp.ExpiresIn) * time.Second),
+		TokenType:    resp.TokenType,
+	}
 }
 
 // ParseIdCResponse 解析 IdC 刷新回應 JSON
@@ -343,15 +201,9 @@ func ParseIdCResponse(jsonData []byte) (*TokenInfo, error) {
 		}
 	}
 
-	return &TokenInfo{
-		AccessToken: idcResp.AccessToken,
-		ExpiresIn:   idcResp.ExpiresIn,
-		ExpiresAt:   CalculateExpiresAt(idcResp.ExpiresIn),
-		TokenType:   idcResp.TokenType,
-	}, nil
+	return newIdCTokenInfo(&idcResp, time.Now()), nil
 }
 
-
 // RefreshAccessToken 刷新 AccessToken
 // 根據 token 中的 AuthMethod 判斷使用 Social 或 IdC 刷新方式
 // machineId 參數應為對應環境快照的 Machine ID 的 SHA256 雜湊值
@@ -367,62 +219,11 @@ func RefreshAccessTokenFromBackup(token *awssso.KiroAuthToken, machineId string,
 	return RefreshAccessTokenWithCredentials(token, machineId, clientID, clientSecret)
 }
 
-// RefreshAccessTokenWithCredentials 刷新 AccessToken（內部實作）
+// RefreshAccessTokenWithCredentials 刷新 AccessToken（使用預設用戶端）
 // 如果提供了 clientID 和 clientSecret，IdC 認證時會直接使用
 // 否則會從 SSO cache 讀取
 func RefreshAccessTokenWithCredentials(token *awssso.KiroAuthToken, machineId string, clientID, clientSecret string) (*TokenInfo, error) {
-	if token == nil {
-		return nil, &RefreshError{
-			Code:    0,
-			Message: "Token 不可為空",
-		}
-	}
-
-	if machineId == "" {
-		return nil, &RefreshError{
-			Code:    0,
-			Message: "machineId 不可為空",
-		}
-	}
-
-	// 偵測認證類型
-	authType := DetectAuthType(token)
-
-	switch authType {
-	case "social":
-		// Social 認證路由到 RefreshSocialToken
-		if token.RefreshToken == "" {
-			return nil, &RefreshError{
-				Code:    0,
-				Message: "RefreshToken 不可為空",
-			}
-		}
-		return RefreshSocialToken(token.RefreshToken, machineId)
-
-	case "idc":
-		// IdC 認證路由到 RefreshIdCToken
-		if token.RefreshToken == "" {
-			return nil, &RefreshError{
-				Code:    0,
-				Message: "RefreshToken 不可為空",
-			}
-		}
-		// 如果沒有提供 clientID 和 clientSecret，從 SSO cache 讀取
-		if clientID == "" || clientSecret == "" {
-			var err error
-			clientID, clientSecret, err = getIdCCredentials(token)
-			if err != nil {
-				return nil, err
-			}
-		}
-		return RefreshIdCToken(token.RefreshToken, clientID, clientSecret)
-
-	default:
-		return nil, &RefreshError{
-			Code:    0,
-			Message: "不支援的認證類型: " + authType,
-		}
-	}
+	return DefaultClient.RefreshAccessToken(context.Background(), token, machineId, clientID, clientSecret)
 }
 
 // DetectAuthType 偵測 token 的認證類型
@@ -84,6 +84,13 @@ func TestProperty_SocialResponseFieldExtraction(t *testing.T) {
 			return false
 		}
 
+		// 輪替後的 refreshToken 必須正確提取（供寫回備份）
+		if tokenInfo.RefreshToken != socialResp.RefreshToken {
+			t.Logf("RefreshToken mismatch: got %q, expected %q",
+				tokenInfo.RefreshToken, socialResp.RefreshToken)
+			return false
+		}
+
 		// Property 5.3: ExpiresAt 必須在合理範圍內
 		// ExpiresAt 應該在 beforeParse + expiresIn 和 afterParse + expiresIn 之間
 		expectedMinExpiresAt := beforeParse.Add(time.Duration(socialResp.ExpiresIn) * time.Second)
@@ -283,6 +290,13 @@ func TestProperty_IdCResponseFieldExtraction(t *testing.T) {
 			return false
 		}
 
+		// 輪替後的 refresh_token 必須正確提取（供寫回備份）
+		if tokenInfo.RefreshToken != idcResp.RefreshToken {
+			t.Logf("RefreshToken mismatch: got %q, expected %q",
+				tokenInfo.RefreshToken, idcResp.RefreshToken)
+			return false
+		}
+
 		// Property 5.3: ExpiresAt 必須在合理範圍內
 		// ExpiresAt 應該在 beforeParse + expiresIn 和 afterParse + expiresIn 之間
 		expectedMinExpiresAt := beforeParse.Add(time.Duration(idcResp.ExpiresIn) * time.Second)
@@ -0,0 +1,144 @@
+package usage
+
+import (
+	"sort"
+	"strings"
+)
+
+// 額度來源類型
+const (
+	CreditBase  = "base"  // 訂閱的基本額度
+	CreditTrial = "trial" // 免費試用額度
+	CreditBonus = "bonus" // 獎勵額度
+)
+
+// statusActive 試用與獎勵額度仍可使用時的狀態值
+const statusActive = "ACTIVE"
+
+// Breakdown 單一用量類別（usageBreakdownList 的一項）的額度明細
+type Breakdown struct {
+	DisplayName     string        `json:"displayName"`               // 用量類別名稱
+	BaseLimit       float64       `json:"baseLimit"`                 // 基本額度
+	BaseUsage       float64       `json:"baseUsage"`                 // 基本額度已使用
+	TrialLimit      float64       `json:"trialLimit"`                // 免費試用額度
+	TrialUsage      float64       `json:"trialUsage"`                // 免費試用已使用
+	FreeTrialStatus string        `json:"freeTrialStatus,omitempty"` // 免費試用狀態（沒有試用時為空）
+	FreeTrialExpiry string        `json:"freeTrialExpiry,omitempty"` // 免費試用到期時間（RFC3339）
+	Bonuses         []BonusCredit `json:"bonuses,omitempty"`         // 獎勵額度
+	NextResetAt     string        `json:"nextResetAt,omitempty"`     // 此類別的下次重置時間（RFC3339）
+}
+
+// BonusCredit 單筆獎勵額度
+type BonusCredit struct {
+	BonusCode    string  `json:"bonusCode"`
+	DisplayName  string  `json:"displayName,omitempty"`
+	UsageLimit   float64 `json:"usageLimit"`
+	CurrentUsage float64 `json:"currentUsage"`
+	Status       string  `json:"status"`
+	ExpiresAt    string  `json:"expiresAt,omitempty"` // 到期時間（RFC3339）
+}
+
+// TrialActive 免費試用是否仍在有效期內
+func (b Breakdown) TrialActive() bool {
+	return strings.EqualFold(b.FreeTrialStatus, statusActive)
+}
+
+// UsageLimit 此類別的總額度（基本 + 試用 + 獎勵）
+func (b Breakdown) UsageLimit() float64 {
+	total := b.BaseLimit + b.TrialLimit
+	for _, bonus := range b.Bonuses {
+		total += bonus.UsageLimit
+	}
+	return total
+}
+
+// CurrentUsage 此類別的總使用量（基本 + 試用 + 獎勵）
+func (b Breakdown) CurrentUsage() float64 {
+	total := b.BaseUsage + b.TrialUsage
+	for _, bonus := range b.Bonuses {
+		total += bonus.CurrentUsage
+	}
+	return total
+}
+
+// newBreakdown 將 API 的用量明細轉為 Breakdown
+func newBreakdown(item UsageBreakdown) Breakdown {
+	b := Breakdown{
+		DisplayName: item.DisplayName,
+		BaseLimit:   item.UsageLimitWithPrecision,
+		BaseUsage:   item.CurrentUsageWithPrecision,
+		NextResetAt: item.NextDateReset.format(),
+	}
+	if trial := item.FreeTrialInfo; trial != nil {
+		b.TrialLimit = trial.UsageLimitWithPrecision
+		b.TrialUsage = trial.CurrentUsageWithPrecision
+		b.FreeTrialStatus = trial.FreeTrialStatus
+		b.FreeTrialExpiry = trial.FreeTrialExpiry.format()
+	}
+	for _, bonus := range item.Bonuses {
+		b.Bonuses = append(b.Bonuses, BonusCredit{
+			BonusCode:    bonus.BonusCode,
+			DisplayName:  bonus.DisplayName,
+			UsageLimit:   bonus.UsageLimit,
+			CurrentUsage: bonus.CurrentUsage,
+			Status:       bonus.Status,
+			ExpiresAt:    bonus.ExpiresAt.format(),
+		})
+	}
+	return b
+}
+
+// Totals 加總所有類別的總額度與總使用量
+func Totals(breakdown []Breakdown) (usageLimit, currentUsage float64) {
+	for _, b := range breakdown {
+		usageLimit += b.UsageLimit()
+		currentUsage += b.CurrentUsage()
+	}
+	return usageLimit, currentUsage
+}
+
+// Credit 仍可使用的單筆額度（用於比較到期先後）
+type Credit struct {
+	Kind        string  `json:"kind"`                // base、trial 或 bonus
+	DisplayName string  `json:"displayName"`         // 所屬用量類別名稱（獎勵額度有名稱時使用獎勵名稱）
+	BonusCode   string  `json:"bonusCode,omitempty"` // 獎勵代碼
+	Remaining   float64 `json:"remaining"`           // 剩餘額度
+	ExpiresAt   string  `json:"expiresAt,omitempty"` // 到期時間（RFC3339），沒有到期時間時為空
+}
+
+// Credits 列出仍有剩餘的額度，依到期時間由早到晚排序（沒有到期時間的排在最後）
+// 已結束的試用與非 ACTIVE 的獎勵額度不列入
+func Credits(breakdown []Breakdown) []Credit {
+	credits := []Credit{}
+	add := func(credit Credit) {
+		if credit.Remaining > 0 {
+			credits = append(credits, credit)
+		}
+	}
+
+	for _, b := range breakdown {
+		if b.TrialActive() {
+			add(Credit{Kind: CreditTrial, DisplayName: b.DisplayName, Remaining: b.TrialLimit - b.TrialUsage, ExpiresAt: b.FreeTrialExpiry})
+		}
+		for _, bonus := range b.Bonuses {
+			if !strings.EqualFold(bonus.Status, statusActive) {
+				continue
+			}
+			name := bonus.DisplayName
+			if name == "" {
+				name = b.DisplayName
+			}
+			add(Credit{Kind: CreditBonus, DisplayName: name, BonusCode: bonus.BonusCode, Remaining: bonus.UsageLimit - bonus.CurrentUsage, ExpiresAt: bonus.ExpiresAt})
+		}
+		add(Credit{Kind: CreditBase, DisplayName: b.DisplayName, Remaining: b.BaseLimit - b.BaseUsage})
+	}
+
+	sort.SliceStable(credits, func(i, j int) bool {
+		a, b := credits[i].ExpiresAt, credits[j].ExpiresAt
+		if a == "" || b == "" {
+			return a != "" && b == ""
+		}
+		return a < b
+	})
+	return credits
+}
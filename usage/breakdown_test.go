@@ -0,0 +1,118 @@
+package usage
+
+import (
+	"encoding/json"
+	"math/rand"
+	"testing"
+	"testing/quick"
+)
+
+// sampleResponse 含基本、試用與獎勵額度的 API 響應
+const sampleResponse = `{
+  "subscriptionInfo": {"subscriptionTitle": "KIRO FREE", "type": "Q_DEVELOPER_STANDALONE_FREE"},
+  "usageBreakdownList": [{
+    "displayName": "Credit",
+    "usageLimitWithPrecision": 50,
+    "currentUsageWithPrecision": 12.5,
+    "freeTrialInfo": {
+      "usageLimitWithPrecision": 500,
+      "currentUsageWithPrecision": 100,
+      "freeTrialStatus": "ACTIVE",
+      "freeTrialExpiry": 1767225600
+    },
+    "bonuses": [
+      {"bonusCode": "WELCOME", "displayName": "Welcome bonus", "usageLimit": 100, "currentUsage": 20, "status": "ACTIVE", "expiresAt": "2025-12-20T00:00:00Z"},
+      {"bonusCode": "OLD", "usageLimit": 100, "currentUsage": 100, "status": "EXHAUSTED"}
+    ]
+  }]
+}`
+
+// TestCalculateBalance_KeepsBreakdown 測試保留各額度來源的明細，總額由明細加總
+func TestCalculateBalance_KeepsBreakdown(t *testing.T) {
+	var response UsageLimitsResponse
+	if err := json.Unmarshal([]byte(sampleResponse), &response); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+
+	info := CalculateBalance(&response)
+	if len(info.Breakdown) != 1 {
+		t.Fatalf("len(Breakdown) = %d, want 1", len(info.Breakdown))
+	}
+
+	b := info.Breakdown[0]
+	if b.DisplayName != "Credit" || b.BaseLimit != 50 || b.BaseUsage != 12.5 || b.TrialLimit != 500 || b.TrialUsage != 100 {
+		t.Errorf("unexpected breakdown: %+v", b)
+	}
+	if !b.TrialActive() || b.FreeTrialExpiry != "2026-01-01T00:00:00Z" {
+		t.Errorf("FreeTrialStatus = %q, FreeTrialExpiry = %q", b.FreeTrialStatus, b.FreeTrialExpiry)
+	}
+	if len(b.Bonuses) != 2 || b.Bonuses[0].BonusCode != "WELCOME" || b.Bonuses[0].ExpiresAt != "2025-12-20T00:00:00Z" || b.Bonuses[1].Status != "EXHAUSTED" {
+		t.Errorf("unexpected bonuses: %+v", b.Bonuses)
+	}
+
+	if info.UsageLimit != 750 || info.CurrentUsage != 232.5 || info.Balance != 517.5 {
+		t.Errorf("totals = %v / %v / %v, want 750 / 232.5 / 517.5", info.UsageLimit, info.CurrentUsage, info.Balance)
+	}
+}
+
+// TestCredits_OrderedByExpiry 測試剩餘額度依到期時間排序，並略過已結束的額度
+func TestCredits_OrderedByExpiry(t *testing.T) {
+	var response UsageLimitsResponse
+	if err := json.Unmarshal([]byte(sampleResponse), &response); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+
+	credits := Credits(CalculateBalance(&response).Breakdown)
+	want := []Credit{
+		{Kind: CreditBonus, DisplayName: "Welcome bonus", BonusCode: "WELCOME", Remaining: 80, ExpiresAt: "2025-12-20T00:00:00Z"},
+		{Kind: CreditTrial, DisplayName: "Credit", Remaining: 400, ExpiresAt: "2026-01-01T00:00:00Z"},
+		{Kind: CreditBase, DisplayName: "Credit", Remaining: 37.5},
+	}
+	if len(credits) != len(want) {
+		t.Fatalf("Credits = %+v, want %+v", credits, want)
+	}
+	for i := range want {
+		if credits[i] != want[i] {
+			t.Errorf("credits[%d] = %+v, want %+v", i, credits[i], want[i])
+		}
+	}
+
+	expired := []Breakdown{{DisplayName: "Credit", TrialLimit: 500, FreeTrialStatus: "EXPIRED"}}
+	if got := Credits(expired); len(got) != 0 {
+		t.Errorf("expired trial should not be listed: %+v", got)
+	}
+}
+
+// **Feature: usage-breakdown, Property 1: Totals Derived From Breakdown**
+// *For any* usageBreakdownList array, the totals in UsageInfo SHALL equal the
+// sum of the per-category base, trial and bonus limits and usage in its Breakdown.
+func TestProperty_TotalsDerivedFromBreakdown(t *testing.T) {
+	f := func(seed int64) bool {
+		rand := rand.New(rand.NewSource(seed))
+		response := &UsageLimitsResponse{UsageBreakdownList: generateUsageBreakdownList(rand, 0)}
+
+		info := CalculateBalance(response)
+		if len(info.Breakdown) != len(response.UsageBreakdownList) {
+			return false
+		}
+
+		var limit, used float64
+		for _, b := range info.Breakdown {
+			limit += b.BaseLimit + b.TrialLimit
+			used += b.BaseUsage + b.TrialUsage
+			for _, bonus := range b.Bonuses {
+				limit += bonus.UsageLimit
+				used += bonus.CurrentUsage
+			}
+		}
+		return limit == info.UsageLimit && used == info.CurrentUsage
+	}
+
+	config := &quick.Config{
+		MaxCount: 100,
+	}
+
+	if err := quick.Check(f, config); err != nil {
+		t.Errorf("Property test failed: %v", err)
+	}
+}
@@ -0,0 +1,179 @@
+package usage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"kiro-manager/awssso"
+	"kiro-manager/internal/retry"
+	"kiro-manager/settings"
+)
+
+// Client 用量 API 用戶端，以 access token 查詢帳號的訂閱與額度使用情況
+// 遇到 429 與 5xx 時依重試策略重送，其他非 200 回應以 *StatusError 回傳
+type Client struct {
+	BaseURL    string           // API 端點，空值時依 token 的 profile ARN 區域與設定覆寫解析
+	HTTPClient *http.Client     // 空值時使用逾時 10 秒、共用連線設定（代理與 CA）的 http.Client
+	Now        func() time.Time // 設定 FetchedAt 使用的時鐘，空值時使用 time.Now
+	Retry      *retry.Policy    // 重試策略，空值時使用設定中的策略
+
+	defaultHTTPOnce   sync.Once
+	defaultHTTPClient *http.Client
+}
+
+// StatusError 用量 API 回傳非 200 的狀態碼
+type StatusError struct {
+	StatusCode int    // HTTP 狀態碼
+	Attempts   int    // 實際發送的請求次數（含重試）
+	Body       string // 回應內容
+}
+
+// Error 實作 error 介面
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("API request failed with status %d (attempts: %d): %s", e.StatusCode, e.Attempts, e.Body)
+}
+
+// DefaultClient 套件層級函數使用的預設用戶端
+var DefaultClient = &Client{}
+
+// baseURL 取得 token 對應的 API 端點
+// 用量 API 由 profile ARN 所在的區域提供，不一定與 token 的 SSO 區域相同
+func (c *Client) baseURL(token *awssso.KiroAuthToken) string {
+	if c.BaseURL != "" {
+		return strings.TrimRight(c.BaseURL, "/")
+	}
+	return settings.ResolveEndpoints(token.Region, token.ProfileArn).UsageBaseURL
+}
+
+// httpClient 取得 HTTP 用戶端，未指定時建立一次預設用戶端後重複使用
+func (c *Client) httpClient() *http.Client {
+	if c.HTTPClient != nil {
+		return c.HTTPClient
+	}
+	c.defaultHTTPOnce.Do(func() {
+		c.defaultHTTPClient = &http.Client{Timeout: httpTimeout, Transport: settings.LiveHTTPTransport()}
+	})
+	return c.defaultHTTPClient
+}
+
+// now 取得目前時間
+func (c *Client) now() time.Time {
+	if c.Now != nil {
+		return c.Now()
+	}
+	return time.Now()
+}
+
+// retryPolicy 取得重試策略
+func (c *Client) retryPolicy() retry.Policy {
+	if c.Retry != nil {
+		return *c.Retry
+	}
+	return settings.GetRetryPolicy()
+}
+
+// GetUsageLimits 呼叫 API 取得用量資訊
+// machineID 應為 SHA256 雜湊後的值
+// Requirements: 2.1, 2.2, 2.3
+// 支援兩種認證類型：
+// - social (GitHub/Google): 需要 profileArn 作為 query parameter
+// - idc (AWS Identity Center): 不需要 profileArn
+func (c *Client) GetUsageLimits(ctx context.Context, token *awssso.KiroAuthToken, machineID string) (*UsageInfo, error) {
+	if token == nil || token.AccessToken == "" {
+		return nil, fmt.Errorf("invalid token: missing accessToken")
+	}
+
+	// social 類型需要 profileArn
+	if token.AuthMethod == "social" && token.ProfileArn == "" {
+		return nil, fmt.Errorf("invalid token: social auth requires profileArn")
+	}
+
+	if machineID == "" {
+		return nil, fmt.Errorf("invalid machineID: empty")
+	}
+
+	// 建構 API URL with query parameters
+	// Requirements: 2.2 - social 類型使用 profileArn 作為 query parameter
+	apiURL, err := url.Parse(c.baseURL(token) + usageLimitsPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse URL: %w", err)
+	}
+
+	query := apiURL.Query()
+	query.Set("origin", originParam)
+	query.Set("resourceType", resourceTypeParam)
+	// 只有 social 類型才加入 profileArn
+	if token.AuthMethod == "social" && token.ProfileArn != "" {
+		query.Set("profileArn", token.ProfileArn)
+	}
+	apiURL.RawQuery = query.Encode()
+
+	// Requirements: 2.3 - 設定 User-Agent headers
+	// 格式: aws-sdk-js/1.0.0 ua/2.1 os/{os}#{osVersion} lang/js md/nodejs#{nodeVersion} api/codewhispererruntime#1.0.0 m/N,E KiroIDE-{kiroVersion}-{machineIdSHA256}
+	osName := runtime.GOOS
+	kiroVersion := getEffectiveKiroVersion()
+	userAgent := fmt.Sprintf("aws-sdk-js/1.0.0 ua/2.1 os/%s lang/go api/codewhispererruntime#1.0.0 m/N,E KiroIDE-%s-%s",
+		osName, kiroVersion, machineID)
+
+	// x-amz-user-agent header
+	xAmzUserAgent := fmt.Sprintf("aws-sdk-js/1.0.0 KiroIDE-%s-%s", kiroVersion, machineID)
+
+	// amz-sdk-invocation-id: 每次呼叫隨機生成 UUID，重試時沿用
+	invocationID := uuid.New().String()
+
+	slog.Debug("querying usage limits",
+		"url", apiURL.Redacted(),
+		"authMethod", token.AuthMethod,
+		"userAgent", userAgent,
+		"invocationId", invocationID)
+
+	// 發送 HTTP GET 請求（網路錯誤、429 與 5xx 依重試策略重試）
+	// Requirements: 1.4 - 設定超時以避免長時間等待
+	client := c.httpClient()
+	resp, attempts, err := c.retryPolicy().Do(ctx, func(attempt retry.Attempt) (*http.Response, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, apiURL.String(), nil)
+		if err != nil {
+			return nil, err
+		}
+
+		// Requirements: 2.1 - 使用 accessToken 作為 Bearer authorization
+		req.Header.Set("Authorization", "Bearer "+token.AccessToken)
+		req.Header.Set("User-Agent", userAgent)
+		req.Header.Set("x-amz-user-agent", xAmzUserAgent)
+		req.Header.Set("amz-sdk-invocation-id", invocationID)
+		// amz-sdk-request header：回報實際的嘗試次數
+		req.Header.Set("amz-sdk-request", attempt.SDKHeader())
+		req.Header.Set("Connection", "close")
+		return client.Do(req)
+	})
+	if err != nil {
+		slog.Warn("usage request failed", "attempts", attempts, "error", err)
+		return nil, fmt.Errorf("failed to send request (attempts: %d): %w", attempts, err)
+	}
+	defer resp.Body.Close()
+
+	// 檢查 HTTP 狀態碼
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		slog.Warn("usage request rejected", "status", resp.StatusCode, "attempts", attempts, "body", string(body))
+		return nil, &StatusError{StatusCode: resp.StatusCode, Attempts: attempts, Body: string(body)}
+	}
+
+	// 解析 JSON 響應
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	// 計算餘額並返回 UsageInfo（保留原始回應）
+	return ParseUsageLimits(body, c.now())
+}
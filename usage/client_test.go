@@ -0,0 +1,147 @@
+package usage
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"kiro-manager/awssso"
+	"kiro-manager/internal/retry"
+)
+
+// TestClient_GetUsageLimits 測試請求參數、Headers 與回應解析
+func TestClient_GetUsageLimits(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/getUsageLimits" {
+			t.Errorf("path = %s, want /getUsageLimits", r.URL.Path)
+		}
+		query := r.URL.Query()
+		if query.Get("origin") != originParam || query.Get("resourceType") != resourceTypeParam {
+			t.Errorf("unexpected query: %s", r.URL.RawQuery)
+		}
+		if query.Has("profileArn") {
+			t.Error("IdC request should not include profileArn")
+		}
+		if got := r.Header.Get("Authorization"); got != "Bearer access" {
+			t.Errorf("Authorization = %q", got)
+		}
+		if ua := r.Header.Get("User-Agent"); !strings.HasSuffix(ua, "-hashed-machine") {
+			t.Errorf("User-Agent = %q, want machine id suffix", ua)
+		}
+
+		w.Write([]byte(`{"subscriptionInfo":{"subscriptionTitle":"KIRO FREE"},"usageBreakdownList":[{"usageLimitWithPrecision":50,"currentUsageWithPrecision":45}]}`))
+	}))
+	defer server.Close()
+
+	client := &Client{BaseURL: server.URL + "/", HTTPClient: server.Client()}
+	token := &awssso.KiroAuthToken{AccessToken: "access", AuthMethod: "IdC"}
+
+	info, err := client.GetUsageLimits(context.Background(), token, "hashed-machine")
+	if err != nil {
+		t.Fatalf("GetUsageLimits failed: %v", err)
+	}
+	if info.UsageLimit != 50 || info.Balance != 5 || !info.IsLowBalance {
+		t.Errorf("UsageInfo = %+v", info)
+	}
+}
+
+// TestClient_GetUsageLimits_HTTPError 測試非 200 回應回傳錯誤
+func TestClient_GetUsageLimits_HTTPError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "throttled", http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	client := &Client{BaseURL: server.URL, HTTPClient: server.Client(), Retry: &retry.Policy{MaxAttempts: 1}}
+	token := &awssso.KiroAuthToken{AccessToken: "access", AuthMethod: "social", ProfileArn: "arn"}
+
+	_, err := client.GetUsageLimits(context.Background(), token, "hashed-machine")
+	if err == nil || !strings.Contains(err.Error(), "429") {
+		t.Errorf("error = %v, want status 429", err)
+	}
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusTooManyRequests || statusErr.Attempts != 1 {
+		t.Errorf("error = %#v, want *StatusError with status 429 after 1 attempt", err)
+	}
+}
+
+// TestClient_GetUsageLimits_RetriesWithAttemptHeader 測試重試時回報正確的嘗試次數並沿用 invocation id
+func TestClient_GetUsageLimits_RetriesWithAttemptHeader(t *testing.T) {
+	var attempts []string
+	var invocationIDs []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts = append(attempts, r.Header.Get("amz-sdk-request"))
+		invocationIDs = append(invocationIDs, r.Header.Get("amz-sdk-invocation-id"))
+		if len(attempts) < 3 {
+			w.Header().Set("Retry-After", "1")
+			http.Error(w, "throttled", http.StatusTooManyRequests)
+			return
+		}
+		w.Write([]byte(`{"subscriptionInfo":{"subscriptionTitle":"KIRO PRO"},"usageBreakdownList":[]}`))
+	}))
+	defer server.Close()
+
+	var delays []time.Duration
+	policy := &retry.Policy{
+		MaxAttempts: 3,
+		BaseDelay:   time.Millisecond,
+		MaxDelay:    time.Minute,
+		Sleep: func(ctx context.Context, d time.Duration) error {
+			delays = append(delays, d)
+			return nil
+		},
+	}
+	client := &Client{BaseURL: server.URL, HTTPClient: server.Client(), Retry: policy}
+	token := &awssso.KiroAuthToken{AccessToken: "access", AuthMethod: "IdC"}
+
+	if _, err := client.GetUsageLimits(context.Background(), token, "hashed-machine"); err != nil {
+		t.Fatalf("GetUsageLimits failed: %v", err)
+	}
+
+	want := []string{"attempt=1; max=3", "attempt=2; max=3", "attempt=3; max=3"}
+	if strings.Join(attempts, ",") != strings.Join(want, ",") {
+		t.Errorf("amz-sdk-request = %v, want %v", attempts, want)
+	}
+	if invocationIDs[0] == "" || invocationIDs[0] != invocationIDs[2] {
+		t.Errorf("invocation id should be reused across retries: %v", invocationIDs)
+	}
+	for _, d := range delays {
+		if d != time.Second {
+			t.Errorf("delay = %v, want Retry-After of 1s", d)
+		}
+	}
+}
+
+// TestClient_ReusesDefaultHTTPClient 測試未指定 HTTPClient 時重複使用同一個預設用戶端
+func TestClient_ReusesDefaultHTTPClient(t *testing.T) {
+	client := &Client{}
+	first := client.httpClient()
+	if first != client.httpClient() {
+		t.Error("httpClient() built a new client on the second call")
+	}
+	if first.Timeout != httpTimeout {
+		t.Errorf("Timeout = %v, want %v", first.Timeout, httpTimeout)
+	}
+
+	custom := &http.Client{}
+	if got := (&Client{HTTPClient: custom}).httpClient(); got != custom {
+		t.Error("httpClient() should return the configured HTTPClient")
+	}
+}
+
+// TestClient_BaseURLUsesProfileRegion 測試用量 API 主機取自 profile ARN 的區域，而非 token 的 SSO 區域
+func TestClient_BaseURLUsesProfileRegion(t *testing.T) {
+	token := &awssso.KiroAuthToken{Region: "eu-west-1", ProfileArn: "arn:aws:codewhisperer:us-east-1:123456789012:profile/ABCDEF"}
+	if got := (&Client{}).baseURL(token); got != "https://q.us-east-1.amazonaws.com" {
+		t.Errorf("baseURL = %s, want the profile region host", got)
+	}
+
+	// 沒有 profile ARN 時使用 us-east-1
+	if got := (&Client{}).baseURL(&awssso.KiroAuthToken{Region: "eu-west-1"}); got != "https://q.us-east-1.amazonaws.com" {
+		t.Errorf("baseURL without profile ARN = %s, want us-east-1", got)
+	}
+}
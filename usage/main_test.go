@@ -0,0 +1,14 @@
+package usage
+
+import (
+	"os"
+	"testing"
+
+	"kiro-manager/internal/retry"
+)
+
+// TestMain 停用預設用戶端的重試，避免呼叫真實 API 的測試在無網路環境下等待退避
+func TestMain(m *testing.M) {
+	DefaultClient = &Client{Retry: &retry.Policy{MaxAttempts: 1}}
+	os.Exit(m.Run())
+}
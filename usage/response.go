@@ -0,0 +1,123 @@
+package usage
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+)
+
+// timestampLayouts Timestamp 接受的字串格式
+var timestampLayouts = []string{time.RFC3339Nano, "2006-01-02T15:04:05", "2006-01-02"}
+
+// Timestamp API 回傳的時間，接受 epoch 秒數（或毫秒）、RFC3339 與日期字串
+// 無法辨識的格式視為沒有時間（零值），不讓單一欄位的格式變更導致整個回應解析失敗
+type Timestamp struct {
+	time.Time
+}
+
+// UnmarshalJSON 實作 json.Unmarshaler
+func (t *Timestamp) UnmarshalJSON(data []byte) error {
+	t.Time = parseTimestamp(bytes.TrimSpace(data))
+	return nil
+}
+
+// parseTimestamp 解析 JSON 數字或字串形式的時間，無法解析時回傳零值
+func parseTimestamp(data []byte) time.Time {
+	if len(data) == 0 || bytes.Equal(data, []byte("null")) {
+		return time.Time{}
+	}
+
+	raw := string(data)
+	if data[0] == '"' {
+		if err := json.Unmarshal(data, &raw); err != nil || raw == "" {
+			return time.Time{}
+		}
+		for _, layout := range timestampLayouts {
+			if parsed, err := time.Parse(layout, raw); err == nil {
+				return parsed.UTC()
+			}
+		}
+	}
+
+	seconds, err := strconv.ParseFloat(raw, 64)
+	if err != nil || seconds <= 0 {
+		return time.Time{}
+	}
+	// 超過 1e12 視為毫秒
+	if seconds >= 1e12 {
+		seconds /= 1000
+	}
+	whole, frac := math.Modf(seconds)
+	return time.Unix(int64(whole), int64(frac*1e9)).UTC()
+}
+
+// format 轉為 RFC3339 字串，零值時回傳空字串
+func (t Timestamp) format() string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+// ParseUsageLimits 解析 API 回應並計算用量
+// 原始回應保留於 UsageInfo.Raw（模型未涵蓋的欄位不會遺失），並解析計費週期的重置時間
+func ParseUsageLimits(body []byte, now time.Time) (*UsageInfo, error) {
+	var response UsageLimitsResponse
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, fmt.Errorf("failed to parse JSON response: %w", err)
+	}
+
+	var raw bytes.Buffer
+	if err := json.Compact(&raw, body); err != nil {
+		return nil, fmt.Errorf("failed to parse JSON response: %w", err)
+	}
+
+	info := CalculateBalance(&response)
+	info.FetchedAt = now
+	info.NextResetAt = nextReset(&response, now).format()
+	info.Raw = raw.Bytes()
+	return info, nil
+}
+
+// nextReset 取得下次重置時間
+// 優先使用頂層的 nextDateReset，其次為各用量類別中最早的 nextDateReset，最後以 daysUntilReset 推算
+func nextReset(response *UsageLimitsResponse, now time.Time) Timestamp {
+	if !response.NextDateReset.IsZero() {
+		return response.NextDateReset
+	}
+
+	var earliest Timestamp
+	for _, item := range response.UsageBreakdownList {
+		if item.NextDateReset.IsZero() {
+			continue
+		}
+		if earliest.IsZero() || item.NextDateReset.Before(earliest.Time) {
+			earliest = item.NextDateReset
+		}
+	}
+	if !earliest.IsZero() {
+		return earliest
+	}
+
+	if response.DaysUntilReset != nil && *response.DaysUntilReset >= 0 {
+		days := time.Duration(*response.DaysUntilReset * 24 * float64(time.Hour))
+		return Timestamp{now.Add(days).Truncate(time.Second)}
+	}
+	return Timestamp{}
+}
+
+// DaysUntilReset 計算距離重置的天數（不足一天以一天計，已過重置時間為 0）
+// resetAt 為 RFC3339 字串；空值或無法解析時回傳 0
+func DaysUntilReset(resetAt string, now time.Time) int {
+	if resetAt == "" {
+		return 0
+	}
+	t, err := time.Parse(time.RFC3339, resetAt)
+	if err != nil || !t.After(now) {
+		return 0
+	}
+	return int(math.Ceil(t.Sub(now).Hours() / 24))
+}
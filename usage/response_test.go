@@ -0,0 +1,177 @@
+package usage
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fixtureNow 解析錄製回應時使用的時間
+var fixtureNow = time.Date(2025, 12, 1, 12, 0, 0, 0, time.UTC)
+
+// fixtureWant 錄製回應的預期解析結果
+type fixtureWant struct {
+	subscriptionTitle string
+	usageLimit        float64
+	currentUsage      float64
+	nextResetAt       string
+	daysUntilReset    int
+	breakdownCount    int
+	trialActive       bool
+	firstCredit       string // 最先到期的額度（kind:expiresAt），沒有到期時間時為空
+}
+
+// fixtureWants testdata/responses 中每個錄製回應的預期結果（新增錄製回應時必須一併加入）
+var fixtureWants = map[string]fixtureWant{
+	"free-trial.json": {
+		subscriptionTitle: "KIRO FREE", usageLimit: 550, currentUsage: 123.7,
+		nextResetAt: "2026-01-01T00:00:00Z", daysUntilReset: 31, breakdownCount: 1,
+		trialActive: true, firstCredit: "trial:2025-12-13T00:00:00Z",
+	},
+	"pro-bonus.json": {
+		subscriptionTitle: "KIRO PRO", usageLimit: 1150, currentUsage: 340.75,
+		nextResetAt: "2026-01-01T00:00:00Z", daysUntilReset: 31, breakdownCount: 1,
+		firstCredit: "bonus:2025-12-13T00:00:00Z",
+	},
+	"legacy-minimal.json": {
+		subscriptionTitle: "KIRO FREE", usageLimit: 50, currentUsage: 45, breakdownCount: 1,
+	},
+	"days-only.json": {
+		subscriptionTitle: "KIRO PRO+", usageLimit: 2000, currentUsage: 10,
+		nextResetAt: "2025-12-13T12:00:00Z", daysUntilReset: 12, breakdownCount: 1,
+	},
+	"per-category-reset.json": {
+		subscriptionTitle: "KIRO POWER", usageLimit: 10040, currentUsage: 105,
+		nextResetAt: "2025-12-13T00:00:00Z", daysUntilReset: 12, breakdownCount: 2,
+	},
+	"expired-trial.json": {
+		subscriptionTitle: "KIRO FREE", usageLimit: 550, currentUsage: 530,
+		nextResetAt: "2025-12-02T00:00:00Z", daysUntilReset: 1, breakdownCount: 1,
+	},
+}
+
+// TestParseUsageLimits_Fixtures 以錄製的 API 回應檢查解析結果，防止回應格式變動時靜默出錯
+func TestParseUsageLimits_Fixtures(t *testing.T) {
+	paths, err := filepath.Glob(filepath.Join("testdata", "responses", "*.json"))
+	if err != nil {
+		t.Fatalf("Glob failed: %v", err)
+	}
+	if len(paths) != len(fixtureWants) {
+		t.Errorf("found %d fixtures, want %d (add expectations for new fixtures)", len(paths), len(fixtureWants))
+	}
+
+	for _, path := range paths {
+		name := filepath.Base(path)
+		t.Run(strings.TrimSuffix(name, ".json"), func(t *testing.T) {
+			want, ok := fixtureWants[name]
+			if !ok {
+				t.Fatalf("no expectation for fixture %s", name)
+			}
+			body, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatalf("ReadFile failed: %v", err)
+			}
+
+			info, err := ParseUsageLimits(body, fixtureNow)
+			if err != nil {
+				t.Fatalf("ParseUsageLimits failed: %v", err)
+			}
+
+			if info.SubscriptionTitle != want.subscriptionTitle {
+				t.Errorf("SubscriptionTitle = %q, want %q", info.SubscriptionTitle, want.subscriptionTitle)
+			}
+			if !nearlyEqual(info.UsageLimit, want.usageLimit) || !nearlyEqual(info.CurrentUsage, want.currentUsage) {
+				t.Errorf("totals = %v / %v, want %v / %v", info.CurrentUsage, info.UsageLimit, want.currentUsage, want.usageLimit)
+			}
+			if info.NextResetAt != want.nextResetAt {
+				t.Errorf("NextResetAt = %q, want %q", info.NextResetAt, want.nextResetAt)
+			}
+			if got := DaysUntilReset(info.NextResetAt, fixtureNow); got != want.daysUntilReset {
+				t.Errorf("DaysUntilReset = %d, want %d", got, want.daysUntilReset)
+			}
+			if len(info.Breakdown) != want.breakdownCount {
+				t.Fatalf("len(Breakdown) = %d, want %d", len(info.Breakdown), want.breakdownCount)
+			}
+			if got := info.Breakdown[0].TrialActive(); got != want.trialActive {
+				t.Errorf("TrialActive = %v, want %v", got, want.trialActive)
+			}
+
+			firstCredit := ""
+			if credits := Credits(info.Breakdown); len(credits) > 0 && credits[0].ExpiresAt != "" {
+				firstCredit = credits[0].Kind + ":" + credits[0].ExpiresAt
+			}
+			if firstCredit != want.firstCredit {
+				t.Errorf("first expiring credit = %q, want %q", firstCredit, want.firstCredit)
+			}
+
+			// 原始回應完整保留（包含模型未涵蓋的欄位）
+			var original, kept map[string]any
+			json.Unmarshal(body, &original)
+			if err := json.Unmarshal(info.Raw, &kept); err != nil {
+				t.Fatalf("Raw is not valid JSON: %v", err)
+			}
+			if len(kept) != len(original) {
+				t.Errorf("Raw keeps %d top-level fields, want %d", len(kept), len(original))
+			}
+		})
+	}
+}
+
+// nearlyEqual 比較浮點數（容許加總的誤差）
+func nearlyEqual(a, b float64) bool {
+	diff := a - b
+	return diff < 1e-9 && diff > -1e-9
+}
+
+// TestParseUsageLimits_InvalidJSON 測試無法解析的回應回傳錯誤
+func TestParseUsageLimits_InvalidJSON(t *testing.T) {
+	if _, err := ParseUsageLimits([]byte(`<html>`), fixtureNow); err == nil {
+		t.Error("expected error for non-JSON body")
+	}
+}
+
+// TestTimestamp_UnmarshalJSON 測試 epoch 秒數、毫秒、RFC3339 與日期字串；無法辨識的格式視為沒有時間
+func TestTimestamp_UnmarshalJSON(t *testing.T) {
+	want := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	for _, input := range []string{`1767225600`, `1767225600000`, `1.7672256E9`, `"2026-01-01T00:00:00Z"`, `"2026-01-01T08:00:00+08:00"`, `"2026-01-01"`, `"1767225600"`} {
+		var ts Timestamp
+		if err := json.Unmarshal([]byte(input), &ts); err != nil {
+			t.Errorf("Unmarshal(%s) failed: %v", input, err)
+			continue
+		}
+		if !ts.Equal(want) {
+			t.Errorf("Unmarshal(%s) = %v, want %v", input, ts.Time, want)
+		}
+	}
+
+	for _, input := range []string{`null`, `""`, `"soon"`, `0`, `{"seconds":1}`} {
+		var ts Timestamp
+		if err := json.Unmarshal([]byte(input), &ts); err != nil || !ts.IsZero() {
+			t.Errorf("Unmarshal(%s) = %v, %v; want zero time without error", input, ts.Time, err)
+		}
+	}
+}
+
+// TestDaysUntilReset 測試不足一天以一天計、已過重置時間與空值為 0
+func TestDaysUntilReset(t *testing.T) {
+	tests := []struct {
+		resetAt string
+		want    int
+	}{
+		{"2025-12-02T12:00:00Z", 1},
+		{"2025-12-02T13:00:00Z", 2},
+		{"2025-12-01T12:00:01Z", 1},
+		{"2025-12-01T12:00:00Z", 0},
+		{"2025-11-30T00:00:00Z", 0},
+		{"", 0},
+		{"invalid", 0},
+	}
+	for _, tt := range tests {
+		if got := DaysUntilReset(tt.resetAt, fixtureNow); got != tt.want {
+			t.Errorf("DaysUntilReset(%q) = %d, want %d", tt.resetAt, got, tt.want)
+		}
+	}
+}
@@ -1,15 +1,12 @@
 package usage
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
-	"io"
-	"net/http"
-	"net/url"
-	"runtime"
+	"log/slog"
 	"time"
 
-	"github.com/google/uuid"
 	"kiro-manager/awssso"
 	"kiro-manager/kiroversion"
 	"kiro-manager/machineid"
@@ -20,8 +17,10 @@ import (
 const httpTimeout = 10 * time.Second
 
 const (
-	// API endpoint
-	usageLimitsURL = "https://q.us-east-1.amazonaws.com/getUsageLimits"
+	// DefaultBaseURL 預設區域的用量 API 端點（實際依 token 的區域與設定覆寫解析）
+	DefaultBaseURL = "https://q.us-east-1.amazonaws.com"
+	// usageLimitsPath 用量查詢路徑
+	usageLimitsPath = "/getUsageLimits"
 	// Query parameters
 	originParam       = "AI_EDITOR"
 	resourceTypeParam = "AGENTIC_REQUEST"
@@ -41,9 +40,12 @@ func getEffectiveKiroVersion() string {
 }
 
 // UsageLimitsResponse API 響應結構
+// 只建模計算用量所需的欄位，完整的原始回應保留於 UsageInfo.Raw
 type UsageLimitsResponse struct {
 	SubscriptionInfo   SubscriptionInfo `json:"subscriptionInfo"`
 	UsageBreakdownList []UsageBreakdown `json:"usageBreakdownList"`
+	NextDateReset      Timestamp        `json:"nextDateReset"`  // 下次重置時間（目前計費週期結束）
+	DaysUntilReset     *float64         `json:"daysUntilReset"` // 距離重置的天數（沒有 nextDateReset 時使用）
 }
 
 // SubscriptionInfo 訂閱資訊結構
@@ -54,17 +56,20 @@ type SubscriptionInfo struct {
 
 // FreeTrialInfo 免費試用資訊
 type FreeTrialInfo struct {
-	UsageLimitWithPrecision   float64 `json:"usageLimitWithPrecision"`
-	CurrentUsageWithPrecision float64 `json:"currentUsageWithPrecision"`
-	FreeTrialStatus           string  `json:"freeTrialStatus"`
+	UsageLimitWithPrecision   float64   `json:"usageLimitWithPrecision"`
+	CurrentUsageWithPrecision float64   `json:"currentUsageWithPrecision"`
+	FreeTrialStatus           string    `json:"freeTrialStatus"`
+	FreeTrialExpiry           Timestamp `json:"freeTrialExpiry"`
 }
 
 // Bonus 獎勵額度
 type Bonus struct {
-	BonusCode    string  `json:"bonusCode"`
-	UsageLimit   float64 `json:"usageLimit"`
-	CurrentUsage float64 `json:"currentUsage"`
-	Status       string  `json:"status"`
+	BonusCode    string    `json:"bonusCode"`
+	DisplayName  string    `json:"displayName"`
+	UsageLimit   float64   `json:"usageLimit"`
+	CurrentUsage float64   `json:"currentUsage"`
+	Status       string    `json:"status"`
+	ExpiresAt    Timestamp `json:"expiresAt"`
 }
 
 // UsageBreakdown 用量明細結構
@@ -74,15 +79,20 @@ type UsageBreakdown struct {
 	DisplayName               string         `json:"displayName"`
 	FreeTrialInfo             *FreeTrialInfo `json:"freeTrialInfo"`
 	Bonuses                   []Bonus        `json:"bonuses"`
+	NextDateReset             Timestamp      `json:"nextDateReset"` // 此類別的下次重置時間
 }
 
 // UsageInfo 計算後的用量資訊
 type UsageInfo struct {
-	SubscriptionTitle string  // 訂閱類型名稱
-	UsageLimit        float64 // 總額度
-	CurrentUsage      float64 // 已使用
-	Balance           float64 // 餘額 = UsageLimit - CurrentUsage
-	IsLowBalance      bool    // 餘額低於 20%
+	SubscriptionTitle string          // 訂閱類型名稱
+	UsageLimit        float64         // 總額度
+	CurrentUsage      float64         // 已使用
+	Balance           float64         // 餘額 = UsageLimit - CurrentUsage
+	IsLowBalance      bool            // 餘額低於 20%
+	FetchedAt         time.Time       // 取得時間（由 API 查詢時設定）
+	Breakdown         []Breakdown     // 各用量類別的基本、試用與獎勵額度明細（總額由此加總）
+	NextResetAt       string          // 下次重置時間（RFC3339），回應沒有提供時為空
+	Raw               json.RawMessage // 原始 API 回應（保留模型未涵蓋的欄位）
 }
 
 // CalculateBalance 從 API 響應計算餘額（使用預設閾值 0.2）
@@ -102,46 +112,36 @@ func CalculateBalanceWithThreshold(response *UsageLimitsResponse, threshold floa
 		return &UsageInfo{}
 	}
 
-	var totalUsageLimit float64
-	var totalCurrentUsage float64
-
-	for _, breakdown := range response.UsageBreakdownList {
-		// 基本額度
-		totalUsageLimit += breakdown.UsageLimitWithPrecision
-		totalCurrentUsage += breakdown.CurrentUsageWithPrecision
-
-		// 免費試用額度（如果存在）
-		if breakdown.FreeTrialInfo != nil {
-			totalUsageLimit += breakdown.FreeTrialInfo.UsageLimitWithPrecision
-			totalCurrentUsage += breakdown.FreeTrialInfo.CurrentUsageWithPrecision
-		}
-
-		// 獎勵額度（如果存在）
-		for _, bonus := range breakdown.Bonuses {
-			totalUsageLimit += bonus.UsageLimit
-			totalCurrentUsage += bonus.CurrentUsage
-		}
+	// 保留每個用量類別的基本、免費試用與獎勵額度明細，總額由明細加總
+	breakdown := make([]Breakdown, 0, len(response.UsageBreakdownList))
+	for _, item := range response.UsageBreakdownList {
+		breakdown = append(breakdown, newBreakdown(item))
 	}
+	totalUsageLimit, totalCurrentUsage := Totals(breakdown)
 
 	balance := totalUsageLimit - totalCurrentUsage
 
 	// Property 2: Low Balance Detection
 	// Validates: Requirements 3.2
 	// IsLowBalance = (Balance / TotalUsageLimit) < threshold
-	var isLowBalance bool
-	if totalUsageLimit > 0 {
-		isLowBalance = (balance / totalUsageLimit) < threshold
-	}
-
 	return &UsageInfo{
 		SubscriptionTitle: response.SubscriptionInfo.SubscriptionTitle,
 		UsageLimit:        totalUsageLimit,
 		CurrentUsage:      totalCurrentUsage,
 		Balance:           balance,
-		IsLowBalance:      isLowBalance,
+		IsLowBalance:      IsLowBalance(balance, totalUsageLimit, threshold),
+		Breakdown:         breakdown,
 	}
 }
 
+// IsLowBalance 餘額比率是否低於閾值（總額度為 0 時不視為低餘額）
+func IsLowBalance(balance, usageLimit, threshold float64) bool {
+	if usageLimit <= 0 {
+		return false
+	}
+	return balance/usageLimit < threshold
+}
+
 // GetUsageLimits 呼叫 API 取得用量資訊（使用當前系統 Machine ID）
 // Requirements: 2.1, 2.2, 2.3
 func GetUsageLimits(token *awssso.KiroAuthToken) (*UsageInfo, error) {
@@ -152,107 +152,11 @@ func GetUsageLimits(token *awssso.KiroAuthToken) (*UsageInfo, error) {
 	return GetUsageLimitsWithMachineID(token, machineID)
 }
 
-// GetUsageLimitsWithMachineID 呼叫 API 取得用量資訊（使用指定的 Machine ID）
+// GetUsageLimitsWithMachineID 呼叫 API 取得用量資訊（使用指定的 Machine ID 與預設用戶端）
 // machineID 應為 SHA256 雜湊後的值
 // Requirements: 2.1, 2.2, 2.3
-// 支援兩種認證類型：
-// - social (GitHub/Google): 需要 profileArn 作為 query parameter
-// - idc (AWS Identity Center): 不需要 profileArn
 func GetUsageLimitsWithMachineID(token *awssso.KiroAuthToken, machineID string) (*UsageInfo, error) {
-	if token == nil || token.AccessToken == "" {
-		return nil, fmt.Errorf("invalid token: missing accessToken")
-	}
-
-	// social 類型需要 profileArn
-	if token.AuthMethod == "social" && token.ProfileArn == "" {
-		return nil, fmt.Errorf("invalid token: social auth requires profileArn")
-	}
-
-	if machineID == "" {
-		return nil, fmt.Errorf("invalid machineID: empty")
-	}
-
-	// 建構 API URL with query parameters
-	// Requirements: 2.2 - social 類型使用 profileArn 作為 query parameter
-	apiURL, err := url.Parse(usageLimitsURL)
-	if err != nil {
-		return nil, fmt.Errorf("failed to parse URL: %w", err)
-	}
-
-	query := apiURL.Query()
-	query.Set("origin", originParam)
-	query.Set("resourceType", resourceTypeParam)
-	// 只有 social 類型才加入 profileArn
-	if token.AuthMethod == "social" && token.ProfileArn != "" {
-		query.Set("profileArn", token.ProfileArn)
-	}
-	apiURL.RawQuery = query.Encode()
-
-	// 建立 HTTP 請求
-	req, err := http.NewRequest(http.MethodGet, apiURL.String(), nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
-
-	// 設定 Headers
-	// Requirements: 2.1 - 使用 accessToken 作為 Bearer authorization
-	req.Header.Set("Authorization", "Bearer "+token.AccessToken)
-
-	// Requirements: 2.3 - 設定 User-Agent headers
-	// 格式: aws-sdk-js/1.0.0 ua/2.1 os/{os}#{osVersion} lang/js md/nodejs#{nodeVersion} api/codewhispererruntime#1.0.0 m/N,E KiroIDE-{kiroVersion}-{machineIdSHA256}
-	osName := runtime.GOOS
-	kiroVersion := getEffectiveKiroVersion()
-	userAgent := fmt.Sprintf("aws-sdk-js/1.0.0 ua/2.1 os/%s lang/go api/codewhispererruntime#1.0.0 m/N,E KiroIDE-%s-%s",
-		osName, kiroVersion, machineID)
-	req.Header.Set("User-Agent", userAgent)
-
-	// x-amz-user-agent header
-	xAmzUserAgent := fmt.Sprintf("aws-sdk-js/1.0.0 KiroIDE-%s-%s", kiroVersion, machineID)
-	req.Header.Set("x-amz-user-agent", xAmzUserAgent)
-
-	// DEBUG: 顯示 User-Agent headers
-	fmt.Printf("[DEBUG] User-Agent: %s\n", userAgent)
-	fmt.Printf("[DEBUG] x-amz-user-agent: %s\n", xAmzUserAgent)
-
-	// amz-sdk-invocation-id: 每次請求隨機生成 UUID
-	req.Header.Set("amz-sdk-invocation-id", uuid.New().String())
-
-	// amz-sdk-request header
-	req.Header.Set("amz-sdk-request", "attempt=1; max=1")
-
-	// Connection header
-	req.Header.Set("Connection", "close")
-
-	// 發送 HTTP GET 請求
-	// Requirements: 1.4 - 設定超時以避免長時間等待
-	client := &http.Client{
-		Timeout: httpTimeout,
-	}
-	resp, err := client.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to send request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	// 檢查 HTTP 狀態碼
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(body))
-	}
-
-	// 解析 JSON 響應
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read response body: %w", err)
-	}
-
-	var response UsageLimitsResponse
-	if err := json.Unmarshal(body, &response); err != nil {
-		return nil, fmt.Errorf("failed to parse JSON response: %w", err)
-	}
-
-	// 計算餘額並返回 UsageInfo
-	return CalculateBalance(&response), nil
+	return DefaultClient.GetUsageLimits(context.Background(), token, machineID)
 }
 
 // GetUsageLimitsSafe 安全地呼叫 API 取得用量資訊（使用當前系統 Machine ID）
@@ -284,14 +188,14 @@ func GetUsageLimitsSafe(token *awssso.KiroAuthToken) *UsageInfo {
 // 當發生任何錯誤時，返回空的 UsageInfo 而非 panic
 func GetUsageLimitsSafeWithMachineID(token *awssso.KiroAuthToken, machineID string) *UsageInfo {
 	if token == nil || machineID == "" {
-		fmt.Printf("[DEBUG] GetUsageLimitsSafeWithMachineID: token=%v, machineID=%s\n", token != nil, machineID)
+		slog.Debug("skipping usage query: missing token or machine id", "hasToken", token != nil, "machineId", machineID)
 		return &UsageInfo{}
 	}
 
 	info, err := GetUsageLimitsWithMachineID(token, machineID)
 	if err != nil {
 		// 錯誤時返回空的 UsageInfo，不 panic
-		fmt.Printf("[DEBUG] GetUsageLimitsWithMachineID error: %v\n", err)
+		slog.Warn("usage query failed", "error", err)
 		return &UsageInfo{}
 	}
 
@@ -299,6 +203,10 @@ func GetUsageLimitsSafeWithMachineID(token *awssso.KiroAuthToken, machineID stri
 		return &UsageInfo{}
 	}
 
-	fmt.Printf("[DEBUG] GetUsageLimitsWithMachineID success: %+v\n", info)
+	slog.Debug("usage query succeeded",
+		"subscription", info.SubscriptionTitle,
+		"usageLimit", info.UsageLimit,
+		"currentUsage", info.CurrentUsage,
+		"balance", info.Balance)
 	return info
 }
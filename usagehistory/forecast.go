@@ -0,0 +1,140 @@
+package usagehistory
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+const (
+	// minForecastSpan 估算消耗速率所需的最短資料跨度
+	minForecastSpan = time.Hour
+	// resetTolerance 用量下降超過此值時視為計費週期重置
+	resetTolerance = 1e-6
+)
+
+// Forecast 依用量歷史計算的消耗速率與預測
+// 只使用目前計費週期（最近一次用量重置之後）的紀錄
+type Forecast struct {
+	SampleCount        int         `json:"sampleCount"`        // 目前計費週期內的紀錄數
+	Since              string      `json:"since"`              // 目前計費週期第一筆紀錄的時間（RFC3339）
+	LatestAt           string      `json:"latestAt"`           // 最新紀錄的時間（RFC3339）
+	SubscriptionTitle  string      `json:"subscriptionTitle"`  // 最新紀錄的訂閱類型
+	UsageLimit         float64     `json:"usageLimit"`         // 最新紀錄的總額度
+	CurrentUsage       float64     `json:"currentUsage"`       // 最新紀錄的已使用量
+	Remaining          float64     `json:"remaining"`          // 剩餘額度
+	HasEnoughData      bool        `json:"hasEnoughData"`      // 至少 2 筆紀錄且跨度 1 小時以上，以下預測欄位才有意義
+	DailyBurnRate      float64     `json:"dailyBurnRate"`      // 每日平均用量
+	WillExhaust        bool        `json:"willExhaust"`        // 依目前速率額度是否會用完
+	DaysUntilExhausted float64     `json:"daysUntilExhausted"` // 距離用完的天數（WillExhaust 為 false 時為 0）
+	ExhaustsAt         string      `json:"exhaustsAt"`         // 預估用完的時間（RFC3339），不會用完時為空
+	LastsUntilMonthEnd bool        `json:"lastsUntilMonthEnd"` // 依目前速率額度是否足以撐到本月底
+	Weekly             []WeekUsage `json:"weekly"`             // 每個日曆週的用量（所有紀錄）
+}
+
+// WeekUsage 單一日曆週（週一開始）的用量
+type WeekUsage struct {
+	WeekStart string  `json:"weekStart"` // 週一的日期（YYYY-MM-DD）
+	Used      float64 `json:"used"`
+}
+
+// Compute 依用量歷史計算預測（records 需依時間排序，now 的時區決定月底與週的界線）
+func Compute(records []Record, now time.Time) Forecast {
+	forecast := Forecast{Weekly: WeeklyUsage(records, now.Location())}
+	if len(records) == 0 {
+		return forecast
+	}
+
+	cycle := currentCycle(records)
+	first, latest := cycle[0], cycle[len(cycle)-1]
+
+	forecast.SampleCount = len(cycle)
+	forecast.Since = first.Time.Format(time.RFC3339)
+	forecast.LatestAt = latest.Time.Format(time.RFC3339)
+	forecast.SubscriptionTitle = latest.SubscriptionTitle
+	forecast.UsageLimit = latest.UsageLimit
+	forecast.CurrentUsage = latest.CurrentUsage
+	forecast.Remaining = math.Max(latest.UsageLimit-latest.CurrentUsage, 0)
+
+	span := latest.Time.Sub(first.Time)
+	if len(cycle) < 2 || span < minForecastSpan {
+		return forecast
+	}
+	forecast.HasEnoughData = true
+	forecast.DailyBurnRate = math.Max(latest.CurrentUsage-first.CurrentUsage, 0) / span.Hours() * 24
+
+	monthEnd := time.Date(now.Year(), now.Month()+1, 1, 0, 0, 0, 0, now.Location())
+	switch {
+	case forecast.Remaining <= 0:
+		forecast.WillExhaust = true
+		forecast.ExhaustsAt = latest.Time.Format(time.RFC3339)
+	case forecast.DailyBurnRate > 0:
+		days := forecast.Remaining / forecast.DailyBurnRate
+		exhaustsAt := latest.Time.Add(time.Duration(days * 24 * float64(time.Hour)))
+		forecast.WillExhaust = true
+		forecast.DaysUntilExhausted = math.Max(exhaustsAt.Sub(now).Hours()/24, 0)
+		forecast.ExhaustsAt = exhaustsAt.Format(time.RFC3339)
+		forecast.LastsUntilMonthEnd = !exhaustsAt.Before(monthEnd)
+	default:
+		forecast.LastsUntilMonthEnd = true
+	}
+
+	return forecast
+}
+
+// currentCycle 取得最近一次用量重置之後的紀錄
+func currentCycle(records []Record) []Record {
+	start := 0
+	for i := 1; i < len(records); i++ {
+		if records[i].CurrentUsage < records[i-1].CurrentUsage-resetTolerance {
+			start = i
+		}
+	}
+	return records[start:]
+}
+
+// WeeklyUsage 計算每個日曆週（週一開始，依 loc 時區）的用量
+// 用量為相鄰紀錄的增量，計入較新紀錄所在的週；用量下降（週期重置）時以重置後的用量計
+func WeeklyUsage(records []Record, loc *time.Location) []WeekUsage {
+	totals := make(map[string]float64)
+	for i := 1; i < len(records); i++ {
+		if delta := increment(records[i-1], records[i]); delta > 0 {
+			totals[weekStart(records[i].Time.In(loc))] += delta
+		}
+	}
+
+	weeks := make([]WeekUsage, 0, len(totals))
+	for start, used := range totals {
+		weeks = append(weeks, WeekUsage{WeekStart: start, Used: used})
+	}
+	sort.Slice(weeks, func(i, j int) bool {
+		return weeks[i].WeekStart < weeks[j].WeekStart
+	})
+	return weeks
+}
+
+// Consumed 計算相鄰紀錄之間的總用量（records 需依時間排序）
+// 用量下降（週期重置）時以重置後的用量計
+func Consumed(records []Record) float64 {
+	total := 0.0
+	for i := 1; i < len(records); i++ {
+		total += max(increment(records[i-1], records[i]), 0)
+	}
+	return total
+}
+
+// increment 計算兩筆相鄰紀錄之間的用量；用量下降時視為重置，以重置後的用量計
+func increment(prev, cur Record) float64 {
+	delta := cur.CurrentUsage - prev.CurrentUsage
+	if delta < -resetTolerance {
+		return cur.CurrentUsage
+	}
+	return delta
+}
+
+// weekStart 取得 t 所在週的週一日期
+func weekStart(t time.Time) string {
+	offset := (int(t.Weekday()) + 6) % 7
+	monday := time.Date(t.Year(), t.Month(), t.Day()-offset, 0, 0, 0, 0, t.Location())
+	return monday.Format("2006-01-02")
+}
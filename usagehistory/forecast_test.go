@@ -0,0 +1,166 @@
+package usagehistory
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+	"testing/quick"
+	"time"
+)
+
+// record 建立測試用紀錄
+func record(t time.Time, used float64) Record {
+	return Record{Time: t, SubscriptionTitle: "KIRO PRO", UsageLimit: 1000, CurrentUsage: used}
+}
+
+// TestCompute_BurnRateAndExhaustion 測試每日用量與預估用完日期
+func TestCompute_BurnRateAndExhaustion(t *testing.T) {
+	start := time.Date(2025, 12, 1, 0, 0, 0, 0, time.UTC)
+	records := []Record{
+		record(start, 100),
+		record(start.Add(24*time.Hour), 150),
+		record(start.Add(48*time.Hour), 200),
+	}
+	now := start.Add(48 * time.Hour)
+
+	f := Compute(records, now)
+	if !f.HasEnoughData || f.SampleCount != 3 {
+		t.Fatalf("HasEnoughData = %v, SampleCount = %d", f.HasEnoughData, f.SampleCount)
+	}
+	if math.Abs(f.DailyBurnRate-50) > 1e-9 {
+		t.Errorf("DailyBurnRate = %v, want 50", f.DailyBurnRate)
+	}
+	if f.Remaining != 800 || !f.WillExhaust || math.Abs(f.DaysUntilExhausted-16) > 1e-9 {
+		t.Errorf("Remaining = %v, WillExhaust = %v, DaysUntilExhausted = %v", f.Remaining, f.WillExhaust, f.DaysUntilExhausted)
+	}
+	if f.ExhaustsAt != "2025-12-19T00:00:00Z" {
+		t.Errorf("ExhaustsAt = %s, want 2025-12-19T00:00:00Z", f.ExhaustsAt)
+	}
+	if f.LastsUntilMonthEnd {
+		t.Error("plan should not last until month end")
+	}
+}
+
+// TestCompute_LastsUntilMonthEnd 測試額度足夠或沒有用量時撐到月底
+func TestCompute_LastsUntilMonthEnd(t *testing.T) {
+	start := time.Date(2025, 12, 20, 0, 0, 0, 0, time.UTC)
+	now := start.Add(24 * time.Hour)
+
+	f := Compute([]Record{record(start, 100), record(now, 110)}, now)
+	if !f.WillExhaust || !f.LastsUntilMonthEnd {
+		t.Errorf("WillExhaust = %v, LastsUntilMonthEnd = %v; want true, true", f.WillExhaust, f.LastsUntilMonthEnd)
+	}
+
+	idle := Compute([]Record{record(start, 100), record(now, 100)}, now)
+	if idle.WillExhaust || !idle.LastsUntilMonthEnd || idle.ExhaustsAt != "" {
+		t.Errorf("idle forecast = %+v", idle)
+	}
+}
+
+// TestCompute_NotEnoughData 測試紀錄不足或跨度太短時不做預測
+func TestCompute_NotEnoughData(t *testing.T) {
+	start := time.Date(2025, 12, 1, 0, 0, 0, 0, time.UTC)
+
+	for _, records := range [][]Record{
+		nil,
+		{record(start, 10)},
+		{record(start, 10), record(start.Add(30*time.Minute), 20)},
+	} {
+		f := Compute(records, start.Add(time.Hour))
+		if f.HasEnoughData || f.DailyBurnRate != 0 || f.WillExhaust {
+			t.Errorf("Compute(%d records) = %+v, want no forecast", len(records), f)
+		}
+	}
+}
+
+// TestCompute_UsesCurrentCycle 測試用量歸零後只以新週期的紀錄計算
+func TestCompute_UsesCurrentCycle(t *testing.T) {
+	start := time.Date(2025, 11, 29, 0, 0, 0, 0, time.UTC)
+	records := []Record{
+		record(start, 900),
+		record(start.Add(24*time.Hour), 990),
+		record(start.Add(48*time.Hour), 5),
+		record(start.Add(72*time.Hour), 25),
+	}
+
+	f := Compute(records, start.Add(72*time.Hour))
+	if f.SampleCount != 2 || f.Since != "2025-12-01T00:00:00Z" {
+		t.Errorf("SampleCount = %d, Since = %s; want current cycle only", f.SampleCount, f.Since)
+	}
+	if math.Abs(f.DailyBurnRate-20) > 1e-9 {
+		t.Errorf("DailyBurnRate = %v, want 20", f.DailyBurnRate)
+	}
+}
+
+// TestWeeklyUsage 測試依週一開始的日曆週統計用量，重置後以新用量計
+func TestWeeklyUsage(t *testing.T) {
+	// 2025-12-07 為週日，2025-12-08 為週一
+	records := []Record{
+		record(time.Date(2025, 12, 5, 10, 0, 0, 0, time.UTC), 100),
+		record(time.Date(2025, 12, 7, 23, 0, 0, 0, time.UTC), 130),
+		record(time.Date(2025, 12, 8, 1, 0, 0, 0, time.UTC), 140),
+		record(time.Date(2025, 12, 9, 1, 0, 0, 0, time.UTC), 15),
+	}
+
+	weeks := WeeklyUsage(records, time.UTC)
+	want := []WeekUsage{{WeekStart: "2025-12-01", Used: 30}, {WeekStart: "2025-12-08", Used: 25}}
+	if len(weeks) != len(want) {
+		t.Fatalf("weeks = %+v, want %+v", weeks, want)
+	}
+	for i := range want {
+		if weeks[i].WeekStart != want[i].WeekStart || math.Abs(weeks[i].Used-want[i].Used) > 1e-9 {
+			t.Errorf("weeks[%d] = %+v, want %+v", i, weeks[i], want[i])
+		}
+	}
+}
+
+// TestConsumed 測試加總相鄰紀錄的用量，重置後以新用量計
+func TestConsumed(t *testing.T) {
+	at := time.Date(2025, 12, 1, 0, 0, 0, 0, time.UTC)
+	records := []Record{
+		record(at, 100),
+		record(at.Add(time.Hour), 130),
+		record(at.Add(2*time.Hour), 10),
+		record(at.Add(3*time.Hour), 25),
+	}
+	if got := Consumed(records); math.Abs(got-55) > 1e-9 {
+		t.Errorf("Consumed = %v, want 55", got)
+	}
+	if got := Consumed(records[:1]); got != 0 {
+		t.Errorf("Consumed of a single record = %v, want 0", got)
+	}
+}
+
+// **Feature: usage-history, Property 1: Weekly Usage Sums To Cycle Usage**
+// *For any* monotonically increasing usage history within one billing cycle,
+// the weekly usage totals SHALL sum to the usage consumed between the first and last record.
+func TestProperty_WeeklyUsageSumsToCycleUsage(t *testing.T) {
+	f := func(seed int64, n uint8) bool {
+		rng := rand.New(rand.NewSource(seed))
+		count := 2 + int(n%50)
+		at := time.Date(2025, 12, 1, 0, 0, 0, 0, time.UTC)
+		used := rng.Float64() * 100
+
+		records := make([]Record, count)
+		for i := range records {
+			records[i] = record(at, used)
+			at = at.Add(time.Duration(1+rng.Intn(72)) * time.Hour)
+			used += rng.Float64() * 20
+		}
+
+		total := 0.0
+		for _, week := range WeeklyUsage(records, time.UTC) {
+			total += week.Used
+		}
+		want := records[count-1].CurrentUsage - records[0].CurrentUsage
+		return math.Abs(total-want) < 1e-6
+	}
+
+	config := &quick.Config{
+		MaxCount: 100,
+	}
+
+	if err := quick.Check(f, config); err != nil {
+		t.Errorf("Property test failed: %v", err)
+	}
+}
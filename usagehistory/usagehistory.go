@@ -0,0 +1,181 @@
+package usagehistory
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"kiro-manager/backup"
+	"kiro-manager/internal/atomicfile"
+)
+
+// FileName 用量歷史檔名稱（位於各備份目錄，每行一筆 JSON 紀錄）
+const FileName = "usage-history.jsonl"
+
+// Record 單次成功查詢的用量紀錄
+type Record struct {
+	Time              time.Time `json:"time"`
+	SubscriptionTitle string    `json:"subscriptionTitle"`
+	UsageLimit        float64   `json:"usageLimit"`
+	CurrentUsage      float64   `json:"currentUsage"`
+}
+
+// historyPath 取得備份的用量歷史檔路徑
+func historyPath(name string) (string, error) {
+	if err := backup.ValidateBackupName(name); err != nil {
+		return "", err
+	}
+	if !backup.BackupExists(name) {
+		return "", backup.ErrBackupNotFound
+	}
+	backupPath, err := backup.GetBackupPath(name)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(backupPath, FileName), nil
+}
+
+// Append 附加一筆用量紀錄（持有跨行程鎖，只附加不改寫既有紀錄）
+func Append(name string, record Record) error {
+	path, err := historyPath(name)
+	if err != nil {
+		return err
+	}
+
+	line, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("failed to marshal usage record: %w", err)
+	}
+	line = append(line, '\n')
+
+	unlock, err := atomicfile.Lock(path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open usage history: %w", err)
+	}
+	if _, err := file.Write(line); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to append usage history: %w", err)
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// Read 讀取備份的用量歷史（依時間排序）
+// 歷史檔不存在時回傳空清單；無法解析的行（例如寫入中斷的最後一行）會被略過
+func Read(name string) ([]Record, error) {
+	path, err := historyPath(name)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return []Record{}, nil
+		}
+		return nil, fmt.Errorf("failed to read usage history: %w", err)
+	}
+	return parseRecords(data), nil
+}
+
+// Prune 刪除早於 before 的紀錄，回傳刪除的筆數
+// 沒有需要刪除的紀錄時不改寫檔案
+func Prune(name string, before time.Time) (int, error) {
+	path, err := historyPath(name)
+	if err != nil {
+		return 0, err
+	}
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+
+	removed := 0
+	err = atomicfile.Update(path, 0600, func(old []byte, exists bool) ([]byte, error) {
+		var kept bytes.Buffer
+		for _, record := range parseRecords(old) {
+			if record.Time.Before(before) {
+				removed++
+				continue
+			}
+			line, err := json.Marshal(record)
+			if err != nil {
+				return nil, err
+			}
+			kept.Write(line)
+			kept.WriteByte('\n')
+		}
+		if removed == 0 {
+			return nil, errNothingToPrune
+		}
+		return kept.Bytes(), nil
+	})
+	if errors.Is(err, errNothingToPrune) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to prune usage history: %w", err)
+	}
+	return removed, nil
+}
+
+// errNothingToPrune 沒有需要刪除的紀錄（用於略過改寫）
+var errNothingToPrune = errors.New("nothing to prune")
+
+// PruneAll 依保留天數清理所有備份的用量歷史，回傳刪除的總筆數
+func PruneAll(retentionDays int, now time.Time) (int, error) {
+	backups, err := backup.ListBackups()
+	if err != nil {
+		return 0, err
+	}
+
+	before := now.AddDate(0, 0, -retentionDays)
+	total := 0
+	var errs []error
+	for _, b := range backups {
+		removed, err := Prune(b.Name, before)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", b.Name, err))
+			continue
+		}
+		total += removed
+	}
+	return total, errors.Join(errs...)
+}
+
+// parseRecords 解析 JSON Lines 內容並依時間排序
+func parseRecords(data []byte) []Record {
+	records := []Record{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var record Record
+		if err := json.Unmarshal(line, &record); err != nil || record.Time.IsZero() {
+			continue
+		}
+		records = append(records, record)
+	}
+
+	sort.SliceStable(records, func(i, j int) bool {
+		return records[i].Time.Before(records[j].Time)
+	})
+	return records
+}
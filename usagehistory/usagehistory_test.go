@@ -0,0 +1,111 @@
+package usagehistory
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"kiro-manager/backup"
+	"kiro-manager/internal/datadir"
+	"kiro-manager/settings"
+)
+
+// newTestBackup 在臨時資料目錄建立空的備份目錄
+func newTestBackup(t *testing.T, name string) string {
+	t.Helper()
+	// 結束時（環境變數還原後）重新載入設定
+	t.Cleanup(func() { settings.LoadSettings() })
+	t.Setenv(datadir.EnvVar, t.TempDir())
+	settings.LoadSettings()
+
+	backupPath, err := backup.GetBackupPath(name)
+	if err != nil {
+		t.Fatalf("GetBackupPath failed: %v", err)
+	}
+	if err := os.MkdirAll(backupPath, 0700); err != nil {
+		t.Fatalf("MkdirAll failed: %v", err)
+	}
+	return backupPath
+}
+
+// TestAppendRead 測試附加的紀錄可依時間順序讀回，且略過損毀的行
+func TestAppendRead(t *testing.T) {
+	backupPath := newTestBackup(t, "history-test")
+	base := time.Date(2025, 12, 1, 8, 0, 0, 0, time.UTC)
+
+	for _, offset := range []int{2, 0, 1} {
+		record := Record{Time: base.Add(time.Duration(offset) * time.Hour), SubscriptionTitle: "KIRO PRO", UsageLimit: 1000, CurrentUsage: float64(offset * 10)}
+		if err := Append("history-test", record); err != nil {
+			t.Fatalf("Append failed: %v", err)
+		}
+	}
+
+	// 模擬寫入中斷的最後一行
+	file, _ := os.OpenFile(filepath.Join(backupPath, FileName), os.O_WRONLY|os.O_APPEND, 0600)
+	file.WriteString(`{"time":"2025-12-01T`)
+	file.Close()
+
+	records, err := Read("history-test")
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	if len(records) != 3 {
+		t.Fatalf("len(records) = %d, want 3", len(records))
+	}
+	for i, r := range records {
+		if !r.Time.Equal(base.Add(time.Duration(i)*time.Hour)) || r.CurrentUsage != float64(i*10) {
+			t.Errorf("records[%d] = %+v, want sorted by time", i, r)
+		}
+	}
+}
+
+// TestRead_MissingHistory 測試尚無歷史檔時回傳空清單，備份不存在時回傳錯誤
+func TestRead_MissingHistory(t *testing.T) {
+	newTestBackup(t, "empty")
+
+	records, err := Read("empty")
+	if err != nil || len(records) != 0 {
+		t.Errorf("Read = %v, %v; want empty list", records, err)
+	}
+	if _, err := Read("missing"); !errors.Is(err, backup.ErrBackupNotFound) {
+		t.Errorf("Read(missing) error = %v, want ErrBackupNotFound", err)
+	}
+}
+
+// TestPrune 測試只刪除早於指定時間的紀錄
+func TestPrune(t *testing.T) {
+	backupPath := newTestBackup(t, "prune-test")
+	now := time.Date(2025, 12, 31, 0, 0, 0, 0, time.UTC)
+
+	for _, daysAgo := range []int{120, 95, 30, 1} {
+		record := Record{Time: now.AddDate(0, 0, -daysAgo), UsageLimit: 50, CurrentUsage: 1}
+		if err := Append("prune-test", record); err != nil {
+			t.Fatalf("Append failed: %v", err)
+		}
+	}
+
+	removed, err := PruneAll(90, now)
+	if err != nil {
+		t.Fatalf("PruneAll failed: %v", err)
+	}
+	if removed != 2 {
+		t.Errorf("removed = %d, want 2", removed)
+	}
+
+	records, _ := Read("prune-test")
+	if len(records) != 2 || !records[0].Time.Equal(now.AddDate(0, 0, -30)) {
+		t.Errorf("remaining records = %+v", records)
+	}
+
+	// 沒有需要刪除的紀錄時不改寫檔案
+	info, _ := os.Stat(filepath.Join(backupPath, FileName))
+	if removed, err := Prune("prune-test", now.AddDate(0, 0, -90)); err != nil || removed != 0 {
+		t.Errorf("second Prune = %d, %v; want 0, nil", removed, err)
+	}
+	after, _ := os.Stat(filepath.Join(backupPath, FileName))
+	if !after.ModTime().Equal(info.ModTime()) {
+		t.Error("history file should not be rewritten when nothing is pruned")
+	}
+}
@@ -0,0 +1,129 @@
+package workspace
+
+import (
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// Binding 目錄與備份（帳號）的綁定
+type Binding struct {
+	Path   string `json:"path"`   // 目錄的絕對路徑，子目錄同樣適用此綁定
+	Backup string `json:"backup"` // 備份名稱
+}
+
+// Normalize 清理綁定清單
+// 路徑需為絕對路徑（會被 Clean），備份名稱不可為空；同一目錄重複綁定時保留最後一筆
+func Normalize(bindings []Binding) []Binding {
+	return normalize(bindings, runtime.GOOS == "windows")
+}
+
+func normalize(bindings []Binding, fold bool) []Binding {
+	var result []Binding
+	for _, b := range bindings {
+		b.Path = strings.TrimSpace(b.Path)
+		b.Backup = strings.TrimSpace(b.Backup)
+		if b.Path == "" || b.Backup == "" || !filepath.IsAbs(b.Path) {
+			continue
+		}
+		b.Path = filepath.Clean(b.Path)
+
+		replaced := false
+		for i := range result {
+			if samePath(result[i].Path, b.Path, fold) {
+				result[i] = b
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			result = append(result, b)
+		}
+	}
+	return result
+}
+
+// Resolve 找出目錄適用的綁定（綁定的目錄本身或其子目錄），多筆符合時取最深的目錄
+// dir 需為絕對路徑
+func Resolve(bindings []Binding, dir string) (Binding, bool) {
+	return resolve(bindings, dir, runtime.GOOS == "windows")
+}
+
+func resolve(bindings []Binding, dir string, fold bool) (Binding, bool) {
+	dir = filepath.Clean(dir)
+	var best Binding
+	found := false
+	for _, b := range bindings {
+		if !contains(b.Path, dir, fold) {
+			continue
+		}
+		if !found || len(b.Path) > len(best.Path) {
+			best, found = b, true
+		}
+	}
+	return best, found
+}
+
+// Set 新增或更新目錄的綁定
+func Set(bindings []Binding, path, backup string) []Binding {
+	updated := append(append([]Binding(nil), bindings...), Binding{Path: path, Backup: backup})
+	return Normalize(updated)
+}
+
+// Remove 移除目錄的綁定，回傳是否有移除
+func Remove(bindings []Binding, path string) ([]Binding, bool) {
+	fold := runtime.GOOS == "windows"
+	path = filepath.Clean(path)
+
+	var result []Binding
+	removed := false
+	for _, b := range bindings {
+		if samePath(b.Path, path, fold) {
+			removed = true
+			continue
+		}
+		result = append(result, b)
+	}
+	return result, removed
+}
+
+// RenameBackup 將綁定到 oldName 的目錄改為 newName；newName 為空時移除這些綁定
+// 回傳是否有變更
+func RenameBackup(bindings []Binding, oldName, newName string) ([]Binding, bool) {
+	var result []Binding
+	changed := false
+	for _, b := range bindings {
+		if b.Backup == oldName {
+			changed = true
+			if newName == "" {
+				continue
+			}
+			b.Backup = newName
+		}
+		result = append(result, b)
+	}
+	return result, changed
+}
+
+// contains 判斷 path 是否為 dir 本身或位於其內
+func contains(dir, path string, fold bool) bool {
+	if fold {
+		dir, path = strings.ToLower(dir), strings.ToLower(path)
+	}
+	if dir == path {
+		return true
+	}
+	rel, err := filepath.Rel(dir, path)
+	if err != nil {
+		return false
+	}
+	return rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) && !filepath.IsAbs(rel)
+}
+
+// samePath 判斷兩個已 Clean 的路徑是否相同（Windows 不分大小寫）
+func samePath(a, b string, fold bool) bool {
+	if fold {
+		return strings.EqualFold(a, b)
+	}
+	return a == b
+}
@@ -0,0 +1,91 @@
+package workspace
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+// TestResolve 測試子目錄沿用上層綁定、取最深的目錄，且不誤判名稱前綴相同的目錄
+func TestResolve(t *testing.T) {
+	bindings := []Binding{
+		{Path: "/src", Backup: "personal"},
+		{Path: "/src/work", Backup: "work"},
+		{Path: "/src/work/oss", Backup: "builder"},
+	}
+
+	cases := []struct {
+		dir  string
+		want string
+		ok   bool
+	}{
+		{"/src/work", "work", true},
+		{"/src/work/api/cmd", "work", true},
+		{"/src/work/oss/lib", "builder", true},
+		{"/src/workshop", "personal", true},
+		{"/src/", "personal", true},
+		{"/other", "", false},
+		{"/", "", false},
+	}
+	for _, c := range cases {
+		got, ok := resolve(bindings, c.dir, false)
+		if ok != c.ok || got.Backup != c.want {
+			t.Errorf("resolve(%q) = %+v, %v; want %q, %v", c.dir, got, ok, c.want, c.ok)
+		}
+	}
+
+	if got, ok := resolve(bindings, "/SRC/Work", true); !ok || got.Backup != "work" {
+		t.Errorf("case-insensitive resolve = %+v, %v; want work", got, ok)
+	}
+	if _, ok := resolve(bindings, "/SRC/Work", false); ok {
+		t.Error("case-sensitive resolve should not match /SRC/Work")
+	}
+}
+
+// TestNormalize 測試清理路徑、捨棄無效的綁定，重複的目錄保留最後一筆
+func TestNormalize(t *testing.T) {
+	got := normalize([]Binding{
+		{Path: " /src/work/ ", Backup: "work"},
+		{Path: "relative/dir", Backup: "work"},
+		{Path: "/src/empty", Backup: " "},
+		{Path: "/src/work", Backup: "work2"},
+		{Path: "/src/other", Backup: "personal"},
+	}, false)
+
+	want := []Binding{{Path: filepath.Clean("/src/work"), Backup: "work2"}, {Path: "/src/other", Backup: "personal"}}
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("normalize = %+v, want %+v", got, want)
+	}
+}
+
+// TestRemoveAndRenameBackup 測試移除目錄綁定，以及備份重新命名或刪除時更新綁定
+func TestRemoveAndRenameBackup(t *testing.T) {
+	bindings := []Binding{
+		{Path: "/src/a", Backup: "work"},
+		{Path: "/src/b", Backup: "personal"},
+		{Path: "/src/c", Backup: "work"},
+	}
+
+	removed, ok := Remove(bindings, "/src/b/")
+	if !ok || len(removed) != 2 || removed[1].Path != "/src/c" {
+		t.Errorf("Remove = %+v, %v", removed, ok)
+	}
+	if _, ok := Remove(bindings, "/src/z"); ok {
+		t.Error("Remove should report no change for unknown path")
+	}
+
+	renamed, ok := RenameBackup(bindings, "work", "corp")
+	if !ok || renamed[0].Backup != "corp" || renamed[1].Backup != "personal" || renamed[2].Backup != "corp" {
+		t.Errorf("RenameBackup = %+v, %v", renamed, ok)
+	}
+	if bindings[0].Backup != "work" {
+		t.Error("RenameBackup should not modify the input slice")
+	}
+
+	deleted, ok := RenameBackup(bindings, "work", "")
+	if !ok || len(deleted) != 1 || deleted[0].Backup != "personal" {
+		t.Errorf("RenameBackup to empty = %+v, %v", deleted, ok)
+	}
+	if _, ok := RenameBackup(bindings, "missing", "x"); ok {
+		t.Error("RenameBackup should report no change for unbound backup")
+	}
+}